@@ -17,6 +17,7 @@ package agent
 import (
 	"encoding/json"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/coreos/fleet/job"
@@ -30,6 +31,9 @@ import (
 const (
 	// TTL to use with all state pushed to Registry
 	DefaultTTL = "30s"
+
+	// file the desired state of the Agent is persisted to
+	DefaultStateFile = "/var/lib/fleet/agent-state.json"
 )
 
 type Agent struct {
@@ -39,11 +43,27 @@ type Agent struct {
 	Machine  machine.Machine
 	ttl      time.Duration
 
-	cache *agentCache
+	// cacheMu guards cache against tasks running concurrently
+	cacheMu sync.Mutex
+	cache   *agentCache
+	health  *HealthChecker
 }
 
 func New(mgr unit.UnitManager, uGen *unit.UnitStateGenerator, reg registry.Registry, mach machine.Machine, ttl time.Duration) *Agent {
-	return &Agent{reg, mgr, uGen, mach, ttl, &agentCache{}}
+	return &Agent{
+		registry: reg,
+		um:       mgr,
+		uGen:     uGen,
+		Machine:  mach,
+		ttl:      ttl,
+		cache:    &agentCache{},
+	}
+}
+
+// SetHealthChecker makes the Agent run the health checks of the units it
+// launches with the given HealthChecker.
+func (a *Agent) SetHealthChecker(hc *HealthChecker) {
+	a.health = hc
 }
 
 func (a *Agent) MarshalJSON() ([]byte, error) {
@@ -64,7 +84,9 @@ func (a *Agent) Heartbeat(stop <-chan struct{}) {
 func (a *Agent) heartbeatJobs(ttl time.Duration, stop <-chan struct{}) {
 	heartbeat := func() {
 		machID := a.Machine.State().ID
+		a.cacheMu.Lock()
 		launched := a.cache.launchedJobs()
+		a.cacheMu.Unlock()
 		for _, j := range launched {
 			go a.registry.UnitHeartbeat(j, machID, ttl)
 		}
@@ -94,15 +116,27 @@ func (a *Agent) reloadUnitFiles() error {
 	return a.um.ReloadUnitFiles()
 }
 
+func (a *Agent) setTargetState(unitName string, state job.JobState) {
+	a.cacheMu.Lock()
+	defer a.cacheMu.Unlock()
+
+	if state == job.JobStateInactive {
+		a.cache.dropTargetState(unitName)
+	} else {
+		a.cache.setTargetState(unitName, state)
+	}
+}
+
 func (a *Agent) loadUnit(u *job.Unit) error {
-	a.cache.setTargetState(u.Name, job.JobStateLoaded)
+	a.setTargetState(u.Name, job.JobStateLoaded)
 	a.uGen.Subscribe(u.Name)
+	a.health.Watch(u)
 	return a.um.Load(u.Name, u.Unit)
 }
 
 func (a *Agent) unloadUnit(unitName string) error {
 	a.registry.ClearUnitHeartbeat(unitName)
-	a.cache.dropTargetState(unitName)
+	a.setTargetState(unitName, job.JobStateInactive)
 
 	errStop := a.um.TriggerStop(unitName)
 	if errStop != nil {
@@ -112,6 +146,7 @@ func (a *Agent) unloadUnit(unitName string) error {
 	}
 
 	a.uGen.Unsubscribe(unitName)
+	a.health.Unwatch(unitName)
 
 	// unit should be unloaded and unit file should be removed, only if the unit
 	// could be successfully stopped. Otherwise the unit could get into a state
@@ -126,24 +161,27 @@ func (a *Agent) unloadUnit(unitName string) error {
 }
 
 func (a *Agent) startUnit(unitName string) error {
-	a.cache.setTargetState(unitName, job.JobStateLaunched)
+	a.setTargetState(unitName, job.JobStateLaunched)
 
 	machID := a.Machine.State().ID
 	a.registry.UnitHeartbeat(unitName, machID, a.ttl)
+	a.health.Start(unitName)
 
 	return a.um.TriggerStart(unitName)
 }
 
 func (a *Agent) stopUnit(unitName string) error {
-	a.cache.setTargetState(unitName, job.JobStateLoaded)
+	a.setTargetState(unitName, job.JobStateLoaded)
 	a.registry.ClearUnitHeartbeat(unitName)
+	a.health.Stop(unitName)
 
 	return a.um.TriggerStop(unitName)
 }
 
 type unitState struct {
-	state job.JobState
-	hash  string
+	state  job.JobState
+	hash   string
+	active string
 }
 type unitStates map[string]unitState
 
@@ -183,8 +221,9 @@ func (a *Agent) units() (unitStates, error) {
 			js = job.JobStateLaunched
 		}
 		us := unitState{
-			state: js,
-			hash:  uState.UnitHash,
+			state:  js,
+			hash:   uState.UnitHash,
+			active: uState.ActiveState,
 		}
 		states[uName] = us
 	}
@@ -79,7 +79,9 @@ func TestAgentLoadUnloadUnit(t *testing.T) {
 	jsLoaded := job.JobStateLoaded
 	expectUnits := unitStates{
 		"foo.service": unitState{
-			state: jsLoaded,
+			state:  jsLoaded,
+			hash:   emptyStringHash,
+			active: "active",
 		},
 	}
 
@@ -130,7 +132,9 @@ func TestAgentLoadStartStopUnit(t *testing.T) {
 	jsLaunched := job.JobStateLaunched
 	expectUnits := unitStates{
 		"foo.service": unitState{
-			state: jsLaunched,
+			state:  jsLaunched,
+			hash:   emptyStringHash,
+			active: "active",
 		},
 	}
 
@@ -151,7 +155,9 @@ func TestAgentLoadStartStopUnit(t *testing.T) {
 	jsLoaded := job.JobStateLoaded
 	expectUnits = unitStates{
 		"foo.service": unitState{
-			state: jsLoaded,
+			state:  jsLoaded,
+			hash:   emptyStringHash,
+			active: "active",
 		},
 	}
 
@@ -0,0 +1,139 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/coreos/fleet/job"
+	"github.com/coreos/fleet/log"
+	"github.com/coreos/fleet/machine"
+	"github.com/coreos/fleet/pkg"
+)
+
+// interval at which Evacuate checks whether the units moved elsewhere
+const evacuationPollInterval = time.Second
+
+// Evacuate drains the machine of the Agent ahead of a planned shutdown:
+// it marks the machine as draining, making the engine move its non-global
+// units to other machines, and waits until none of them is scheduled to
+// the machine anymore and the launched ones are active elsewhere, or the
+// timeout expires. Meanwhile, the Agent keeps running the units which
+// were moved, leaving them to be stopped by Purge. The scheduling state
+// the machine had before is restored by FinishEvacuation.
+func (ar *AgentReconciler) Evacuate(a *Agent, timeout time.Duration) error {
+	machID := a.Machine.State().ID
+	ms, err := ar.reg.MachineState(machID)
+	if err != nil {
+		return err
+	}
+
+	atomic.StoreInt32(&ar.evacuating, 1)
+	if err := ar.reg.SetMachineSchedulingState(machID, machine.StateDraining); err != nil {
+		atomic.StoreInt32(&ar.evacuating, 0)
+		return err
+	}
+	ar.evacuatedFrom = ms.SchedulingState()
+	log.Infof("Evacuating Machine(%s), waiting up to %v for its units to run elsewhere", machID, timeout)
+
+	deadline := time.After(timeout)
+	moved := pkg.NewUnsafeSet()
+	for {
+		pending, err := ar.evacuationPending(machID, moved)
+		if err != nil {
+			log.Errorf("Failed determining units left to evacuate: %v", err)
+		} else if len(pending) == 0 {
+			log.Infof("Machine(%s) evacuated", machID)
+			return nil
+		} else {
+			log.Debugf("Waiting for units %v to be evacuated", pending)
+		}
+
+		select {
+		case <-deadline:
+			log.Warningf("Timed out evacuating Machine(%s), units not running elsewhere: %v", machID, pending)
+			return nil
+		case <-time.After(evacuationPollInterval):
+		}
+	}
+}
+
+// evacuationPending returns the names of the units still scheduled to the
+// machine, and of the launched units moved away from it which are not yet
+// active on their new machine. moved collects the units seen on the machine.
+func (ar *AgentReconciler) evacuationPending(machID string, moved pkg.Set) ([]string, error) {
+	units, err := ar.reg.Units()
+	if err != nil {
+		return nil, err
+	}
+	schedule, err := ar.reg.Schedule()
+	if err != nil {
+		return nil, err
+	}
+	states, err := ar.reg.UnitStates()
+	if err != nil {
+		return nil, err
+	}
+
+	targetStates := make(map[string]job.JobState, len(units))
+	for _, u := range units {
+		if !u.IsGlobal() {
+			targetStates[u.Name] = u.TargetState
+		}
+	}
+	active := pkg.NewUnsafeSet()
+	for _, us := range states {
+		if us.ActiveState == "active" {
+			active.Add(us.UnitName + "/" + us.MachineID)
+		}
+	}
+
+	var pending []string
+	for _, su := range schedule {
+		ts, ok := targetStates[su.Name]
+		if !ok {
+			continue
+		}
+
+		switch {
+		case su.TargetMachineID == machID:
+			moved.Add(su.Name)
+			pending = append(pending, su.Name)
+		case su.TargetMachineID == "" || !moved.Contains(su.Name):
+		case ts == job.JobStateLaunched && !active.Contains(su.Name+"/"+su.TargetMachineID):
+			pending = append(pending, su.Name)
+		}
+	}
+	sort.Strings(pending)
+
+	return pending, nil
+}
+
+// FinishEvacuation restores the scheduling state the machine of the Agent
+// had before Evacuate, to be called once the machine left the cluster.
+func (ar *AgentReconciler) FinishEvacuation(a *Agent) {
+	if ar.evacuatedFrom == "" {
+		return
+	}
+
+	machID := a.Machine.State().ID
+	if err := ar.reg.SetMachineSchedulingState(machID, ar.evacuatedFrom); err != nil {
+		log.Errorf("Failed restoring scheduling state of Machine(%s): %v", machID, err)
+		return
+	}
+	ar.evacuatedFrom = ""
+}
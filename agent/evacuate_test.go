@@ -0,0 +1,100 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/coreos/fleet/job"
+	"github.com/coreos/fleet/machine"
+	"github.com/coreos/fleet/pkg"
+	"github.com/coreos/fleet/registry"
+	"github.com/coreos/fleet/unit"
+)
+
+func TestEvacuationPending(t *testing.T) {
+	jobs := []job.Job{
+		job.Job{Name: "here.service", TargetState: job.JobStateLaunched, TargetMachineID: "XXX"},
+		job.Job{Name: "starting.service", TargetState: job.JobStateLaunched, TargetMachineID: "YYY"},
+		job.Job{Name: "active.service", TargetState: job.JobStateLaunched, TargetMachineID: "YYY"},
+		job.Job{Name: "loaded.service", TargetState: job.JobStateLoaded, TargetMachineID: "YYY"},
+		job.Job{Name: "unscheduled.service", TargetState: job.JobStateLaunched},
+		job.Job{Name: "elsewhere.service", TargetState: job.JobStateLaunched, TargetMachineID: "ZZZ"},
+		job.Job{Name: "global.service", TargetState: job.JobStateLaunched, TargetMachineID: "XXX", Unit: newUF(t, "[X-Fleet]\nGlobal=true\n")},
+	}
+	reg := registry.NewFakeRegistry()
+	reg.SetJobs(jobs)
+	reg.SetUnitStates([]unit.UnitState{
+		{UnitName: "starting.service", MachineID: "YYY", ActiveState: "activating"},
+		{UnitName: "starting.service", MachineID: "XXX", ActiveState: "active"},
+		{UnitName: "active.service", MachineID: "YYY", ActiveState: "active"},
+	})
+	ar := NewReconciler(reg, nil, nil)
+
+	moved := pkg.NewUnsafeSet("here.service", "starting.service", "active.service", "loaded.service", "unscheduled.service")
+	pending, err := ar.evacuationPending("XXX", moved)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if want := []string{"here.service", "starting.service"}; !reflect.DeepEqual(want, pending) {
+		t.Errorf("unexpected pending units\nexpected %v\n     got %v", want, pending)
+	}
+}
+
+func TestEvacuate(t *testing.T) {
+	reg := registry.NewFakeRegistry()
+	mach := &machine.FakeMachine{MachineState: machine.MachineState{ID: "XXX"}}
+	reg.SetMachines([]machine.MachineState{mach.State(), {ID: "YYY"}})
+	uf := newUF(t, "[Service]\nExecStart=/bin/foo\n")
+	reg.SetJobs([]job.Job{
+		job.Job{Name: "foo.service", Unit: uf, TargetState: job.JobStateLaunched, TargetMachineID: "XXX"},
+	})
+
+	uManager := unit.NewFakeUnitManager()
+	a := New(uManager, unit.NewUnitStateGenerator(uManager), reg, mach, time.Second)
+	ar := NewReconciler(reg, nil, nil)
+	ar.Reconcile(a)
+
+	schedulingState := func() string {
+		ms, err := reg.MachineState("XXX")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return ms.SchedulingState()
+	}
+
+	// units no other machine takes are left behind once timed out
+	if err := ar.Evacuate(a, time.Millisecond); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if state := schedulingState(); state != machine.StateDraining {
+		t.Fatalf("expected machine to be draining, got %s", state)
+	}
+
+	// units moved away keep running until purged
+	reg.ScheduleUnit("foo.service", "YYY")
+	ar.Reconcile(a)
+	if launched := a.cache.launchedJobs(); !reflect.DeepEqual([]string{"foo.service"}, launched) {
+		t.Errorf("expected foo.service to keep running, got %v", launched)
+	}
+
+	// the scheduling state is restored once the machine left
+	ar.FinishEvacuation(a)
+	if state := schedulingState(); state != machine.StateSchedulable {
+		t.Errorf("expected machine to be schedulable again, got %s", state)
+	}
+}
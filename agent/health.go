@@ -0,0 +1,244 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"fmt"
+	"net/http"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+
+	"github.com/coreos/fleet/job"
+	"github.com/coreos/fleet/log"
+	"github.com/coreos/fleet/unit"
+)
+
+// HealthChecker periodically runs the health checks of the units launched
+// by the Agent, and reports their outcome to the UnitStateGenerator.
+type HealthChecker struct {
+	clock clockwork.Clock
+	check func(job.HealthCheck) error
+
+	mu     sync.Mutex
+	checks map[string]*healthCheck
+	wg     sync.WaitGroup
+}
+
+type healthCheck struct {
+	spec    job.HealthCheck
+	started bool
+	running bool
+	next    time.Time
+	health  string
+}
+
+func NewHealthChecker() *HealthChecker {
+	return &HealthChecker{
+		clock:  clockwork.NewRealClock(),
+		check:  runHealthCheck,
+		checks: make(map[string]*healthCheck),
+	}
+}
+
+// Watch registers the health check of the given Unit, if it defines one.
+// The check runs only once the Unit was started.
+func (hc *HealthChecker) Watch(u *job.Unit) {
+	if hc == nil {
+		return
+	}
+	spec, ok := u.HealthCheck()
+
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	c, exists := hc.checks[u.Name]
+	switch {
+	case !ok:
+		delete(hc.checks, u.Name)
+	case !exists || c.spec != spec:
+		hc.checks[u.Name] = &healthCheck{spec: spec}
+	}
+}
+
+// Unwatch forgets the health check of the named unit.
+func (hc *HealthChecker) Unwatch(name string) {
+	if hc == nil {
+		return
+	}
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	delete(hc.checks, name)
+}
+
+// Start begins checking the named unit, giving it one interval to come up
+// before the first check.
+func (hc *HealthChecker) Start(name string) {
+	if hc == nil {
+		return
+	}
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	c, ok := hc.checks[name]
+	if !ok || c.started {
+		return
+	}
+	hc.checks[name] = &healthCheck{
+		spec:    c.spec,
+		started: true,
+		next:    hc.clock.Now().Add(c.spec.Interval),
+	}
+}
+
+// Stop stops checking the named unit, and forgets its health.
+func (hc *HealthChecker) Stop(name string) {
+	if hc == nil {
+		return
+	}
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	if c, ok := hc.checks[name]; ok {
+		hc.checks[name] = &healthCheck{spec: c.spec}
+	}
+}
+
+// Health returns the outcome of the latest check of the named unit, or an
+// empty string if it was not checked since it was started.
+func (hc *HealthChecker) Health(name string) string {
+	if hc == nil {
+		return ""
+	}
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	if c, ok := hc.checks[name]; ok {
+		return c.health
+	}
+	return ""
+}
+
+// Run checks the units whose checks are due every second, until the stop
+// channel is closed.
+func (hc *HealthChecker) Run(stop <-chan struct{}) {
+	tick := time.Tick(time.Second)
+	for {
+		select {
+		case <-stop:
+			return
+		case <-tick:
+			hc.checkDue()
+		}
+	}
+}
+
+// checkDue starts the checks which are due and not running yet. Each
+// check runs in its own goroutine, so slow checks do not delay others.
+func (hc *HealthChecker) checkDue() {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	now := hc.clock.Now()
+	for name, c := range hc.checks {
+		if !c.started || c.running || now.Before(c.next) {
+			continue
+		}
+		c.running = true
+		hc.wg.Add(1)
+		go hc.run(name, c)
+	}
+}
+
+func (hc *HealthChecker) run(name string, c *healthCheck) {
+	defer hc.wg.Done()
+	err := hc.check(c.spec)
+
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	c.running = false
+	c.next = hc.clock.Now().Add(c.spec.Interval)
+	// the unit was stopped or unloaded while being checked
+	if hc.checks[name] != c {
+		return
+	}
+
+	health := unit.HealthHealthy
+	if err != nil {
+		health = unit.HealthUnhealthy
+	}
+	if health != c.health {
+		if err != nil {
+			log.Infof("Unit(%s) became unhealthy: %v", name, err)
+		} else {
+			log.Infof("Unit(%s) became healthy", name)
+		}
+	}
+	c.health = health
+}
+
+// runHealthCheck runs the command and requests the URL of the given
+// HealthCheck, each of which must succeed within one interval.
+func runHealthCheck(spec job.HealthCheck) error {
+	if spec.Exec != "" {
+		if err := execHealthCheck(spec.Exec, spec.Interval); err != nil {
+			return fmt.Errorf("command %q failed: %v", spec.Exec, err)
+		}
+	}
+	if spec.HTTP != "" {
+		if err := httpHealthCheck(spec.HTTP, spec.Interval); err != nil {
+			return fmt.Errorf("request to %s failed: %v", spec.HTTP, err)
+		}
+	}
+	return nil
+}
+
+func execHealthCheck(command string, timeout time.Duration) error {
+	cmd := exec.Command("/bin/sh", "-c", command)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(timeout):
+		cmd.Process.Kill()
+		<-done
+		return fmt.Errorf("timed out after %v", timeout)
+	}
+}
+
+func httpHealthCheck(url string, timeout time.Duration) error {
+	client := http.Client{Timeout: timeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+
+	"github.com/coreos/fleet/job"
+	"github.com/coreos/fleet/unit"
+)
+
+func TestHealthChecker(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+	var checks int
+	var result error
+	hc := NewHealthChecker()
+	hc.clock = clock
+	hc.check = func(job.HealthCheck) error {
+		checks++
+		return result
+	}
+
+	step := func(d time.Duration) {
+		clock.Advance(d)
+		hc.checkDue()
+		hc.wg.Wait()
+	}
+	expect := func(desc, health string, n int) {
+		if got := hc.Health("web.service"); got != health {
+			t.Errorf("%s: expected health %q, got %q", desc, health, got)
+		}
+		if checks != n {
+			t.Errorf("%s: expected %d checks, got %d", desc, n, checks)
+		}
+	}
+
+	// units without health checks are ignored
+	hc.Watch(&job.Unit{Name: "db.service", Unit: newUF(t, "[Service]\nExecStart=/bin/db\n")})
+	hc.Start("db.service")
+	if len(hc.checks) != 0 {
+		t.Fatalf("expected no checks, got %v", hc.checks)
+	}
+
+	hc.Watch(&job.Unit{Name: "web.service", Unit: newUF(t, "[X-Fleet]\nHealthCheckExec=check\nHealthCheckInterval=10s\n")})
+	step(time.Minute)
+	expect("not started", "", 0)
+
+	// the unit gets one interval to come up
+	hc.Start("web.service")
+	step(5 * time.Second)
+	expect("starting", "", 0)
+	step(5 * time.Second)
+	expect("first check", unit.HealthHealthy, 1)
+
+	result = errors.New("connection refused")
+	step(5 * time.Second)
+	expect("between checks", unit.HealthHealthy, 1)
+	step(5 * time.Second)
+	expect("second check", unit.HealthUnhealthy, 2)
+
+	// stopped units are neither checked nor report any health
+	hc.Stop("web.service")
+	step(time.Minute)
+	expect("stopped", "", 2)
+
+	hc.Start("web.service")
+	hc.Unwatch("web.service")
+	step(time.Minute)
+	expect("unwatched", "", 2)
+}
+
+func TestRunHealthCheck(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		spec job.HealthCheck
+		ok   bool
+	}{
+		{job.HealthCheck{Exec: "exit 0"}, true},
+		{job.HealthCheck{Exec: "exit 1"}, false},
+		{job.HealthCheck{Exec: "sleep 5"}, false},
+		{job.HealthCheck{HTTP: srv.URL + "/health"}, true},
+		{job.HealthCheck{HTTP: srv.URL + "/other"}, false},
+		{job.HealthCheck{Exec: "exit 0", HTTP: srv.URL + "/other"}, false},
+		{job.HealthCheck{Exec: "exit 1", HTTP: srv.URL + "/health"}, false},
+	}
+	for i, tt := range tests {
+		tt.spec.Interval = 500 * time.Millisecond
+		if err := runHealthCheck(tt.spec); (err == nil) != tt.ok {
+			t.Errorf("case %d: expected success %t, got error %v", i, tt.ok, err)
+		}
+	}
+}
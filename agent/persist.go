@@ -0,0 +1,149 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+
+	"github.com/coreos/fleet/job"
+	"github.com/coreos/fleet/log"
+	"github.com/coreos/fleet/pkg"
+	"github.com/coreos/fleet/unit"
+)
+
+// persistedUnit is a unit an Agent should run, as written to its state
+// file. The contents of the unit are kept so that the unit can be loaded
+// again without the Registry.
+type persistedUnit struct {
+	Name        string       `json:"name"`
+	Hash        string       `json:"hash"`
+	TargetState job.JobState `json:"targetState"`
+	Contents    string       `json:"contents"`
+}
+
+// stateFile persists the units an Agent should run according to the
+// Registry, so that the Agent keeps running them when restarted while
+// the Registry is unavailable.
+type stateFile struct {
+	path string
+	// saved holds the units last written to the file
+	saved []persistedUnit
+}
+
+// save writes the units of the given desired AgentState to the file, if
+// they changed since last written. As units held back by a rollout keep
+// running in their current version, the version last written is kept for
+// them.
+func (sf *stateFile) save(dState *AgentState, held pkg.Set) error {
+	previous := make(map[string]persistedUnit, len(sf.saved))
+	for _, pu := range sf.saved {
+		previous[pu.Name] = pu
+	}
+
+	units := make([]persistedUnit, 0, len(dState.Units))
+	for name, u := range dState.Units {
+		if held.Contains(name) {
+			if pu, ok := previous[name]; ok {
+				units = append(units, pu)
+			}
+			continue
+		}
+		units = append(units, persistedUnit{
+			Name:        name,
+			Hash:        u.Unit.Hash().String(),
+			TargetState: u.TargetState,
+			Contents:    u.Unit.String(),
+		})
+	}
+	sort.Sort(persistedUnits(units))
+
+	if sf.saved != nil && reflect.DeepEqual(units, sf.saved) {
+		return nil
+	}
+
+	data, err := json.Marshal(struct {
+		Units []persistedUnit `json:"units"`
+	}{
+		Units: units,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(sf.path), 0700); err != nil {
+		return err
+	}
+	tmp := sf.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, sf.path); err != nil {
+		return err
+	}
+
+	sf.saved = units
+	return nil
+}
+
+// load reads the units last written to the file. A missing file yields a
+// nil map, while units whose contents do not match their hash are skipped.
+func (sf *stateFile) load() (map[string]*job.Unit, error) {
+	data, err := ioutil.ReadFile(sf.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var state struct {
+		Units []persistedUnit `json:"units"`
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed decoding %s: %v", sf.path, err)
+	}
+
+	units := make(map[string]*job.Unit, len(state.Units))
+	for _, pu := range state.Units {
+		uf, err := unit.NewUnitFile(pu.Contents)
+		if err != nil {
+			log.Errorf("Skipping persisted Unit(%s): %v", pu.Name, err)
+			continue
+		}
+		if hash := uf.Hash().String(); hash != pu.Hash {
+			log.Errorf("Skipping persisted Unit(%s): hash %s differs from expected %s", pu.Name, hash, pu.Hash)
+			continue
+		}
+		units[pu.Name] = &job.Unit{
+			Name:        pu.Name,
+			Unit:        *uf,
+			TargetState: pu.TargetState,
+		}
+	}
+	sf.saved = state.Units
+
+	return units, nil
+}
+
+type persistedUnits []persistedUnit
+
+func (pus persistedUnits) Len() int           { return len(pus) }
+func (pus persistedUnits) Swap(i, j int)      { pus[i], pus[j] = pus[j], pus[i] }
+func (pus persistedUnits) Less(i, j int) bool { return pus[i].Name < pus[j].Name }
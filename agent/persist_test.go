@@ -0,0 +1,177 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coreos/fleet/job"
+	"github.com/coreos/fleet/machine"
+	"github.com/coreos/fleet/pkg"
+	"github.com/coreos/fleet/registry"
+	"github.com/coreos/fleet/unit"
+)
+
+func TestStateFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fleet-agent-state")
+	if err != nil {
+		t.Fatalf("Failed creating temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "fleet", "agent-state.json")
+	foo := &job.Unit{Name: "foo.service", Unit: newUF(t, "[Service]\nExecStart=/bin/foo\n"), TargetState: job.JobStateLaunched}
+	bar := &job.Unit{Name: "bar.service", Unit: newUF(t, "[Service]\nExecStart=/bin/bar\n"), TargetState: job.JobStateLoaded}
+	newBar := &job.Unit{Name: "bar.service", Unit: newUF(t, "[Service]\nExecStart=/bin/bar --new\n"), TargetState: job.JobStateLaunched}
+
+	// a missing file holds no units
+	sf := &stateFile{path: path}
+	units, err := sf.load()
+	if err != nil || units != nil {
+		t.Fatalf("expected no units from missing file, got %v, %v", units, err)
+	}
+
+	dState := &AgentState{Units: map[string]*job.Unit{"foo.service": foo, "bar.service": bar}}
+	if err := sf.save(dState, pkg.NewUnsafeSet()); err != nil {
+		t.Fatalf("Failed saving state file: %v", err)
+	}
+	units, err = (&stateFile{path: path}).load()
+	if err != nil {
+		t.Fatalf("Failed loading state file: %v", err)
+	}
+	if !reflect.DeepEqual(dState.Units, units) {
+		t.Fatalf("unexpected units loaded\nexpected %#v\n got %#v", dState.Units, units)
+	}
+
+	// units held back by a rollout keep the version last written
+	dState = &AgentState{Units: map[string]*job.Unit{"foo.service": foo, "bar.service": newBar}}
+	if err := sf.save(dState, pkg.NewUnsafeSet("bar.service")); err != nil {
+		t.Fatalf("Failed saving state file: %v", err)
+	}
+	units, err = (&stateFile{path: path}).load()
+	if err != nil {
+		t.Fatalf("Failed loading state file: %v", err)
+	}
+	if want := map[string]*job.Unit{"foo.service": foo, "bar.service": bar}; !reflect.DeepEqual(want, units) {
+		t.Fatalf("unexpected units loaded\nexpected %#v\n got %#v", want, units)
+	}
+
+	// unchanged units are not written again
+	os.Remove(path)
+	if err := sf.save(dState, pkg.NewUnsafeSet("bar.service")); err != nil {
+		t.Fatalf("Failed saving state file: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected no write of unchanged units, got %v", err)
+	}
+
+	// units not matching their hash are skipped
+	if err := (&stateFile{path: path}).save(dState, pkg.NewUnsafeSet()); err != nil {
+		t.Fatalf("Failed saving state file: %v", err)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed reading state file: %v", err)
+	}
+	data = []byte(strings.Replace(string(data), "/bin/foo", "/bin/evil", 1))
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("Failed writing state file: %v", err)
+	}
+	units, err = (&stateFile{path: path}).load()
+	if err != nil {
+		t.Fatalf("Failed loading state file: %v", err)
+	}
+	if want := map[string]*job.Unit{"bar.service": newBar}; !reflect.DeepEqual(want, units) {
+		t.Fatalf("unexpected units loaded\nexpected %#v\n got %#v", want, units)
+	}
+}
+
+func TestAgentReconcilerRestore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fleet-agent-state")
+	if err != nil {
+		t.Fatalf("Failed creating temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "agent-state.json")
+
+	reg := registry.NewFakeRegistry()
+	mach := &machine.FakeMachine{MachineState: machine.MachineState{ID: "XXX"}}
+	reg.SetMachines([]machine.MachineState{mach.State()})
+	reg.SetJobs([]job.Job{
+		job.Job{Name: "foo.service", Unit: newUF(t, "[Service]\nExecStart=/bin/foo\n"), TargetState: job.JobStateLaunched, TargetMachineID: "XXX"},
+	})
+
+	other := &job.Unit{Name: "other.service", Unit: newUF(t, "[Service]\nExecStart=/bin/other\n"), TargetState: job.JobStateLaunched}
+
+	newAgent := func() (*Agent, *AgentReconciler, *unit.FakeUnitManager) {
+		uManager := unit.NewFakeUnitManager()
+		a := New(uManager, unit.NewUnitStateGenerator(uManager), reg, mach, time.Second)
+		ar := NewReconciler(reg, nil, nil)
+		ar.SetStateFile(path)
+		return a, ar, uManager
+	}
+
+	// without a state file, units loaded before are left alone
+	a, ar, uManager := newAgent()
+	if err := uManager.Load(other.Name, other.Unit); err != nil {
+		t.Fatalf("Failed loading unit: %v", err)
+	}
+	ar.Restore(a)
+	if units, _ := uManager.Units(); !reflect.DeepEqual([]string{"other.service"}, units) {
+		t.Fatalf("expected other.service to stay loaded, got %v", units)
+	}
+
+	// the desired state is persisted while reconciling with the Registry
+	a, ar, _ = newAgent()
+	ar.Reconcile(a)
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("expected state file to be written: %v", err)
+	}
+
+	// and restored by a restarted agent without the Registry, leaving
+	// units not persisted alone
+	a, ar, uManager = newAgent()
+	if err := uManager.Load(other.Name, other.Unit); err != nil {
+		t.Fatalf("Failed loading unit: %v", err)
+	}
+	ar.Restore(a)
+	if launched := a.cache.launchedJobs(); !reflect.DeepEqual([]string{"foo.service"}, launched) {
+		t.Fatalf("expected foo.service to be launched, got %v", launched)
+	}
+	states, err := uManager.GetUnitStates(pkg.NewUnsafeSet("foo.service"))
+	if err != nil || states["foo.service"] == nil || states["foo.service"].ActiveState != "active" {
+		t.Errorf("expected foo.service to be active, got %v, %v", states, err)
+	}
+	units, _ := uManager.Units()
+	sort.Strings(units)
+	if !reflect.DeepEqual([]string{"foo.service", "other.service"}, units) {
+		t.Errorf("expected other.service to stay loaded, got %v", units)
+	}
+
+	// without a state file nothing is restored
+	a, ar, _ = newAgent()
+	ar.stateFile = nil
+	ar.Restore(a)
+	if len(*a.cache) != 0 {
+		t.Errorf("expected no units to be restored, got %v", *a.cache)
+	}
+}
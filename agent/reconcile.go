@@ -17,12 +17,14 @@ package agent
 import (
 	"fmt"
 	"sort"
+	"sync/atomic"
 	"time"
 
 	"github.com/coreos/fleet/job"
 	"github.com/coreos/fleet/log"
 	"github.com/coreos/fleet/machine"
 	"github.com/coreos/fleet/pkg"
+	"github.com/coreos/fleet/pkg/lease"
 	"github.com/coreos/fleet/registry"
 )
 
@@ -31,11 +33,12 @@ const (
 	reconcileInterval = 5 * time.Second
 )
 
-func NewReconciler(reg registry.Registry, rStream pkg.EventStream) *AgentReconciler {
+func NewReconciler(reg registry.Registry, lManager lease.Manager, rStream pkg.EventStream) *AgentReconciler {
 	return &AgentReconciler{
 		reg:      reg,
 		rStream:  rStream,
 		tManager: newTaskManager(),
+		rollout:  newRollout(lManager),
 	}
 }
 
@@ -43,6 +46,25 @@ type AgentReconciler struct {
 	reg      registry.Registry
 	rStream  pkg.EventStream
 	tManager *taskManager
+	rollout  *rollout
+
+	// status records the outcome of the latest reconciliation
+	status reconcileStatus
+
+	// stateFile persists the desired state of the Agent, if set
+	stateFile *stateFile
+
+	// evacuating is set once Evacuate started, and evacuatedFrom holds
+	// the scheduling state of the machine before
+	evacuating    int32
+	evacuatedFrom string
+}
+
+// SetStateFile makes the AgentReconciler persist the units the Agent
+// should run to the file at the given path, and restore them from it
+// with Restore.
+func (ar *AgentReconciler) SetStateFile(path string) {
+	ar.stateFile = &stateFile{path: path}
 }
 
 // Run periodically attempts to reconcile the provided Agent until the stop
@@ -68,9 +90,65 @@ func (ar *AgentReconciler) Run(a *Agent, stop <-chan struct{}) {
 // Reconcile drives the local Agent's state towards the desired state
 // stored in the Registry.
 func (ar *AgentReconciler) Reconcile(a *Agent) {
-	dAgentState, err := desiredAgentState(a, ar.reg)
+	cAgentState, err := a.units()
+	if err != nil {
+		log.Errorf("Unable to determine agent's current state: %v", err)
+		return
+	}
+
+	dAgentState, err := desiredAgentState(a, ar.reg, cAgentState)
 	if err != nil {
 		log.Errorf("Unable to determine agent's desired state: %v", err)
+		ar.status.syncFailed(err)
+		return
+	}
+	syncedAt := time.Now()
+
+	// units waiting for a rollout slot stay as they are
+	held := ar.rollout.gate(a.Machine.State().ID, a.ttl, dAgentState, cAgentState)
+	ar.status.synced(syncedAt, dAgentState, held)
+	if ar.stateFile != nil {
+		if err := ar.stateFile.save(dAgentState, held); err != nil {
+			log.Errorf("Failed persisting agent's desired state: %v", err)
+		}
+	}
+	for _, name := range held.Values() {
+		delete(dAgentState.Units, name)
+		delete(cAgentState, name)
+	}
+
+	// units moved away by an evacuation keep running until purged
+	if atomic.LoadInt32(&ar.evacuating) == 1 {
+		for name := range cAgentState {
+			if _, ok := dAgentState.Units[name]; !ok {
+				delete(cAgentState, name)
+			}
+		}
+	}
+
+	tasks := ar.calculateTasksForUnits(dAgentState, cAgentState)
+	lookupUnitFiles(a, tasks)
+	results := ar.launchTasks(tasks, a)
+	ar.status.reconciled(a.cache, tasks, results)
+}
+
+// Restore loads and starts the units last persisted to the state file of
+// the local Agent, so that the Agent keeps running its units while the
+// Registry is unavailable. Nothing is done if there is no state file, and
+// units not persisted are left alone, as only the Registry can tell
+// whether they should be unloaded.
+func (ar *AgentReconciler) Restore(a *Agent) {
+	if ar.stateFile == nil {
+		return
+	}
+
+	units, err := ar.stateFile.load()
+	if err != nil {
+		log.Errorf("Unable to restore agent's desired state: %v", err)
+		return
+	}
+	if units == nil {
+		log.Infof("No agent state to restore from %s", ar.stateFile.path)
 		return
 	}
 
@@ -80,8 +158,19 @@ func (ar *AgentReconciler) Reconcile(a *Agent) {
 		return
 	}
 
+	for name := range cAgentState {
+		if _, ok := units[name]; !ok {
+			delete(cAgentState, name)
+		}
+	}
+
+	log.Infof("Restoring %d units from %s", len(units), ar.stateFile.path)
+	ms := a.Machine.State()
+	dAgentState := &AgentState{MState: &ms, Units: units}
 	tasks := ar.calculateTasksForUnits(dAgentState, cAgentState)
-	ar.launchTasks(tasks, a)
+	lookupUnitFiles(a, tasks)
+	results := ar.launchTasks(tasks, a)
+	ar.status.reconciled(a.cache, tasks, results)
 }
 
 // Purge attempts to unload all Units that have been loaded locally
@@ -107,14 +196,33 @@ func (ar *AgentReconciler) Purge(a *Agent) {
 			})
 		}
 
+		lookupUnitFiles(a, tasks)
 		ar.launchTasks(tasks, a)
 		time.Sleep(time.Second)
 	}
 }
 
+// lookupUnitFiles fills in the unit files of units to be unloaded which are
+// not part of the desired state, from those loaded in the UnitManager, so
+// that the units are unloaded in reverse dependency order.
+func lookupUnitFiles(a *Agent, tasks []task) {
+	for _, t := range tasks {
+		if t.typ != taskTypeUnloadUnit || len(t.unit.Unit.Contents) != 0 {
+			continue
+		}
+		uf, err := a.um.UnitFile(t.unit.Name)
+		if err != nil {
+			log.Debugf("Unable to look up unit file of Job(%s): %v", t.unit.Name, err)
+			continue
+		}
+		t.unit.Unit = *uf
+	}
+}
+
 // desiredAgentState builds an *AgentState object that represents what the
-// provided Agent should currently be doing.
-func desiredAgentState(a *Agent, reg registry.Registry) (*AgentState, error) {
+// provided Agent should currently be doing, given the units currently
+// loaded on its machine.
+func desiredAgentState(a *Agent, reg registry.Registry, current unitStates) (*AgentState, error) {
 	units, err := reg.Units()
 	if err != nil {
 		log.Errorf("Failed fetching Units from Registry: %v", err)
@@ -147,13 +255,19 @@ func desiredAgentState(a *Agent, reg registry.Registry) (*AgentState, error) {
 
 	for _, u := range units {
 		u := u
-		md := u.RequiredTargetMetadata()
+		md := u.RequiredTargetMetadataExprs()
 
 		if u.IsGlobal() {
-			if !machine.HasMetadata(&ms, md) {
+			if !machine.MatchesMetadata(&ms, md) {
 				log.Debugf("Agent unable to run global unit %s: missing required metadata", u.Name)
 				continue
 			}
+			// unschedulable machines keep the global units they run,
+			// but do not start any new ones
+			if _, loaded := current[u.Name]; ms.Unschedulable && !loaded {
+				log.Debugf("Agent unable to run global unit %s: Machine is %s", u.Name, ms.SchedulingState())
+				continue
+			}
 		}
 
 		if !u.IsGlobal() {
@@ -332,7 +446,7 @@ func (ar *AgentReconciler) calculateTasksForUnit(dState *AgentState, cState unit
 	return
 }
 
-func (ar *AgentReconciler) launchTasks(tasks []task, a *Agent) {
+func (ar *AgentReconciler) launchTasks(tasks []task, a *Agent) []taskResult {
 	log.Debugf("AgentReconciler attempting tasks %s", tasks)
 	results := ar.tManager.Do(tasks, a)
 	for _, res := range results {
@@ -347,4 +461,5 @@ func (ar *AgentReconciler) launchTasks(tasks []task, a *Agent) {
 			log.Infof("AgentReconciler task failed: type=%s job=%s reason=%q err=%v", res.task.typ, unitName, res.task.reason, res.err)
 		}
 	}
+	return results
 }
@@ -170,6 +170,20 @@ MachineMetadata=dog=woof`),
 			},
 			map[string]*job.Unit{},
 		},
+		// Global Unit excluding machines with metadata we have? Skip it
+		{
+			map[string]string{"spot": "true"},
+			[]job.Job{
+				job.Job{
+					Name: "global.mount",
+					Unit: newUF(t, `
+[X-Fleet]
+Global=true
+MachineMetadata=!spot`),
+				},
+			},
+			map[string]*job.Unit{},
+		},
 		// Mix it up a bit!
 		{
 			nil,
@@ -255,7 +269,7 @@ MachineMetadata=dog=woof`),
 		reg.SetJobs(tt.regJobs)
 		a := makeAgentWithMetadata(tt.metadata)
 		reg.SetMachines([]machine.MachineState{a.Machine.State()})
-		as, err := desiredAgentState(a, reg)
+		as, err := desiredAgentState(a, reg, nil)
 		if err != nil {
 			t.Errorf("case %d: unexpected error: %v", i, err)
 		} else if !reflect.DeepEqual(as.Units, tt.asUnits) {
@@ -272,6 +286,33 @@ MachineMetadata=dog=woof`),
 	}
 }
 
+func TestDesiredAgentStateCordoned(t *testing.T) {
+	reg := registry.NewFakeRegistry()
+	reg.SetJobs([]job.Job{
+		job.Job{Name: "old.service", Unit: newUF(t, "[X-Fleet]\nGlobal=true")},
+		job.Job{Name: "new.service", Unit: newUF(t, "[X-Fleet]\nGlobal=true")},
+	})
+	a := makeAgentWithMetadata(nil)
+	reg.SetMachines([]machine.MachineState{a.Machine.State()})
+	reg.SetMachineSchedulingState("this_machine", machine.StateCordoned)
+
+	// what is loaded on the machine counts, as the agent cache is empty
+	// after fleetd restarted
+	current := unitStates{"old.service": unitState{state: job.JobStateInactive}}
+	as, err := desiredAgentState(a, reg, current)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// global units already running stay, new ones are not started
+	want := map[string]*job.Unit{
+		"old.service": &job.Unit{Name: "old.service", Unit: newUF(t, "[X-Fleet]\nGlobal=true")},
+	}
+	if !reflect.DeepEqual(want, as.Units) {
+		t.Errorf("expected units %v, got %v", want, as.Units)
+	}
+}
+
 func TestAbleToRun(t *testing.T) {
 	tests := []struct {
 		dState *AgentState
@@ -320,6 +361,27 @@ func TestAbleToRun(t *testing.T) {
 			want:   job.JobActionUnschedule,
 		},
 
+		// match negated MachineMetadata
+		{
+			dState: NewAgentState(&machine.MachineState{ID: "123", Metadata: map[string]string{"region": "us-west"}}),
+			job:    newTestJobWithXFleetValues(t, "MachineMetadata=region!=us-east"),
+			want:   job.JobActionSchedule,
+		},
+
+		// mismatch MachineMetadata existence
+		{
+			dState: NewAgentState(&machine.MachineState{ID: "123", Metadata: map[string]string{"spot": "true"}}),
+			job:    newTestJobWithXFleetValues(t, "MachineMetadata=!spot"),
+			want:   job.JobActionUnschedule,
+		},
+
+		// mismatch numeric MachineMetadata
+		{
+			dState: NewAgentState(&machine.MachineState{ID: "123", Metadata: map[string]string{"disk_gb": "250"}}),
+			job:    newTestJobWithXFleetValues(t, "MachineMetadata=disk_gb>=500"),
+			want:   job.JobActionUnschedule,
+		},
+
 		// peer scheduled locally
 		{
 			dState: &AgentState{
@@ -435,6 +497,25 @@ func TestAbleToRun(t *testing.T) {
 			job:  newTestJobWithXFleetValues(t, "Replaces=ping.service"),
 			want: job.JobActionReschedule,
 		},
+
+		// cordoned machine accepts no new units
+		{
+			dState: NewAgentState(&machine.MachineState{ID: "123", Unschedulable: true}),
+			job:    &job.Job{Name: "ping.service", Unit: unit.UnitFile{}},
+			want:   job.JobActionUnschedule,
+		},
+
+		// cordoned machine keeps units already scheduled to it
+		{
+			dState: &AgentState{
+				MState: &machine.MachineState{ID: "123", Unschedulable: true},
+				Units: map[string]*job.Unit{
+					"ping.service": &job.Unit{Name: "ping.service"},
+				},
+			},
+			job:  &job.Job{Name: "ping.service", Unit: unit.UnitFile{}},
+			want: job.JobActionSchedule,
+		},
 	}
 
 	for i, tt := range tests {
@@ -762,7 +843,7 @@ func TestCalculateTasksForJob(t *testing.T) {
 	}
 
 	for i, tt := range tests {
-		ar := NewReconciler(registry.NewFakeRegistry(), nil)
+		ar := NewReconciler(registry.NewFakeRegistry(), nil, nil)
 		got := ar.calculateTasksForUnit(tt.dState, tt.cState, tt.uName)
 		if !reflect.DeepEqual(tt.want, got) {
 			t.Errorf("case %d: calculated incorrect tasks\nexpected=%#v\nreceived=%#v\n", i, tt.want, got)
@@ -926,7 +1007,7 @@ func TestCalculateTasksForUnitsReloadUnitFilesTask(t *testing.T) {
 	}
 
 	for i, tt := range tests {
-		ar := NewReconciler(registry.NewFakeRegistry(), nil)
+		ar := NewReconciler(registry.NewFakeRegistry(), nil, nil)
 		got := ar.calculateTasksForUnits(tt.dState, tt.cState)
 		if !reflect.DeepEqual(tt.want, got) {
 			t.Errorf("case %d: calculated incorrect tasks", i)
@@ -941,3 +1022,35 @@ func TestCalculateTasksForUnitsReloadUnitFilesTask(t *testing.T) {
 		}
 	}
 }
+
+func TestLookupUnitFiles(t *testing.T) {
+	uManager := unit.NewFakeUnitManager()
+	a := &Agent{um: uManager}
+	foo := newUF(t, "[Unit]\nRequires=bar.service\n")
+	bar := newUF(t, "[Service]\nExecStart=/bin/bar\n")
+	if err := uManager.Load("foo.service", foo); err != nil {
+		t.Fatalf("Failed loading unit: %v", err)
+	}
+
+	// bar.service is being loaded with a new unit file, which is kept,
+	// while baz.service is not loaded in the UnitManager
+	tasks := []task{
+		task{typ: taskTypeUnloadUnit, unit: &job.Unit{Name: "bar.service", Unit: bar}},
+		task{typ: taskTypeUnloadUnit, unit: &job.Unit{Name: "baz.service"}},
+		task{typ: taskTypeUnloadUnit, unit: &job.Unit{Name: "foo.service"}},
+	}
+	lookupUnitFiles(a, tasks)
+
+	want := []unit.UnitFile{bar, unit.UnitFile{}, foo}
+	for i, tsk := range tasks {
+		if !reflect.DeepEqual(want[i], tsk.unit.Unit) {
+			t.Errorf("task %d: expected unit file %#v, got %#v", i, want[i], tsk.unit.Unit)
+		}
+	}
+
+	// foo.service requires bar.service, so it must be unloaded first
+	_, dependents := taskDependencies(tasks)
+	if want := [][]int{nil, nil, []int{0}}; !reflect.DeepEqual(want, dependents) {
+		t.Errorf("expected dependents %v, got %v", want, dependents)
+	}
+}
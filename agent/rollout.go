@@ -0,0 +1,170 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/coreos/fleet/job"
+	"github.com/coreos/fleet/log"
+	"github.com/coreos/fleet/pkg"
+	"github.com/coreos/fleet/pkg/lease"
+)
+
+// rollout coordinates the agents of a cluster loading new versions of
+// global units which limit how many machines may do so at the same time
+// using MaxParallel or MaxUnavailable. Each limit is a number of slots,
+// represented by leases named after the unit. An agent only loads a new
+// version of such a unit while holding a slot, and holds it until its
+// instance of the unit reached the desired state.
+type rollout struct {
+	lManager lease.Manager
+	slots    map[string]lease.Lease
+}
+
+func newRollout(lManager lease.Manager) *rollout {
+	return &rollout{
+		lManager: lManager,
+		slots:    make(map[string]lease.Lease),
+	}
+}
+
+func rolloutSlotName(unitName string, slot int) string {
+	return fmt.Sprintf("rollout/%s/%d", unitName, slot)
+}
+
+// rolloutSlots returns the number of slots an agent may pick from to load
+// the given version of a global unit, given its current state on the
+// agent's machine, or 0 if the unit is not limited. Replacing a running
+// instance is limited by both MaxParallel and MaxUnavailable, while loading
+// the unit anew is only limited by MaxParallel. Both use the lowest
+// numbered slots, so that no more than MaxParallel machines in total load
+// the unit at the same time.
+func rolloutSlots(u *job.Unit, cur *unitState) int {
+	if !u.IsGlobal() {
+		return 0
+	}
+
+	n, _ := u.MaxParallel()
+	if cur != nil && cur.state == job.JobStateLaunched {
+		if mu, ok := u.MaxUnavailable(); ok && (n == 0 || mu < n) {
+			n = mu
+		}
+	}
+	return n
+}
+
+// settled determines whether the current state of a unit on the agent's
+// machine reached the given desired state, so that the agent no longer
+// needs a slot for it. Launched units must also be active.
+func settled(dUnit *job.Unit, cur *unitState) bool {
+	if cur == nil || cur.hash != dUnit.Unit.Hash().String() {
+		return false
+	}
+	return dUnit.TargetState != job.JobStateLaunched || cur.active == "active"
+}
+
+// gate acquires slots for the limited global units of the given desired
+// state which are about to change on the agent's machine, renews the slots
+// of those still changing and releases all others. The names of units
+// that must not change until a slot is available are returned.
+func (r *rollout) gate(machID string, ttl time.Duration, dState *AgentState, cState unitStates) pkg.Set {
+	held := pkg.NewUnsafeSet()
+	if r == nil || r.lManager == nil {
+		return held
+	}
+
+	names := make([]string, 0, len(dState.Units))
+	for name := range dState.Units {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	wanted := pkg.NewUnsafeSet()
+	for _, name := range names {
+		dUnit := dState.Units[name]
+		if dUnit.TargetState == job.JobStateInactive {
+			continue
+		}
+
+		var cur *unitState
+		if us, ok := cState[name]; ok {
+			cur = &us
+		}
+		if settled(dUnit, cur) {
+			continue
+		}
+
+		if l, ok := r.slots[name]; ok {
+			err := l.Renew(ttl)
+			if err == nil {
+				wanted.Add(name)
+				continue
+			}
+			log.Warningf("Lost rollout slot of Unit(%s): %v", name, err)
+			delete(r.slots, name)
+		}
+
+		if cur != nil && cur.hash == dUnit.Unit.Hash().String() {
+			// the current version is loaded already, the agent only
+			// waits for it to become active
+			continue
+		}
+
+		n := rolloutSlots(dUnit, cur)
+		if n == 0 {
+			continue
+		}
+		if l := r.acquire(name, machID, n, ttl); l != nil {
+			log.Infof("Acquired rollout slot of Unit(%s)", name)
+			r.slots[name] = l
+			wanted.Add(name)
+			continue
+		}
+
+		log.Debugf("Unit(%s) waiting for one of %d rollout slots", name, n)
+		held.Add(name)
+	}
+
+	for name, l := range r.slots {
+		if wanted.Contains(name) {
+			continue
+		}
+		if err := l.Release(); err != nil {
+			log.Warningf("Failed releasing rollout slot of Unit(%s): %v", name, err)
+		} else {
+			log.Infof("Released rollout slot of Unit(%s)", name)
+		}
+		delete(r.slots, name)
+	}
+
+	return held
+}
+
+func (r *rollout) acquire(name, machID string, n int, ttl time.Duration) lease.Lease {
+	for i := 0; i < n; i++ {
+		l, err := r.lManager.AcquireLease(rolloutSlotName(name, i), machID, 0, ttl)
+		if err != nil {
+			log.Debugf("Unable to acquire rollout slot %d of Unit(%s): %v", i, name, err)
+			continue
+		}
+		if l != nil {
+			return l
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/coreos/fleet/job"
+	"github.com/coreos/fleet/registry"
+)
+
+func TestRolloutSlots(t *testing.T) {
+	tests := []struct {
+		contents string
+		cur      *unitState
+		slots    int
+	}{
+		// only global units are limited
+		{"[X-Fleet]\nMaxParallel=2\n", nil, 0},
+		{"[X-Fleet]\nGlobal=true\n", nil, 0},
+		{"[X-Fleet]\nGlobal=true\nMaxParallel=2\n", nil, 2},
+		{"[X-Fleet]\nGlobal=true\nMaxParallel=2\nMaxUnavailable=1\n", nil, 2},
+		// MaxUnavailable only limits replacing running instances
+		{"[X-Fleet]\nGlobal=true\nMaxUnavailable=1\n", nil, 0},
+		{"[X-Fleet]\nGlobal=true\nMaxUnavailable=1\n", &unitState{state: jsLoaded, hash: "old"}, 0},
+		{"[X-Fleet]\nGlobal=true\nMaxUnavailable=1\n", &unitState{state: jsLaunched, hash: "old"}, 1},
+		{"[X-Fleet]\nGlobal=true\nMaxParallel=3\nMaxUnavailable=1\n", &unitState{state: jsLaunched, hash: "old"}, 1},
+		{"[X-Fleet]\nGlobal=true\nMaxParallel=1\nMaxUnavailable=3\n", &unitState{state: jsLaunched, hash: "old"}, 1},
+	}
+
+	for i, tt := range tests {
+		u := &job.Unit{Name: "log.service", Unit: newUF(t, tt.contents)}
+		if got := rolloutSlots(u, tt.cur); got != tt.slots {
+			t.Errorf("case %d: expected %d slots, got %d", i, tt.slots, got)
+		}
+	}
+}
+
+func TestRolloutGate(t *testing.T) {
+	lReg := registry.NewFakeLeaseRegistry()
+	ra := newRollout(lReg)
+	rb := newRollout(lReg)
+
+	u := &job.Unit{
+		Name:        "log.service",
+		Unit:        newUF(t, "[Service]\nExecStart=/bin/logger\n[X-Fleet]\nGlobal=true\nMaxParallel=1\n"),
+		TargetState: jsLaunched,
+	}
+	hash := u.Unit.Hash().String()
+	dState := func() *AgentState {
+		return &AgentState{Units: map[string]*job.Unit{u.Name: u}}
+	}
+	old := unitStates{u.Name: unitState{state: jsLaunched, hash: "old", active: "active"}}
+
+	check := func(step string, held []string, want []string) {
+		sort.Strings(held)
+		if len(held) != len(want) || len(want) > 0 && !reflect.DeepEqual(held, want) {
+			t.Errorf("%s: expected held units %v, got %v", step, want, held)
+		}
+	}
+
+	// the first machine takes the only slot, the second one waits
+	check("A acquires", ra.gate("A", time.Minute, dState(), old).Values(), []string{})
+	check("B waits", rb.gate("B", time.Minute, dState(), old).Values(), []string{u.Name})
+
+	// the first machine holds its slot until its instance is active
+	activating := unitStates{u.Name: unitState{state: jsLaunched, hash: hash, active: "activating"}}
+	check("A activating", ra.gate("A", time.Minute, dState(), activating).Values(), []string{})
+	check("B still waits", rb.gate("B", time.Minute, dState(), old).Values(), []string{u.Name})
+
+	active := unitStates{u.Name: unitState{state: jsLaunched, hash: hash, active: "active"}}
+	check("A active", ra.gate("A", time.Minute, dState(), active).Values(), []string{})
+	if len(ra.slots) != 0 {
+		t.Errorf("expected A to release its slot, holds %v", ra.slots)
+	}
+	check("B acquires", rb.gate("B", time.Minute, dState(), old).Values(), []string{})
+
+	// slots of units no longer desired are released
+	check("B unit destroyed", rb.gate("B", time.Minute, &AgentState{Units: map[string]*job.Unit{}}, old).Values(), []string{})
+	if l, _ := lReg.GetLease(rolloutSlotName(u.Name, 0)); l != nil {
+		t.Errorf("expected slot to be released, held by %s", l.MachineID())
+	}
+}
+
+func TestRolloutGateUnlimited(t *testing.T) {
+	var r *rollout
+	u := &job.Unit{
+		Name:        "log.service",
+		Unit:        newUF(t, "[X-Fleet]\nGlobal=true\nMaxParallel=1\n"),
+		TargetState: jsLaunched,
+	}
+	dState := &AgentState{Units: map[string]*job.Unit{u.Name: u}}
+	if held := r.gate("A", time.Minute, dState, unitStates{}); held.Length() != 0 {
+		t.Errorf("expected no held units without lease manager, got %v", held.Values())
+	}
+
+	r = newRollout(nil)
+	if held := r.gate("A", time.Minute, dState, unitStates{}); held.Length() != 0 {
+		t.Errorf("expected no held units without lease manager, got %v", held.Values())
+	}
+}
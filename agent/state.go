@@ -21,11 +21,16 @@ import (
 	"github.com/coreos/fleet/job"
 	"github.com/coreos/fleet/log"
 	"github.com/coreos/fleet/machine"
+	"github.com/coreos/fleet/resource"
 )
 
 type AgentState struct {
 	MState *machine.MachineState
 	Units  map[string]*job.Unit
+
+	// Excluded maps the names of units which must not run on the
+	// machine to the reason they are excluded, if any
+	Excluded map[string]string
 }
 
 func NewAgentState(ms *machine.MachineState) *AgentState {
@@ -77,6 +82,34 @@ func (as *AgentState) HasConflict(pUnitName string, pConflicts []string) (bool,
 	return true, conflicts
 }
 
+// PreferenceScore sums up the weights of the soft requirements of the
+// given Job fulfilled by the agent: metadata preferences the machine
+// matches, and soft conflicts with none of the units scheduled to it.
+func (as *AgentState) PreferenceScore(j *job.Job) int {
+	score := 0
+
+	for _, pref := range j.PreferredMetadata() {
+		if pref.Expr.Matches(as.MState.Metadata) {
+			score += pref.Weight
+		}
+	}
+
+	for _, pref := range j.PreferredConflicts() {
+		conflict := false
+		for _, eUnit := range as.Units {
+			if eUnit.Name != j.Name && globMatches(pref.Pattern, eUnit.Name) {
+				conflict = true
+				break
+			}
+		}
+		if !conflict {
+			score += pref.Weight
+		}
+	}
+
+	return score
+}
+
 // hasReplace determines whether there are any known replaces with the given Unit
 func (as *AgentState) hasReplace(pUnitName string, pReplaces []string) (found bool, replace string) {
 	for _, eUnit := range as.Units {
@@ -119,6 +152,44 @@ func (as *AgentState) hasReplace(pUnitName string, pReplaces []string) (found bo
 	return
 }
 
+// allocatedResources returns the sum of the resources required by all Units
+// scheduled to the Agent, except for the Unit of the given name.
+func (as *AgentState) allocatedResources(except string) resource.ResourceTuple {
+	var res []resource.ResourceTuple
+	for _, u := range as.Units {
+		if u.Name == except {
+			continue
+		}
+		res = append(res, u.Resources())
+	}
+	return resource.Sum(res...)
+}
+
+// HasResources determines whether the Agent is able to satisfy the resource
+// requirements of the given Job in addition to those of all other Units
+// scheduled to it. Agents which do not publish their capacity are assumed
+// to have sufficient resources. If the resources are insufficient, a
+// description of the first exhausted resource is returned.
+func (as *AgentState) HasResources(j *job.Job) (bool, string) {
+	capacity := as.MState.AllocatableResources
+	required := j.Resources()
+	if capacity == nil || required.Empty() {
+		return true, ""
+	}
+
+	free := resource.Sub(*capacity, as.allocatedResources(j.Name))
+	switch {
+	case required.Cores > free.Cores:
+		return false, fmt.Sprintf("insufficient cores: %.2f required, %.2f available", float64(required.Cores)/100, float64(free.Cores)/100)
+	case required.Memory > free.Memory:
+		return false, fmt.Sprintf("insufficient memory: %dMB required, %dMB available", required.Memory, free.Memory)
+	case required.Disk > free.Disk:
+		return false, fmt.Sprintf("insufficient disk space: %dMB required, %dMB available", required.Disk, free.Disk)
+	}
+
+	return true, ""
+}
+
 func globMatches(pattern, target string) bool {
 	matched, err := path.Match(pattern, target)
 	if err != nil {
@@ -132,21 +203,35 @@ func globMatches(pattern, target string) bool {
 // case or not is returned. The following criteria is used:
 //   - Agent must meet the Job's machine target requirement (if any)
 //   - Agent must have all of the Job's required metadata (if any)
+//   - Agent must have enough free resources for the Job (if it requires any)
 //   - Agent must have all required Peers of the Job scheduled locally (if any)
 //   - Job must not conflict with any other Units scheduled to the agent
 //   - Job must specially handle replaced units to be rescheduled
 func (as *AgentState) AbleToRun(j *job.Job) (jobAction job.JobAction, errstr string) {
+	// units already scheduled to an unschedulable machine may stay
+	if as.MState.Unschedulable && !as.unitScheduled(j.Name) {
+		return job.JobActionUnschedule, fmt.Sprintf("Machine is %s", as.MState.SchedulingState())
+	}
+
+	if reason, ok := as.Excluded[j.Name]; ok {
+		return job.JobActionUnschedule, reason
+	}
+
 	if tgt, ok := j.RequiredTarget(); ok && !as.MState.MatchID(tgt) {
 		return job.JobActionUnschedule, fmt.Sprintf("agent ID %q does not match required %q", as.MState.ID, tgt)
 	}
 
-	metadata := j.RequiredTargetMetadata()
+	metadata := j.RequiredTargetMetadataExprs()
 	if len(metadata) != 0 {
-		if !machine.HasMetadata(as.MState, metadata) {
+		if !machine.MatchesMetadata(as.MState, metadata) {
 			return job.JobActionUnschedule, "local Machine metadata insufficient"
 		}
 	}
 
+	if ok, reason := as.HasResources(j); !ok {
+		return job.JobActionUnschedule, reason
+	}
+
 	peers := j.Peers()
 	if len(peers) != 0 {
 		for _, peer := range peers {
@@ -20,6 +20,7 @@ import (
 
 	"github.com/coreos/fleet/job"
 	"github.com/coreos/fleet/machine"
+	"github.com/coreos/fleet/resource"
 	"github.com/coreos/fleet/unit"
 )
 
@@ -255,6 +256,81 @@ func TestHasReplaces(t *testing.T) {
 	}
 }
 
+func TestHasResources(t *testing.T) {
+	capacity := resource.ResourceTuple{Cores: 200, Memory: 1024, Disk: 10240}
+
+	tests := []struct {
+		cState *AgentState
+		job    *job.Job
+		want   bool
+	}{
+		// machines not publishing their capacity accept everything
+		{
+			cState: NewAgentState(&machine.MachineState{ID: "XXX"}),
+			job:    &job.Job{Name: "foo.service", Unit: fleetUnit(t, "MemoryRequired=64G")},
+			want:   true,
+		},
+
+		// jobs without requirements always fit
+		{
+			cState: NewAgentState(&machine.MachineState{ID: "XXX", AllocatableResources: &capacity}),
+			job:    &job.Job{Name: "foo.service", Unit: fleetUnit(t)},
+			want:   true,
+		},
+
+		// job fits onto empty machine
+		{
+			cState: NewAgentState(&machine.MachineState{ID: "XXX", AllocatableResources: &capacity}),
+			job:    &job.Job{Name: "foo.service", Unit: fleetUnit(t, "MemoryRequired=1G", "CoresRequired=2")},
+			want:   true,
+		},
+
+		// job exceeds capacity of empty machine
+		{
+			cState: NewAgentState(&machine.MachineState{ID: "XXX", AllocatableResources: &capacity}),
+			job:    &job.Job{Name: "foo.service", Unit: fleetUnit(t, "DiskRequired=20G")},
+			want:   false,
+		},
+
+		// existing units leave too little memory
+		{
+			cState: &AgentState{
+				MState: &machine.MachineState{ID: "XXX", AllocatableResources: &capacity},
+				Units: map[string]*job.Unit{
+					"bar.service": &job.Unit{
+						Name: "bar.service",
+						Unit: fleetUnit(t, "MemoryRequired=768M"),
+					},
+				},
+			},
+			job:  &job.Job{Name: "foo.service", Unit: fleetUnit(t, "MemoryRequired=512M")},
+			want: false,
+		},
+
+		// the job itself is not counted twice if already scheduled
+		{
+			cState: &AgentState{
+				MState: &machine.MachineState{ID: "XXX", AllocatableResources: &capacity},
+				Units: map[string]*job.Unit{
+					"foo.service": &job.Unit{
+						Name: "foo.service",
+						Unit: fleetUnit(t, "MemoryRequired=768M"),
+					},
+				},
+			},
+			job:  &job.Job{Name: "foo.service", Unit: fleetUnit(t, "MemoryRequired=768M")},
+			want: true,
+		},
+	}
+
+	for i, tt := range tests {
+		got, reason := tt.cState.HasResources(tt.job)
+		if got != tt.want {
+			t.Errorf("case %d: HasResources returned %t (%q), want %t", i, got, reason, tt.want)
+		}
+	}
+}
+
 func TestGlobMatches(t *testing.T) {
 	tests := []struct {
 		pattern  string
@@ -278,3 +354,56 @@ func TestGlobMatches(t *testing.T) {
 		}
 	}
 }
+
+func TestPreferenceScore(t *testing.T) {
+	ms := &machine.MachineState{ID: "XXX", Metadata: map[string]string{"ssd": "true", "disk_gb": "500"}}
+
+	tests := []struct {
+		units []string
+		job   *job.Job
+		want  int
+	}{
+		// no preferences at all
+		{
+			job:  &job.Job{Name: "foo.service", Unit: fleetUnit(t)},
+			want: 0,
+		},
+
+		// matching and mismatching metadata preferences
+		{
+			job:  &job.Job{Name: "foo.service", Unit: fleetUnit(t, "PreferMachineMetadata=ssd=true:50", "PreferMachineMetadata=disk_gb>=1000:30")},
+			want: 50,
+		},
+
+		// omitted weights default to 1
+		{
+			job:  &job.Job{Name: "foo.service", Unit: fleetUnit(t, "PreferMachineMetadata=ssd")},
+			want: 1,
+		},
+
+		// soft conflicts only score if no matching unit is scheduled
+		{
+			units: []string{"cache@1.service"},
+			job:   &job.Job{Name: "foo.service", Unit: fleetUnit(t, "PreferConflicts=cache@*:20", "PreferConflicts=db@*:10")},
+			want:  10,
+		},
+
+		// the unit itself is not a conflict
+		{
+			units: []string{"cache@1.service"},
+			job:   &job.Job{Name: "cache@1.service", Unit: fleetUnit(t, "PreferConflicts=cache@*:20")},
+			want:  20,
+		},
+	}
+
+	for i, tt := range tests {
+		as := NewAgentState(ms)
+		for _, name := range tt.units {
+			as.Units[name] = &job.Unit{Name: name}
+		}
+
+		if got := as.PreferenceScore(tt.job); got != tt.want {
+			t.Errorf("case %d: expected score %d, got %d", i, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,322 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/fleet/job"
+	"github.com/coreos/fleet/log"
+	"github.com/coreos/fleet/pkg"
+)
+
+const (
+	TaskStateCompleted = "completed"
+	TaskStateFailed    = "failed"
+	TaskStatePending   = "pending"
+)
+
+// Status is the local view of an Agent: the units it believes it should
+// run, what systemd reports about them, and the tasks of its latest
+// reconciliation.
+type Status struct {
+	MachineID string `json:"machineID"`
+
+	// LastRegistrySync is the RFC3339 timestamp of the last time the
+	// Agent successfully fetched its desired state from the Registry,
+	// empty if it never did. RegistrySyncError holds the error of the
+	// attempts failing since.
+	LastRegistrySync  string `json:"lastRegistrySync,omitempty"`
+	RegistrySyncError string `json:"registrySyncError,omitempty"`
+
+	Units []UnitStatus `json:"units"`
+	Tasks []TaskStatus `json:"tasks"`
+}
+
+// UnitStatus describes a single unit known to the Agent.
+type UnitStatus struct {
+	Name string `json:"name"`
+
+	// DesiredState and DesiredHash are the target state and the hash
+	// of the unit the Agent should run according to the Registry, as
+	// of the last sync. Both are empty for units the Agent should not
+	// run at all.
+	DesiredState string `json:"desiredState,omitempty"`
+	DesiredHash  string `json:"desiredHash,omitempty"`
+
+	// CurrentState is the state the Agent last brought the unit to.
+	CurrentState string `json:"currentState,omitempty"`
+
+	// RolloutHeld is set if the unit waits for a rollout slot before
+	// the Agent replaces or starts it.
+	RolloutHeld bool `json:"rolloutHeld,omitempty"`
+
+	// Systemd holds the latest state of the unit reported by systemd.
+	Systemd *SystemdStatus `json:"systemd,omitempty"`
+}
+
+// SystemdStatus is the state of a unit as reported by systemd.
+type SystemdStatus struct {
+	LoadState   string `json:"loadState"`
+	ActiveState string `json:"activeState"`
+	SubState    string `json:"subState"`
+	Hash        string `json:"hash,omitempty"`
+	Health      string `json:"health,omitempty"`
+}
+
+// TaskStatus describes a task of the latest reconciliation. As the Agent
+// stops at the first task failing, the tasks not yet started when a task
+// failed stay pending until the next reconciliation.
+type TaskStatus struct {
+	Type   string `json:"type"`
+	Unit   string `json:"unit,omitempty"`
+	Reason string `json:"reason"`
+	State  string `json:"state"`
+	Error  string `json:"error,omitempty"`
+}
+
+// reconcileStatus records the outcome of the reconciliations of an
+// AgentReconciler, to be reported by a StatusServer.
+type reconcileStatus struct {
+	mu sync.RWMutex
+
+	lastSync time.Time
+	syncErr  error
+	desired  map[string]*job.Unit
+	current  map[string]job.JobState
+	held     []string
+	tasks    []TaskStatus
+}
+
+func (rs *reconcileStatus) syncFailed(err error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	rs.syncErr = err
+}
+
+func (rs *reconcileStatus) synced(at time.Time, desired *AgentState, held pkg.Set) {
+	units := make(map[string]*job.Unit, len(desired.Units))
+	for name, u := range desired.Units {
+		units[name] = u
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	rs.lastSync = at
+	rs.syncErr = nil
+	rs.desired = units
+	rs.held = held.Values()
+}
+
+func (rs *reconcileStatus) reconciled(cache *agentCache, tasks []task, results []taskResult) {
+	current := make(map[string]job.JobState, len(*cache))
+	for name, state := range *cache {
+		current[name] = state
+	}
+
+	attempted := make(map[task]taskResult, len(results))
+	for _, res := range results {
+		attempted[res.task] = res
+	}
+
+	statuses := make([]TaskStatus, len(tasks))
+	for i, t := range tasks {
+		ts := TaskStatus{Type: t.typ, Reason: t.reason, State: TaskStatePending}
+		if t.unit != nil {
+			ts.Unit = t.unit.Name
+		}
+		if res, ok := attempted[t]; ok {
+			if err := res.err; err != nil {
+				ts.State = TaskStateFailed
+				ts.Error = err.Error()
+			} else {
+				ts.State = TaskStateCompleted
+			}
+		}
+		statuses[i] = ts
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	rs.current = current
+	rs.tasks = statuses
+}
+
+// StatusServer serves the Status of an Agent as JSON on a unix socket.
+// It is read-only, so any local process able to connect to the socket
+// may find out why a unit does or does not run on the machine.
+type StatusServer struct {
+	path string
+	a    *Agent
+	ar   *AgentReconciler
+	pub  *UnitStatePublisher
+}
+
+func NewStatusServer(path string, a *Agent, ar *AgentReconciler, pub *UnitStatePublisher) *StatusServer {
+	return &StatusServer{path: path, a: a, ar: ar, pub: pub}
+}
+
+// Status assembles the current Status of the Agent.
+func (s *StatusServer) Status() Status {
+	rs := &s.ar.status
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	st := Status{
+		MachineID: s.a.Machine.State().ID,
+		Units:     []UnitStatus{},
+		Tasks:     []TaskStatus{},
+	}
+	if !rs.lastSync.IsZero() {
+		st.LastRegistrySync = rs.lastSync.UTC().Format(time.RFC3339)
+	}
+	if rs.syncErr != nil {
+		st.RegistrySyncError = rs.syncErr.Error()
+	}
+	st.Tasks = append(st.Tasks, rs.tasks...)
+
+	systemd := s.pub.UnitStates()
+	held := pkg.NewUnsafeSet(rs.held...)
+
+	names := pkg.NewUnsafeSet()
+	for name := range rs.desired {
+		names.Add(name)
+	}
+	for name := range rs.current {
+		names.Add(name)
+	}
+	for name := range systemd {
+		names.Add(name)
+	}
+	sorted := names.Values()
+	sort.Strings(sorted)
+
+	for _, name := range sorted {
+		us := UnitStatus{
+			Name:         name,
+			CurrentState: string(rs.current[name]),
+			RolloutHeld:  held.Contains(name),
+		}
+		if u, ok := rs.desired[name]; ok {
+			us.DesiredState = string(u.TargetState)
+			us.DesiredHash = u.Unit.Hash().String()
+		}
+		if ss := systemd[name]; ss != nil {
+			us.Systemd = &SystemdStatus{
+				LoadState:   ss.LoadState,
+				ActiveState: ss.ActiveState,
+				SubState:    ss.SubState,
+				Hash:        ss.UnitHash,
+				Health:      ss.Health,
+			}
+		}
+		st.Units = append(st.Units, us)
+	}
+
+	return st
+}
+
+// ServeHTTP serves the whole Status at /status, and the Status restricted
+// to a single unit and its tasks at /units/<name>.
+func (s *StatusServer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if req.Method != "GET" {
+		http.Error(rw, "only GET supported against this resource", http.StatusMethodNotAllowed)
+		return
+	}
+
+	st := s.Status()
+	switch {
+	case req.URL.Path == "/status":
+	case strings.HasPrefix(req.URL.Path, "/units/"):
+		name := strings.TrimPrefix(req.URL.Path, "/units/")
+		var ok bool
+		if st, ok = filterStatus(st, name); !ok {
+			http.Error(rw, fmt.Sprintf("unit %s unknown to the agent", name), http.StatusNotFound)
+			return
+		}
+	default:
+		http.NotFound(rw, req)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(rw).Encode(st); err != nil {
+		log.Errorf("Failed sending agent status: %v", err)
+	}
+}
+
+// filterStatus restricts the given Status to the named unit and the tasks
+// operating on it, reporting whether the unit is known at all.
+func filterStatus(st Status, name string) (Status, bool) {
+	filtered := Status{
+		MachineID:         st.MachineID,
+		LastRegistrySync:  st.LastRegistrySync,
+		RegistrySyncError: st.RegistrySyncError,
+		Units:             []UnitStatus{},
+		Tasks:             []TaskStatus{},
+	}
+	for _, us := range st.Units {
+		if us.Name == name {
+			filtered.Units = append(filtered.Units, us)
+		}
+	}
+	for _, ts := range st.Tasks {
+		if ts.Unit == name {
+			filtered.Tasks = append(filtered.Tasks, ts)
+		}
+	}
+	return filtered, len(filtered.Units) > 0 || len(filtered.Tasks) > 0
+}
+
+// Run serves the Status on the unix socket until the stop channel is
+// closed. A socket left behind by a previous run is replaced.
+func (s *StatusServer) Run(stop <-chan struct{}) {
+	if fi, err := os.Lstat(s.path); err == nil {
+		if fi.Mode()&os.ModeSocket == 0 {
+			log.Errorf("Not serving agent status: %s exists and is not a socket", s.path)
+			return
+		}
+		if err := os.Remove(s.path); err != nil {
+			log.Errorf("Not serving agent status: failed removing stale socket %s: %v", s.path, err)
+			return
+		}
+	}
+
+	l, err := net.Listen("unix", s.path)
+	if err != nil {
+		log.Errorf("Not serving agent status: %v", err)
+		return
+	}
+	defer l.Close()
+	if err := os.Chmod(s.path, 0600); err != nil {
+		log.Errorf("Not serving agent status: failed restricting access to %s: %v", s.path, err)
+		return
+	}
+
+	log.Infof("Serving agent status on %s", s.path)
+	go http.Serve(l, s)
+	<-stop
+}
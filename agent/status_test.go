@@ -0,0 +1,146 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/coreos/fleet/job"
+	"github.com/coreos/fleet/machine"
+	"github.com/coreos/fleet/registry"
+	"github.com/coreos/fleet/unit"
+)
+
+func TestStatusServer(t *testing.T) {
+	uManager := unit.NewFakeUnitManager()
+	usGenerator := unit.NewUnitStateGenerator(uManager)
+	reg := registry.NewFakeRegistry()
+	mach := &machine.FakeMachine{MachineState: machine.MachineState{ID: "XXX"}}
+	reg.SetMachines([]machine.MachineState{mach.State()})
+	uf := newUF(t, "[Service]\nExecStart=/bin/foo\n")
+	reg.SetJobs([]job.Job{
+		job.Job{Name: "foo.service", Unit: uf, TargetState: job.JobStateLaunched, TargetMachineID: "XXX"},
+	})
+
+	a := New(uManager, usGenerator, reg, mach, time.Second)
+	ar := NewReconciler(reg, nil, nil)
+	pub := NewUnitStatePublisher(reg, mach, time.Second)
+	srv := NewStatusServer("", a, ar, pub)
+
+	// nothing is reported before the first reconciliation
+	want := Status{MachineID: "XXX", Units: []UnitStatus{}, Tasks: []TaskStatus{}}
+	if got := srv.Status(); !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected status\nexpected %#v\n got %#v", want, got)
+	}
+
+	// tasks following a failed one stay pending
+	ar.tManager.mapper = func(t task, a *Agent) (func() error, error) {
+		return nil, errors.New("unit file not writable")
+	}
+	ar.Reconcile(a)
+	ar.status.syncFailed(errors.New("etcd unavailable"))
+
+	st := srv.Status()
+	if st.LastRegistrySync == "" || st.RegistrySyncError != "etcd unavailable" {
+		t.Errorf("unexpected registry sync %q, error %q", st.LastRegistrySync, st.RegistrySyncError)
+	}
+	wantUnits := []UnitStatus{
+		{Name: "foo.service", DesiredState: "launched", DesiredHash: uf.Hash().String()},
+	}
+	if !reflect.DeepEqual(wantUnits, st.Units) {
+		t.Errorf("unexpected units\nexpected %#v\n got %#v", wantUnits, st.Units)
+	}
+	wantTasks := []TaskStatus{
+		{Type: taskTypeLoadUnit, Unit: "foo.service", Reason: taskReasonScheduledButUnloaded, State: TaskStateFailed, Error: "unit file not writable"},
+		{Type: taskTypeReloadUnitFiles, Reason: taskReasonAlwaysReloadUnitFiles, State: TaskStatePending},
+		{Type: taskTypeStartUnit, Unit: "foo.service", Reason: taskReasonLoadedDesiredStateLaunched, State: TaskStatePending},
+	}
+	if !reflect.DeepEqual(wantTasks, st.Tasks) {
+		t.Errorf("unexpected tasks\nexpected %#v\n got %#v", wantTasks, st.Tasks)
+	}
+
+	// completed tasks bring the unit to its desired state
+	ar.tManager.mapper = mapTaskToFunc
+	ar.Reconcile(a)
+	pub.updateCache(&unit.UnitStateHeartbeat{
+		Name:  "foo.service",
+		State: &unit.UnitState{LoadState: "loaded", ActiveState: "active", SubState: "running", UnitHash: uf.Hash().String()},
+	})
+	pub.updateCache(&unit.UnitStateHeartbeat{Name: "bar.service"})
+
+	st = srv.Status()
+	if st.RegistrySyncError != "" {
+		t.Errorf("expected registry sync error to be cleared, got %q", st.RegistrySyncError)
+	}
+	wantUnits = []UnitStatus{
+		{Name: "bar.service"},
+		{
+			Name:         "foo.service",
+			DesiredState: "launched",
+			DesiredHash:  uf.Hash().String(),
+			CurrentState: "launched",
+			Systemd:      &SystemdStatus{LoadState: "loaded", ActiveState: "active", SubState: "running", Hash: uf.Hash().String()},
+		},
+	}
+	if !reflect.DeepEqual(wantUnits, st.Units) {
+		t.Errorf("unexpected units\nexpected %#v\n got %#v", wantUnits, st.Units)
+	}
+	for _, ts := range st.Tasks {
+		if ts.State != TaskStateCompleted {
+			t.Errorf("expected task %v to be completed", ts)
+		}
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		code   int
+		units  int
+	}{
+		{"GET", "/status", http.StatusOK, 2},
+		{"GET", "/units/foo.service", http.StatusOK, 1},
+		{"GET", "/units/baz.service", http.StatusNotFound, 0},
+		{"GET", "/", http.StatusNotFound, 0},
+		{"DELETE", "/status", http.StatusMethodNotAllowed, 0},
+	}
+	for i, tt := range tests {
+		req, err := http.NewRequest(tt.method, "http://agent"+tt.path, nil)
+		if err != nil {
+			t.Fatalf("case %d: failed creating request: %v", i, err)
+		}
+		rw := httptest.NewRecorder()
+		srv.ServeHTTP(rw, req)
+		if rw.Code != tt.code {
+			t.Errorf("case %d: expected status %d, got %d", i, tt.code, rw.Code)
+			continue
+		}
+		if tt.code != http.StatusOK {
+			continue
+		}
+
+		var got Status
+		if err := json.Unmarshal(rw.Body.Bytes(), &got); err != nil {
+			t.Errorf("case %d: failed decoding status: %v", i, err)
+		} else if len(got.Units) != tt.units {
+			t.Errorf("case %d: expected %d units, got %v", i, tt.units, got.Units)
+		}
+	}
+}
@@ -16,6 +16,8 @@ package agent
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/coreos/fleet/job"
 )
@@ -35,6 +37,9 @@ const (
 	taskReasonLaunchedDesiredStateLoaded = "unit currently launched but desired state is loaded"
 	taskReasonPurgingAgent               = "purging agent"
 	taskReasonAlwaysReloadUnitFiles      = "always reload unit files"
+
+	// maximum number of tasks of the same type run at once
+	defaultTaskParallelism = 4
 )
 
 type task struct {
@@ -65,38 +70,171 @@ type taskResult struct {
 }
 
 type taskManager struct {
-	mapper taskMapperFunc
+	mapper      taskMapperFunc
+	parallelism int
 }
 
 func newTaskManager() *taskManager {
 	return &taskManager{
-		mapper: mapTaskToFunc,
+		mapper:      mapTaskToFunc,
+		parallelism: defaultTaskParallelism,
 	}
 }
 
-// Do attempts to complete a series of tasks against an Agent. Each task
-// is executed in order. If any task is unable to be attempted, or is able
-// to be attempted but fails, Do will halt execution. The returned slice
-// will contain a taskResult for every task that was attempted. Do is not
-// threadsafe.
+// Do attempts to complete a series of tasks against an Agent. The tasks
+// are expected to be sorted by type, and each run of tasks of the same
+// type is completed before the next one is started. Within such a run,
+// up to tm.parallelism tasks are executed at once, each only after the
+// tasks of the units it depends on (see taskDependencies). If any task is
+// unable to be attempted, fails, or is held back by a dependency cycle,
+// Do waits for the tasks already executing and halts execution. The
+// returned slice will contain a taskResult for every task that was
+// attempted, in the order the tasks completed. Do is not threadsafe.
 func (tm *taskManager) Do(tasks []task, a *Agent) []taskResult {
 	results := make([]taskResult, 0, len(tasks))
-	for _, t := range tasks {
-		taskFunc, err := tm.mapper(t, a)
-		if err == nil {
-			err = taskFunc()
+	for len(tasks) > 0 {
+		n := 1
+		for n < len(tasks) && tasks[n].typ == tasks[0].typ {
+			n++
 		}
 
-		results = append(results, taskResult{task: t, err: err})
-
-		if err != nil {
+		res, ok := tm.doOrdered(tasks[:n], a)
+		results = append(results, res...)
+		if !ok {
 			break
 		}
+		tasks = tasks[n:]
 	}
 
 	return results
 }
 
+// doOrdered executes the given tasks in the order of their dependencies,
+// reporting whether all of them completed.
+func (tm *taskManager) doOrdered(tasks []task, a *Agent) ([]taskResult, bool) {
+	parallelism := tm.parallelism
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	waiting, dependents := taskDependencies(tasks)
+	var ready []int
+	for i := range tasks {
+		if waiting[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	type completion struct {
+		i   int
+		err error
+	}
+	completions := make(chan completion)
+
+	results := make([]taskResult, 0, len(tasks))
+	running, ok := 0, true
+	for {
+		for ok && running < parallelism && len(ready) > 0 {
+			i := ready[0]
+			ready = ready[1:]
+			running++
+			go func(i int) {
+				completions <- completion{i, tm.do(tasks[i], a)}
+			}(i)
+		}
+		if running == 0 {
+			break
+		}
+
+		c := <-completions
+		running--
+		results = append(results, taskResult{task: tasks[c.i], err: c.err})
+		if c.err != nil {
+			ok = false
+			continue
+		}
+		for _, j := range dependents[c.i] {
+			waiting[j]--
+			if waiting[j] == 0 {
+				ready = append(ready, j)
+			}
+		}
+	}
+
+	if !ok {
+		return results, false
+	}
+
+	// any task still waiting is part of a dependency cycle, or depends
+	// on a task which is
+	var cyclic []int
+	var names []string
+	for i := range tasks {
+		if waiting[i] > 0 {
+			cyclic = append(cyclic, i)
+			names = append(names, tasks[i].unit.Name)
+		}
+	}
+	if len(cyclic) == 0 {
+		return results, true
+	}
+	sort.Strings(names)
+	for _, i := range cyclic {
+		err := fmt.Errorf("dependency cycle among units %s", strings.Join(names, ", "))
+		results = append(results, taskResult{task: tasks[i], err: err})
+	}
+	return results, false
+}
+
+func (tm *taskManager) do(t task, a *Agent) error {
+	taskFunc, err := tm.mapper(t, a)
+	if err != nil {
+		return err
+	}
+	return taskFunc()
+}
+
+// taskDependencies builds the dependency graph of the given tasks from
+// the dependencies of their units (see job.Unit.Dependencies), ignoring
+// units without a task. It returns the number of tasks each task has to
+// wait for, and the tasks waiting for each task. Units are loaded and
+// started after the units they depend on, and stopped and unloaded
+// before them.
+func taskDependencies(tasks []task) (waiting []int, dependents [][]int) {
+	index := make(map[string]int, len(tasks))
+	for i, t := range tasks {
+		if t.unit != nil {
+			index[t.unit.Name] = i
+		}
+	}
+
+	waiting = make([]int, len(tasks))
+	dependents = make([][]int, len(tasks))
+	for i, t := range tasks {
+		if t.unit == nil {
+			continue
+		}
+
+		seen := make(map[int]bool)
+		for _, name := range t.unit.Dependencies() {
+			j, ok := index[name]
+			if !ok || j == i || seen[j] {
+				continue
+			}
+			seen[j] = true
+
+			first, then := j, i
+			if t.typ == taskTypeStopUnit || t.typ == taskTypeUnloadUnit {
+				first, then = i, j
+			}
+			waiting[then]++
+			dependents[first] = append(dependents[first], then)
+		}
+	}
+
+	return waiting, dependents
+}
+
 type taskMapperFunc func(t task, a *Agent) (func() error, error)
 
 func mapTaskToFunc(t task, a *Agent) (fn func() error, err error) {
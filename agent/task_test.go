@@ -15,9 +15,15 @@
 package agent
 
 import (
+	"errors"
 	"reflect"
 	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/coreos/fleet/job"
 )
 
 func TestTaskSorting(t *testing.T) {
@@ -87,3 +93,177 @@ func TestTaskSorting(t *testing.T) {
 		}
 	}
 }
+
+func TestTaskManagerDo(t *testing.T) {
+	newUnit := func(name, contents string) *job.Unit {
+		return &job.Unit{Name: name, Unit: newUF(t, contents)}
+	}
+	main := newUnit("main.service", "[Service]\nExecStart=/bin/main\n")
+	sidekick := newUnit("sidekick.service", "[Unit]\nAfter=main.service\n[X-Fleet]\nMachineOf=main.service\n")
+	db := newUnit("db.service", "[Service]\nExecStart=/bin/db\n")
+	app := newUnit("app.service", "[Unit]\nRequires=db.service sidekick.service\n")
+	ping := newUnit("ping.service", "[Unit]\nAfter=pong.service\n")
+	pong := newUnit("pong.service", "[Unit]\nAfter=ping.service\n")
+	orphan := &job.Unit{Name: "orphan.service"}
+
+	tests := []struct {
+		tasks []task
+		fail  string
+		want  []string
+		errs  map[string]string
+	}{
+		// units start after their dependencies
+		{
+			tasks: []task{
+				{typ: taskTypeLoadUnit, unit: app},
+				{typ: taskTypeLoadUnit, unit: sidekick},
+				{typ: taskTypeLoadUnit, unit: main},
+				{typ: taskTypeLoadUnit, unit: db},
+				{typ: taskTypeReloadUnitFiles},
+				{typ: taskTypeStartUnit, unit: app},
+				{typ: taskTypeStartUnit, unit: sidekick},
+				{typ: taskTypeStartUnit, unit: main},
+			},
+			want: []string{
+				"LoadUnit main.service", "LoadUnit db.service", "LoadUnit sidekick.service", "LoadUnit app.service",
+				"ReloadUnitFiles",
+				"StartUnit main.service", "StartUnit sidekick.service", "StartUnit app.service",
+			},
+		},
+
+		// and stop before them
+		{
+			tasks: []task{
+				{typ: taskTypeUnloadUnit, unit: db},
+				{typ: taskTypeUnloadUnit, unit: app},
+				{typ: taskTypeStopUnit, unit: main},
+				{typ: taskTypeStopUnit, unit: sidekick},
+			},
+			want: []string{
+				"UnloadUnit app.service", "UnloadUnit db.service",
+				"StopUnit sidekick.service", "StopUnit main.service",
+			},
+		},
+
+		// dependencies without a task are ignored
+		{
+			tasks: []task{
+				{typ: taskTypeStartUnit, unit: app},
+				{typ: taskTypeUnloadUnit, unit: orphan},
+			},
+			want: []string{"StartUnit app.service", "UnloadUnit orphan.service"},
+		},
+
+		// nothing depending on a failed task is attempted
+		{
+			tasks: []task{
+				{typ: taskTypeLoadUnit, unit: sidekick},
+				{typ: taskTypeLoadUnit, unit: main},
+				{typ: taskTypeReloadUnitFiles},
+			},
+			fail: "LoadUnit main.service",
+			want: []string{"LoadUnit main.service"},
+			errs: map[string]string{"LoadUnit main.service": "failed"},
+		},
+
+		// cycles are reported once all other tasks completed
+		{
+			tasks: []task{
+				{typ: taskTypeStartUnit, unit: ping},
+				{typ: taskTypeStartUnit, unit: pong},
+				{typ: taskTypeStartUnit, unit: main},
+				{typ: taskTypeStartUnit, unit: sidekick},
+			},
+			want: []string{"StartUnit main.service", "StartUnit sidekick.service", "StartUnit ping.service", "StartUnit pong.service"},
+			errs: map[string]string{
+				"StartUnit ping.service": "dependency cycle among units ping.service, pong.service",
+				"StartUnit pong.service": "dependency cycle among units ping.service, pong.service",
+			},
+		},
+	}
+
+	describe := func(t task) string {
+		if t.unit == nil {
+			return t.typ
+		}
+		return t.typ + " " + t.unit.Name
+	}
+
+	for i, tt := range tests {
+		var attempted []string
+		tm := &taskManager{
+			mapper: func(t task, a *Agent) (func() error, error) {
+				return func() error {
+					attempted = append(attempted, describe(t))
+					if describe(t) == tt.fail {
+						return errors.New("failed")
+					}
+					return nil
+				}, nil
+			},
+			parallelism: 1,
+		}
+
+		results := tm.Do(tt.tasks, nil)
+		var got []string
+		errs := make(map[string]string)
+		for _, res := range results {
+			got = append(got, describe(res.task))
+			if res.err != nil {
+				errs[describe(res.task)] = res.err.Error()
+			}
+		}
+		if tt.errs == nil {
+			tt.errs = make(map[string]string)
+		}
+		if !reflect.DeepEqual(tt.want, got) {
+			t.Errorf("case %d: unexpected results\nexpected %v\n     got %v", i, tt.want, got)
+		}
+		if !reflect.DeepEqual(tt.errs, errs) {
+			t.Errorf("case %d: unexpected errors\nexpected %v\n     got %v", i, tt.errs, errs)
+		}
+		for _, desc := range attempted {
+			if strings.Contains(tt.errs[desc], "cycle") {
+				t.Errorf("case %d: task %s in dependency cycle attempted", i, desc)
+			}
+		}
+	}
+}
+
+func TestTaskManagerDoParallel(t *testing.T) {
+	var tasks []task
+	for _, name := range []string{"a.service", "b.service", "c.service", "d.service", "e.service"} {
+		tasks = append(tasks, task{typ: taskTypeStartUnit, unit: &job.Unit{Name: name}})
+	}
+
+	var mu sync.Mutex
+	running, max := 0, 0
+	tm := &taskManager{
+		mapper: func(t task, a *Agent) (func() error, error) {
+			return func() error {
+				mu.Lock()
+				running++
+				if running > max {
+					max = running
+				}
+				mu.Unlock()
+
+				time.Sleep(10 * time.Millisecond)
+
+				mu.Lock()
+				running--
+				mu.Unlock()
+				return nil
+			}, nil
+		},
+		parallelism: 2,
+	}
+
+	results := tm.Do(tasks, nil)
+	if len(results) != len(tasks) {
+		t.Fatalf("expected %d results, got %d", len(tasks), len(results))
+	}
+	if max != 2 {
+		t.Errorf("expected 2 tasks running at once, got %d", max)
+	}
+}
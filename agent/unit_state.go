@@ -35,6 +35,7 @@ func NewUnitStatePublisher(reg registry.Registry, mach machine.Machine, ttl time
 		mach:            mach,
 		ttl:             ttl,
 		publisher:       newPublisher(reg, ttl),
+		recorder:        newRecorder(reg),
 		cache:           make(map[string]*unit.UnitState),
 		cacheMutex:      sync.RWMutex{},
 		toPublish:       make(chan string),
@@ -46,6 +47,8 @@ func NewUnitStatePublisher(reg registry.Registry, mach machine.Machine, ttl time
 
 type publishFunc func(name string, us *unit.UnitState)
 
+type recordFunc func(name string, t registry.UnitStateTransition)
+
 type UnitStatePublisher struct {
 	mach machine.Machine
 	ttl  time.Duration
@@ -63,6 +66,8 @@ type UnitStatePublisher struct {
 	toPublishMutex  sync.RWMutex
 
 	publisher publishFunc
+	// recorder records the state transitions of units in their history
+	recorder recordFunc
 
 	clock clockwork.Clock
 }
@@ -153,6 +158,19 @@ func (p *UnitStatePublisher) MarshalJSON() ([]byte, error) {
 	return json.Marshal(data)
 }
 
+// UnitStates returns a copy of the latest UnitStates of the units the
+// UnitStatePublisher is aware of.
+func (p *UnitStatePublisher) UnitStates() map[string]*unit.UnitState {
+	p.cacheMutex.RLock()
+	defer p.cacheMutex.RUnlock()
+
+	states := make(map[string]*unit.UnitState, len(p.cache))
+	for name, us := range p.cache {
+		states[name] = us
+	}
+	return states
+}
+
 func (p *UnitStatePublisher) pruneCache() {
 	for name, us := range p.cache {
 		if us == nil {
@@ -189,6 +207,20 @@ func (p *UnitStatePublisher) updateCache(update *unit.UnitStateHeartbeat) (chang
 	if !ok || !reflect.DeepEqual(last, update.State) {
 		changed = true
 	}
+
+	// the health of a unit is not part of its history
+	us := update.State
+	if us != nil && p.recorder != nil && (last == nil || last.LoadState != us.LoadState ||
+		last.ActiveState != us.ActiveState || last.SubState != us.SubState || last.UnitHash != us.UnitHash) {
+		go p.recorder(update.Name, registry.UnitStateTransition{
+			Time:        p.clock.Now(),
+			MachineID:   us.MachineID,
+			LoadState:   us.LoadState,
+			ActiveState: us.ActiveState,
+			SubState:    us.SubState,
+			UnitHash:    us.UnitHash,
+		})
+	}
 	return
 }
 
@@ -200,6 +232,19 @@ func (p *UnitStatePublisher) Purge() {
 	}
 }
 
+// newRecorder returns a recordFunc that records a single state transition
+// in the history of the unit by the given name in the provided Registry.
+func newRecorder(reg registry.Registry) recordFunc {
+	return func(name string, t registry.UnitStateTransition) {
+		if len(t.UnitHash) == 0 || len(t.MachineID) == 0 {
+			return
+		}
+		if err := reg.RecordUnitStateTransition(name, t); err != nil {
+			log.Errorf("Failed recording state transition of Unit(%s): %v", name, err)
+		}
+	}
+}
+
 // newPublisher returns a publishFunc that publishes a single UnitState
 // by the given name to the provided Registry, with the given TTL
 func newPublisher(reg registry.Registry, ttl time.Duration) publishFunc {
@@ -123,6 +123,68 @@ func TestUpdateCache(t *testing.T) {
 	}
 }
 
+func TestUpdateCacheRecordsTransitions(t *testing.T) {
+	fclock := clockwork.NewFakeClock()
+	recorded := make(chan registry.UnitStateTransition, 4)
+	usp := NewUnitStatePublisher(nil, &machine.FakeMachine{}, 0)
+	usp.clock = fclock
+	usp.recorder = func(name string, t registry.UnitStateTransition) {
+		recorded <- t
+	}
+
+	state := func(active, health string) *unit.UnitState {
+		return &unit.UnitState{
+			LoadState:   "loaded",
+			ActiveState: active,
+			SubState:    active,
+			MachineID:   "XXX",
+			UnitHash:    "abc",
+			Health:      health,
+		}
+	}
+	tests := []struct {
+		state  *unit.UnitState
+		record bool
+	}{
+		// the first state of a unit is recorded
+		{state("active", ""), true},
+		// a change of health alone is not
+		{state("active", "healthy"), false},
+		// neither is the unit disappearing
+		{nil, false},
+		// but a different state is
+		{state("inactive", "healthy"), true},
+	}
+
+	for i, tt := range tests {
+		usp.updateCache(&unit.UnitStateHeartbeat{Name: "foo.service", State: tt.state})
+		if !tt.record {
+			continue
+		}
+		want := registry.UnitStateTransition{
+			Time:        fclock.Now(),
+			MachineID:   "XXX",
+			LoadState:   "loaded",
+			ActiveState: tt.state.ActiveState,
+			SubState:    tt.state.SubState,
+			UnitHash:    "abc",
+		}
+		select {
+		case got := <-recorded:
+			if !reflect.DeepEqual(want, got) {
+				t.Errorf("case %d: expected transition %#v, got %#v", i, want, got)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("case %d: expected transition to be recorded", i)
+		}
+	}
+	select {
+	case got := <-recorded:
+		t.Errorf("unexpected transition recorded: %#v", got)
+	default:
+	}
+}
+
 func TestPruneCache(t *testing.T) {
 	tests := []struct {
 		cacheBefore map[string]*unit.UnitState
@@ -0,0 +1,82 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"errors"
+	"net/http"
+	"path"
+
+	"github.com/coreos/fleet/client"
+	"github.com/coreos/fleet/log"
+	"github.com/coreos/fleet/registry"
+)
+
+func wireUpEngineResource(mux *http.ServeMux, prefix string, cAPI client.API) {
+	base := path.Join(prefix, "engine")
+	er := engineResource{cAPI, base}
+	mux.Handle(base, &er)
+	mux.Handle(base+"/", &er)
+}
+
+type engineResource struct {
+	cAPI     client.API
+	basePath string
+}
+
+func (er *engineResource) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if isCollectionPath(er.basePath, req.URL.Path) {
+		switch req.Method {
+		case "GET":
+			er.get(rw, req)
+		default:
+			sendError(rw, http.StatusMethodNotAllowed, errors.New("only GET supported against this resource"))
+		}
+	} else if item, ok := isItemPath(er.basePath, req.URL.Path); ok && item == "leader" {
+		switch req.Method {
+		case "DELETE":
+			er.stepDown(rw, req)
+		default:
+			sendError(rw, http.StatusMethodNotAllowed, errors.New("only DELETE supported against this resource"))
+		}
+	} else {
+		sendError(rw, http.StatusNotFound, nil)
+	}
+}
+
+func (er *engineResource) get(rw http.ResponseWriter, req *http.Request) {
+	status, err := er.cAPI.EngineStatus()
+	if err != nil {
+		log.Errorf("Failed fetching engine status: %v", err)
+		sendError(rw, http.StatusInternalServerError, nil)
+		return
+	}
+
+	sendResponse(rw, http.StatusOK, *status)
+}
+
+func (er *engineResource) stepDown(rw http.ResponseWriter, req *http.Request) {
+	err := er.cAPI.EngineStepDown()
+	if err == registry.ErrNoEngineLeader {
+		sendError(rw, http.StatusNotFound, err)
+		return
+	} else if err != nil {
+		log.Errorf("Failed releasing engine leadership: %v", err)
+		sendError(rw, http.StatusInternalServerError, nil)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
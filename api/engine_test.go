@@ -0,0 +1,96 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/coreos/fleet/client"
+	"github.com/coreos/fleet/registry"
+)
+
+func TestEngineGet(t *testing.T) {
+	fr := registry.NewFakeRegistry()
+	fr.SetReconcileStatus(registry.ReconcileStatus{
+		MachineID: "XXX",
+		Time:      time.Date(2016, 5, 4, 12, 0, 0, 0, time.UTC),
+		Duration:  250 * time.Millisecond,
+		Tasks:     map[string]int{"UnscheduleUnit": 1, "AttemptScheduleUnit": 2},
+	})
+	lManager := registry.NewFakeLeaseRegistry()
+	lManager.SetLease("engine-leader", "XXX", 1, 10*time.Second)
+
+	resource := &engineResource{&client.RegistryClient{Registry: fr, LeaseManager: lManager}, "/engine"}
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "http://example.com/engine", nil)
+	if err != nil {
+		t.Fatalf("Failed creating http.Request: %v", err)
+	}
+
+	resource.ServeHTTP(rw, req)
+	if rw.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rw.Code)
+	}
+
+	body := rw.Body.String()
+	expected := `{"lastReconcile":{"duration":0.25,"machineID":"XXX","tasks":[{"count":2,"type":"AttemptScheduleUnit"},{"count":1,"type":"UnscheduleUnit"}],"time":"2016-05-04T12:00:00Z"},"leaderMachineID":"XXX","leaseTimeRemaining":10}`
+	if body != expected {
+		t.Errorf("Expected body:\n%s\n\nReceived body:\n%s\n", expected, body)
+	}
+}
+
+func TestEngineStepDown(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		leader bool
+		code   int
+	}{
+		{"DELETE", "/engine/leader", true, http.StatusNoContent},
+		{"DELETE", "/engine/leader", false, http.StatusNotFound},
+		{"GET", "/engine/leader", true, http.StatusMethodNotAllowed},
+		{"DELETE", "/engine", true, http.StatusMethodNotAllowed},
+		{"DELETE", "/engine/bogus", true, http.StatusNotFound},
+	}
+
+	for i, tt := range tests {
+		lManager := registry.NewFakeLeaseRegistry()
+		if tt.leader {
+			lManager.SetLease("engine-leader", "XXX", 1, 10*time.Second)
+		}
+		resource := &engineResource{&client.RegistryClient{Registry: registry.NewFakeRegistry(), LeaseManager: lManager}, "/engine"}
+
+		rw := httptest.NewRecorder()
+		req, err := http.NewRequest(tt.method, "http://example.com"+tt.path, nil)
+		if err != nil {
+			t.Fatalf("case %d: failed creating http.Request: %v", i, err)
+		}
+
+		resource.ServeHTTP(rw, req)
+		if rw.Code != tt.code {
+			t.Errorf("case %d: expected %d, got %d", i, tt.code, rw.Code)
+			continue
+		}
+
+		if tt.code == http.StatusNoContent {
+			if l, _ := lManager.GetLease("engine-leader"); l != nil {
+				t.Errorf("case %d: expected lease to be released", i)
+			}
+		}
+	}
+}
@@ -29,6 +29,7 @@ import (
 
 var (
 	metadataPathRegex = regexp.MustCompile("^/([^/]+)/metadata/([A-Za-z0-9_.-]+$)")
+	statePathRegex    = regexp.MustCompile("^/([^/]+)/state$")
 )
 
 func wireUpMachinesResource(mux *http.ServeMux, prefix string, tokenLimit int, cAPI client.API) {
@@ -97,19 +98,40 @@ func (mr *machinesResource) patch(rw http.ResponseWriter, req *http.Request) {
 			return
 		}
 
-		if metadataPathRegex.FindStringSubmatch(op.Path) == nil {
-			sendError(rw, http.StatusBadRequest, errors.New("machine metadata path invalid"))
+		if op.Operation != "remove" && len(op.Value.Value) == 0 {
+			sendError(rw, http.StatusBadRequest, errors.New("invalid value: add and replace require a value"))
 			return
 		}
 
-		if op.Operation != "remove" && len(op.Value.Value) == 0 {
-			sendError(rw, http.StatusBadRequest, errors.New("invalid value: add and replace require a value"))
+		if statePathRegex.MatchString(op.Path) {
+			var ms machine.MachineState
+			if err := ms.SetSchedulingState(op.Value.Value); op.Operation != "remove" && err != nil {
+				sendError(rw, http.StatusBadRequest, err)
+				return
+			}
+			continue
+		}
+
+		if metadataPathRegex.FindStringSubmatch(op.Path) == nil {
+			sendError(rw, http.StatusBadRequest, errors.New("machine metadata path invalid"))
 			return
 		}
 	}
 
 	for _, op := range ops {
-		// regex already validated above
+		// paths already validated above
+		if s := statePathRegex.FindStringSubmatch(op.Path); s != nil {
+			state := op.Value.Value
+			if op.Operation == "remove" {
+				state = machine.StateSchedulable
+			}
+			if err := mr.cAPI.SetMachineSchedulingState(s[1], state); err != nil {
+				sendError(rw, http.StatusInternalServerError, err)
+				return
+			}
+			continue
+		}
+
 		s := metadataPathRegex.FindStringSubmatch(op.Path)
 		machID := s[1]
 		key := s[2]
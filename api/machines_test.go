@@ -266,3 +266,63 @@ func TestMachinesPatchBadValue(t *testing.T) {
 		t.Errorf("Expected 400, got %d", rw.Code)
 	}
 }
+
+func TestMachinesPatchState(t *testing.T) {
+	reqBody := `
+	[{"op": "replace", "path": "/XXX/state", "value": { "value": "cordoned" }},
+	 {"op": "replace", "path": "/YYY/state", "value": { "value": "draining" }}]
+	`
+
+	resource, rw := fakeMachinesSetup()
+	req, err := http.NewRequest("PATCH", "http://example.com/machines", strings.NewReader(reqBody))
+	if err != nil {
+		t.Fatalf("Failed creating http.Request: %v", err)
+	}
+
+	resource.ServeHTTP(rw, req)
+	if rw.Code != http.StatusNoContent {
+		t.Errorf("Expected 204, got %d", rw.Code)
+	}
+
+	req, err = http.NewRequest("GET", "http://example.com/machines", nil)
+	if err != nil {
+		t.Fatalf("Failed creating http.Request: %v", err)
+	}
+	rw.Body.Reset()
+	resource.ServeHTTP(rw, req)
+
+	body := rw.Body.String()
+	expected := `{"machines":[{"id":"XXX","state":"cordoned"},{"id":"YYY","metadata":{"ping":"pong"},"primaryIP":"1.2.3.4","state":"draining"}]}`
+	if body != expected {
+		t.Errorf("Expected body:\n%s\n\nReceived body:\n%s\n", expected, body)
+	}
+
+	// removing the state makes machines schedulable again
+	reqBody = `[{"op": "remove", "path": "/XXX/state"}]`
+	req, err = http.NewRequest("PATCH", "http://example.com/machines", strings.NewReader(reqBody))
+	if err != nil {
+		t.Fatalf("Failed creating http.Request: %v", err)
+	}
+	rw.Body.Reset()
+	resource.ServeHTTP(rw, req)
+	if rw.Code != http.StatusNoContent {
+		t.Errorf("Expected 204, got %d", rw.Code)
+	}
+}
+
+func TestMachinesPatchBadState(t *testing.T) {
+	reqBody := `
+	[{"op": "replace", "path": "/XXX/state", "value": { "value": "paused" }}]
+	`
+
+	resource, rw := fakeMachinesSetup()
+	req, err := http.NewRequest("PATCH", "http://example.com/machines", strings.NewReader(reqBody))
+	if err != nil {
+		t.Fatalf("Failed creating http.Request: %v", err)
+	}
+
+	resource.ServeHTTP(rw, req)
+	if rw.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400, got %d", rw.Code)
+	}
+}
@@ -18,16 +18,18 @@ import (
 	"net/http"
 
 	"github.com/coreos/fleet/client"
+	"github.com/coreos/fleet/engine"
 	"github.com/coreos/fleet/log"
+	"github.com/coreos/fleet/pkg/lease"
 	"github.com/coreos/fleet/registry"
 	"github.com/coreos/fleet/version"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
 
-func NewServeMux(reg registry.Registry, tokenLimit int) http.Handler {
+func NewServeMux(reg registry.Registry, lManager lease.Manager, tokenLimit int) http.Handler {
 	sm := http.NewServeMux()
-	cAPI := &client.RegistryClient{Registry: reg}
+	cAPI := &client.RegistryClient{Registry: reg, LeaseManager: lManager, Planner: engine.NewPlanner(reg)}
 
 	for _, prefix := range []string{"/v1-alpha", "/fleet/v1"} {
 		wireUpDiscoveryResource(sm, prefix)
@@ -35,6 +37,8 @@ func NewServeMux(reg registry.Registry, tokenLimit int) http.Handler {
 		wireUpMachinesResource(sm, prefix, tokenLimit, cAPI)
 		wireUpStateResource(sm, prefix, tokenLimit, cAPI)
 		wireUpUnitsResource(sm, prefix, tokenLimit, cAPI)
+		wireUpPlanResource(sm, prefix, cAPI)
+		wireUpEngineResource(sm, prefix, cAPI)
 		sm.HandleFunc(prefix, methodNotAllowedHandler)
 	}
 
@@ -43,3 +43,10 @@ func isItemPath(base, p string) (item string, matched bool) {
 
 	return
 }
+
+func isSubItemPath(base, p, sub string) (item string, matched bool) {
+	if !strings.HasSuffix(p, "/"+sub) {
+		return
+	}
+	return isItemPath(base, strings.TrimSuffix(p, "/"+sub))
+}
@@ -93,3 +93,29 @@ func TestIsItemPathFail(t *testing.T) {
 		}
 	}
 }
+
+func TestIsSubItemPath(t *testing.T) {
+	tests := []struct {
+		base    string
+		arg     string
+		item    string
+		matched bool
+	}{
+		{"/v1/units", "/v1/units/foo.service/scheduling", "foo.service", true},
+		{"/v1/units/", "/v1/units/foo.service/scheduling", "foo.service", true},
+		{"/v1/units", "/v1/units/foo.service", "", false},
+		{"/v1/units", "/v1/units/scheduling", "", false},
+		{"/v1/units", "/v1/units/foo.service/scheduling/", "", false},
+		{"/v1/units", "/v1/units/foo/bar/scheduling", "", false},
+		{"/v1/units", "/v1/units/foo.service/history", "", false},
+	}
+
+	for i, tt := range tests {
+		item, ok := isSubItemPath(tt.base, tt.arg, "scheduling")
+		if ok != tt.matched {
+			t.Errorf("case %d: expected matched=%t with base=%s arg=%s", i, tt.matched, tt.base, tt.arg)
+		} else if item != tt.item {
+			t.Errorf("case %d: expected item=%s, got %s", i, tt.item, item)
+		}
+	}
+}
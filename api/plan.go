@@ -0,0 +1,121 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+
+	"github.com/coreos/fleet/client"
+	"github.com/coreos/fleet/engine"
+	"github.com/coreos/fleet/job"
+	"github.com/coreos/fleet/log"
+	"github.com/coreos/fleet/schema"
+)
+
+func wireUpPlanResource(mux *http.ServeMux, prefix string, cAPI client.API) {
+	base := path.Join(prefix, "plan")
+	pr := planResource{cAPI, base}
+	mux.Handle(base, &pr)
+}
+
+type planResource struct {
+	cAPI     client.API
+	basePath string
+}
+
+func (pr *planResource) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if !isCollectionPath(pr.basePath, req.URL.Path) {
+		sendError(rw, http.StatusNotFound, nil)
+		return
+	}
+
+	switch req.Method {
+	case "POST":
+		pr.create(rw, req)
+	default:
+		sendError(rw, http.StatusMethodNotAllowed, errors.New("only POST supported against this resource"))
+	}
+}
+
+func (pr *planResource) create(rw http.ResponseWriter, req *http.Request) {
+	if err := validateContentType(req); err != nil {
+		sendError(rw, http.StatusUnsupportedMediaType, err)
+		return
+	}
+
+	var spr schema.PlanRequest
+	dec := json.NewDecoder(req.Body)
+	if err := dec.Decode(&spr); err != nil {
+		sendError(rw, http.StatusBadRequest, fmt.Errorf("unable to decode body: %v", err))
+		return
+	}
+
+	if _, err := engine.NewScheduler(spr.Strategy, 0); err != nil {
+		sendError(rw, http.StatusBadRequest, err)
+		return
+	}
+
+	for _, su := range spr.Units {
+		if err := ValidateName(su.Name); err != nil {
+			sendError(rw, http.StatusBadRequest, err)
+			return
+		}
+		if su.DesiredState != "" {
+			if _, err := job.ParseJobState(su.DesiredState); err != nil {
+				sendError(rw, http.StatusBadRequest, err)
+				return
+			}
+		}
+
+		if len(su.Options) > 0 {
+			if err := ValidateOptions(su.Options); err != nil {
+				sendError(rw, http.StatusBadRequest, err)
+				return
+			}
+			continue
+		}
+
+		eu, err := pr.cAPI.Unit(su.Name)
+		if err != nil {
+			log.Errorf("Failed fetching Unit(%s) from Registry: %v", su.Name, err)
+			sendError(rw, http.StatusInternalServerError, nil)
+			return
+		}
+		if eu == nil {
+			sendError(rw, http.StatusConflict, fmt.Errorf("unit %s does not exist and options field empty", su.Name))
+			return
+		}
+	}
+
+	for _, md := range spr.AddMetadata {
+		if md.MachineID == "" || md.Key == "" {
+			sendError(rw, http.StatusBadRequest, errors.New("machineID and key required to add metadata"))
+			return
+		}
+	}
+
+	plan, err := pr.cAPI.Plan(&spr)
+	if err != nil {
+		log.Errorf("Failed planning changes: %v", err)
+		sendError(rw, http.StatusInternalServerError, nil)
+		return
+	}
+
+	sendResponse(rw, http.StatusOK, *plan)
+}
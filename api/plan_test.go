@@ -0,0 +1,159 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/coreos/fleet/client"
+	"github.com/coreos/fleet/engine"
+	"github.com/coreos/fleet/job"
+	"github.com/coreos/fleet/machine"
+	"github.com/coreos/fleet/registry"
+	"github.com/coreos/fleet/schema"
+)
+
+func TestPlanCreate(t *testing.T) {
+	ignored := append([]string{"scheduler_strategy"}, engine.PlanIgnoredSettings...)
+	tests := []struct {
+		method string
+		body   string
+		code   int
+		plan   *schema.Plan
+	}{
+		// removing a machine reschedules its units
+		{
+			method: "POST",
+			body:   `{"removeMachines": ["XXX"]}`,
+			code:   http.StatusOK,
+			plan: &schema.Plan{
+				IgnoredSettings: ignored,
+				Tasks: []*schema.PlanTask{
+					{Type: "UnscheduleUnit", UnitName: "foo.service", MachineID: "XXX", Reason: "target Machine(XXX) went away"},
+					{Type: "AttemptScheduleUnit", UnitName: "foo.service", MachineID: "YYY", Reason: "target state launched and unit not scheduled"},
+				},
+			},
+		},
+		// new units are planned from their options
+		{
+			method: "POST",
+			body:   `{"units": [{"name": "bar.service", "options": [{"section": "X-Fleet", "name": "MachineMetadata", "value": "region=eu"}]}], "addMetadata": [{"machineID": "YYY", "key": "region", "value": "eu"}]}`,
+			code:   http.StatusOK,
+			plan: &schema.Plan{
+				IgnoredSettings: ignored,
+				Tasks: []*schema.PlanTask{
+					{Type: "AttemptScheduleUnit", UnitName: "bar.service", MachineID: "YYY", Reason: "target state launched and unit not scheduled"},
+				},
+			},
+		},
+		// existing units may be given by name only
+		{
+			method: "POST",
+			body:   `{"units": [{"name": "foo.service", "desiredState": "inactive"}]}`,
+			code:   http.StatusOK,
+			plan: &schema.Plan{
+				IgnoredSettings: ignored,
+				Tasks: []*schema.PlanTask{
+					{Type: "UnscheduleUnit", UnitName: "foo.service", MachineID: "XXX", Reason: "target state inactive"},
+				},
+			},
+		},
+		// a given strategy is not listed as ignored
+		{
+			method: "POST",
+			body:   `{"strategy": "least-loaded"}`,
+			code:   http.StatusOK,
+			plan: &schema.Plan{
+				IgnoredSettings: engine.PlanIgnoredSettings,
+			},
+		},
+		{
+			method: "POST",
+			body:   `{"units": [{"name": "bar.service"}]}`,
+			code:   http.StatusConflict,
+		},
+		{
+			method: "POST",
+			body:   `{"units": [{"name": "foo.service", "desiredState": "running"}]}`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			method: "POST",
+			body:   `{"strategy": "fastest"}`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			method: "POST",
+			body:   `{"addMetadata": [{"key": "region", "value": "eu"}]}`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			method: "GET",
+			code:   http.StatusMethodNotAllowed,
+		},
+	}
+
+	for i, tt := range tests {
+		fr := registry.NewFakeRegistry()
+		fr.SetMachines([]machine.MachineState{
+			machine.MachineState{ID: "XXX"},
+			machine.MachineState{ID: "YYY"},
+		})
+		fr.SetJobs([]job.Job{
+			job.Job{Name: "foo.service", TargetState: job.JobStateLaunched, TargetMachineID: "XXX"},
+		})
+		fAPI := &client.RegistryClient{Registry: fr, Planner: engine.NewPlanner(fr)}
+		resource := &planResource{fAPI, "/plan"}
+
+		rw := httptest.NewRecorder()
+		req, err := http.NewRequest(tt.method, "http://example.com/plan", bytes.NewBufferString(tt.body))
+		if err != nil {
+			t.Fatalf("case %d: failed creating http.Request: %v", i, err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resource.ServeHTTP(rw, req)
+
+		if tt.code/100 != 2 {
+			if err := assertErrorResponse(rw, tt.code); err != nil {
+				t.Errorf("case %d: %v", i, err)
+			}
+			continue
+		}
+
+		if rw.Code != tt.code {
+			t.Errorf("case %d: expected %d, got %d", i, tt.code, rw.Code)
+			continue
+		}
+
+		var plan schema.Plan
+		if err := json.Unmarshal(rw.Body.Bytes(), &plan); err != nil {
+			t.Errorf("case %d: unable to decode response: %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(*tt.plan, plan) {
+			t.Errorf("case %d: expected %#v, got %#v", i, *tt.plan, plan)
+		}
+
+		if su, _ := fr.ScheduledUnit("foo.service"); su.TargetMachineID != "XXX" {
+			t.Errorf("case %d: registry was modified by plan", i)
+		}
+	}
+}
@@ -52,6 +52,13 @@ func (sr *stateResource) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 		default:
 			sendError(rw, http.StatusMethodNotAllowed, errors.New("only GET supported against this resource"))
 		}
+	} else if item, ok := isSubItemPath(sr.basePath, req.URL.Path, "history"); ok {
+		switch req.Method {
+		case "GET":
+			sr.history(rw, req, item)
+		default:
+			sendError(rw, http.StatusMethodNotAllowed, errors.New("only GET supported against this resource"))
+		}
 	} else {
 		sendError(rw, http.StatusNotFound, nil)
 	}
@@ -105,6 +112,22 @@ func (sr *stateResource) get(rw http.ResponseWriter, req *http.Request, item str
 	sendResponse(rw, http.StatusOK, *us)
 }
 
+func (sr *stateResource) history(rw http.ResponseWriter, req *http.Request, item string) {
+	h, err := sr.cAPI.UnitStateHistory(item)
+	if err != nil {
+		log.Errorf("Failed fetching UnitStateHistory(%s) from Registry: %v", item, err)
+		sendError(rw, http.StatusInternalServerError, nil)
+		return
+	}
+
+	if h == nil {
+		sendError(rw, http.StatusNotFound, errors.New("unit does not exist"))
+		return
+	}
+
+	sendResponse(rw, http.StatusOK, *h)
+}
+
 func getUnitStatePage(cAPI client.API, machineID, unitName string, tok PageToken) (*schema.UnitStatePage, error) {
 	states, err := cAPI.UnitStates()
 	if err != nil {
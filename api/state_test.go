@@ -16,13 +16,16 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/coreos/fleet/client"
+	"github.com/coreos/fleet/job"
 	"github.com/coreos/fleet/registry"
 	"github.com/coreos/fleet/schema"
 	"github.com/coreos/fleet/unit"
@@ -243,3 +246,74 @@ func TestExtractUnitStatePage(t *testing.T) {
 		}
 	}
 }
+
+func TestUnitStateHistory(t *testing.T) {
+	tests := []struct {
+		item string
+		code int
+		resp *schema.UnitStateHistory
+	}{
+		{
+			item: "XXX.service",
+			code: http.StatusOK,
+			resp: &schema.UnitStateHistory{
+				Name: "XXX.service",
+				Transitions: []*schema.UnitStateTransition{
+					{Time: "2016-05-04T12:00:00Z", MachineID: "abc", LoadState: "loaded", ActiveState: "active", SubState: "running", UnitHash: "abc123"},
+					{Time: "2016-05-04T12:10:00.25Z", MachineID: "def", LoadState: "loaded", ActiveState: "failed", SubState: "failed", UnitHash: "abc123"},
+				},
+			},
+		},
+		{
+			item: "YYY.service",
+			code: http.StatusOK,
+			resp: &schema.UnitStateHistory{Name: "YYY.service"},
+		},
+		{item: "ZZZ.service", code: http.StatusNotFound},
+	}
+
+	fr := registry.NewFakeRegistry()
+	fr.SetJobs([]job.Job{{Name: "XXX.service"}, {Name: "YYY.service"}})
+	since := time.Date(2016, time.May, 4, 12, 0, 0, 0, time.UTC)
+	fr.RecordUnitStateTransition("XXX.service", registry.UnitStateTransition{
+		Time: since, MachineID: "abc", LoadState: "loaded", ActiveState: "active", SubState: "running", UnitHash: "abc123",
+	})
+	fr.RecordUnitStateTransition("XXX.service", registry.UnitStateTransition{
+		Time: since.Add(10*time.Minute + 250*time.Millisecond), MachineID: "def", LoadState: "loaded", ActiveState: "failed", SubState: "failed", UnitHash: "abc123",
+	})
+	fAPI := &client.RegistryClient{Registry: fr}
+	resource := &stateResource{fAPI, "/state", testTokenLimit}
+
+	for i, tt := range tests {
+		rw := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", fmt.Sprintf("http://example.com/state/%s/history", tt.item), nil)
+		if err != nil {
+			t.Errorf("case %d: failed creating http.Request: %v", i, err)
+			continue
+		}
+
+		resource.ServeHTTP(rw, req)
+
+		if tt.code/100 != 2 {
+			err = assertErrorResponse(rw, tt.code)
+			if err != nil {
+				t.Errorf("case %d: %v", i, err)
+			}
+			continue
+		}
+
+		if tt.code != rw.Code {
+			t.Errorf("case %d: expected %d, got %d", i, tt.code, rw.Code)
+			continue
+		}
+
+		var got schema.UnitStateHistory
+		if err := json.Unmarshal(rw.Body.Bytes(), &got); err != nil {
+			t.Errorf("case %d: unable to decode response: %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(*tt.resp, got) {
+			t.Errorf("case %d: expected %#v, got %#v", i, *tt.resp, got)
+		}
+	}
+}
@@ -61,8 +61,17 @@ func (ur *unitsResource) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 			ur.destroy(rw, req, item)
 		case "PUT":
 			ur.set(rw, req, item)
+		case "PATCH":
+			ur.patch(rw, req, item)
 		default:
-			sendError(rw, http.StatusMethodNotAllowed, errors.New("only GET, PUT and DELETE supported against this resource"))
+			sendError(rw, http.StatusMethodNotAllowed, errors.New("only GET, PUT, PATCH and DELETE supported against this resource"))
+		}
+	} else if item, ok := isSubItemPath(ur.basePath, req.URL.Path, "scheduling"); ok {
+		switch req.Method {
+		case "GET":
+			ur.scheduling(rw, req, item)
+		default:
+			sendError(rw, http.StatusMethodNotAllowed, errors.New("only GET supported against this resource"))
 		}
 	} else {
 		sendError(rw, http.StatusNotFound, nil)
@@ -149,6 +158,65 @@ func (ur *unitsResource) set(rw http.ResponseWriter, req *http.Request, item str
 	ur.update(rw, su.Name, su.DesiredState)
 }
 
+// patch sets the replica count of a template unit. Unlike set, the
+// replicas field must be given explicitly, as a count of zero is valid.
+func (ur *unitsResource) patch(rw http.ResponseWriter, req *http.Request, item string) {
+	if err := validateContentType(req); err != nil {
+		sendError(rw, http.StatusUnsupportedMediaType, err)
+		return
+	}
+
+	var body struct {
+		Name     string `json:"name"`
+		Replicas *int64 `json:"replicas"`
+	}
+	dec := json.NewDecoder(req.Body)
+	if err := dec.Decode(&body); err != nil {
+		sendError(rw, http.StatusBadRequest, fmt.Errorf("unable to decode body: %v", err))
+		return
+	}
+	if body.Name != "" && item != body.Name {
+		sendError(rw, http.StatusBadRequest, fmt.Errorf("name in URL %q differs from unit name in request body %q", item, body.Name))
+		return
+	}
+	if body.Replicas == nil {
+		sendError(rw, http.StatusBadRequest, errors.New("replicas field required"))
+		return
+	}
+	if *body.Replicas < 0 {
+		sendError(rw, http.StatusBadRequest, errors.New("replicas must not be negative"))
+		return
+	}
+	if un := unit.NewUnitNameInfo(item); un == nil || !un.IsTemplate() {
+		sendError(rw, http.StatusBadRequest, fmt.Errorf("cannot set replicas of %q, which is not a template", item))
+		return
+	}
+
+	u, err := ur.cAPI.Unit(item)
+	if err != nil {
+		log.Errorf("Failed fetching Unit(%s) from Registry: %v", item, err)
+		sendError(rw, http.StatusInternalServerError, nil)
+		return
+	}
+	if u == nil {
+		sendError(rw, http.StatusNotFound, errors.New("unit does not exist"))
+		return
+	}
+	// global units run on every machine, so they are not scaled
+	if schema.MapSchemaUnitToUnit(u).IsGlobal() {
+		sendError(rw, http.StatusBadRequest, fmt.Errorf("cannot set replicas of %q, which is a global unit", item))
+		return
+	}
+
+	if err := ur.cAPI.SetUnitReplicas(item, int(*body.Replicas)); err != nil {
+		log.Errorf("Failed setting replicas of Unit(%s): %v", item, err)
+		sendError(rw, http.StatusInternalServerError, nil)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
 const (
 	// These constants taken from systemd
 	unitNameMax    = 256
@@ -234,6 +302,9 @@ func ValidateOptions(opts []*schema.UnitOption) error {
 	j := &job.Job{
 		Unit: *uf,
 	}
+	if err := j.ValidateMetadata(); err != nil {
+		return err
+	}
 	conflicts := pkg.NewUnsafeSet(j.Conflicts()...)
 	replaces := pkg.NewUnsafeSet(j.Replaces()...)
 	peers := pkg.NewUnsafeSet(j.Peers()...)
@@ -259,6 +330,19 @@ func ValidateOptions(opts []*schema.UnitOption) error {
 		Unit: *uf,
 	}
 	isGlobal := u.IsGlobal()
+	hasSpreadBy := false
+	hasReplicas := false
+	for _, opt := range opts {
+		if opt.Section != "X-Fleet" {
+			continue
+		}
+		switch opt.Name {
+		case "SpreadBy":
+			hasSpreadBy = true
+		case "Replicas":
+			hasReplicas = true
+		}
+	}
 
 	switch {
 	case hasReqTarget && hasPeers:
@@ -273,6 +357,10 @@ func ValidateOptions(opts []*schema.UnitOption) error {
 		return errors.New("Global cannot be used with Peers")
 	case isGlobal && hasReplaces:
 		return errors.New("Global cannot be used with Replaces")
+	case isGlobal && hasSpreadBy:
+		return errors.New("Global cannot be used with SpreadBy")
+	case isGlobal && hasReplicas:
+		return errors.New("Global cannot be used with Replicas")
 	case hasConflicts && hasReplaces:
 		return errors.New("Conflicts cannot be used with Replaces")
 	}
@@ -339,6 +427,22 @@ func (ur *unitsResource) get(rw http.ResponseWriter, req *http.Request, item str
 	sendResponse(rw, http.StatusOK, *u)
 }
 
+func (ur *unitsResource) scheduling(rw http.ResponseWriter, req *http.Request, item string) {
+	us, err := ur.cAPI.UnitScheduling(item)
+	if err != nil {
+		log.Errorf("Failed fetching UnitScheduling(%s) from Registry: %v", item, err)
+		sendError(rw, http.StatusInternalServerError, nil)
+		return
+	}
+
+	if us == nil {
+		sendError(rw, http.StatusNotFound, errors.New("unit does not exist"))
+		return
+	}
+
+	sendResponse(rw, http.StatusOK, *us)
+}
+
 func (ur *unitsResource) list(rw http.ResponseWriter, req *http.Request) {
 	token, err := findNextPageToken(req.URL, ur.tokenLimit)
 	if err != nil {
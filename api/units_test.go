@@ -24,9 +24,11 @@ import (
 	"reflect"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/coreos/fleet/client"
 	"github.com/coreos/fleet/job"
+	"github.com/coreos/fleet/machine"
 	"github.com/coreos/fleet/registry"
 	"github.com/coreos/fleet/schema"
 	"github.com/coreos/fleet/unit"
@@ -100,6 +102,47 @@ func TestUnitsList(t *testing.T) {
 	}
 }
 
+func TestUnitsListMachineLost(t *testing.T) {
+	launched := job.JobStateLaunched
+	fr := registry.NewFakeRegistry()
+	fr.SetJobs([]job.Job{
+		{Name: "XXX.service", State: &launched, TargetMachineID: "AAA"},
+		{Name: "YYY.service", State: &launched, TargetMachineID: "BBB"},
+		{Name: "ZZZ.service", State: &launched, TargetMachineID: "CCC"},
+	})
+	fr.SetMachines([]machine.MachineState{{ID: "AAA"}, {ID: "CCC"}})
+	// CCC returned since the engine last recorded it as lost
+	fr.SetLostMachines(map[string]time.Time{"BBB": time.Now(), "CCC": time.Now()})
+
+	fAPI := &client.RegistryClient{Registry: fr}
+	resource := &unitsResource{fAPI, "/units", testTokenLimit}
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "http://example.com/units", nil)
+	if err != nil {
+		t.Fatalf("Failed creating http.Request: %v", err)
+	}
+
+	resource.list(rw, req)
+	if rw.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rw.Code)
+	}
+
+	var page schema.UnitPage
+	if err := json.Unmarshal(rw.Body.Bytes(), &page); err != nil {
+		t.Fatalf("Received unparseable body: %v", err)
+	}
+
+	want := []string{"launched", "machine-lost", "launched"}
+	if len(page.Units) != len(want) {
+		t.Fatalf("Expected %d units, got %d", len(want), len(page.Units))
+	}
+	for i, u := range page.Units {
+		if u.CurrentState != want[i] {
+			t.Errorf("Unit %s: expected current state %q, got %q", u.Name, want[i], u.CurrentState)
+		}
+	}
+}
+
 func TestUnitsListBadNextPageToken(t *testing.T) {
 	fr := registry.NewFakeRegistry()
 	fAPI := &client.RegistryClient{Registry: fr}
@@ -209,6 +252,85 @@ func TestUnitGet(t *testing.T) {
 	}
 }
 
+func TestUnitScheduling(t *testing.T) {
+	since := time.Date(2016, time.May, 4, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		item string
+		code int
+		resp *schema.UnitScheduling
+	}{
+		{
+			item: "XXX.service",
+			code: http.StatusOK,
+			resp: &schema.UnitScheduling{
+				Name:   "XXX.service",
+				Reason: "no agents able to run job",
+				Since:  "2016-05-04T12:00:00Z",
+				Machines: []*schema.UnitSchedulingMachine{
+					{MachineID: "abc", Reason: "insufficient resources"},
+					{MachineID: "def", Reason: "unit conflicts with YYY.service"},
+				},
+			},
+		},
+		{
+			item: "YYY.service",
+			code: http.StatusOK,
+			resp: &schema.UnitScheduling{Name: "YYY.service", MachineID: "def"},
+		},
+		{item: "ZZZ", code: http.StatusNotFound},
+	}
+
+	fr := registry.NewFakeRegistry()
+	fr.SetJobs([]job.Job{
+		{Name: "XXX.service"},
+		{Name: "YYY.service", TargetMachineID: "def"},
+	})
+	fr.SetSchedulingExplanation(job.SchedulingExplanation{
+		Name:   "XXX.service",
+		Reason: "no agents able to run job",
+		MachineReasons: map[string]string{
+			"def": "unit conflicts with YYY.service",
+			"abc": "insufficient resources",
+		},
+		Since: since,
+	})
+	fAPI := &client.RegistryClient{Registry: fr}
+	resource := &unitsResource{fAPI, "/units", testTokenLimit}
+
+	for i, tt := range tests {
+		rw := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", fmt.Sprintf("http://example.com/units/%s/scheduling", tt.item), nil)
+		if err != nil {
+			t.Errorf("case %d: failed creating http.Request: %v", i, err)
+			continue
+		}
+
+		resource.ServeHTTP(rw, req)
+
+		if tt.code/100 != 2 {
+			err = assertErrorResponse(rw, tt.code)
+			if err != nil {
+				t.Errorf("case %d: %v", i, err)
+			}
+			continue
+		}
+
+		if tt.code != rw.Code {
+			t.Errorf("case %d: expected %d, got %d", i, tt.code, rw.Code)
+			continue
+		}
+
+		var got schema.UnitScheduling
+		if err := json.Unmarshal(rw.Body.Bytes(), &got); err != nil {
+			t.Errorf("case %d: unable to decode response: %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(*tt.resp, got) {
+			t.Errorf("case %d: expected %#v, got %#v", i, *tt.resp, got)
+		}
+	}
+}
+
 func TestUnitsDestroy(t *testing.T) {
 	tests := []struct {
 		// initial state of registry
@@ -721,6 +843,70 @@ func TestValidateOptions(t *testing.T) {
 			},
 			false,
 		},
+		// Global with SpreadBy no good
+		{
+			[]*schema.UnitOption{
+				&schema.UnitOption{
+					Section: "X-Fleet",
+					Name:    "Global",
+					Value:   "true",
+				},
+				&schema.UnitOption{
+					Section: "X-Fleet",
+					Name:    "SpreadBy",
+					Value:   "rack",
+				},
+			},
+			false,
+		},
+		// Global with Replicas no good
+		{
+			[]*schema.UnitOption{
+				&schema.UnitOption{
+					Section: "X-Fleet",
+					Name:    "Global",
+					Value:   "true",
+				},
+				&schema.UnitOption{
+					Section: "X-Fleet",
+					Name:    "Replicas",
+					Value:   "3",
+				},
+			},
+			false,
+		},
+		// MachineMetadata expressions must be parseable
+		{
+			[]*schema.UnitOption{
+				&schema.UnitOption{
+					Section: "X-Fleet",
+					Name:    "MachineMetadata",
+					Value:   "disk_gb>=lots",
+				},
+			},
+			false,
+		},
+		{
+			[]*schema.UnitOption{
+				&schema.UnitOption{
+					Section: "X-Fleet",
+					Name:    "MachineMetadata",
+					Value:   "!=db",
+				},
+			},
+			false,
+		},
+		// unless ignored for compatibility
+		{
+			[]*schema.UnitOption{
+				&schema.UnitOption{
+					Section: "X-Fleet",
+					Name:    "MachineMetadata",
+					Value:   "foo=bar=baz",
+				},
+			},
+			true,
+		},
 	}
 	for i, tt := range testCases {
 		err := ValidateOptions(tt.opts)
@@ -823,3 +1009,64 @@ func TestUnitsSetDesiredStateBadContentType(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestUnitsPatchReplicas(t *testing.T) {
+	tests := []struct {
+		item string
+		body string
+		code int
+		// expected replicas of the template after the request
+		replicas int
+	}{
+		{"web@.service", `{"replicas":8}`, http.StatusNoContent, 8},
+		{"web@.service", `{"name":"web@.service","replicas":0}`, http.StatusNoContent, 0},
+		// the count must be given explicitly
+		{"web@.service", `{}`, http.StatusBadRequest, 2},
+		{"web@.service", `{"replicas":-1}`, http.StatusBadRequest, 2},
+		{"web@.service", `{"name":"api@.service","replicas":8}`, http.StatusBadRequest, 2},
+		{"web@1.service", `{"replicas":8}`, http.StatusBadRequest, 2},
+		{"api@.service", `{"replicas":8}`, http.StatusNotFound, 2},
+		{"global@.service", `{"replicas":8}`, http.StatusBadRequest, 2},
+	}
+
+	tmpl := newUnit(t, "[Service]\nExecStart=/bin/true\n[X-Fleet]\nReplicas=2")
+	for i, tt := range tests {
+		fr := registry.NewFakeRegistry()
+		fr.SetJobs([]job.Job{
+			job.Job{Name: "web@.service", Unit: tmpl},
+			job.Job{Name: "web@1.service", Unit: newUnit(t, "[Service]\nExecStart=/bin/true")},
+			job.Job{Name: "global@.service", Unit: newUnit(t, "[Service]\nExecStart=/bin/true\n[X-Fleet]\nGlobal=true")},
+		})
+		fAPI := &client.RegistryClient{Registry: fr}
+		resource := &unitsResource{fAPI, "/units", testTokenLimit}
+		rw := httptest.NewRecorder()
+
+		req, err := http.NewRequest("PATCH", fmt.Sprintf("http://example.com/units/%s", tt.item), bytes.NewBufferString(tt.body))
+		if err != nil {
+			t.Fatalf("case %d: failed creating http.Request: %v", i, err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resource.ServeHTTP(rw, req)
+		if rw.Code != tt.code {
+			t.Errorf("case %d: expected %d, got %d", i, tt.code, rw.Code)
+		}
+
+		u, err := fAPI.Unit("web@.service")
+		if err != nil || u == nil {
+			t.Fatalf("case %d: failed fetching template: %v", i, err)
+		}
+		if u.Replicas != int64(tt.replicas) {
+			t.Errorf("case %d: expected %d replicas, got %d", i, tt.replicas, u.Replicas)
+		}
+
+		// the template keeps the unit file it was submitted with
+		ru, err := fr.Unit("web@.service")
+		if err != nil || ru == nil {
+			t.Fatalf("case %d: failed fetching template: %v", i, err)
+		}
+		if ru.Unit.Hash() != tmpl.Hash() {
+			t.Errorf("case %d: expected unit file of template to be unchanged", i)
+		}
+	}
+}
@@ -23,13 +23,22 @@ type API interface {
 	Machines() ([]machine.MachineState, error)
 	SetMachineMetadata(machID, key, value string) error
 	DeleteMachineMetadata(machID, key string) error
+	SetMachineSchedulingState(machID, state string) error
 
 	Unit(string) (*schema.Unit, error)
 	Units() ([]*schema.Unit, error)
 	UnitState(string) (*schema.UnitState, error)
 	UnitStates() ([]*schema.UnitState, error)
+	UnitScheduling(string) (*schema.UnitScheduling, error)
+	UnitStateHistory(string) (*schema.UnitStateHistory, error)
+
+	Plan(*schema.PlanRequest) (*schema.Plan, error)
+
+	EngineStatus() (*schema.EngineStatus, error)
+	EngineStepDown() error
 
 	SetUnitTargetState(name, target string) error
+	SetUnitReplicas(name string, replicas int) error
 	CreateUnit(*schema.Unit) error
 	DestroyUnit(string) error
 }
@@ -15,6 +15,9 @@
 package client
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/url"
 	"path"
@@ -35,12 +38,15 @@ func NewHTTPClient(c *http.Client, ep url.URL) (API, error) {
 	ep.Path = path.Join(ep.Path, "fleet", "v1") + "/"
 	svc.BasePath = ep.String()
 
-	return &HTTPClient{svc: svc}, nil
+	return &HTTPClient{svc: svc, client: c}, nil
 }
 
 type HTTPClient struct {
 	svc *schema.Service
 
+	// client is used for requests the schema.Service does not cover
+	client *http.Client
+
 	//NOTE(bcwaldon): This is only necessary until the API interface
 	// is fully implemented by HTTPClient
 	API
@@ -67,6 +73,40 @@ func (c *HTTPClient) Machines() ([]machine.MachineState, error) {
 	return machines, nil
 }
 
+// SetMachineSchedulingState cordons, drains or uncordons a machine using
+// the JSON patch operations of the machines resource.
+func (c *HTTPClient) SetMachineSchedulingState(machID, state string) error {
+	op := struct {
+		Operation string `json:"op"`
+		Path      string `json:"path"`
+		Value     struct {
+			Value string `json:"value"`
+		} `json:"value"`
+	}{
+		Operation: "replace",
+		Path:      fmt.Sprintf("/%s/state", machID),
+	}
+	op.Value.Value = state
+
+	body, err := json.Marshal([]interface{}{op})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("PATCH", c.svc.BasePath+"machines", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return googleapi.CheckResponse(resp)
+}
+
 func (c *HTTPClient) Units() ([]*schema.Unit, error) {
 	var units []*schema.Unit
 	call := c.svc.Units.List()
@@ -125,6 +165,34 @@ func (c *HTTPClient) UnitState(name string) (*schema.UnitState, error) {
 	return u, nil
 }
 
+func (c *HTTPClient) UnitScheduling(name string) (*schema.UnitScheduling, error) {
+	us, err := c.svc.Units.Scheduling(name).Do()
+	if err != nil && !is404(err) {
+		return nil, err
+	}
+	return us, nil
+}
+
+func (c *HTTPClient) UnitStateHistory(name string) (*schema.UnitStateHistory, error) {
+	h, err := c.svc.UnitState.History(name).Do()
+	if err != nil && !is404(err) {
+		return nil, err
+	}
+	return h, nil
+}
+
+func (c *HTTPClient) Plan(req *schema.PlanRequest) (*schema.Plan, error) {
+	return c.svc.Plan.Create(req).Do()
+}
+
+func (c *HTTPClient) EngineStatus() (*schema.EngineStatus, error) {
+	return c.svc.Engine.Get().Do()
+}
+
+func (c *HTTPClient) EngineStepDown() error {
+	return c.svc.Engine.StepDown().Do()
+}
+
 func (c *HTTPClient) DestroyUnit(name string) error {
 	return c.svc.Units.Delete(name).Do()
 }
@@ -141,6 +209,16 @@ func (c *HTTPClient) SetUnitTargetState(name, target string) error {
 	return c.svc.Units.Set(name, &u).Do()
 }
 
+func (c *HTTPClient) SetUnitReplicas(name string, replicas int) error {
+	u := schema.Unit{
+		Name:     name,
+		Replicas: int64(replicas),
+		// a count of zero must be sent as well
+		ForceSendFields: []string{"Replicas"},
+	}
+	return c.svc.Units.Patch(name, &u).Do()
+}
+
 func is404(err error) bool {
 	googerr, ok := err.(*googleapi.Error)
 	return ok && googerr.Code == http.StatusNotFound
@@ -15,13 +15,27 @@
 package client
 
 import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
 	"github.com/coreos/fleet/job"
+	"github.com/coreos/fleet/pkg/lease"
 	"github.com/coreos/fleet/registry"
 	"github.com/coreos/fleet/schema"
 )
 
 type RegistryClient struct {
 	registry.Registry
+
+	// LeaseManager is used to determine the engine leader. Without it,
+	// the engine status is unavailable.
+	LeaseManager lease.Manager
+
+	// Planner is used to simulate scheduling. Without it, plans are
+	// unavailable.
+	Planner registry.Planner
 }
 
 func (rc *RegistryClient) Units() ([]*schema.Unit, error) {
@@ -41,9 +55,21 @@ func (rc *RegistryClient) Units() ([]*schema.Unit, error) {
 		sUnitMap[sUnit.Name] = &sUnit
 	}
 
+	lost, err := rc.lostMachines()
+	if err != nil {
+		return nil, err
+	}
+
+	replicas, err := rc.Registry.UnitReplicas()
+	if err != nil {
+		return nil, err
+	}
+
 	units := make([]*schema.Unit, len(rUnits))
 	for i, ru := range rUnits {
 		units[i] = schema.MapUnitToSchemaUnit(&ru, sUnitMap[ru.Name])
+		markMachineLost(units[i], lost)
+		setUnitReplicas(units[i], replicas)
 	}
 
 	return units, nil
@@ -64,7 +90,59 @@ func (rc *RegistryClient) Unit(name string) (*schema.Unit, error) {
 		}
 	}
 
-	return schema.MapUnitToSchemaUnit(rUnit, sUnit), nil
+	lost, err := rc.lostMachines()
+	if err != nil {
+		return nil, err
+	}
+
+	replicas, err := rc.Registry.UnitReplicas()
+	if err != nil {
+		return nil, err
+	}
+
+	u := schema.MapUnitToSchemaUnit(rUnit, sUnit)
+	markMachineLost(u, lost)
+	setUnitReplicas(u, replicas)
+	return u, nil
+}
+
+// lostMachines returns the IDs of the machines the engine leader recorded
+// as lost, which have not returned since.
+func (rc *RegistryClient) lostMachines() (map[string]bool, error) {
+	rLost, err := rc.Registry.LostMachines()
+	if err != nil || len(rLost) == 0 {
+		return nil, err
+	}
+
+	machines, err := rc.Registry.Machines()
+	if err != nil {
+		return nil, err
+	}
+
+	lost := make(map[string]bool, len(rLost))
+	for id := range rLost {
+		lost[id] = true
+	}
+	for _, ms := range machines {
+		delete(lost, ms.ID)
+	}
+	return lost, nil
+}
+
+// markMachineLost reports the given Unit as machine-lost if it is scheduled
+// to one of the given lost machines.
+func markMachineLost(u *schema.Unit, lost map[string]bool) {
+	if u.MachineID != "" && lost[u.MachineID] {
+		u.CurrentState = string(job.JobStateMachineLost)
+	}
+}
+
+// setUnitReplicas reports the replica count a template Unit was scaled
+// to, which overrides the Replicas option of its unit file.
+func setUnitReplicas(u *schema.Unit, replicas map[string]int) {
+	if n, ok := replicas[u.Name]; ok {
+		u.Replicas = int64(n)
+	}
 }
 
 func (rc *RegistryClient) CreateUnit(u *schema.Unit) error {
@@ -112,3 +190,246 @@ func (rc *RegistryClient) UnitStates() ([]*schema.UnitState, error) {
 func (rc *RegistryClient) SetUnitTargetState(name, target string) error {
 	return rc.Registry.SetUnitTargetState(name, job.JobState(target))
 }
+
+// SetUnitReplicas sets the number of instances the engine maintains of
+// the template Unit of the given name. The unit file of the template is
+// left as submitted.
+func (rc *RegistryClient) SetUnitReplicas(name string, replicas int) error {
+	rUnit, err := rc.Registry.Unit(name)
+	if err != nil {
+		return err
+	}
+	if rUnit == nil {
+		return fmt.Errorf("unit %s does not exist", name)
+	}
+	if rUnit.IsGlobal() {
+		return fmt.Errorf("unit %s is a global unit and cannot be scaled", name)
+	}
+
+	return rc.Registry.SetUnitReplicas(name, replicas)
+}
+
+// UnitScheduling explains why the Unit of the given name is not scheduled,
+// based on the explanations recorded by the engine leader. If the Unit is
+// scheduled, only the ID of its target machine is returned. In both cases,
+// the failures of the Unit counted by the engine on each machine are
+// attached. nil is returned if no such Unit exists.
+func (rc *RegistryClient) UnitScheduling(name string) (*schema.UnitScheduling, error) {
+	rUnit, err := rc.Registry.Unit(name)
+	if err != nil || rUnit == nil {
+		return nil, err
+	}
+
+	if rUnit.IsGlobal() {
+		return &schema.UnitScheduling{
+			Name:   name,
+			Reason: "global units are not scheduled by the engine",
+		}, nil
+	}
+
+	us, err := rc.unitScheduling(name)
+	if err != nil {
+		return nil, err
+	}
+
+	failures, err := rc.Registry.UnitFailures()
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range failures {
+		if f.Name != name {
+			continue
+		}
+		sf := &schema.UnitSchedulingFailure{
+			MachineID: f.MachineID,
+			Count:     int64(f.Count),
+			Failed:    f.Failed,
+		}
+		if !f.ExcludedUntil.IsZero() {
+			sf.ExcludedUntil = f.ExcludedUntil.UTC().Format(time.RFC3339)
+		}
+		us.Failures = append(us.Failures, sf)
+	}
+
+	return us, nil
+}
+
+func (rc *RegistryClient) unitScheduling(name string) (*schema.UnitScheduling, error) {
+	sUnit, err := rc.Registry.ScheduledUnit(name)
+	if err != nil {
+		return nil, err
+	}
+	if sUnit != nil && sUnit.TargetMachineID != "" {
+		return &schema.UnitScheduling{
+			Name:      name,
+			MachineID: sUnit.TargetMachineID,
+		}, nil
+	}
+
+	exp, err := rc.Registry.SchedulingExplanation(name)
+	if err != nil {
+		return nil, err
+	}
+	if exp != nil {
+		return schema.MapSchedulingExplanationToSchema(exp), nil
+	}
+
+	return &schema.UnitScheduling{Name: name}, nil
+}
+
+// UnitStateHistory returns the state transitions recorded for the Unit of
+// the given name, oldest first. nil is returned if no such Unit exists.
+func (rc *RegistryClient) UnitStateHistory(name string) (*schema.UnitStateHistory, error) {
+	rUnit, err := rc.Registry.Unit(name)
+	if err != nil || rUnit == nil {
+		return nil, err
+	}
+
+	history, err := rc.Registry.UnitStateHistory(name)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &schema.UnitStateHistory{
+		Name:        name,
+		Transitions: make([]*schema.UnitStateTransition, 0, len(history)),
+	}
+	for _, t := range history {
+		h.Transitions = append(h.Transitions, &schema.UnitStateTransition{
+			Time:        t.Time.UTC().Format(time.RFC3339Nano),
+			MachineID:   t.MachineID,
+			LoadState:   t.LoadState,
+			ActiveState: t.ActiveState,
+			SubState:    t.SubState,
+			UnitHash:    t.UnitHash,
+		})
+	}
+	return h, nil
+}
+
+// Plan simulates how the engine would schedule the cluster with the
+// changes of the given request applied, without modifying the Registry.
+// The settings of the engine the simulation ignores are listed in the Plan.
+func (rc *RegistryClient) Plan(req *schema.PlanRequest) (*schema.Plan, error) {
+	if rc.Planner == nil {
+		return nil, errors.New("plan unavailable")
+	}
+
+	var err error
+	change := registry.PlanChange{
+		RemoveMachines: req.RemoveMachines,
+	}
+	for _, su := range req.Units {
+		var u *job.Unit
+		if len(su.Options) > 0 {
+			u = schema.MapSchemaUnitToUnit(su)
+		} else if u, err = rc.Registry.Unit(su.Name); err != nil {
+			return nil, err
+		} else if u == nil {
+			return nil, fmt.Errorf("unit %s does not exist and options field empty", su.Name)
+		}
+
+		u.TargetState = job.JobStateLaunched
+		if su.DesiredState != "" {
+			u.TargetState = job.JobState(su.DesiredState)
+		}
+		change.Units = append(change.Units, *u)
+	}
+	for _, md := range req.AddMetadata {
+		if change.AddMetadata == nil {
+			change.AddMetadata = make(map[string]map[string]string)
+		}
+		if change.AddMetadata[md.MachineID] == nil {
+			change.AddMetadata[md.MachineID] = make(map[string]string)
+		}
+		change.AddMetadata[md.MachineID][md.Key] = md.Value
+	}
+
+	res, err := rc.Planner.Plan(req.Strategy, change)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := schema.Plan{IgnoredSettings: res.IgnoredSettings}
+	for _, t := range res.Tasks {
+		plan.Tasks = append(plan.Tasks, &schema.PlanTask{
+			Type:      t.Type,
+			UnitName:  t.JobName,
+			MachineID: t.MachineID,
+			Reason:    t.Reason,
+		})
+	}
+	for i := range res.Unschedulable {
+		plan.Unschedulable = append(plan.Unschedulable, schema.MapSchedulingExplanationToSchema(&res.Unschedulable[i]))
+	}
+
+	return &plan, nil
+}
+
+// EngineStatus reports the current engine leader along with the status
+// of the last reconciliation recorded by the engine leader.
+func (rc *RegistryClient) EngineStatus() (*schema.EngineStatus, error) {
+	if rc.LeaseManager == nil {
+		return nil, errors.New("engine status unavailable")
+	}
+
+	var status schema.EngineStatus
+	l, err := registry.EngineLeader(rc.LeaseManager)
+	if err != nil {
+		return nil, err
+	}
+	if l != nil {
+		status.LeaderMachineID = l.MachineID()
+		status.LeaseIndex = int64(l.Index())
+		status.LeaseTimeRemaining = l.TimeRemaining().Seconds()
+	}
+
+	if cReg, ok := rc.Registry.(registry.ClusterRegistry); ok {
+		ver, err := cReg.EngineVersion()
+		if err != nil {
+			return nil, err
+		}
+		status.EngineVersion = int64(ver)
+	}
+
+	rs, err := rc.Registry.ReconcileStatus()
+	if err != nil {
+		return nil, err
+	}
+	if rs != nil {
+		status.LastReconcile = mapReconcileStatusToSchema(rs)
+	}
+
+	return &status, nil
+}
+
+func mapReconcileStatusToSchema(rs *registry.ReconcileStatus) *schema.EngineReconcile {
+	types := make([]string, 0, len(rs.Tasks))
+	for typ := range rs.Tasks {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+
+	tasks := make([]*schema.EngineTaskCount, 0, len(types))
+	for _, typ := range types {
+		tasks = append(tasks, &schema.EngineTaskCount{Type: typ, Count: int64(rs.Tasks[typ])})
+	}
+
+	return &schema.EngineReconcile{
+		MachineID: rs.MachineID,
+		Time:      rs.Time.UTC().Format(time.RFC3339),
+		Duration:  rs.Duration.Seconds(),
+		Tasks:     tasks,
+	}
+}
+
+// EngineStepDown releases the engine leadership, making another engine
+// take over.
+func (rc *RegistryClient) EngineStepDown() error {
+	if rc.LeaseManager == nil {
+		return errors.New("engine leadership unavailable")
+	}
+
+	_, err := registry.EngineStepDown(rc.LeaseManager)
+	return err
+}
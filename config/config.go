@@ -30,10 +30,22 @@ type Config struct {
 	EtcdCAFile              string
 	EtcdRequestTimeout      float64
 	EngineReconcileInterval float64
+	SchedulerStrategy       string
+	SchedulerSeed           int64
+	RebalanceInterval       float64
+	RebalanceMaxMoves       int
+	MachineLossGracePeriod  float64
+	FailureCooldown         float64
+	EvacuationTimeout       float64
+	DrainBatchSize          int
+	ScheduleLimit           int
+	ScheduleLimitPerMachine int
 	PublicIP                string
 	Verbosity               int
 	RawMetadata             string
 	AgentTTL                string
+	AgentStatusSocket       string
+	AgentStateFile          string
 	TokenLimit              int
 	DisableEngine           bool
 	DisableWatches          bool
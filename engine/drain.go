@@ -0,0 +1,77 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"sort"
+
+	"github.com/coreos/fleet/job"
+)
+
+// DefaultDrainBatchSize is the number of units moved away from draining
+// machines per reconciliation, unless configured otherwise.
+const DefaultDrainBatchSize = 1
+
+// drain computes up to max moves of units from draining machines to
+// machines able to run them, applying each to the given clusterState.
+// Units collocated with another unit using MachineOf are not moved, but
+// follow that unit once it moved. Units no other machine is able to run
+// stay. The IDs of draining machines without any units left are returned
+// as drained.
+func drain(clust *clusterState, sched Scheduler, max int) (moves []move, drained []string) {
+	var ids []string
+	for id, ms := range clust.machines {
+		if ms.Draining {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		var hosted []*job.Job
+		for _, j := range clust.jobs {
+			if j.TargetMachineID == id {
+				hosted = append(hosted, j)
+			}
+		}
+		sort.Sort(jobsByName(hosted))
+
+		remaining := len(hosted)
+		for _, j := range hosted {
+			if len(moves) >= max {
+				break
+			}
+			if len(j.Peers()) > 0 {
+				continue
+			}
+
+			clust.unschedule(j.Name)
+			dec, err := sched.Decide(clust, j)
+			if err != nil {
+				clust.schedule(j.Name, id)
+				continue
+			}
+			clust.schedule(j.Name, dec.machineID)
+			moves = append(moves, move{jobName: j.Name, from: id, to: dec.machineID})
+			remaining--
+		}
+
+		if remaining == 0 {
+			drained = append(drained, id)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,153 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/coreos/fleet/job"
+	"github.com/coreos/fleet/machine"
+)
+
+func TestDrain(t *testing.T) {
+	launched := func(name string, opts ...string) job.Unit {
+		return job.Unit{Name: name, Unit: newFleetUnit(t, opts...), TargetState: job.JobStateLaunched}
+	}
+	scheduled := func(name, machID string) job.ScheduledUnit {
+		return job.ScheduledUnit{Name: name, TargetMachineID: machID}
+	}
+	draining := []machine.MachineState{
+		machine.MachineState{ID: "XXX", Unschedulable: true, Draining: true},
+		machine.MachineState{ID: "YYY"},
+	}
+
+	tests := []struct {
+		units    []job.Unit
+		sUnits   []job.ScheduledUnit
+		machines []machine.MachineState
+		max      int
+		moves    []move
+		drained  []string
+	}{
+		// all units move away from a draining machine
+		{
+			units:    []job.Unit{launched("a.service"), launched("b.service")},
+			sUnits:   []job.ScheduledUnit{scheduled("a.service", "XXX"), scheduled("b.service", "XXX")},
+			machines: draining,
+			max:      5,
+			moves: []move{
+				move{jobName: "a.service", from: "XXX", to: "YYY"},
+				move{jobName: "b.service", from: "XXX", to: "YYY"},
+			},
+			drained: []string{"XXX"},
+		},
+
+		// the number of moves is bounded
+		{
+			units:    []job.Unit{launched("a.service"), launched("b.service")},
+			sUnits:   []job.ScheduledUnit{scheduled("a.service", "XXX"), scheduled("b.service", "XXX")},
+			machines: draining,
+			max:      1,
+			moves: []move{
+				move{jobName: "a.service", from: "XXX", to: "YYY"},
+			},
+		},
+
+		// an empty draining machine is drained
+		{
+			units:    []job.Unit{launched("a.service")},
+			sUnits:   []job.ScheduledUnit{scheduled("a.service", "YYY")},
+			machines: draining,
+			max:      1,
+			drained:  []string{"XXX"},
+		},
+
+		// cordoned machines are not drained
+		{
+			units:  []job.Unit{launched("a.service")},
+			sUnits: []job.ScheduledUnit{scheduled("a.service", "XXX")},
+			machines: []machine.MachineState{
+				machine.MachineState{ID: "XXX", Unschedulable: true},
+				machine.MachineState{ID: "YYY"},
+			},
+			max: 5,
+		},
+
+		// units no other machine is able to run stay
+		{
+			units:    []job.Unit{launched("a.service", "MachineID=XXX"), launched("b.service")},
+			sUnits:   []job.ScheduledUnit{scheduled("a.service", "XXX"), scheduled("b.service", "XXX")},
+			machines: draining,
+			max:      5,
+			moves: []move{
+				move{jobName: "b.service", from: "XXX", to: "YYY"},
+			},
+		},
+
+		// units following another unit using MachineOf are not moved
+		{
+			units:    []job.Unit{launched("a.service"), launched("b.service", "MachineOf=a.service")},
+			sUnits:   []job.ScheduledUnit{scheduled("a.service", "XXX"), scheduled("b.service", "XXX")},
+			machines: draining,
+			max:      5,
+			moves: []move{
+				move{jobName: "a.service", from: "XXX", to: "YYY"},
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		clust := newClusterState(tt.units, tt.sUnits, tt.machines)
+		moves, drained := drain(clust, &leastLoadedScheduler{}, tt.max)
+		if !reflect.DeepEqual(tt.moves, moves) {
+			t.Errorf("case %d: expected moves %v, got %v", i, tt.moves, moves)
+		}
+		if !reflect.DeepEqual(tt.drained, drained) {
+			t.Errorf("case %d: expected drained %v, got %v", i, tt.drained, drained)
+		}
+	}
+}
+
+func TestCalculateClusterTasksDrain(t *testing.T) {
+	clust := newClusterState(
+		[]job.Unit{
+			job.Unit{Name: "a.service", TargetState: job.JobStateLaunched},
+		},
+		[]job.ScheduledUnit{
+			job.ScheduledUnit{Name: "a.service", TargetMachineID: "XXX"},
+		},
+		[]machine.MachineState{
+			machine.MachineState{ID: "XXX", Unschedulable: true, Draining: true},
+			machine.MachineState{ID: "YYY"},
+		},
+	)
+
+	r := NewReconciler(&leastLoadedScheduler{})
+	var tasks []*task
+	for tsk := range r.calculateClusterTasks(clust, make(chan struct{})) {
+		tasks = append(tasks, tsk)
+	}
+
+	reason := "draining Machine(XXX)"
+	want := []*task{
+		&task{Type: taskTypeDrainUnit, Reason: reason, JobName: "a.service", MachineID: "XXX"},
+		&task{Type: taskTypeAttemptScheduleUnit, Reason: reason, JobName: "a.service", MachineID: "YYY"},
+		&task{Type: taskTypeCordonMachine, Reason: "all units drained", MachineID: "XXX"},
+	}
+	if !reflect.DeepEqual(want, tasks) {
+		t.Errorf("task mismatch\nexpected %v\n got %v", want, tasks)
+	}
+}
@@ -18,17 +18,21 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/jonboulle/clockwork"
+
+	"github.com/coreos/fleet/job"
 	"github.com/coreos/fleet/log"
 	"github.com/coreos/fleet/machine"
 	"github.com/coreos/fleet/metrics"
 	"github.com/coreos/fleet/pkg"
 	"github.com/coreos/fleet/pkg/lease"
 	"github.com/coreos/fleet/registry"
+	"github.com/coreos/fleet/unit"
 )
 
 const (
 	// name of lease that must be held by the lead engine in a cluster
-	engineLeaseName = "engine-leader"
+	engineLeaseName = registry.EngineLeaseName
 
 	// version at which the current engine code operates
 	engineVersion = 1
@@ -44,6 +48,16 @@ type Engine struct {
 
 	lease lease.Lease
 
+	// leading is true while this engine holds the leadership, so the
+	// active scheduler strategy is reported once per term
+	leading bool
+
+	// an engine that lost its leadership, e.g. because the lease was
+	// released to make it step down, does not attempt to acquire the
+	// leadership again until standDownUntil, giving other engines a
+	// chance to take over
+	standDownUntil time.Time
+
 	updateEngineState func(newEngine machine.MachineState)
 }
 
@@ -52,8 +66,8 @@ type CompleteRegistry interface {
 	registry.ClusterRegistry
 }
 
-func New(reg CompleteRegistry, lManager lease.Manager, rStream pkg.EventStream, mach machine.Machine, updateEngineState func(newEngine machine.MachineState)) *Engine {
-	rec := NewReconciler()
+func New(reg CompleteRegistry, lManager lease.Manager, rStream pkg.EventStream, mach machine.Machine, sched Scheduler, updateEngineState func(newEngine machine.MachineState)) *Engine {
+	rec := NewReconciler(sched)
 	return &Engine{
 		rec:               rec,
 		registry:          reg,
@@ -65,7 +79,57 @@ func New(reg CompleteRegistry, lManager lease.Manager, rStream pkg.EventStream,
 	}
 }
 
+// EnableRebalancing makes the Engine move up to maxMoves units every ival
+// from the machines running the most units to those running the fewest.
+func (e *Engine) EnableRebalancing(ival time.Duration, maxMoves int) {
+	e.rec.rebalanceInterval = ival
+	e.rec.rebalanceMaxMoves = maxMoves
+}
+
+// SetDrainBatchSize sets the number of units the Engine moves away from
+// draining machines per reconciliation.
+func (e *Engine) SetDrainBatchSize(n int) {
+	e.rec.drainBatchSize = n
+}
+
+// SetScheduleLimits makes the Engine schedule at most total units across
+// the cluster and perMachine units to any single machine per ival. Units
+// exceeding either limit are scheduled during later reconciliations. A
+// limit of 0 disables it.
+func (e *Engine) SetScheduleLimits(total, perMachine int, ival time.Duration) {
+	if total <= 0 && perMachine <= 0 {
+		e.rec.limiter = nil
+		return
+	}
+	e.rec.limiter = newScheduleLimiter(total, perMachine, ival)
+}
+
+// SetMachineLossGracePeriod makes the Engine keep units scheduled to a
+// machine that went away for the given period, before rescheduling them.
+func (e *Engine) SetMachineLossGracePeriod(d time.Duration) {
+	e.rec.machineLossGracePeriod = d
+}
+
+// SetFailureCooldown makes the Engine exclude a machine from running a
+// unit which failed there too often for the given period.
+func (e *Engine) SetFailureCooldown(d time.Duration) {
+	e.rec.failureCooldown = d
+}
+
 func (e *Engine) Run(ival time.Duration, stop <-chan struct{}) {
+	reconcile := func() {
+		e.Step(ival, stop)
+	}
+
+	rec := pkg.NewPeriodicReconciler(ival, reconcile, e.rStream)
+	rec.Run(stop)
+}
+
+// Step attempts to acquire or renew the leadership of the cluster's
+// engines and, while holding it, reconciles the cluster once. Run calls
+// Step at least every ival; calling Step directly drives the Engine
+// synchronously, e.g. in a simulated cluster.
+func (e *Engine) Step(ival time.Duration, stop <-chan struct{}) {
 	leaseTTL := ival * 5
 	if e.machine.State().Capabilities.Has(machine.CapGRPC) {
 		// With grpc it doesn't make sense to set to 5secs the TTL of the etcd key.
@@ -75,71 +139,87 @@ func (e *Engine) Run(ival time.Duration, stop <-chan struct{}) {
 		leaseTTL = ival * 500000
 	}
 	machID := e.machine.State().ID
+	clock := e.rec.clock
 
-	reconcile := func() {
-		if !ensureEngineVersionMatch(e.cRegistry, engineVersion) {
-			return
-		}
-
-		if e.machine.State().Capabilities.Has(machine.CapGRPC) {
-			// rpcLeadership gets the lease (leader), and apply changes to the engine state if need it.
-			e.lease = e.rpcLeadership(leaseTTL, machID)
-		} else {
-			var l lease.Lease
-			if isLeader(e.lease, machID) {
-				l = renewLeadership(e.lease, leaseTTL)
-			} else {
-				l = acquireLeadership(e.lManager, machID, engineVersion, leaseTTL)
-			}
+	if !ensureEngineVersionMatch(e.cRegistry, engineVersion) {
+		return
+	}
 
-			// log all leadership changes
-			if l != nil && e.lease == nil && l.MachineID() != machID {
-				log.Infof("Engine leader is %s", l.MachineID())
-			} else if l != nil && e.lease != nil && l.MachineID() != e.lease.MachineID() {
-				log.Infof("Engine leadership changed from %s to %s", e.lease.MachineID(), l.MachineID())
+	if e.machine.State().Capabilities.Has(machine.CapGRPC) {
+		// rpcLeadership gets the lease (leader), and apply changes to the engine state if need it.
+		e.lease = e.rpcLeadership(leaseTTL, machID)
+	} else {
+		var l lease.Lease
+		if isLeader(e.lease, machID) {
+			l = renewLeadership(e.lease, leaseTTL)
+			if l == nil {
+				e.standDownUntil = clock.Now().Add(leaseTTL)
 			}
+		} else if clock.Now().After(e.standDownUntil) {
+			l = acquireLeadership(e.lManager, machID, engineVersion, leaseTTL)
+		}
 
-			e.lease = l
+		// log all leadership changes
+		if l != nil && e.lease == nil && l.MachineID() != machID {
+			log.Infof("Engine leader is %s", l.MachineID())
+		} else if l != nil && e.lease != nil && l.MachineID() != e.lease.MachineID() {
+			log.Infof("Engine leadership changed from %s to %s", e.lease.MachineID(), l.MachineID())
 		}
 
-		if !isLeader(e.lease, machID) {
+		e.lease = l
+	}
+
+	if !isLeader(e.lease, machID) {
+		e.leading = false
+		return
+	}
+
+	if !e.leading {
+		// another leader may have written explanations meanwhile
+		e.rec.saved = nil
+		e.rec.loadLostMachines(e.registry)
+		e.rec.loadFailures(e.registry)
+		log.Infof("Engine leader using %s scheduler strategy", e.rec.sched.Name())
+		metrics.ReportEngineSchedulerStrategy(e.rec.sched.Name())
+		e.leading = true
+	}
+
+	// abort is closed when reconciliation must stop prematurely, either
+	// by a local timeout or the fleet server shutting down
+	abort := make(chan struct{})
+
+	// monitor is used to shut down the following goroutine
+	monitor := make(chan struct{})
+
+	go func() {
+		select {
+		case <-monitor:
 			return
+		case <-clock.After(leaseTTL):
+			close(abort)
+		case <-stop:
+			close(abort)
 		}
+	}()
 
-		// abort is closed when reconciliation must stop prematurely, either
-		// by a local timeout or the fleet server shutting down
-		abort := make(chan struct{})
-
-		// monitor is used to shut down the following goroutine
-		monitor := make(chan struct{})
-
-		go func() {
-			select {
-			case <-monitor:
-				return
-			case <-time.After(leaseTTL):
-				close(abort)
-			case <-stop:
-				close(abort)
-			}
-		}()
-
-		start := time.Now()
-		e.rec.Reconcile(e, abort)
-		close(monitor)
-		elapsed := time.Now().Sub(start)
-		metrics.ReportEngineReconcileSuccess(start)
-
-		msg := fmt.Sprintf("Engine completed reconciliation in %s", elapsed)
-		if elapsed > ival {
-			log.Warning(msg)
-		} else {
-			log.Debug(msg)
-		}
+	start := clock.Now()
+	e.rec.Reconcile(e, abort)
+	close(monitor)
+	elapsed := clock.Since(start)
+	metrics.ReportEngineReconcileSuccess(start)
+
+	msg := fmt.Sprintf("Engine completed reconciliation in %s", elapsed)
+	if elapsed > ival {
+		log.Warning(msg)
+	} else {
+		log.Debug(msg)
 	}
+}
 
-	rec := pkg.NewPeriodicReconciler(ival, reconcile, e.rStream)
-	rec.Run(stop)
+// SetClock makes the Engine use the given clock rather than the system
+// clock, e.g. to control the passing of time in a simulated cluster.
+func (e *Engine) SetClock(clock clockwork.Clock) {
+	e.rec.clock = clock
 }
 
 func (e *Engine) Purge() {
@@ -263,7 +343,22 @@ func (e *Engine) clusterState() (*clusterState, error) {
 		return nil, err
 	}
 
-	return newClusterState(units, sUnits, machines), nil
+	states, err := e.registry.UnitStates()
+	if err != nil {
+		log.Errorf("Failed fetching UnitStates from Registry: %v", err)
+		return nil, err
+	}
+
+	replicas, err := e.registry.UnitReplicas()
+	if err != nil {
+		log.Errorf("Failed fetching Unit replicas from Registry: %v", err)
+		return nil, err
+	}
+
+	clust := newClusterState(units, sUnits, machines)
+	clust.setUnitStates(states)
+	clust.replicas = replicas
+	return clust, nil
 }
 
 func (e *Engine) unscheduleUnit(name, machID string) (err error) {
@@ -276,6 +371,52 @@ func (e *Engine) unscheduleUnit(name, machID string) (err error) {
 	return
 }
 
+// createReplica creates the instance of the given name from its template,
+// to be launched by the engine.
+func (e *Engine) createReplica(name string) error {
+	tmpl := unit.NewUnitNameInfo(name).Template
+	tu, err := e.registry.Unit(tmpl)
+	if err != nil {
+		log.Errorf("Failed fetching template Unit(%s) from Registry: %v", tmpl, err)
+		return err
+	}
+	if tu == nil {
+		return fmt.Errorf("template Unit(%s) does not exist", tmpl)
+	}
+
+	u := job.Unit{
+		Name:        name,
+		Unit:        tu.Unit,
+		TargetState: job.JobStateLaunched,
+	}
+	if err := e.registry.CreateUnit(&u); err != nil {
+		log.Errorf("Failed creating Unit(%s): %v", name, err)
+		return err
+	}
+	log.Infof("Created Unit(%s) from template Unit(%s)", name, tmpl)
+	return nil
+}
+
+// cordonMachine marks a drained machine as cordoned.
+func (e *Engine) cordonMachine(machID string) error {
+	if err := e.registry.SetMachineSchedulingState(machID, machine.StateCordoned); err != nil {
+		log.Errorf("Failed cordoning Machine(%s): %v", machID, err)
+		return err
+	}
+	log.Infof("Machine(%s) drained", machID)
+	return nil
+}
+
+// destroyReplica destroys the instance of the given name.
+func (e *Engine) destroyReplica(name string) error {
+	if err := e.registry.DestroyUnit(name); err != nil {
+		log.Errorf("Failed destroying Unit(%s): %v", name, err)
+		return err
+	}
+	log.Infof("Destroyed Unit(%s)", name)
+	return nil
+}
+
 // attemptScheduleUnit tries to persist a scheduling decision in the
 // Registry, returning true on success. If any communication with the
 // Registry fails, false is returned.
@@ -0,0 +1,190 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/coreos/fleet/job"
+	"github.com/coreos/fleet/log"
+	"github.com/coreos/fleet/registry"
+)
+
+// DefaultFailureCooldown is the time a machine is excluded from running a
+// unit which failed there too often, unless configured otherwise.
+const DefaultFailureCooldown = 10 * time.Minute
+
+type failureKey struct {
+	name   string
+	machID string
+}
+
+// trackFailures counts the times each launched job defining
+// RescheduleOnFailure entered the failed state, or the auto-restart state
+// systemd holds a failed service in before restarting it, on the machine
+// it is scheduled to. Once a job failed as often as it allows, the machine is
+// excluded from running it for the failure cooldown, which makes the job
+// unable to run there and therefore rescheduled. Failures on machines a
+// job is no longer scheduled to are forgotten, unless the machine is
+// still excluded.
+func (r *Reconciler) trackFailures(clust *clusterState, now time.Time) {
+	failures := make(map[failureKey]*registry.UnitFailure)
+	for k, f := range r.failures {
+		j := clust.jobs[k.name]
+		if j == nil {
+			continue
+		}
+		if f.ExcludedUntil.IsZero() {
+			if j.TargetMachineID != k.machID {
+				continue
+			}
+		} else if !now.Before(f.ExcludedUntil) {
+			log.Infof("Machine(%s) no longer excluded from running Job(%s)", k.machID, k.name)
+			continue
+		}
+		failures[k] = f
+	}
+
+	for name, us := range clust.states {
+		j := clust.jobs[name]
+		max, ok := j.RescheduleOnFailure()
+		if !ok || j.TargetState != job.JobStateLaunched {
+			continue
+		}
+
+		k := failureKey{name: name, machID: j.TargetMachineID}
+		f, ok := failures[k]
+		if !ok {
+			f = &registry.UnitFailure{Name: name, MachineID: j.TargetMachineID}
+		}
+
+		failed := us.ActiveState == "failed" || us.SubState == "auto-restart"
+		if failed && !f.Failed {
+			f.Count++
+			log.Infof("Job(%s) failed on Machine(%s), %d of %d failures", name, k.machID, f.Count, max)
+			if f.Count >= max && f.ExcludedUntil.IsZero() {
+				f.ExcludedUntil = now.Add(r.failureCooldown)
+				log.Infof("Job(%s) failed too often, excluding Machine(%s) until %v", name, k.machID, f.ExcludedUntil)
+			}
+		}
+		f.Failed = failed
+
+		if f.Count > 0 {
+			failures[k] = f
+		}
+	}
+	r.failures = failures
+
+	for k, f := range failures {
+		if f.ExcludedUntil.IsZero() {
+			continue
+		}
+		reason := fmt.Sprintf("unit failed %d times on the machine, excluded until %s", f.Count, f.ExcludedUntil.UTC().Format(time.RFC3339))
+		clust.exclude(k.name, k.machID, reason)
+	}
+}
+
+// loadFailures picks up the failures recorded by a previous engine
+// leader, so that neither the failure counts nor the exclusions start
+// over.
+func (r *Reconciler) loadFailures(reg registry.Registry) {
+	failures, err := reg.UnitFailures()
+	if err != nil {
+		log.Errorf("Failed fetching unit failures from Registry: %v", err)
+		return
+	}
+
+	r.failures = make(map[failureKey]*registry.UnitFailure, len(failures))
+	for _, f := range failures {
+		f := f
+		r.failures[failureKey{name: f.Name, machID: f.MachineID}] = &f
+	}
+	r.savedFailures = groupFailures(failures)
+}
+
+// saveFailures writes the failures of each unit to the Registry, if they
+// changed since last written. The failures of units which have none left,
+// e.g. because their machines are no longer excluded, are removed.
+func (r *Reconciler) saveFailures(reg registry.Registry) {
+	if r.savedFailures == nil {
+		failures, err := reg.UnitFailures()
+		if err != nil {
+			log.Errorf("Failed fetching unit failures from Registry: %v", err)
+			return
+		}
+		r.savedFailures = groupFailures(failures)
+	}
+
+	failures := make([]registry.UnitFailure, 0, len(r.failures))
+	for _, f := range r.failures {
+		failures = append(failures, *f)
+	}
+	sort.Sort(unitFailures(failures))
+	byName := groupFailures(failures)
+
+	for name := range r.savedFailures {
+		if _, ok := byName[name]; ok {
+			continue
+		}
+		if err := reg.RemoveUnitFailures(name); err != nil {
+			log.Errorf("Failed removing failures of Job(%s): %v", name, err)
+			continue
+		}
+		delete(r.savedFailures, name)
+	}
+
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		failures := byName[name]
+		if reflect.DeepEqual(failures, r.savedFailures[name]) {
+			continue
+		}
+		if err := reg.SetUnitFailures(name, failures); err != nil {
+			log.Errorf("Failed saving failures of Job(%s): %v", name, err)
+			continue
+		}
+		r.savedFailures[name] = failures
+	}
+}
+
+// groupFailures groups the given failures by unit name, keeping their
+// order.
+func groupFailures(failures []registry.UnitFailure) map[string][]registry.UnitFailure {
+	byName := make(map[string][]registry.UnitFailure)
+	for _, f := range failures {
+		byName[f.Name] = append(byName[f.Name], f)
+	}
+	return byName
+}
+
+// unitFailures sorts failures by Unit name, then by machine ID.
+type unitFailures []registry.UnitFailure
+
+func (fs unitFailures) Len() int      { return len(fs) }
+func (fs unitFailures) Swap(i, j int) { fs[i], fs[j] = fs[j], fs[i] }
+func (fs unitFailures) Less(i, j int) bool {
+	if fs[i].Name != fs[j].Name {
+		return fs[i].Name < fs[j].Name
+	}
+	return fs[i].MachineID < fs[j].MachineID
+}
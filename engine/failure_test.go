@@ -0,0 +1,230 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+
+	"github.com/coreos/fleet/job"
+	"github.com/coreos/fleet/machine"
+	"github.com/coreos/fleet/registry"
+	"github.com/coreos/fleet/unit"
+)
+
+func TestCalculateClusterTasksFailedUnit(t *testing.T) {
+	newClust := func(target, active, sub string) *clusterState {
+		clust := newClusterState(
+			[]job.Unit{
+				job.Unit{Name: "foo.service", Unit: newFleetUnit(t, "RescheduleOnFailure=2"), TargetState: job.JobStateLaunched},
+			},
+			[]job.ScheduledUnit{
+				job.ScheduledUnit{Name: "foo.service", TargetMachineID: target},
+			},
+			[]machine.MachineState{machine.MachineState{ID: "XXX"}, machine.MachineState{ID: "YYY"}},
+		)
+		clust.setUnitStates([]*unit.UnitState{
+			&unit.UnitState{UnitName: "foo.service", MachineID: target, ActiveState: active, SubState: sub},
+		})
+		return clust
+	}
+	tasks := func(r *Reconciler, clust *clusterState) []*task {
+		var tasks []*task
+		for tsk := range r.calculateClusterTasks(clust, make(chan struct{})) {
+			tasks = append(tasks, tsk)
+		}
+		return tasks
+	}
+
+	clock := clockwork.NewFakeClock()
+	r := NewReconciler(&leastLoadedScheduler{})
+	r.clock = clock
+	count := func(machID string) int {
+		if f, ok := r.failures[failureKey{name: "foo.service", machID: machID}]; ok {
+			return f.Count
+		}
+		return 0
+	}
+
+	steps := []struct {
+		active, sub string
+		count       int
+	}{
+		{"active", "running", 0},
+		{"failed", "failed", 1},
+		// units staying failed fail once
+		{"failed", "failed", 1},
+		{"activating", "auto-restart", 1},
+		{"active", "running", 1},
+		// restarting after a failure counts as well
+		{"activating", "auto-restart", 2},
+	}
+	for i, s := range steps {
+		got := tasks(r, newClust("XXX", s.active, s.sub))
+		if c := count("XXX"); c != s.count {
+			t.Fatalf("step %d: expected %d failures, got %d", i, s.count, c)
+		}
+		if s.count < 2 && got != nil {
+			t.Fatalf("step %d: expected no tasks, got %v", i, got)
+		}
+		if s.count < 2 {
+			continue
+		}
+
+		// the unit failed as often as it allows, so it moves away
+		until := clock.Now().Add(DefaultFailureCooldown).UTC().Format(time.RFC3339)
+		want := []*task{
+			&task{Type: taskTypeUnscheduleUnit, Reason: "target Machine(XXX) unable to run unit: unit failed 2 times on the machine, excluded until " + until, JobName: "foo.service", MachineID: "XXX"},
+			&task{Type: taskTypeAttemptScheduleUnit, Reason: "target state launched and unit not scheduled", JobName: "foo.service", MachineID: "YYY"},
+		}
+		if !reflect.DeepEqual(want, got) {
+			t.Fatalf("task mismatch\nexpected %v\n got %v", want, got)
+		}
+	}
+
+	// the excluded machine is remembered while the unit runs elsewhere
+	if got := tasks(r, newClust("YYY", "active", "running")); got != nil {
+		t.Fatalf("expected no tasks, got %v", got)
+	}
+	if c := count("XXX"); c != 2 {
+		t.Fatalf("expected failures on excluded Machine(XXX) to be kept, got %d", c)
+	}
+
+	// and not used when the unit fails there as well
+	for _, active := range []string{"failed", "active", "failed"} {
+		tasks(r, newClust("YYY", active, active))
+	}
+	if got := tasks(r, newClust("", "", "")); got != nil {
+		t.Fatalf("expected no tasks while all machines are excluded, got %v", got)
+	}
+
+	// until the cooldown passed
+	clock.Advance(DefaultFailureCooldown)
+	want := []*task{
+		&task{Type: taskTypeAttemptScheduleUnit, Reason: "target state launched and unit not scheduled", JobName: "foo.service", MachineID: "XXX"},
+	}
+	if got := tasks(r, newClust("", "", "")); !reflect.DeepEqual(want, got) {
+		t.Fatalf("task mismatch\nexpected %v\n got %v", want, got)
+	}
+	if len(r.failures) != 0 {
+		t.Errorf("expected failures to be forgotten after the cooldown, got %v", r.failures)
+	}
+
+	// failures on machines the unit moved off are forgotten
+	tasks(r, newClust("XXX", "failed", "failed"))
+	tasks(r, newClust("YYY", "active", "running"))
+	if len(r.failures) != 0 {
+		t.Errorf("expected failures on Machine(XXX) to be forgotten, got %v", r.failures)
+	}
+}
+
+func TestSaveFailures(t *testing.T) {
+	until := time.Date(2016, time.May, 4, 12, 0, 0, 0, time.UTC)
+	failures := []registry.UnitFailure{
+		{Name: "bar.service", MachineID: "XXX", Count: 1, Failed: true},
+		{Name: "foo.service", MachineID: "XXX", Count: 3, ExcludedUntil: until},
+		{Name: "foo.service", MachineID: "YYY", Count: 1},
+	}
+
+	reg := registry.NewFakeRegistry()
+	reg.SetUnitFailures("bar.service", failures[:1])
+	reg.SetUnitFailures("foo.service", failures[1:])
+
+	// failures recorded by a previous leader are picked up
+	r := NewReconciler(&leastLoadedScheduler{})
+	r.loadFailures(reg)
+	if len(r.failures) != 3 {
+		t.Fatalf("expected 3 failures, got %v", r.failures)
+	}
+	if f := r.failures[failureKey{name: "foo.service", machID: "XXX"}]; f == nil || !reflect.DeepEqual(failures[1], *f) {
+		t.Fatalf("expected failure %v, got %v", failures[1], f)
+	}
+
+	// unchanged failures are not written again
+	reg.RemoveUnitFailures("bar.service")
+	r.saveFailures(reg)
+	if got, _ := reg.UnitFailures(); !reflect.DeepEqual(failures[1:], got) {
+		t.Errorf("expected no write of unchanged failures, got %v", got)
+	}
+
+	// changed failures are written per unit, sorted
+	r.failures[failureKey{name: "bar.service", machID: "XXX"}].Failed = false
+	r.saveFailures(reg)
+	want := []registry.UnitFailure{
+		{Name: "bar.service", MachineID: "XXX", Count: 1},
+		failures[1],
+		failures[2],
+	}
+	if got, _ := reg.UnitFailures(); !reflect.DeepEqual(want, got) {
+		t.Errorf("expected failures %v to be written, got %v", want, got)
+	}
+
+	// units without failures left have them removed
+	delete(r.failures, failureKey{name: "bar.service", machID: "XXX"})
+	r.saveFailures(reg)
+	if got, _ := reg.UnitFailures(); !reflect.DeepEqual(failures[1:], got) {
+		t.Errorf("expected failures of bar.service to be removed, got %v", got)
+	}
+
+	// a reconciler that did not load the failures leaves no stale ones
+	r = NewReconciler(&leastLoadedScheduler{})
+	r.failures = map[failureKey]*registry.UnitFailure{}
+	r.saveFailures(reg)
+	if got, _ := reg.UnitFailures(); len(got) != 0 {
+		t.Errorf("expected failures to be cleared, got %v", got)
+	}
+}
+
+func TestCalculateClusterTasksReplacesExcluded(t *testing.T) {
+	clust := newClusterState(
+		[]job.Unit{
+			job.Unit{Name: "foo.service", Unit: newFleetUnit(t, "Replaces=qux.service"), TargetState: job.JobStateLaunched},
+			job.Unit{Name: "qux.service", Unit: newFleetUnit(t, "RescheduleOnFailure=2"), TargetState: job.JobStateLaunched},
+		},
+		[]job.ScheduledUnit{
+			job.ScheduledUnit{Name: "foo.service", TargetMachineID: "XXX"},
+			job.ScheduledUnit{Name: "qux.service", TargetMachineID: "XXX"},
+		},
+		[]machine.MachineState{machine.MachineState{ID: "XXX"}, machine.MachineState{ID: "YYY"}, machine.MachineState{ID: "ZZZ"}},
+	)
+
+	clock := clockwork.NewFakeClock()
+	r := NewReconciler(&leastLoadedScheduler{})
+	r.clock = clock
+	r.failures = map[failureKey]*registry.UnitFailure{
+		failureKey{name: "qux.service", machID: "YYY"}: &registry.UnitFailure{
+			Name:          "qux.service",
+			MachineID:     "YYY",
+			Count:         2,
+			ExcludedUntil: clock.Now().Add(DefaultFailureCooldown),
+		},
+	}
+
+	// the replaced unit does not move to a machine it is excluded from
+	var got []*task
+	for tsk := range r.calculateClusterTasks(clust, make(chan struct{})) {
+		got = append(got, tsk)
+	}
+	want := []*task{
+		&task{Type: taskTypeUnscheduleUnit, Reason: "target Machine(XXX) unable to run unit: found replace with locally-scheduled Unit(qux.service)", JobName: "qux.service", MachineID: "XXX"},
+		&task{Type: taskTypeAttemptScheduleUnit, Reason: "target Machine(XXX) unable to run unit: found replace with locally-scheduled Unit(qux.service)", JobName: "qux.service", MachineID: "ZZZ"},
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("task mismatch\nexpected %v\n got %v", want, got)
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/coreos/fleet/job"
+	"github.com/coreos/fleet/machine"
+)
+
+// gang is a group of unscheduled jobs that must be scheduled to the same
+// machine: an anchor job along with all jobs declaring MachineOf on it,
+// directly or through other members. Each member comes after the members
+// it declares MachineOf on.
+type gang []*job.Job
+
+// findGangs groups the unscheduled jobs of the given clusterState that are
+// to be launched. A job joins the gang of its peers if all of its peers are
+// either members of that gang or already scheduled. Only gangs of more than
+// one job are returned, indexed by the name of each member.
+func findGangs(clust *clusterState) map[string]gang {
+	pending := func(j *job.Job) bool {
+		return !j.Scheduled() && j.TargetState != job.JobStateInactive
+	}
+
+	names := make([]string, 0, len(clust.jobs))
+	for name, j := range clust.jobs {
+		if pending(j) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	// anchors are pending jobs without pending peers
+	anchorOf := make(map[string]string)
+	gangs := make(map[string]gang)
+	for _, name := range names {
+		j := clust.jobs[name]
+		anchor := true
+		for _, p := range j.Peers() {
+			if pj, ok := clust.jobs[p]; !ok || pending(pj) {
+				anchor = false
+				break
+			}
+		}
+		if anchor {
+			anchorOf[name] = name
+			gangs[name] = gang{j}
+		}
+	}
+
+	// dependents join until no more jobs are able to join
+	for joined := true; joined; {
+		joined = false
+		for _, name := range names {
+			if _, ok := anchorOf[name]; ok {
+				continue
+			}
+
+			j := clust.jobs[name]
+			anchor := ""
+			for _, p := range j.Peers() {
+				pa, ok := anchorOf[p]
+				if !ok {
+					if pj, ok := clust.jobs[p]; ok && !pending(pj) {
+						continue
+					}
+					anchor = ""
+					break
+				}
+				if anchor != "" && anchor != pa {
+					anchor = ""
+					break
+				}
+				anchor = pa
+			}
+			if anchor == "" {
+				continue
+			}
+
+			anchorOf[name] = anchor
+			gangs[anchor] = append(gangs[anchor], j)
+			joined = true
+		}
+	}
+
+	members := make(map[string]gang)
+	for name, anchor := range anchorOf {
+		if g := gangs[anchor]; len(g) > 1 {
+			members[name] = g
+		}
+	}
+	return members
+}
+
+// withScheduledPeers amends the given error of scheduling the given job
+// with the peers of the job that are already scheduled. As only pending
+// jobs form gangs, scheduled peers are never moved to make room for the
+// job, which stays unscheduled until the peers are unscheduled and
+// scheduled again along with it.
+func withScheduledPeers(clust *clusterState, j *job.Job, err error) error {
+	var peers []string
+	for _, p := range j.Peers() {
+		if pj, ok := clust.jobs[p]; ok && pj.Scheduled() {
+			peers = append(peers, fmt.Sprintf("Unit(%s) on Machine(%s)", p, pj.TargetMachineID))
+		}
+	}
+	if len(peers) == 0 {
+		return err
+	}
+
+	serr := &schedulingError{
+		reason: fmt.Sprintf("%v; scheduled units named by MachineOf are not moved along: %s", err, strings.Join(peers, ", ")),
+	}
+	if e, ok := err.(*schedulingError); ok {
+		serr.machines = e.machines
+	}
+	return serr
+}
+
+// decideGang picks a machine able to run all members of the given gang.
+// The Scheduler picks a machine for the anchor of the gang; if another
+// member is unable to run there, the machine is ruled out and the
+// Scheduler asked again.
+func decideGang(clust *clusterState, sched Scheduler, g gang) (*decision, error) {
+	rejected := make(map[string]string)
+	trial := &clusterState{
+		jobs:     clust.jobs,
+		gUnits:   clust.gUnits,
+		machines: make(map[string]*machine.MachineState, len(clust.machines)),
+		excluded: clust.excluded,
+	}
+	for id, ms := range clust.machines {
+		trial.machines[id] = ms
+	}
+
+	for {
+		dec, err := sched.Decide(trial, g[0])
+		if err != nil {
+			serr := &schedulingError{
+				reason:   fmt.Sprintf("no agents able to run group of %d units", len(g)),
+				machines: rejected,
+			}
+			if e, ok := err.(*schedulingError); ok {
+				for id, reason := range e.machines {
+					serr.machines[id] = reason
+				}
+			}
+			return nil, serr
+		}
+
+		reason := gangMisfit(trial, dec.machineID, g)
+		if reason == "" {
+			return dec, nil
+		}
+		rejected[dec.machineID] = reason
+		delete(trial.machines, dec.machineID)
+	}
+}
+
+// gangMisfit returns why the members of the given gang following its
+// anchor are unable to run on the given machine along with the anchor,
+// or an empty string if they are able to.
+func gangMisfit(clust *clusterState, machID string, g gang) string {
+	as := clust.agents()[machID]
+	for i, j := range g {
+		if i > 0 {
+			if act, reason := as.AbleToRun(j); act == job.JobActionUnschedule {
+				return fmt.Sprintf("unable to run Unit(%s) of group: %s", j.Name, reason)
+			}
+		}
+		as.Units[j.Name] = &job.Unit{Name: j.Name, Unit: j.Unit, TargetState: j.TargetState}
+	}
+	return ""
+}
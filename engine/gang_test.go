@@ -0,0 +1,195 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/coreos/fleet/job"
+	"github.com/coreos/fleet/machine"
+)
+
+func TestFindGangs(t *testing.T) {
+	launched := func(name string, opts ...string) job.Unit {
+		return job.Unit{Name: name, Unit: newFleetUnit(t, opts...), TargetState: job.JobStateLaunched}
+	}
+
+	tests := []struct {
+		units  []job.Unit
+		sUnits []job.ScheduledUnit
+		gangs  map[string][]string
+	}{
+		// units without MachineOf are not grouped
+		{
+			units: []job.Unit{launched("a.service"), launched("b.service")},
+			gangs: map[string][]string{},
+		},
+
+		// dependents join their anchor, directly or through other dependents
+		{
+			units: []job.Unit{
+				launched("a.service"),
+				launched("c.service", "MachineOf=b.service"),
+				launched("b.service", "MachineOf=a.service"),
+			},
+			gangs: map[string][]string{
+				"a.service": {"a.service", "b.service", "c.service"},
+				"b.service": {"a.service", "b.service", "c.service"},
+				"c.service": {"a.service", "b.service", "c.service"},
+			},
+		},
+
+		// dependents of a scheduled unit are scheduled one by one
+		{
+			units:  []job.Unit{launched("a.service"), launched("b.service", "MachineOf=a.service")},
+			sUnits: []job.ScheduledUnit{job.ScheduledUnit{Name: "a.service", TargetMachineID: "XXX"}},
+			gangs:  map[string][]string{},
+		},
+
+		// dependents may also require scheduled units
+		{
+			units: []job.Unit{
+				launched("a.service"),
+				launched("b.service"),
+				launched("c.service", "MachineOf=a.service", "MachineOf=b.service"),
+			},
+			sUnits: []job.ScheduledUnit{job.ScheduledUnit{Name: "a.service", TargetMachineID: "XXX"}},
+			gangs: map[string][]string{
+				"b.service": {"b.service", "c.service"},
+				"c.service": {"b.service", "c.service"},
+			},
+		},
+
+		// dependents of units in different gangs, of inactive or of
+		// missing units join no gang
+		{
+			units: []job.Unit{
+				launched("a.service"),
+				launched("b.service"),
+				launched("c.service", "MachineOf=a.service", "MachineOf=b.service"),
+				job.Unit{Name: "d.service", TargetState: job.JobStateInactive},
+				launched("e.service", "MachineOf=d.service"),
+				launched("f.service", "MachineOf=missing.service"),
+			},
+			gangs: map[string][]string{},
+		},
+	}
+
+	for i, tt := range tests {
+		clust := newClusterState(tt.units, tt.sUnits, nil)
+		gangs := make(map[string][]string)
+		for name, g := range findGangs(clust) {
+			for _, j := range g {
+				gangs[name] = append(gangs[name], j.Name)
+			}
+		}
+		if !reflect.DeepEqual(tt.gangs, gangs) {
+			t.Errorf("case %d: expected gangs %v, got %v", i, tt.gangs, gangs)
+		}
+	}
+}
+
+func TestCalculateClusterTasksGang(t *testing.T) {
+	launched := func(name string, opts ...string) job.Unit {
+		return job.Unit{Name: name, Unit: newFleetUnit(t, opts...), TargetState: job.JobStateLaunched}
+	}
+	machines := []machine.MachineState{
+		machine.MachineState{ID: "XXX", Metadata: map[string]string{"disk": "hdd"}},
+		machine.MachineState{ID: "YYY", Metadata: map[string]string{"disk": "ssd"}},
+	}
+
+	tests := []struct {
+		units        []job.Unit
+		schedule     []job.ScheduledUnit
+		tasks        []*task
+		explanations map[string]job.SchedulingExplanation
+	}{
+		// the anchor is placed where its dependents are able to run
+		{
+			units: []job.Unit{
+				launched("a.service"),
+				launched("b.service", "MachineOf=a.service", "MachineMetadata=disk=ssd"),
+			},
+			tasks: []*task{
+				&task{Type: taskTypeAttemptScheduleUnit, Reason: "target state launched and unit not scheduled, along with group of Unit(a.service)", JobName: "a.service", MachineID: "YYY"},
+				&task{Type: taskTypeAttemptScheduleUnit, Reason: "target state launched and unit not scheduled, along with group of Unit(a.service)", JobName: "b.service", MachineID: "YYY"},
+			},
+			explanations: map[string]job.SchedulingExplanation{},
+		},
+
+		// nothing is scheduled if no machine is able to run the gang
+		{
+			units: []job.Unit{
+				launched("a.service", "MachineMetadata=disk=hdd"),
+				launched("b.service", "MachineOf=a.service", "MachineMetadata=disk=ssd"),
+			},
+			explanations: map[string]job.SchedulingExplanation{
+				"a.service": {
+					Name:   "a.service",
+					Reason: "no agents able to run group of 2 units",
+					MachineReasons: map[string]string{
+						"XXX": "unable to run Unit(b.service) of group: local Machine metadata insufficient",
+						"YYY": "local Machine metadata insufficient",
+					},
+				},
+				"b.service": {
+					Name:   "b.service",
+					Reason: "no agents able to run group of 2 units",
+					MachineReasons: map[string]string{
+						"XXX": "unable to run Unit(b.service) of group: local Machine metadata insufficient",
+						"YYY": "local Machine metadata insufficient",
+					},
+				},
+			},
+		},
+
+		// scheduled anchors are not moved along with new dependents
+		{
+			units: []job.Unit{
+				launched("a.service"),
+				launched("b.service", "MachineOf=a.service", "MachineMetadata=disk=ssd"),
+			},
+			schedule: []job.ScheduledUnit{
+				job.ScheduledUnit{Name: "a.service", TargetMachineID: "XXX"},
+			},
+			explanations: map[string]job.SchedulingExplanation{
+				"b.service": {
+					Name:   "b.service",
+					Reason: "no agents able to run job; scheduled units named by MachineOf are not moved along: Unit(a.service) on Machine(XXX)",
+					MachineReasons: map[string]string{
+						"XXX": "local Machine metadata insufficient",
+						"YYY": "required peer Unit(a.service) is not scheduled locally",
+					},
+				},
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		clust := newClusterState(tt.units, tt.schedule, machines)
+		r := NewReconciler(&leastLoadedScheduler{})
+		var tasks []*task
+		for tsk := range r.calculateClusterTasks(clust, make(chan struct{})) {
+			tasks = append(tasks, tsk)
+		}
+		if !reflect.DeepEqual(tt.tasks, tasks) {
+			t.Errorf("case %d: task mismatch\nexpected %v\n got %v", i, tt.tasks, tasks)
+		}
+		if !reflect.DeepEqual(tt.explanations, r.explanations) {
+			t.Errorf("case %d: expected explanations %v, got %v", i, tt.explanations, r.explanations)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"sort"
+	"time"
+
+	"github.com/coreos/fleet/job"
+	"github.com/coreos/fleet/log"
+	"github.com/coreos/fleet/machine"
+	"github.com/coreos/fleet/unit"
+)
+
+// unhealthyRecord holds since when a job was reported unhealthy by the
+// machine it is scheduled to.
+type unhealthyRecord struct {
+	machID string
+	since  time.Time
+}
+
+// trackUnhealthyUnits records the time each launched job defining
+// RescheduleUnhealthyAfter was first reported unhealthy by the machine it
+// is scheduled to. Jobs reported healthy again, or scheduled elsewhere,
+// are forgotten.
+func (r *Reconciler) trackUnhealthyUnits(clust *clusterState, now time.Time) {
+	unhealthy := make(map[string]unhealthyRecord)
+	for name, us := range clust.states {
+		j := clust.jobs[name]
+		if us.Health != unit.HealthUnhealthy || j.TargetState != job.JobStateLaunched {
+			continue
+		}
+		if _, ok := j.RescheduleUnhealthyAfter(); !ok {
+			continue
+		}
+
+		rec, ok := r.unhealthy[name]
+		if !ok || rec.machID != j.TargetMachineID {
+			log.Infof("Job(%s) reported unhealthy by Machine(%s)", name, j.TargetMachineID)
+			rec = unhealthyRecord{machID: j.TargetMachineID, since: now}
+		}
+		unhealthy[name] = rec
+	}
+	r.unhealthy = unhealthy
+}
+
+// evictUnhealthy computes moves of the jobs which stayed unhealthy for
+// longer than their RescheduleUnhealthyAfter to other machines able to
+// run them, applying each to the given clusterState. Like drained units,
+// units collocated with another unit using MachineOf are not moved, and
+// units no other machine is able to run stay.
+func (r *Reconciler) evictUnhealthy(clust *clusterState, sched Scheduler, now time.Time) (moves []move) {
+	var names []string
+	for name := range r.unhealthy {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		j, rec := clust.jobs[name], r.unhealthy[name]
+		after, _ := j.RescheduleUnhealthyAfter()
+		if j.TargetMachineID != rec.machID || now.Sub(rec.since) < after || len(j.Peers()) > 0 {
+			continue
+		}
+
+		trial := &clusterState{
+			jobs:     clust.jobs,
+			gUnits:   clust.gUnits,
+			machines: make(map[string]*machine.MachineState, len(clust.machines)),
+			excluded: clust.excluded,
+		}
+		for id, ms := range clust.machines {
+			if id != rec.machID {
+				trial.machines[id] = ms
+			}
+		}
+
+		clust.unschedule(name)
+		dec, err := sched.Decide(trial, j)
+		if err != nil {
+			log.Debugf("Job(%s) stays on Machine(%s) while unhealthy: %v", name, rec.machID, err)
+			clust.schedule(name, rec.machID)
+			continue
+		}
+		clust.schedule(name, dec.machineID)
+		moves = append(moves, move{jobName: name, from: rec.machID, to: dec.machineID})
+		delete(r.unhealthy, name)
+	}
+
+	return
+}
@@ -0,0 +1,115 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+
+	"github.com/coreos/fleet/job"
+	"github.com/coreos/fleet/machine"
+	"github.com/coreos/fleet/unit"
+)
+
+func TestCalculateClusterTasksUnhealthyUnit(t *testing.T) {
+	newClust := func(health string, opts ...string) *clusterState {
+		clust := newClusterState(
+			[]job.Unit{
+				job.Unit{Name: "foo.service", Unit: newFleetUnit(t, opts...), TargetState: job.JobStateLaunched},
+			},
+			[]job.ScheduledUnit{
+				job.ScheduledUnit{Name: "foo.service", TargetMachineID: "XXX"},
+			},
+			[]machine.MachineState{machine.MachineState{ID: "XXX"}, machine.MachineState{ID: "YYY"}},
+		)
+		clust.setUnitStates([]*unit.UnitState{
+			&unit.UnitState{UnitName: "foo.service", MachineID: "XXX", Health: health},
+			// states reported by other machines are ignored
+			&unit.UnitState{UnitName: "foo.service", MachineID: "YYY", Health: unit.HealthHealthy},
+		})
+		return clust
+	}
+	tasks := func(r *Reconciler, clust *clusterState) []*task {
+		var tasks []*task
+		for tsk := range r.calculateClusterTasks(clust, make(chan struct{})) {
+			tasks = append(tasks, tsk)
+		}
+		return tasks
+	}
+	opts := []string{"HealthCheckHTTP=http://localhost/", "RescheduleUnhealthyAfter=1m"}
+	rescheduled := []*task{
+		&task{Type: taskTypeEvictUnhealthyUnit, Reason: "unhealthy on Machine(XXX)", JobName: "foo.service", MachineID: "XXX"},
+		&task{Type: taskTypeAttemptScheduleUnit, Reason: "unhealthy on Machine(XXX)", JobName: "foo.service", MachineID: "YYY"},
+	}
+
+	clock := clockwork.NewFakeClock()
+	r := NewReconciler(&leastLoadedScheduler{})
+	r.clock = clock
+
+	// unhealthy units stay for RescheduleUnhealthyAfter
+	if got := tasks(r, newClust(unit.HealthUnhealthy, opts...)); got != nil {
+		t.Fatalf("expected no tasks for unit just reported unhealthy, got %v", got)
+	}
+	clock.Advance(30 * time.Second)
+	if got := tasks(r, newClust(unit.HealthUnhealthy, opts...)); got != nil {
+		t.Fatalf("expected no tasks within RescheduleUnhealthyAfter, got %v", got)
+	}
+
+	// units reported healthy again start over
+	if got := tasks(r, newClust(unit.HealthHealthy, opts...)); got != nil {
+		t.Fatalf("expected no tasks for healthy unit, got %v", got)
+	}
+	if len(r.unhealthy) != 0 {
+		t.Fatalf("expected healthy unit to be forgotten, got %v", r.unhealthy)
+	}
+	tasks(r, newClust(unit.HealthUnhealthy, opts...))
+	clock.Advance(30 * time.Second)
+	if got := tasks(r, newClust(unit.HealthUnhealthy, opts...)); got != nil {
+		t.Fatalf("expected no tasks within RescheduleUnhealthyAfter, got %v", got)
+	}
+
+	// and move to another machine once it passed
+	clock.Advance(30 * time.Second)
+	if got := tasks(r, newClust(unit.HealthUnhealthy, opts...)); !reflect.DeepEqual(rescheduled, got) {
+		t.Fatalf("task mismatch\nexpected %v\n got %v", rescheduled, got)
+	}
+	if len(r.unhealthy) != 0 {
+		t.Errorf("expected moved unit to be forgotten, got %v", r.unhealthy)
+	}
+
+	// units not defining RescheduleUnhealthyAfter stay unhealthy
+	r = NewReconciler(&leastLoadedScheduler{})
+	r.clock = clock
+	tasks(r, newClust(unit.HealthUnhealthy, "HealthCheckHTTP=http://localhost/"))
+	clock.Advance(time.Hour)
+	if got := tasks(r, newClust(unit.HealthUnhealthy, "HealthCheckHTTP=http://localhost/")); got != nil {
+		t.Errorf("expected no tasks without RescheduleUnhealthyAfter, got %v", got)
+	}
+
+	// units no other machine is able to run stay as well
+	opts = append(opts, "MachineMetadata=role=web")
+	clust := newClust(unit.HealthUnhealthy, opts...)
+	clust.machines["XXX"].Metadata = map[string]string{"role": "web"}
+	tasks(r, clust)
+	clock.Advance(time.Hour)
+	clust = newClust(unit.HealthUnhealthy, opts...)
+	clust.machines["XXX"].Metadata = map[string]string{"role": "web"}
+	if got := tasks(r, clust); got != nil {
+		t.Errorf("expected no tasks without another machine able to run the unit, got %v", got)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/coreos/fleet/job"
+	"github.com/coreos/fleet/log"
+	"github.com/coreos/fleet/registry"
+)
+
+// trackLostMachines records the time each machine that jobs are scheduled
+// to was first found missing from the cluster. Machines which returned, or
+// which no job is scheduled to anymore, are forgotten.
+func (r *Reconciler) trackLostMachines(clust *clusterState, now time.Time) {
+	lost := make(map[string]time.Time)
+	for _, j := range clust.jobs {
+		if !j.Scheduled() {
+			continue
+		}
+		if _, ok := clust.machines[j.TargetMachineID]; ok {
+			continue
+		}
+
+		since, ok := r.lostMachines[j.TargetMachineID]
+		if !ok {
+			log.Infof("Machine(%s) went away, Units scheduled to it are machine-lost", j.TargetMachineID)
+			since = now
+		}
+		lost[j.TargetMachineID] = since
+	}
+	r.lostMachines = lost
+}
+
+// awaitingLostMachine determines whether the given job, which is scheduled
+// to a machine that went away, should stay scheduled to it in the hope it
+// returns. The job's RescheduleAfter takes precedence over the machine
+// loss grace period.
+func (r *Reconciler) awaitingLostMachine(j *job.Job, now time.Time) bool {
+	since, ok := r.lostMachines[j.TargetMachineID]
+	if !ok {
+		return false
+	}
+
+	grace := r.machineLossGracePeriod
+	if d, ok := j.RescheduleAfter(); ok {
+		grace = d
+	}
+	return now.Sub(since) < grace
+}
+
+// loadLostMachines picks up the lost machines recorded by a previous engine
+// leader, so that their grace period does not start over.
+func (r *Reconciler) loadLostMachines(reg registry.Registry) {
+	lost, err := reg.LostMachines()
+	if err != nil {
+		log.Errorf("Failed fetching lost machines from Registry: %v", err)
+		return
+	}
+	r.lostMachines = lost
+	r.savedLost = nil
+}
+
+// saveLostMachines writes the lost machines to the Registry, if they
+// changed since last written.
+func (r *Reconciler) saveLostMachines(reg registry.Registry) {
+	if r.savedLost != nil && reflect.DeepEqual(r.lostMachines, r.savedLost) {
+		return
+	}
+
+	if err := reg.SetLostMachines(r.lostMachines); err != nil {
+		log.Errorf("Failed saving lost machines: %v", err)
+		return
+	}
+	r.savedLost = r.lostMachines
+}
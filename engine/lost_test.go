@@ -0,0 +1,135 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/coreos/fleet/job"
+	"github.com/coreos/fleet/machine"
+	"github.com/coreos/fleet/registry"
+)
+
+func TestCalculateClusterTasksLostMachine(t *testing.T) {
+	newClust := func(opts ...string) *clusterState {
+		return newClusterState(
+			[]job.Unit{
+				job.Unit{Name: "foo.service", Unit: newFleetUnit(t, opts...), TargetState: job.JobStateLaunched},
+			},
+			[]job.ScheduledUnit{
+				job.ScheduledUnit{Name: "foo.service", TargetMachineID: "YYY"},
+			},
+			[]machine.MachineState{machine.MachineState{ID: "XXX"}},
+		)
+	}
+	tasks := func(r *Reconciler, clust *clusterState) []*task {
+		var tasks []*task
+		for tsk := range r.calculateClusterTasks(clust, make(chan struct{})) {
+			tasks = append(tasks, tsk)
+		}
+		return tasks
+	}
+	rescheduled := []*task{
+		&task{Type: taskTypeUnscheduleUnit, Reason: "target Machine(YYY) went away", JobName: "foo.service", MachineID: "YYY"},
+		&task{Type: taskTypeAttemptScheduleUnit, Reason: "target state launched and unit not scheduled", JobName: "foo.service", MachineID: "XXX"},
+	}
+
+	// without a grace period, units are rescheduled right away
+	r := NewReconciler(&leastLoadedScheduler{})
+	if got := tasks(r, newClust()); !reflect.DeepEqual(rescheduled, got) {
+		t.Fatalf("task mismatch\nexpected %v\n got %v", rescheduled, got)
+	}
+
+	// within the grace period, units stay
+	r = NewReconciler(&leastLoadedScheduler{})
+	r.machineLossGracePeriod = time.Minute
+	if got := tasks(r, newClust()); got != nil {
+		t.Fatalf("expected no tasks within the grace period, got %v", got)
+	}
+	since, ok := r.lostMachines["YYY"]
+	if !ok {
+		t.Fatalf("expected Machine(YYY) to be recorded as lost")
+	}
+	if got := tasks(r, newClust()); got != nil {
+		t.Fatalf("expected no tasks within the grace period, got %v", got)
+	}
+	if !r.lostMachines["YYY"].Equal(since) {
+		t.Errorf("expected Machine(YYY) to be lost since %v, got %v", since, r.lostMachines["YYY"])
+	}
+
+	// and are rescheduled once it passed
+	r.lostMachines["YYY"] = time.Now().Add(-time.Minute)
+	if got := tasks(r, newClust()); !reflect.DeepEqual(rescheduled, got) {
+		t.Errorf("task mismatch\nexpected %v\n got %v", rescheduled, got)
+	}
+
+	// RescheduleAfter overrides the grace period
+	r = NewReconciler(&leastLoadedScheduler{})
+	r.machineLossGracePeriod = time.Hour
+	if got := tasks(r, newClust("RescheduleAfter=0")); !reflect.DeepEqual(rescheduled, got) {
+		t.Errorf("task mismatch\nexpected %v\n got %v", rescheduled, got)
+	}
+
+	r = NewReconciler(&leastLoadedScheduler{})
+	if got := tasks(r, newClust("RescheduleAfter=1h")); got != nil {
+		t.Errorf("expected no tasks within RescheduleAfter, got %v", got)
+	}
+
+	// machines that returned are forgotten
+	clust := newClust("RescheduleAfter=1h")
+	clust.machines["YYY"] = &machine.MachineState{ID: "YYY"}
+	if got := tasks(r, clust); got != nil {
+		t.Errorf("expected no tasks once the machine returned, got %v", got)
+	}
+	if len(r.lostMachines) != 0 {
+		t.Errorf("expected no lost machines, got %v", r.lostMachines)
+	}
+}
+
+func TestSaveLostMachines(t *testing.T) {
+	since := time.Date(2016, time.May, 4, 12, 0, 0, 0, time.UTC)
+
+	reg := registry.NewFakeRegistry()
+	reg.SetLostMachines(map[string]time.Time{"XXX": since})
+
+	// lost machines of a previous leader are picked up
+	r := NewReconciler(&leastLoadedScheduler{})
+	r.loadLostMachines(reg)
+	if want := map[string]time.Time{"XXX": since}; !reflect.DeepEqual(want, r.lostMachines) {
+		t.Fatalf("expected lost machines %v, got %v", want, r.lostMachines)
+	}
+
+	// and written once after becoming leader
+	reg.SetLostMachines(nil)
+	r.saveLostMachines(reg)
+	if lost, _ := reg.LostMachines(); len(lost) != 1 {
+		t.Fatalf("expected lost machines to be written, got %v", lost)
+	}
+
+	// unchanged lost machines are not written again
+	reg.SetLostMachines(nil)
+	r.saveLostMachines(reg)
+	if lost, _ := reg.LostMachines(); lost != nil {
+		t.Errorf("expected no write of unchanged lost machines, got %v", lost)
+	}
+
+	r.lostMachines = map[string]time.Time{}
+	r.saveLostMachines(reg)
+	if lost, _ := reg.LostMachines(); lost == nil || len(lost) != 0 {
+		t.Errorf("expected lost machines to be cleared, got %v", lost)
+	}
+}
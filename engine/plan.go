@@ -0,0 +1,166 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"sort"
+
+	"github.com/coreos/fleet/job"
+	"github.com/coreos/fleet/machine"
+	"github.com/coreos/fleet/registry"
+)
+
+// PlanIgnoredSettings lists the fleetd options configuring the engine
+// which Plan does not take into account. Plan starts from a fresh
+// Reconciler using the default of each, so it also ignores the machines
+// the engine leader found missing and the failures it counted.
+var PlanIgnoredSettings = []string{
+	"scheduler_seed",
+	"rebalance_interval",
+	"rebalance_max_moves",
+	"drain_batch_size",
+	"schedule_limit",
+	"schedule_limit_per_machine",
+	"machine_loss_grace_period",
+	"failure_cooldown",
+}
+
+// NewPlanner returns a registry.Planner simulating the scheduling of the
+// engine on the state of the given Registry. The configuration of the
+// engine leader is not known to it, so each result lists the settings in
+// PlanIgnoredSettings, along with scheduler_strategy if no strategy is
+// given.
+func NewPlanner(reg registry.Registry) registry.Planner {
+	return &planner{reg}
+}
+
+type planner struct {
+	reg registry.Registry
+}
+
+func (p *planner) Plan(strategy string, change registry.PlanChange) (*registry.PlanResult, error) {
+	sched, err := NewScheduler(strategy, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := Plan(p.reg, sched, change)
+	if err != nil {
+		return nil, err
+	}
+
+	res.IgnoredSettings = PlanIgnoredSettings
+	if strategy == "" {
+		res.IgnoredSettings = append([]string{"scheduler_strategy"}, PlanIgnoredSettings...)
+	}
+	return res, nil
+}
+
+// Plan simulates a single reconciliation of the current state of the
+// Registry with the given changes applied, using the given Scheduler.
+// Nothing is written to the Registry. Like the engine, Plan does not
+// consider global units. See PlanIgnoredSettings for the configuration
+// of the engine the result may differ by.
+func Plan(reg registry.Registry, sched Scheduler, change registry.PlanChange) (*registry.PlanResult, error) {
+	units, err := reg.Units()
+	if err != nil {
+		return nil, err
+	}
+
+	sUnits, err := reg.Schedule()
+	if err != nil {
+		return nil, err
+	}
+
+	machines, err := reg.Machines()
+	if err != nil {
+		return nil, err
+	}
+
+	replicas, err := reg.UnitReplicas()
+	if err != nil {
+		return nil, err
+	}
+
+	clust := newClusterState(planUnits(units, change.Units), sUnits, planMachines(machines, change))
+	clust.replicas = replicas
+
+	r := NewReconciler(sched)
+	var res registry.PlanResult
+	for t := range r.calculateClusterTasks(clust, make(chan struct{})) {
+		res.Tasks = append(res.Tasks, registry.PlanTask{
+			Type:      t.Type,
+			Reason:    t.Reason,
+			JobName:   t.JobName,
+			MachineID: t.MachineID,
+		})
+	}
+
+	names := make([]string, 0, len(r.explanations))
+	for name := range r.explanations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		res.Unschedulable = append(res.Unschedulable, r.explanations[name])
+	}
+
+	return &res, nil
+}
+
+func planUnits(units, proposed []job.Unit) []job.Unit {
+	replaced := make(map[string]bool, len(proposed))
+	for _, u := range proposed {
+		replaced[u.Name] = true
+	}
+
+	planned := make([]job.Unit, 0, len(units)+len(proposed))
+	for _, u := range units {
+		if !replaced[u.Name] {
+			planned = append(planned, u)
+		}
+	}
+
+	return append(planned, proposed...)
+}
+
+func planMachines(machines []machine.MachineState, change registry.PlanChange) []machine.MachineState {
+	removed := make(map[string]bool, len(change.RemoveMachines))
+	for _, id := range change.RemoveMachines {
+		removed[id] = true
+	}
+
+	planned := make([]machine.MachineState, 0, len(machines))
+	for _, ms := range machines {
+		if removed[ms.ID] {
+			continue
+		}
+
+		if add, ok := change.AddMetadata[ms.ID]; ok {
+			md := make(map[string]string, len(ms.Metadata)+len(add))
+			for k, v := range ms.Metadata {
+				md[k] = v
+			}
+			for k, v := range add {
+				md[k] = v
+			}
+			ms.Metadata = md
+		}
+
+		planned = append(planned, ms)
+	}
+
+	return planned
+}
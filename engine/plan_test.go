@@ -0,0 +1,137 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/coreos/fleet/job"
+	"github.com/coreos/fleet/machine"
+	"github.com/coreos/fleet/registry"
+	"github.com/coreos/fleet/unit"
+)
+
+func TestPlan(t *testing.T) {
+	uf, err := unit.NewUnitFile("[X-Fleet]\nMachineMetadata=region=eu\n")
+	if err != nil {
+		t.Fatalf("Unexpected error creating unit file: %v", err)
+	}
+	eu := job.Unit{
+		Name:        "eu.service",
+		Unit:        *uf,
+		TargetState: job.JobStateLaunched,
+	}
+
+	tests := []struct {
+		change registry.PlanChange
+		result registry.PlanResult
+	}{
+		// nothing changes
+		{
+			change: registry.PlanChange{},
+			result: registry.PlanResult{},
+		},
+
+		// removing a machine moves its units
+		{
+			change: registry.PlanChange{RemoveMachines: []string{"XXX"}},
+			result: registry.PlanResult{
+				Tasks: []registry.PlanTask{
+					registry.PlanTask{
+						Type:      taskTypeUnscheduleUnit,
+						Reason:    "target Machine(XXX) went away",
+						JobName:   "foo.service",
+						MachineID: "XXX",
+					},
+					registry.PlanTask{
+						Type:      taskTypeAttemptScheduleUnit,
+						Reason:    "target state launched and unit not scheduled",
+						JobName:   "foo.service",
+						MachineID: "YYY",
+					},
+				},
+			},
+		},
+
+		// submitted units with unmet requirements are explained
+		{
+			change: registry.PlanChange{Units: []job.Unit{eu}},
+			result: registry.PlanResult{
+				Unschedulable: []job.SchedulingExplanation{
+					job.SchedulingExplanation{
+						Name:   "eu.service",
+						Reason: "no agents able to run job",
+						MachineReasons: map[string]string{
+							"XXX": "local Machine metadata insufficient",
+							"YYY": "local Machine metadata insufficient",
+						},
+					},
+				},
+			},
+		},
+
+		// added metadata is taken into account
+		{
+			change: registry.PlanChange{
+				Units:       []job.Unit{eu},
+				AddMetadata: map[string]map[string]string{"YYY": {"region": "eu"}},
+			},
+			result: registry.PlanResult{
+				Tasks: []registry.PlanTask{
+					registry.PlanTask{
+						Type:      taskTypeAttemptScheduleUnit,
+						Reason:    "target state launched and unit not scheduled",
+						JobName:   "eu.service",
+						MachineID: "YYY",
+					},
+				},
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		reg := registry.NewFakeRegistry()
+		reg.SetMachines([]machine.MachineState{
+			machine.MachineState{ID: "XXX", Metadata: map[string]string{"region": "us"}},
+			machine.MachineState{ID: "YYY", Metadata: map[string]string{"region": "us"}},
+		})
+		reg.SetJobs([]job.Job{
+			job.Job{
+				Name:            "foo.service",
+				TargetState:     job.JobStateLaunched,
+				TargetMachineID: "XXX",
+			},
+		})
+
+		res, err := Plan(reg, &leastLoadedScheduler{}, tt.change)
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(tt.result, *res) {
+			t.Errorf("case %d: result mismatch\nexpected %#v\n got %#v", i, tt.result, *res)
+		}
+
+		// the registry must be left untouched
+		ms, _ := reg.Machines()
+		if len(ms) != 2 || ms[1].Metadata["region"] != "us" {
+			t.Errorf("case %d: machines in registry were modified: %v", i, ms)
+		}
+		if su, _ := reg.ScheduledUnit("foo.service"); su.TargetMachineID != "XXX" {
+			t.Errorf("case %d: schedule in registry was modified: %v", i, su)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"sort"
+	"time"
+
+	"github.com/coreos/fleet/agent"
+	"github.com/coreos/fleet/job"
+)
+
+const (
+	// preemptionInterval is the minimum time between two preemptions,
+	// so that a flapping high-priority unit cannot continuously evict
+	// lower-priority units from the cluster.
+	preemptionInterval = 10 * time.Second
+)
+
+// preemption describes the units to unschedule from a machine so that a
+// job of higher priority can be scheduled to it.
+type preemption struct {
+	machineID string
+	victims   []string
+}
+
+// preemptionAllowed reports whether the rate limit permits a preemption
+// at the given time.
+func (r *Reconciler) preemptionAllowed(now time.Time) bool {
+	return r.lastPreemption.IsZero() || now.Sub(r.lastPreemption) >= preemptionInterval
+}
+
+// findPreemption returns the machine on which the fewest units of lower
+// priority than the given job need to be unscheduled for the job to be
+// able to run, or nil if no such machine exists.
+func findPreemption(clust *clusterState, j *job.Job) *preemption {
+	agents := clust.agents()
+	ids := make([]string, 0, len(agents))
+	for id := range agents {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var best *preemption
+	for _, id := range ids {
+		victims := preemptionVictims(clust, agents[id], j)
+		if victims == nil {
+			continue
+		}
+		if best == nil || len(victims) < len(best.victims) {
+			best = &preemption{machineID: id, victims: victims}
+		}
+	}
+
+	return best
+}
+
+// preemptionVictims returns the units of lower priority than the given job
+// to unschedule from the given agent for the job to be able to run there.
+// Units of lowest priority are picked first, and units whose removal turns
+// out to be unnecessary are kept. nil is returned if removing all units of
+// lower priority is not enough.
+func preemptionVictims(clust *clusterState, as *agent.AgentState, j *job.Job) []string {
+	priority := j.Priority()
+	candidates := make(priorityJobs, 0)
+	for name := range as.Units {
+		cj, ok := clust.jobs[name]
+		if ok && cj.Priority() < priority {
+			candidates = append(candidates, cj)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	sort.Sort(candidates)
+
+	trial := agent.NewAgentState(as.MState)
+	for name, u := range as.Units {
+		trial.Units[name] = u
+	}
+	trial.Excluded = as.Excluded
+	able := func() bool {
+		act, _ := trial.AbleToRun(j)
+		return act != job.JobActionUnschedule
+	}
+
+	var removed []string
+	for _, cj := range candidates {
+		delete(trial.Units, cj.Name)
+		removed = append(removed, cj.Name)
+		if able() {
+			break
+		}
+	}
+	if !able() {
+		return nil
+	}
+
+	// give back units not standing in the way, most important first
+	var victims []string
+	for i := len(removed) - 1; i >= 0; i-- {
+		name := removed[i]
+		trial.Units[name] = as.Units[name]
+		if able() {
+			continue
+		}
+		delete(trial.Units, name)
+		victims = append(victims, name)
+	}
+	sort.Strings(victims)
+
+	return victims
+}
+
+// priorityJobs sorts jobs by ascending priority, then by name.
+type priorityJobs []*job.Job
+
+func (pj priorityJobs) Len() int      { return len(pj) }
+func (pj priorityJobs) Swap(i, j int) { pj[i], pj[j] = pj[j], pj[i] }
+
+func (pj priorityJobs) Less(i, j int) bool {
+	pi, pk := pj[i].Priority(), pj[j].Priority()
+	if pi != pk {
+		return pi < pk
+	}
+	return pj[i].Name < pj[j].Name
+}
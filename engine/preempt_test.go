@@ -0,0 +1,190 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/coreos/fleet/job"
+	"github.com/coreos/fleet/machine"
+	"github.com/coreos/fleet/resource"
+)
+
+func TestFindPreemption(t *testing.T) {
+	tests := []struct {
+		units    []job.Unit
+		sUnits   []job.ScheduledUnit
+		machines []machine.MachineState
+		job      string
+		want     *preemption
+	}{
+		// lower-priority conflicting unit is preempted
+		{
+			units: []job.Unit{
+				job.Unit{Name: "ingress.service", Unit: newFleetUnit(t, "Priority=10", "Conflicts=batch*"), TargetState: job.JobStateLaunched},
+				job.Unit{Name: "batch.service", TargetState: job.JobStateLaunched},
+			},
+			sUnits: []job.ScheduledUnit{
+				job.ScheduledUnit{Name: "batch.service", TargetMachineID: "XXX"},
+			},
+			machines: []machine.MachineState{machine.MachineState{ID: "XXX"}},
+			job:      "ingress.service",
+			want:     &preemption{machineID: "XXX", victims: []string{"batch.service"}},
+		},
+
+		// units of equal priority are never preempted
+		{
+			units: []job.Unit{
+				job.Unit{Name: "ingress.service", Unit: newFleetUnit(t, "Conflicts=batch*"), TargetState: job.JobStateLaunched},
+				job.Unit{Name: "batch.service", TargetState: job.JobStateLaunched},
+			},
+			sUnits: []job.ScheduledUnit{
+				job.ScheduledUnit{Name: "batch.service", TargetMachineID: "XXX"},
+			},
+			machines: []machine.MachineState{machine.MachineState{ID: "XXX"}},
+			job:      "ingress.service",
+			want:     nil,
+		},
+
+		// preemption does not help with metadata constraints
+		{
+			units: []job.Unit{
+				job.Unit{Name: "ingress.service", Unit: newFleetUnit(t, "Priority=10", "MachineMetadata=region=eu"), TargetState: job.JobStateLaunched},
+				job.Unit{Name: "batch.service", TargetState: job.JobStateLaunched},
+			},
+			sUnits: []job.ScheduledUnit{
+				job.ScheduledUnit{Name: "batch.service", TargetMachineID: "XXX"},
+			},
+			machines: []machine.MachineState{machine.MachineState{ID: "XXX"}},
+			job:      "ingress.service",
+			want:     nil,
+		},
+
+		// only as many units as needed are preempted, lowest priority first
+		{
+			units: []job.Unit{
+				job.Unit{Name: "ingress.service", Unit: newFleetUnit(t, "Priority=10", "MemoryRequired=512M"), TargetState: job.JobStateLaunched},
+				job.Unit{Name: "batch1.service", Unit: newFleetUnit(t, "Priority=5", "MemoryRequired=512M"), TargetState: job.JobStateLaunched},
+				job.Unit{Name: "batch2.service", Unit: newFleetUnit(t, "Priority=-5", "MemoryRequired=512M"), TargetState: job.JobStateLaunched},
+				job.Unit{Name: "small.service", Unit: newFleetUnit(t, "Priority=-10"), TargetState: job.JobStateLaunched},
+			},
+			sUnits: []job.ScheduledUnit{
+				job.ScheduledUnit{Name: "batch1.service", TargetMachineID: "XXX"},
+				job.ScheduledUnit{Name: "batch2.service", TargetMachineID: "XXX"},
+				job.ScheduledUnit{Name: "small.service", TargetMachineID: "XXX"},
+			},
+			machines: []machine.MachineState{
+				machine.MachineState{ID: "XXX", AllocatableResources: &resource.ResourceTuple{Cores: 100, Memory: 1024}},
+			},
+			job:  "ingress.service",
+			want: &preemption{machineID: "XXX", victims: []string{"batch2.service"}},
+		},
+
+		// the machine needing the fewest preemptions is picked
+		{
+			units: []job.Unit{
+				job.Unit{Name: "ingress.service", Unit: newFleetUnit(t, "Priority=10", "Conflicts=batch*"), TargetState: job.JobStateLaunched},
+				job.Unit{Name: "batch1.service", TargetState: job.JobStateLaunched},
+				job.Unit{Name: "batch2.service", TargetState: job.JobStateLaunched},
+				job.Unit{Name: "batch3.service", TargetState: job.JobStateLaunched},
+			},
+			sUnits: []job.ScheduledUnit{
+				job.ScheduledUnit{Name: "batch1.service", TargetMachineID: "XXX"},
+				job.ScheduledUnit{Name: "batch2.service", TargetMachineID: "XXX"},
+				job.ScheduledUnit{Name: "batch3.service", TargetMachineID: "YYY"},
+			},
+			machines: []machine.MachineState{
+				machine.MachineState{ID: "XXX"},
+				machine.MachineState{ID: "YYY"},
+			},
+			job:  "ingress.service",
+			want: &preemption{machineID: "YYY", victims: []string{"batch3.service"}},
+		},
+	}
+
+	for i, tt := range tests {
+		clust := newClusterState(tt.units, tt.sUnits, tt.machines)
+		got := findPreemption(clust, clust.jobs[tt.job])
+		if !reflect.DeepEqual(tt.want, got) {
+			t.Errorf("case %d: expected %#v, got %#v", i, tt.want, got)
+		}
+	}
+}
+
+func TestCalculateClusterTasksPreemption(t *testing.T) {
+	newClust := func() *clusterState {
+		return newClusterState(
+			[]job.Unit{
+				job.Unit{Name: "ingress.service", Unit: newFleetUnit(t, "Priority=10", "Conflicts=batch*"), TargetState: job.JobStateLaunched},
+				job.Unit{Name: "batch.service", TargetState: job.JobStateLaunched},
+			},
+			[]job.ScheduledUnit{
+				job.ScheduledUnit{Name: "batch.service", TargetMachineID: "XXX"},
+			},
+			[]machine.MachineState{machine.MachineState{ID: "XXX"}},
+		)
+	}
+
+	r := NewReconciler(&leastLoadedScheduler{})
+	var tasks []*task
+	for tsk := range r.calculateClusterTasks(newClust(), make(chan struct{})) {
+		tasks = append(tasks, tsk)
+	}
+
+	want := []*task{
+		&task{
+			Type:      taskTypePreemptUnit,
+			Reason:    "preempted by Unit(ingress.service) of higher priority 10",
+			JobName:   "batch.service",
+			MachineID: "XXX",
+		},
+		&task{
+			Type:      taskTypeAttemptScheduleUnit,
+			Reason:    "target state launched and unit not scheduled",
+			JobName:   "ingress.service",
+			MachineID: "XXX",
+		},
+	}
+	if !reflect.DeepEqual(want, tasks) {
+		t.Fatalf("task mismatch\nexpected %v\n got %v", want, tasks)
+	}
+	if r.lastPreemption.IsZero() {
+		t.Errorf("expected time of preemption to be recorded")
+	}
+
+	// another preemption right away is rate-limited
+	tasks = nil
+	for tsk := range r.calculateClusterTasks(newClust(), make(chan struct{})) {
+		tasks = append(tasks, tsk)
+	}
+	if len(tasks) != 0 {
+		t.Errorf("expected no tasks while rate-limited, got %v", tasks)
+	}
+	if _, ok := r.explanations["ingress.service"]; !ok {
+		t.Errorf("expected rate-limited unit to be explained")
+	}
+
+	// and allowed again once the interval passed
+	r.lastPreemption = time.Now().Add(-preemptionInterval)
+	tasks = nil
+	for tsk := range r.calculateClusterTasks(newClust(), make(chan struct{})) {
+		tasks = append(tasks, tsk)
+	}
+	if !reflect.DeepEqual(want, tasks) {
+		t.Errorf("task mismatch\nexpected %v\n got %v", want, tasks)
+	}
+}
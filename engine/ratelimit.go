@@ -0,0 +1,165 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"sort"
+	"time"
+
+	"github.com/coreos/fleet/job"
+)
+
+// tokenBucket permits up to rate events per interval. Tokens are refilled
+// continuously and accumulate up to rate, so an idle bucket permits a
+// burst of at most rate events.
+type tokenBucket struct {
+	rate     int
+	interval time.Duration
+	tokens   float64
+	last     time.Time
+}
+
+func newTokenBucket(rate int, interval time.Duration, now time.Time) *tokenBucket {
+	return &tokenBucket{rate: rate, interval: interval, tokens: float64(rate), last: now}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	if b.interval <= 0 {
+		b.tokens = float64(b.rate)
+	} else if now.After(b.last) {
+		b.tokens += float64(b.rate) * float64(now.Sub(b.last)) / float64(b.interval)
+	}
+	if b.tokens > float64(b.rate) {
+		b.tokens = float64(b.rate)
+	}
+	b.last = now
+}
+
+// available reports whether n events are permitted. Requests for more
+// events than the rate are permitted once the bucket is full, leaving
+// the bucket in debt.
+func (b *tokenBucket) available(n int) bool {
+	if n > b.rate {
+		n = b.rate
+	}
+	return b.tokens >= float64(n)
+}
+
+func (b *tokenBucket) take() {
+	b.tokens--
+}
+
+// scheduleLimiter limits the number of units the engine schedules, both
+// across the cluster and to each individual machine. A limit of 0 means
+// unlimited. A nil scheduleLimiter permits everything.
+type scheduleLimiter struct {
+	total      int
+	perMachine int
+	interval   time.Duration
+
+	cluster  *tokenBucket
+	machines map[string]*tokenBucket
+	now      time.Time
+}
+
+func newScheduleLimiter(total, perMachine int, interval time.Duration) *scheduleLimiter {
+	l := &scheduleLimiter{
+		total:      total,
+		perMachine: perMachine,
+		interval:   interval,
+		machines:   make(map[string]*tokenBucket),
+	}
+	if total > 0 {
+		l.cluster = newTokenBucket(total, interval, time.Time{})
+	}
+	return l
+}
+
+// refill tops up all buckets at the start of a reconciliation, dropping
+// those of machines no longer part of the cluster.
+func (l *scheduleLimiter) refill(clust *clusterState, now time.Time) {
+	if l == nil {
+		return
+	}
+	l.now = now
+	if l.cluster != nil {
+		l.cluster.refill(now)
+	}
+	for id, b := range l.machines {
+		if _, ok := clust.machines[id]; !ok {
+			delete(l.machines, id)
+			continue
+		}
+		b.refill(now)
+	}
+}
+
+// clusterAvailable reports whether n more units may be scheduled across
+// the cluster.
+func (l *scheduleLimiter) clusterAvailable(n int) bool {
+	return l == nil || l.cluster == nil || l.cluster.available(n)
+}
+
+// machineAvailable reports whether n more units may be scheduled to the
+// given machine.
+func (l *scheduleLimiter) machineAvailable(machID string, n int) bool {
+	if l == nil || l.perMachine <= 0 {
+		return true
+	}
+	b, ok := l.machines[machID]
+	if !ok {
+		b = newTokenBucket(l.perMachine, l.interval, l.now)
+		l.machines[machID] = b
+	}
+	return b.available(n)
+}
+
+// take records a unit being scheduled to the given machine.
+func (l *scheduleLimiter) take(machID string) {
+	if l == nil {
+		return
+	}
+	if l.cluster != nil {
+		l.cluster.take()
+	}
+	if b, ok := l.machines[machID]; ok {
+		b.take()
+	}
+}
+
+// scheduleOrder returns the jobs of the given clusterState in the order
+// they are considered for scheduling: jobs deferred during the previous
+// reconciliation come first, in the order they were deferred, followed by
+// all others sorted by name.
+func scheduleOrder(clust *clusterState, pending []string) []*job.Job {
+	jobs := make([]*job.Job, 0, len(clust.jobs))
+	seen := make(map[string]bool, len(pending))
+	for _, name := range pending {
+		if j, ok := clust.jobs[name]; ok && !seen[name] {
+			jobs = append(jobs, j)
+			seen[name] = true
+		}
+	}
+
+	rest := make([]*job.Job, 0, len(clust.jobs)-len(jobs))
+	for name, j := range clust.jobs {
+		if !seen[name] {
+			rest = append(rest, j)
+		}
+	}
+	sort.Sort(jobsByName(rest))
+
+	return append(jobs, rest...)
+}
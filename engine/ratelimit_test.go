@@ -0,0 +1,131 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/coreos/fleet/job"
+	"github.com/coreos/fleet/machine"
+)
+
+func TestTokenBucket(t *testing.T) {
+	start := time.Unix(1000, 0)
+	b := newTokenBucket(2, 10*time.Second, start)
+
+	tests := []struct {
+		elapsed time.Duration
+		takes   int
+	}{
+		// a new bucket is full
+		{0, 2},
+		// tokens are refilled over time
+		{5 * time.Second, 1},
+		{4 * time.Second, 0},
+		// unused tokens accumulate up to the rate
+		{time.Minute, 2},
+	}
+
+	for i, tt := range tests {
+		start = start.Add(tt.elapsed)
+		b.refill(start)
+		takes := 0
+		for b.available(1) {
+			b.take()
+			takes++
+		}
+		if takes != tt.takes {
+			t.Errorf("case %d: expected %d tokens, got %d", i, tt.takes, takes)
+		}
+	}
+}
+
+func TestCalculateClusterTasksScheduleLimits(t *testing.T) {
+	units := []job.Unit{
+		job.Unit{Name: "a.service", TargetState: job.JobStateLaunched},
+		job.Unit{Name: "b.service", TargetState: job.JobStateLaunched},
+		job.Unit{Name: "c.service", TargetState: job.JobStateLaunched},
+	}
+	machines := []machine.MachineState{
+		machine.MachineState{ID: "XXX"},
+		machine.MachineState{ID: "YYY"},
+	}
+
+	r := NewReconciler(&leastLoadedScheduler{})
+	r.limiter = newScheduleLimiter(2, 1, time.Hour)
+
+	var sUnits []job.ScheduledUnit
+	round := func() []string {
+		var names []string
+		clust := newClusterState(units, sUnits, machines)
+		for tsk := range r.calculateClusterTasks(clust, make(chan struct{})) {
+			if tsk.Type != taskTypeAttemptScheduleUnit {
+				t.Fatalf("unexpected task %v", tsk)
+			}
+			names = append(names, tsk.JobName)
+			sUnits = append(sUnits, job.ScheduledUnit{Name: tsk.JobName, TargetMachineID: tsk.MachineID})
+		}
+		return names
+	}
+	expire := func() {
+		r.limiter.cluster.last = r.limiter.cluster.last.Add(-time.Hour)
+		for _, b := range r.limiter.machines {
+			b.last = b.last.Add(-time.Hour)
+		}
+	}
+
+	if got, want := round(), []string{"a.service", "b.service"}; !reflect.DeepEqual(want, got) {
+		t.Fatalf("first round: expected %v, got %v", want, got)
+	}
+	if want := []string{"c.service"}; !reflect.DeepEqual(want, r.pending) {
+		t.Fatalf("first round: expected pending %v, got %v", want, r.pending)
+	}
+
+	// nothing is scheduled until tokens are refilled
+	if got := round(); got != nil {
+		t.Fatalf("second round: expected nothing scheduled, got %v", got)
+	}
+
+	expire()
+	if got, want := round(), []string{"c.service"}; !reflect.DeepEqual(want, got) {
+		t.Fatalf("third round: expected %v, got %v", want, got)
+	}
+	if r.pending != nil {
+		t.Errorf("third round: expected nothing pending, got %v", r.pending)
+	}
+}
+
+func TestScheduleOrder(t *testing.T) {
+	clust := newClusterState(
+		[]job.Unit{
+			job.Unit{Name: "a.service"},
+			job.Unit{Name: "b.service"},
+			job.Unit{Name: "c.service"},
+			job.Unit{Name: "d.service"},
+		}, nil, nil)
+
+	var got []string
+	for _, j := range scheduleOrder(clust, []string{"c.service", "gone.service", "b.service"}) {
+		got = append(got, j.Name)
+	}
+
+	// pending jobs come first, jobs that went away are ignored
+	want := []string{"c.service", "b.service", "a.service", "d.service"}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"sort"
+	"time"
+
+	"github.com/coreos/fleet/job"
+)
+
+// move describes a unit to be moved from one machine to another.
+type move struct {
+	jobName string
+	from    string
+	to      string
+}
+
+// rebalanceDue reports whether the rebalancer is enabled and its interval
+// has passed. The first interval starts with the first reconciliation.
+func (r *Reconciler) rebalanceDue(now time.Time) bool {
+	if r.rebalanceInterval <= 0 || r.rebalanceMaxMoves <= 0 {
+		return false
+	}
+	if r.lastRebalance.IsZero() {
+		r.lastRebalance = now
+		return false
+	}
+	return now.Sub(r.lastRebalance) >= r.rebalanceInterval
+}
+
+// rebalance computes up to max moves of units from the machines running
+// the most units to those running the fewest, as long as the difference
+// between the two is larger than one unit. Each move is applied to the
+// given clusterState before computing the next one.
+func rebalance(clust *clusterState, max int) []move {
+	var moves []move
+	for len(moves) < max {
+		m := nextMove(clust)
+		if m == nil {
+			break
+		}
+		clust.schedule(m.jobName, m.to)
+		moves = append(moves, *m)
+	}
+	return moves
+}
+
+func nextMove(clust *clusterState) *move {
+	agents := clust.agents()
+
+	load := make(map[string]int, len(agents))
+	hosted := make(map[string][]*job.Job, len(agents))
+	for id := range agents {
+		load[id] = 0
+	}
+	// MachineOf groups are only moved as a whole, which the rebalancer
+	// does not attempt
+	peers := make(map[string]bool)
+	for _, j := range clust.jobs {
+		for _, p := range j.Peers() {
+			peers[p] = true
+		}
+		if !j.Scheduled() || j.TargetState == job.JobStateInactive {
+			continue
+		}
+		if _, ok := agents[j.TargetMachineID]; !ok {
+			continue
+		}
+		load[j.TargetMachineID]++
+		hosted[j.TargetMachineID] = append(hosted[j.TargetMachineID], j)
+	}
+
+	ids := make([]string, 0, len(load))
+	for id := range load {
+		ids = append(ids, id)
+	}
+	sort.Sort(loadSortedIDs{ids, load})
+
+	// ids are sorted by ascending load, so sources are tried from the end
+	for s := len(ids) - 1; s > 0; s-- {
+		from := ids[s]
+		candidates := hosted[from]
+		sort.Sort(jobsByName(candidates))
+		for _, j := range candidates {
+			if !movable(j, peers) {
+				continue
+			}
+			for _, to := range ids[:s] {
+				if load[from]-load[to] <= 1 {
+					break
+				}
+				if act, _ := agents[to].AbleToRun(j); act != job.JobActionSchedule {
+					continue
+				}
+				// keep spread instances within their domain
+				if key := j.SpreadBy(); key != "" && agents[from].MState.Metadata[key] != agents[to].MState.Metadata[key] {
+					continue
+				}
+				return &move{jobName: j.Name, from: from, to: to}
+			}
+		}
+	}
+
+	return nil
+}
+
+// movable determines whether the rebalancer may move the given job.
+func movable(j *job.Job, peers map[string]bool) bool {
+	if j.Pinned() || peers[j.Name] || len(j.Peers()) > 0 || len(j.Replaces()) > 0 {
+		return false
+	}
+	if _, ok := j.RequiredTarget(); ok {
+		return false
+	}
+	return true
+}
+
+// loadSortedIDs sorts machine IDs by ascending load, then by ID.
+type loadSortedIDs struct {
+	ids  []string
+	load map[string]int
+}
+
+func (ls loadSortedIDs) Len() int      { return len(ls.ids) }
+func (ls loadSortedIDs) Swap(i, j int) { ls.ids[i], ls.ids[j] = ls.ids[j], ls.ids[i] }
+
+func (ls loadSortedIDs) Less(i, j int) bool {
+	li, lj := ls.load[ls.ids[i]], ls.load[ls.ids[j]]
+	if li != lj {
+		return li < lj
+	}
+	return ls.ids[i] < ls.ids[j]
+}
+
+type jobsByName []*job.Job
+
+func (jn jobsByName) Len() int           { return len(jn) }
+func (jn jobsByName) Swap(i, j int)      { jn[i], jn[j] = jn[j], jn[i] }
+func (jn jobsByName) Less(i, j int) bool { return jn[i].Name < jn[j].Name }
@@ -0,0 +1,195 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/coreos/fleet/job"
+	"github.com/coreos/fleet/machine"
+)
+
+func TestRebalance(t *testing.T) {
+	launched := func(name string, opts ...string) job.Unit {
+		return job.Unit{Name: name, Unit: newFleetUnit(t, opts...), TargetState: job.JobStateLaunched}
+	}
+	scheduled := func(name, machID string) job.ScheduledUnit {
+		return job.ScheduledUnit{Name: name, TargetMachineID: machID}
+	}
+	twoMachines := []machine.MachineState{
+		machine.MachineState{ID: "XXX"},
+		machine.MachineState{ID: "YYY"},
+	}
+
+	tests := []struct {
+		units    []job.Unit
+		sUnits   []job.ScheduledUnit
+		machines []machine.MachineState
+		max      int
+		moves    []move
+	}{
+		// units move from a busy to an empty machine until balanced
+		{
+			units:    []job.Unit{launched("a.service"), launched("b.service"), launched("c.service"), launched("d.service")},
+			sUnits:   []job.ScheduledUnit{scheduled("a.service", "XXX"), scheduled("b.service", "XXX"), scheduled("c.service", "XXX"), scheduled("d.service", "XXX")},
+			machines: twoMachines,
+			max:      5,
+			moves: []move{
+				move{jobName: "a.service", from: "XXX", to: "YYY"},
+				move{jobName: "b.service", from: "XXX", to: "YYY"},
+			},
+		},
+
+		// the number of moves is bounded
+		{
+			units:    []job.Unit{launched("a.service"), launched("b.service"), launched("c.service"), launched("d.service")},
+			sUnits:   []job.ScheduledUnit{scheduled("a.service", "XXX"), scheduled("b.service", "XXX"), scheduled("c.service", "XXX"), scheduled("d.service", "XXX")},
+			machines: twoMachines,
+			max:      1,
+			moves: []move{
+				move{jobName: "a.service", from: "XXX", to: "YYY"},
+			},
+		},
+
+		// a difference of a single unit is balanced
+		{
+			units:    []job.Unit{launched("a.service"), launched("b.service"), launched("c.service")},
+			sUnits:   []job.ScheduledUnit{scheduled("a.service", "XXX"), scheduled("b.service", "XXX"), scheduled("c.service", "YYY")},
+			machines: twoMachines,
+			max:      5,
+			moves:    nil,
+		},
+
+		// pinned units stay
+		{
+			units:    []job.Unit{launched("a.service", "Pinned=true"), launched("b.service"), launched("c.service")},
+			sUnits:   []job.ScheduledUnit{scheduled("a.service", "XXX"), scheduled("b.service", "XXX"), scheduled("c.service", "XXX")},
+			machines: twoMachines,
+			max:      5,
+			moves: []move{
+				move{jobName: "b.service", from: "XXX", to: "YYY"},
+			},
+		},
+
+		// MachineOf groups stay together
+		{
+			units:    []job.Unit{launched("a.service"), launched("b.service", "MachineOf=a.service"), launched("c.service")},
+			sUnits:   []job.ScheduledUnit{scheduled("a.service", "XXX"), scheduled("b.service", "XXX"), scheduled("c.service", "XXX")},
+			machines: twoMachines,
+			max:      5,
+			moves: []move{
+				move{jobName: "c.service", from: "XXX", to: "YYY"},
+			},
+		},
+
+		// conflicts are respected
+		{
+			units: []job.Unit{
+				launched("a.service", "Conflicts=x.service"),
+				launched("b.service", "Conflicts=x.service"),
+				launched("c.service", "Conflicts=x.service"),
+				launched("x.service"),
+			},
+			sUnits:   []job.ScheduledUnit{scheduled("a.service", "XXX"), scheduled("b.service", "XXX"), scheduled("c.service", "XXX"), scheduled("x.service", "YYY")},
+			machines: twoMachines,
+			max:      5,
+			moves:    nil,
+		},
+
+		// spread instances only move within their domain
+		{
+			units: []job.Unit{
+				launched("web@1.service", "SpreadBy=rack"),
+				launched("web@2.service", "SpreadBy=rack"),
+				launched("web@3.service", "SpreadBy=rack"),
+			},
+			sUnits: []job.ScheduledUnit{scheduled("web@1.service", "XXX"), scheduled("web@2.service", "XXX"), scheduled("web@3.service", "XXX")},
+			machines: []machine.MachineState{
+				machine.MachineState{ID: "XXX", Metadata: map[string]string{"rack": "1"}},
+				machine.MachineState{ID: "YYY", Metadata: map[string]string{"rack": "2"}},
+				machine.MachineState{ID: "ZZZ", Metadata: map[string]string{"rack": "1"}},
+			},
+			max: 5,
+			moves: []move{
+				move{jobName: "web@1.service", from: "XXX", to: "ZZZ"},
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		clust := newClusterState(tt.units, tt.sUnits, tt.machines)
+		moves := rebalance(clust, tt.max)
+		if !reflect.DeepEqual(tt.moves, moves) {
+			t.Errorf("case %d: expected moves %v, got %v", i, tt.moves, moves)
+		}
+	}
+}
+
+func TestCalculateClusterTasksRebalance(t *testing.T) {
+	newClust := func() *clusterState {
+		return newClusterState(
+			[]job.Unit{
+				job.Unit{Name: "a.service", TargetState: job.JobStateLaunched},
+				job.Unit{Name: "b.service", TargetState: job.JobStateLaunched},
+			},
+			[]job.ScheduledUnit{
+				job.ScheduledUnit{Name: "a.service", TargetMachineID: "XXX"},
+				job.ScheduledUnit{Name: "b.service", TargetMachineID: "XXX"},
+			},
+			[]machine.MachineState{
+				machine.MachineState{ID: "XXX"},
+				machine.MachineState{ID: "YYY"},
+			},
+		)
+	}
+	tasks := func(r *Reconciler) []*task {
+		var tasks []*task
+		for tsk := range r.calculateClusterTasks(newClust(), make(chan struct{})) {
+			tasks = append(tasks, tsk)
+		}
+		return tasks
+	}
+
+	// disabled by default
+	r := NewReconciler(&leastLoadedScheduler{})
+	tasks(r)
+	if got := tasks(r); got != nil {
+		t.Fatalf("expected no tasks with rebalancing disabled, got %v", got)
+	}
+
+	r.rebalanceInterval = time.Minute
+	r.rebalanceMaxMoves = 1
+
+	// the first interval starts with the first reconciliation
+	if got := tasks(r); got != nil {
+		t.Fatalf("expected no tasks before the first interval passed, got %v", got)
+	}
+
+	r.lastRebalance = time.Now().Add(-time.Minute)
+	reason := "rebalancing from Machine(XXX) to Machine(YYY)"
+	want := []*task{
+		&task{Type: taskTypeRebalanceUnit, Reason: reason, JobName: "a.service", MachineID: "XXX"},
+		&task{Type: taskTypeAttemptScheduleUnit, Reason: reason, JobName: "a.service", MachineID: "YYY"},
+	}
+	if got := tasks(r); !reflect.DeepEqual(want, got) {
+		t.Fatalf("task mismatch\nexpected %v\n got %v", want, got)
+	}
+
+	if got := tasks(r); got != nil {
+		t.Errorf("expected no tasks within the interval, got %v", got)
+	}
+}
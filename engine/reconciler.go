@@ -16,16 +16,28 @@ package engine
 
 import (
 	"fmt"
+	"reflect"
+	"sort"
 	"time"
 
+	"github.com/jonboulle/clockwork"
+
 	"github.com/coreos/fleet/job"
 	"github.com/coreos/fleet/log"
 	"github.com/coreos/fleet/metrics"
+	"github.com/coreos/fleet/registry"
 )
 
 const (
 	taskTypeUnscheduleUnit      = "UnscheduleUnit"
 	taskTypeAttemptScheduleUnit = "AttemptScheduleUnit"
+	taskTypePreemptUnit         = "PreemptUnit"
+	taskTypeRebalanceUnit       = "RebalanceUnit"
+	taskTypeCreateUnit          = "CreateUnit"
+	taskTypeDestroyUnit         = "DestroyUnit"
+	taskTypeDrainUnit           = "DrainUnit"
+	taskTypeCordonMachine       = "CordonMachine"
+	taskTypeEvictUnhealthyUnit  = "EvictUnhealthyUnit"
 )
 
 type task struct {
@@ -39,20 +51,72 @@ func (t *task) String() string {
 	return fmt.Sprintf("{Type: %s, JobName: %s, MachineID: %s, Reason: %q}", t.Type, t.JobName, t.MachineID, t.Reason)
 }
 
-func NewReconciler() *Reconciler {
+func NewReconciler(sched Scheduler) *Reconciler {
 	return &Reconciler{
-		sched: &leastLoadedScheduler{},
+		sched:           sched,
+		drainBatchSize:  DefaultDrainBatchSize,
+		failureCooldown: DefaultFailureCooldown,
+		clock:           clockwork.NewRealClock(),
 	}
 }
 
 type Reconciler struct {
 	sched Scheduler
+	clock clockwork.Clock
+
+	// explanations are collected for all units the last call of
+	// calculateClusterTasks was unable to schedule, while saved holds
+	// the explanations last written to the Registry. saved is nil until
+	// loaded from the Registry.
+	explanations map[string]job.SchedulingExplanation
+	saved        map[string]job.SchedulingExplanation
+
+	// lastPreemption is the time lower-priority units were last
+	// preempted, used to rate-limit preemptions.
+	lastPreemption time.Time
+
+	// at most rebalanceMaxMoves units are moved every rebalanceInterval,
+	// if both are positive
+	rebalanceInterval time.Duration
+	rebalanceMaxMoves int
+	lastRebalance     time.Time
+
+	// units scheduled to a machine that went away stay scheduled to it
+	// for machineLossGracePeriod, unless overridden by RescheduleAfter.
+	// lostMachines holds the time each such machine was first found
+	// missing, while savedLost holds those last written to the Registry.
+	machineLossGracePeriod time.Duration
+	lostMachines           map[string]time.Time
+	savedLost              map[string]time.Time
+
+	// at most drainBatchSize units are moved away from draining
+	// machines per reconciliation
+	drainBatchSize int
+
+	// units defining RescheduleOnFailure which failed too often on a
+	// machine are not scheduled to it for failureCooldown. failures
+	// holds the failures of each unit on each machine, while
+	// savedFailures holds those of each unit last written to the
+	// Registry, and is nil until loaded from the Registry.
+	failureCooldown time.Duration
+	failures        map[failureKey]*registry.UnitFailure
+	savedFailures   map[string][]registry.UnitFailure
+
+	// unhealthy holds since when units defining RescheduleUnhealthyAfter
+	// were reported unhealthy by the machines they are scheduled to
+	unhealthy map[string]unhealthyRecord
+
+	// limiter bounds the number of units scheduled, if set. Units it
+	// deferred during the last reconciliation are held in pending, and
+	// considered first during the next one.
+	limiter *scheduleLimiter
+	pending []string
 }
 
 func (r *Reconciler) Reconcile(e *Engine, stop chan struct{}) {
 	log.Debugf("Polling Registry for actionable work")
 
-	start := time.Now()
+	start := r.clock.Now()
 
 	clust, err := e.clusterState()
 	if err != nil {
@@ -60,18 +124,153 @@ func (r *Reconciler) Reconcile(e *Engine, stop chan struct{}) {
 		return
 	}
 
+	tasks := make(map[string]int)
 	for t := range r.calculateClusterTasks(clust, stop) {
 		err = doTask(t, e)
 		if err != nil {
 			log.Errorf("Failed resolving task: task=%s err=%v", t, err)
 		}
+		tasks[t.Type]++
+	}
+
+	// explanations are incomplete if reconciliation was aborted
+	select {
+	case <-stop:
+	default:
+		r.saveExplanations(e.registry, start)
+		r.saveLostMachines(e.registry)
+		r.saveFailures(e.registry)
+		r.saveReconcileStatus(e.registry, registry.ReconcileStatus{
+			MachineID: e.machine.State().ID,
+			Time:      start,
+			Duration:  r.clock.Since(start),
+			Tasks:     tasks,
+		})
 	}
 
 	metrics.ReportEngineReconcileSuccess(start)
 }
 
+// saveExplanations writes the scheduling explanations collected during the
+// last reconciliation to the Registry. Only explanations that changed since
+// last written are written, and those of units which got scheduled or were
+// destroyed meanwhile are removed. Explanations whose reasons did not change
+// keep their original time.
+func (r *Reconciler) saveExplanations(reg registry.Registry, now time.Time) {
+	if r.saved == nil {
+		// explanations left behind by a previous leader are
+		// compared with the current ones like any others
+		exps, err := reg.SchedulingExplanations()
+		if err != nil {
+			log.Errorf("Failed fetching scheduling explanations: %v", err)
+			return
+		}
+		r.saved = make(map[string]job.SchedulingExplanation, len(exps))
+		for _, exp := range exps {
+			r.saved[exp.Name] = exp
+		}
+	}
+
+	for name := range r.saved {
+		if _, ok := r.explanations[name]; ok {
+			continue
+		}
+		if err := reg.RemoveSchedulingExplanation(name); err != nil {
+			log.Errorf("Failed removing scheduling explanation of Job(%s): %v", name, err)
+			continue
+		}
+		delete(r.saved, name)
+	}
+
+	names := make([]string, 0, len(r.explanations))
+	for name := range r.explanations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		exp := r.explanations[name]
+		if prev, ok := r.saved[name]; ok && prev.Reason == exp.Reason && reflect.DeepEqual(prev.MachineReasons, exp.MachineReasons) {
+			continue
+		}
+
+		exp.Since = now
+		if err := reg.SetSchedulingExplanation(exp); err != nil {
+			log.Errorf("Failed saving scheduling explanation of Job(%s): %v", name, err)
+			continue
+		}
+		r.saved[name] = exp
+	}
+}
+
+// explain records why the job of the given name could not be scheduled.
+func (r *Reconciler) explain(name string, err error) {
+	exp := job.SchedulingExplanation{
+		Name:   name,
+		Reason: err.Error(),
+	}
+	if serr, ok := err.(*schedulingError); ok {
+		exp.MachineReasons = serr.machines
+	}
+	r.explanations[name] = exp
+}
+
+// scheduleGang schedules all members of the given gang to the same
+// machine, or none of them. Gangs exceeding the schedule limits are added
+// to deferred. false is returned if sending a task failed.
+func (r *Reconciler) scheduleGang(clust *clusterState, g gang, send func(typ, reason, jName, machID string) bool, deferred *[]string) bool {
+	anchor := g[0]
+	if !r.limiter.clusterAvailable(len(g)) {
+		log.Debugf("Job(%s) scheduling deferred: cluster schedule limit reached", anchor.Name)
+		*deferred = append(*deferred, anchor.Name)
+		return true
+	}
+
+	dec, err := decideGang(clust, r.sched, g)
+	if err != nil {
+		log.Debugf("Unable to schedule group of Job(%s): %v", anchor.Name, err)
+		metrics.ReportEngineReconcileFailure(metrics.ScheduleFailure)
+		for _, j := range g {
+			r.explain(j.Name, err)
+		}
+		return true
+	}
+
+	if !r.limiter.machineAvailable(dec.machineID, len(g)) {
+		log.Debugf("Job(%s) scheduling deferred: schedule limit of Machine(%s) reached", anchor.Name, dec.machineID)
+		*deferred = append(*deferred, anchor.Name)
+		return true
+	}
+
+	for _, j := range g {
+		reason := fmt.Sprintf("target state %s and unit not scheduled, along with group of Unit(%s)", j.TargetState, anchor.Name)
+		if !send(taskTypeAttemptScheduleUnit, reason, j.Name, dec.machineID) {
+			metrics.ReportEngineReconcileFailure(metrics.ScheduleFailure)
+			return false
+		}
+		r.limiter.take(dec.machineID)
+		clust.schedule(j.Name, dec.machineID)
+	}
+	return true
+}
+
+// saveReconcileStatus writes the status of the last reconciliation to the
+// Registry, for it to be reported by the API.
+func (r *Reconciler) saveReconcileStatus(reg registry.Registry, status registry.ReconcileStatus) {
+	if err := reg.SetReconcileStatus(status); err != nil {
+		log.Errorf("Failed saving reconcile status: %v", err)
+	}
+}
+
 func (r *Reconciler) calculateClusterTasks(clust *clusterState, stopchan chan struct{}) (taskchan chan *task) {
 	taskchan = make(chan *task)
+	r.explanations = make(map[string]job.SchedulingExplanation)
+
+	now := r.clock.Now()
+	r.trackLostMachines(clust, now)
+	r.trackUnhealthyUnits(clust, now)
+	r.trackFailures(clust, now)
+	r.limiter.refill(clust, now)
 
 	send := func(typ, reason, jName, machID string) bool {
 		select {
@@ -94,6 +293,9 @@ func (r *Reconciler) calculateClusterTasks(clust *clusterState, stopchan chan st
 		as, ok := agents[j.TargetMachineID]
 		if !ok {
 			metrics.ReportEngineReconcileFailure(metrics.MachineAway)
+			if r.awaitingLostMachine(j, now) {
+				return job.JobActionSchedule, fmt.Sprintf("waiting for lost target Machine(%s) to return", j.TargetMachineID)
+			}
 			return job.JobActionUnschedule, fmt.Sprintf("target Machine(%s) went away", j.TargetMachineID)
 		}
 
@@ -138,7 +340,14 @@ func (r *Reconciler) calculateClusterTasks(clust *clusterState, stopchan chan st
 				continue
 			}
 
-			dec, err := r.sched.DecideReschedule(clust, j)
+			// the replaced unit is the one being moved, so its resource
+			// requirements are what matters for finding a new machine
+			rj := j
+			if cj, ok := clust.jobs[replacedUnit]; ok {
+				rj = cj
+			}
+
+			dec, err := r.sched.DecideReschedule(clust, rj)
 			if err != nil {
 				log.Debugf("Unable to schedule Job(%s): %v", j.Name, err)
 				metrics.ReportEngineReconcileFailure(metrics.ScheduleFailure)
@@ -165,7 +374,24 @@ func (r *Reconciler) calculateClusterTasks(clust *clusterState, stopchan chan st
 	go func() {
 		defer close(taskchan)
 
-		for _, j := range clust.jobs {
+		create, destroy := scaleReplicas(clust)
+		for _, c := range create {
+			reason := fmt.Sprintf("Unit(%s) scaled to %d replicas", c.template, c.replicas)
+			if !send(taskTypeCreateUnit, reason, c.jobName, "") {
+				return
+			}
+		}
+		for _, d := range destroy {
+			reason := fmt.Sprintf("Unit(%s) scaled to %d replicas", d.template, d.replicas)
+			if !send(taskTypeDestroyUnit, reason, d.jobName, "") {
+				return
+			}
+			delete(clust.jobs, d.jobName)
+		}
+
+		// in order of names, so that conflicting replacements are
+		// resolved the same way every time
+		for _, j := range scheduleOrder(clust, nil) {
 			if !j.Scheduled() {
 				continue
 			}
@@ -192,15 +418,57 @@ func (r *Reconciler) calculateClusterTasks(clust *clusterState, stopchan chan st
 			clust.unschedule(j.Name)
 		}
 
-		for _, j := range clust.jobs {
+		var deferred []string
+		gangs := findGangs(clust)
+		for _, j := range scheduleOrder(clust, r.pending) {
 			if j.Scheduled() || j.TargetState == job.JobStateInactive {
 				continue
 			}
 
+			if g, ok := gangs[j.Name]; ok {
+				if g[0] != j {
+					// scheduled along with the anchor of its gang
+					continue
+				}
+				if !r.scheduleGang(clust, g, send, &deferred) {
+					return
+				}
+				continue
+			}
+
+			if !r.limiter.clusterAvailable(1) {
+				log.Debugf("Job(%s) scheduling deferred: cluster schedule limit reached", j.Name)
+				deferred = append(deferred, j.Name)
+				continue
+			}
+
 			dec, err := r.sched.Decide(clust, j)
+			if now := r.clock.Now(); err != nil && r.preemptionAllowed(now) {
+				if p := findPreemption(clust, j); p != nil && r.limiter.machineAvailable(p.machineID, 1) {
+					reason := fmt.Sprintf("preempted by Unit(%s) of higher priority %d", j.Name, j.Priority())
+					for _, victim := range p.victims {
+						if !send(taskTypePreemptUnit, reason, victim, p.machineID) {
+							metrics.ReportEngineReconcileFailure(metrics.ScheduleFailure)
+							return
+						}
+						log.Debugf("Job(%s) preempted from Machine(%s) by Job(%s)", victim, p.machineID, j.Name)
+						clust.unschedule(victim)
+					}
+					r.lastPreemption = now
+					dec, err = &decision{machineID: p.machineID}, nil
+				}
+			}
 			if err != nil {
+				err = withScheduledPeers(clust, j, err)
 				log.Debugf("Unable to schedule Job(%s): %v", j.Name, err)
 				metrics.ReportEngineReconcileFailure(metrics.ScheduleFailure)
+				r.explain(j.Name, err)
+				continue
+			}
+
+			if !r.limiter.machineAvailable(dec.machineID, 1) {
+				log.Debugf("Job(%s) scheduling deferred: schedule limit of Machine(%s) reached", j.Name, dec.machineID)
+				deferred = append(deferred, j.Name)
 				continue
 			}
 
@@ -210,8 +478,59 @@ func (r *Reconciler) calculateClusterTasks(clust *clusterState, stopchan chan st
 				return
 			}
 
+			r.limiter.take(dec.machineID)
 			clust.schedule(j.Name, dec.machineID)
 		}
+
+		r.pending = deferred
+		metrics.ReportEngineSchedulePending(len(deferred))
+		if len(deferred) > 0 {
+			metrics.ReportEngineReconcileFailure(metrics.RateLimited)
+			log.Infof("Schedule limit reached, deferred %d units to the next reconciliation", len(deferred))
+		}
+
+		moves, drained := drain(clust, r.sched, r.drainBatchSize)
+		for _, m := range moves {
+			reason := fmt.Sprintf("draining Machine(%s)", m.from)
+			if !send(taskTypeDrainUnit, reason, m.jobName, m.from) {
+				return
+			}
+			if !send(taskTypeAttemptScheduleUnit, reason, m.jobName, m.to) {
+				return
+			}
+			log.Debugf("Job(%s) draining from Machine(%s) to Machine(%s)", m.jobName, m.from, m.to)
+		}
+		for _, machID := range drained {
+			if !send(taskTypeCordonMachine, "all units drained", "", machID) {
+				return
+			}
+		}
+
+		for _, m := range r.evictUnhealthy(clust, r.sched, now) {
+			reason := fmt.Sprintf("unhealthy on Machine(%s)", m.from)
+			if !send(taskTypeEvictUnhealthyUnit, reason, m.jobName, m.from) {
+				return
+			}
+			if !send(taskTypeAttemptScheduleUnit, reason, m.jobName, m.to) {
+				return
+			}
+			log.Debugf("Job(%s) moving from unhealthy on Machine(%s) to Machine(%s)", m.jobName, m.from, m.to)
+		}
+
+		if !r.rebalanceDue(r.clock.Now()) {
+			return
+		}
+		for _, m := range rebalance(clust, r.rebalanceMaxMoves) {
+			reason := fmt.Sprintf("rebalancing from Machine(%s) to Machine(%s)", m.from, m.to)
+			if !send(taskTypeRebalanceUnit, reason, m.jobName, m.from) {
+				return
+			}
+			if !send(taskTypeAttemptScheduleUnit, reason, m.jobName, m.to) {
+				return
+			}
+			log.Debugf("Job(%s) rebalancing from Machine(%s) to Machine(%s)", m.jobName, m.from, m.to)
+		}
+		r.lastRebalance = r.clock.Now()
 	}()
 
 	return
@@ -219,12 +538,21 @@ func (r *Reconciler) calculateClusterTasks(clust *clusterState, stopchan chan st
 
 func doTask(t *task, e *Engine) (err error) {
 	switch t.Type {
-	case taskTypeUnscheduleUnit:
+	case taskTypeUnscheduleUnit, taskTypePreemptUnit, taskTypeRebalanceUnit, taskTypeDrainUnit, taskTypeEvictUnhealthyUnit:
 		err = e.unscheduleUnit(t.JobName, t.MachineID)
 		metrics.ReportEngineTask(t.Type)
 	case taskTypeAttemptScheduleUnit:
 		e.attemptScheduleUnit(t.JobName, t.MachineID)
 		metrics.ReportEngineTask(t.Type)
+	case taskTypeCordonMachine:
+		err = e.cordonMachine(t.MachineID)
+		metrics.ReportEngineTask(t.Type)
+	case taskTypeCreateUnit:
+		err = e.createReplica(t.JobName)
+		metrics.ReportEngineTask(t.Type)
+	case taskTypeDestroyUnit:
+		err = e.destroyReplica(t.JobName)
+		metrics.ReportEngineTask(t.Type)
 	default:
 		err = fmt.Errorf("unrecognized task type %q", t.Type)
 	}
@@ -17,9 +17,12 @@ package engine
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/coreos/fleet/job"
 	"github.com/coreos/fleet/machine"
+	"github.com/coreos/fleet/registry"
+	"github.com/coreos/fleet/unit"
 )
 
 func TestCalculateClusterTasks(t *testing.T) {
@@ -157,7 +160,7 @@ func TestCalculateClusterTasks(t *testing.T) {
 	}
 
 	for i, tt := range tests {
-		r := NewReconciler()
+		r := NewReconciler(&leastLoadedScheduler{})
 		tasks := make([]*task, 0)
 		for tsk := range r.calculateClusterTasks(tt.clust, make(chan struct{})) {
 			tasks = append(tasks, tsk)
@@ -168,3 +171,129 @@ func TestCalculateClusterTasks(t *testing.T) {
 		}
 	}
 }
+
+func TestCalculateClusterTasksExplanations(t *testing.T) {
+	jsInactive := job.JobStateInactive
+	contents := "[X-Fleet]\nConflicts=bar.service\n"
+	uf, err := unit.NewUnitFile(contents)
+	if err != nil {
+		t.Fatalf("Unexpected error creating unit file: %v", err)
+	}
+
+	clust := newClusterState(
+		[]job.Unit{
+			job.Unit{
+				Name:        "foo.service",
+				Unit:        *uf,
+				TargetState: job.JobStateLaunched,
+			},
+			job.Unit{
+				Name:        "bar.service",
+				TargetState: job.JobStateLaunched,
+			},
+		},
+		[]job.ScheduledUnit{
+			job.ScheduledUnit{
+				Name:            "foo.service",
+				State:           &jsInactive,
+				TargetMachineID: "",
+			},
+			job.ScheduledUnit{
+				Name:            "bar.service",
+				State:           &jsInactive,
+				TargetMachineID: "XXX",
+			},
+		},
+		[]machine.MachineState{
+			machine.MachineState{ID: "XXX"},
+		},
+	)
+
+	r := NewReconciler(&leastLoadedScheduler{})
+	for _ = range r.calculateClusterTasks(clust, make(chan struct{})) {
+	}
+
+	want := map[string]job.SchedulingExplanation{
+		"foo.service": job.SchedulingExplanation{
+			Name:   "foo.service",
+			Reason: "no agents able to run job",
+			MachineReasons: map[string]string{
+				"XXX": "found conflict with locally-scheduled Unit([bar.service])",
+			},
+		},
+	}
+	if !reflect.DeepEqual(want, r.explanations) {
+		t.Errorf("explanations mismatch\nexpected %#v\n got %#v", want, r.explanations)
+	}
+}
+
+func TestSaveExplanations(t *testing.T) {
+	first := time.Date(2016, time.May, 4, 12, 0, 0, 0, time.UTC)
+	second := first.Add(time.Minute)
+	third := second.Add(time.Minute)
+
+	foo := job.SchedulingExplanation{
+		Name:           "foo.service",
+		Reason:         "no agents able to run job",
+		MachineReasons: map[string]string{"XXX": "local Machine metadata insufficient"},
+	}
+	bar := job.SchedulingExplanation{
+		Name:   "bar.service",
+		Reason: "zero agents available",
+	}
+
+	reg := registry.NewFakeRegistry()
+	r := NewReconciler(&leastLoadedScheduler{})
+
+	// explanations left behind by a previous leader are cleared
+	reg.SetSchedulingExplanation(bar)
+	r.explanations = map[string]job.SchedulingExplanation{}
+	r.saveExplanations(reg, first)
+	if exps, _ := reg.SchedulingExplanations(); len(exps) != 0 {
+		t.Fatalf("expected stale explanations to be cleared, got %v", exps)
+	}
+
+	r.explanations = map[string]job.SchedulingExplanation{"foo.service": foo}
+	r.saveExplanations(reg, first)
+
+	// unchanged reasons keep their original time
+	r.explanations = map[string]job.SchedulingExplanation{"foo.service": foo, "bar.service": bar}
+	r.saveExplanations(reg, second)
+
+	exps, _ := reg.SchedulingExplanations()
+	if len(exps) != 2 {
+		t.Fatalf("expected 2 explanations, got %v", exps)
+	}
+	if exps[0].Name != "bar.service" || !exps[0].Since.Equal(second) {
+		t.Errorf("unexpected explanation %#v", exps[0])
+	}
+	if exps[1].Name != "foo.service" || !exps[1].Since.Equal(first) {
+		t.Errorf("unexpected explanation %#v", exps[1])
+	}
+
+	// unchanged explanations are not written again
+	reg.RemoveSchedulingExplanation("foo.service")
+	r.saveExplanations(reg, third)
+	if exps, _ := reg.SchedulingExplanations(); len(exps) != 1 || exps[0].Name != "bar.service" {
+		t.Errorf("expected no write of unchanged explanations, got %v", exps)
+	}
+
+	// changed reasons reset the time, and explanations of units
+	// scheduled meanwhile are removed
+	foo.MachineReasons = map[string]string{"XXX": "insufficient resources"}
+	r.explanations = map[string]job.SchedulingExplanation{"foo.service": foo}
+	r.saveExplanations(reg, third)
+	exps, _ = reg.SchedulingExplanations()
+	if len(exps) != 1 || exps[0].Name != "foo.service" || !exps[0].Since.Equal(third) {
+		t.Errorf("expected explanation since %v, got %v", third, exps)
+	}
+
+	// a new leader keeps the time of unchanged explanations
+	r = NewReconciler(&leastLoadedScheduler{})
+	r.explanations = map[string]job.SchedulingExplanation{"foo.service": foo}
+	r.saveExplanations(reg, third.Add(time.Minute))
+	exps, _ = reg.SchedulingExplanations()
+	if len(exps) != 1 || !exps[0].Since.Equal(third) {
+		t.Errorf("expected explanation since %v, got %v", third, exps)
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/coreos/fleet/unit"
+)
+
+// replicaChange describes an instance of a template unit to be created
+// or destroyed to keep the replica count of the template.
+type replicaChange struct {
+	jobName  string
+	template string
+	replicas int
+}
+
+// scaleReplicas determines the instances to create and destroy for all
+// templates defining Replicas, or scaled with fleetctl scale. Instances
+// web@1.service to web@N.service of a template web@.service with N
+// replicas are maintained. Instances with a higher number are destroyed,
+// while instances not named after a number are left alone.
+func scaleReplicas(clust *clusterState) (create, destroy []replicaChange) {
+	templates := make([]string, 0)
+	for name, j := range clust.jobs {
+		if _, ok := clust.replicaCount(j); ok {
+			templates = append(templates, name)
+		}
+	}
+	sort.Strings(templates)
+
+	for _, tmpl := range templates {
+		n, _ := clust.replicaCount(clust.jobs[tmpl])
+
+		existing := make(map[int]string)
+		for name := range clust.jobs {
+			uni := unit.NewUnitNameInfo(name)
+			if uni == nil || !uni.IsInstance() || uni.Template != tmpl {
+				continue
+			}
+			if i, ok := replicaIndex(uni.Instance); ok {
+				existing[i] = name
+			}
+		}
+
+		for i := 1; i <= n; i++ {
+			if _, ok := existing[i]; !ok {
+				create = append(create, replicaChange{jobName: replicaName(tmpl, i), template: tmpl, replicas: n})
+			}
+		}
+
+		var surplus []int
+		for i := range existing {
+			if i > n {
+				surplus = append(surplus, i)
+			}
+		}
+		sort.Ints(surplus)
+		for _, i := range surplus {
+			destroy = append(destroy, replicaChange{jobName: existing[i], template: tmpl, replicas: n})
+		}
+	}
+
+	return
+}
+
+// replicaIndex parses the instance name of a replica, which is a positive
+// number without leading zeros.
+func replicaIndex(instance string) (int, bool) {
+	i, err := strconv.Atoi(instance)
+	if err != nil || i < 1 || strconv.Itoa(i) != instance {
+		return 0, false
+	}
+	return i, true
+}
+
+// replicaName returns the name of the i-th instance of the given template.
+func replicaName(tmpl string, i int) string {
+	return strings.Replace(tmpl, "@", fmt.Sprintf("@%d", i), 1)
+}
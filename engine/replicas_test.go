@@ -0,0 +1,131 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/coreos/fleet/job"
+	"github.com/coreos/fleet/machine"
+)
+
+func TestScaleReplicas(t *testing.T) {
+	template := func(opts ...string) job.Unit {
+		return job.Unit{Name: "web@.service", Unit: newFleetUnit(t, opts...), TargetState: job.JobStateInactive}
+	}
+	instance := func(name string) job.Unit {
+		return job.Unit{Name: name, TargetState: job.JobStateLaunched}
+	}
+	change := func(name string, n int) replicaChange {
+		return replicaChange{jobName: name, template: "web@.service", replicas: n}
+	}
+
+	tests := []struct {
+		units    []job.Unit
+		replicas map[string]int
+		create   []replicaChange
+		destroy  []replicaChange
+	}{
+		// templates without Replicas are left alone
+		{
+			units: []job.Unit{template(), instance("web@1.service")},
+		},
+
+		// missing instances are created
+		{
+			units:  []job.Unit{template("Replicas=3"), instance("web@2.service")},
+			create: []replicaChange{change("web@1.service", 3), change("web@3.service", 3)},
+		},
+
+		// surplus instances are destroyed
+		{
+			units:   []job.Unit{template("Replicas=1"), instance("web@1.service"), instance("web@3.service"), instance("web@2.service")},
+			destroy: []replicaChange{change("web@2.service", 1), change("web@3.service", 1)},
+		},
+
+		// instances not named after a number are left alone
+		{
+			units:   []job.Unit{template("Replicas=0"), instance("web@canary.service"), instance("web@01.service"), instance("web@1.service")},
+			destroy: []replicaChange{change("web@1.service", 0)},
+		},
+
+		// instances of other templates are left alone
+		{
+			units:  []job.Unit{template("Replicas=1"), instance("api@2.service")},
+			create: []replicaChange{change("web@1.service", 1)},
+		},
+
+		// scaled templates override the Replicas option
+		{
+			units:    []job.Unit{template("Replicas=3"), instance("web@1.service"), instance("web@2.service")},
+			replicas: map[string]int{"web@.service": 1},
+			destroy:  []replicaChange{change("web@2.service", 1)},
+		},
+		{
+			units:    []job.Unit{template(), instance("web@1.service")},
+			replicas: map[string]int{"web@.service": 2},
+			create:   []replicaChange{change("web@2.service", 2)},
+		},
+		// counts of units other than templates are ignored
+		{
+			units:    []job.Unit{instance("web@1.service")},
+			replicas: map[string]int{"web@1.service": 2},
+		},
+	}
+
+	for i, tt := range tests {
+		clust := newClusterState(tt.units, nil, nil)
+		clust.replicas = tt.replicas
+		create, destroy := scaleReplicas(clust)
+		if !reflect.DeepEqual(tt.create, create) {
+			t.Errorf("case %d: expected create %v, got %v", i, tt.create, create)
+		}
+		if !reflect.DeepEqual(tt.destroy, destroy) {
+			t.Errorf("case %d: expected destroy %v, got %v", i, tt.destroy, destroy)
+		}
+	}
+}
+
+func TestCalculateClusterTasksReplicas(t *testing.T) {
+	clust := newClusterState(
+		[]job.Unit{
+			job.Unit{Name: "web@.service", Unit: newFleetUnit(t, "Replicas=1"), TargetState: job.JobStateInactive},
+			job.Unit{Name: "web@2.service", TargetState: job.JobStateLaunched},
+			job.Unit{Name: "web@3.service", TargetState: job.JobStateLaunched},
+		},
+		[]job.ScheduledUnit{
+			job.ScheduledUnit{Name: "web@2.service", TargetMachineID: "XXX"},
+		},
+		[]machine.MachineState{machine.MachineState{ID: "XXX"}},
+	)
+
+	r := NewReconciler(&leastLoadedScheduler{})
+	var tasks []*task
+	for tsk := range r.calculateClusterTasks(clust, make(chan struct{})) {
+		tasks = append(tasks, tsk)
+	}
+
+	// destroyed instances are not scheduled
+	reason := "Unit(web@.service) scaled to 1 replicas"
+	want := []*task{
+		&task{Type: taskTypeCreateUnit, Reason: reason, JobName: "web@1.service"},
+		&task{Type: taskTypeDestroyUnit, Reason: reason, JobName: "web@2.service"},
+		&task{Type: taskTypeDestroyUnit, Reason: reason, JobName: "web@3.service"},
+	}
+	if !reflect.DeepEqual(want, tasks) {
+		t.Errorf("task mismatch\nexpected %v\n got %v", want, tasks)
+	}
+}
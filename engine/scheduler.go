@@ -16,43 +16,112 @@ package engine
 
 import (
 	"fmt"
+	"math/rand"
 	"sort"
+	"time"
 
 	"github.com/coreos/fleet/agent"
 	"github.com/coreos/fleet/job"
+	"github.com/coreos/fleet/unit"
 )
 
 type decision struct {
 	machineID string
 }
 
+// schedulingError is returned by a Scheduler if no agent is able to run
+// a job. It records the reason each agent rejected the job.
+type schedulingError struct {
+	reason   string
+	machines map[string]string
+}
+
+func (e *schedulingError) Error() string {
+	return e.reason
+}
+
 type Scheduler interface {
+	// Name returns the name of the strategy implemented by the Scheduler
+	Name() string
+
 	Decide(*clusterState, *job.Job) (*decision, error)
 	DecideReschedule(*clusterState, *job.Job) (*decision, error)
 }
 
-type leastLoadedScheduler struct{}
+const (
+	// SchedulerLeastLoaded places units on the machine running the
+	// fewest units. It is the default strategy.
+	SchedulerLeastLoaded = "least-loaded"
 
-func (lls *leastLoadedScheduler) Decide(clust *clusterState, j *job.Job) (*decision, error) {
-	agents := lls.sortedAgents(clust)
+	// SchedulerBinPack places units on the machine running the most
+	// units that is still able to run them, keeping other machines free.
+	SchedulerBinPack = "bin-pack"
 
+	// SchedulerSpread places instances of a template unit on the machine
+	// running the fewest instances of the same template.
+	SchedulerSpread = "spread"
+
+	// SchedulerRandom places units on a random machine. The sequence of
+	// decisions is reproducible for a given seed.
+	SchedulerRandom = "random"
+)
+
+// NewScheduler returns the Scheduler implementing the named strategy. The
+// seed is only used by the random strategy; if it is zero, a seed is
+// derived from the current time.
+func NewScheduler(name string, seed int64) (Scheduler, error) {
+	switch name {
+	case "", SchedulerLeastLoaded:
+		return &leastLoadedScheduler{}, nil
+	case SchedulerBinPack:
+		return &binPackScheduler{}, nil
+	case SchedulerSpread:
+		return &spreadScheduler{}, nil
+	case SchedulerRandom:
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+		return &randomScheduler{rand: rand.New(rand.NewSource(seed))}, nil
+	}
+	return nil, fmt.Errorf("unknown scheduler strategy %q", name)
+}
+
+// decide picks the first of the given agents able to run the job. If the
+// job defines SpreadBy, agents in less populated domains are tried first.
+// If the job defines soft requirements, the agent with the highest
+// preference score is picked instead.
+func decide(agents []*agent.AgentState, j *job.Job) (*decision, error) {
 	if len(agents) == 0 {
 		return nil, fmt.Errorf("zero agents available")
 	}
 
+	agents = spreadAcrossDomains(agents, j)
+	scored := j.HasPreferences()
+
 	var target *agent.AgentState
+	targetScore := 0
+	rejected := make(map[string]string)
 	for _, as := range agents {
-		if act, _ := as.AbleToRun(j); act == job.JobActionUnschedule {
+		if act, reason := as.AbleToRun(j); act == job.JobActionUnschedule {
+			rejected[as.MState.ID] = reason
 			continue
 		}
 
-		as := as
-		target = as
-		break
+		if !scored {
+			as := as
+			target = as
+			break
+		}
+
+		if score := as.PreferenceScore(j); target == nil || score > targetScore {
+			as := as
+			target = as
+			targetScore = score
+		}
 	}
 
 	if target == nil {
-		return nil, fmt.Errorf("no agents able to run job")
+		return nil, &schedulingError{reason: "no agents able to run job", machines: rejected}
 	}
 
 	dec := decision{
@@ -62,29 +131,55 @@ func (lls *leastLoadedScheduler) Decide(clust *clusterState, j *job.Job) (*decis
 	return &dec, nil
 }
 
-// DecideReschedule() decides scheduling in a much simpler way than
-// Decide(). It just tries to find out another free machine to be scheduled,
+// decideReschedule decides scheduling in a much simpler way than
+// decide(). It just tries to find out another free machine to be scheduled,
 // except for the current target machine. It does not have to run
 // as.AbleToRun(), because its job action must have been already decided
-// before getting into the function.
-func (lls *leastLoadedScheduler) DecideReschedule(clust *clusterState, j *job.Job) (*decision, error) {
-	agents := lls.sortedAgents(clust)
-
+// before getting into the function. Machines that are cordoned or
+// draining, excluded from running the job after it failed there, or
+// lacking the resources required by the job, are skipped, though. Soft
+// requirements are taken into account the same way as in decide().
+func decideReschedule(agents []*agent.AgentState, j *job.Job) (*decision, error) {
 	if len(agents) == 0 {
 		return nil, fmt.Errorf("zero agents available")
 	}
 
+	agents = spreadAcrossDomains(agents, j)
+	scored := j.HasPreferences()
+
 	found := false
 	var target *agent.AgentState
+	targetScore := 0
 	for _, as := range agents {
 		if as.MState.ID == j.TargetMachineID {
 			continue
 		}
 
-		as := as
-		target = as
-		found = true
-		break
+		if as.MState.Unschedulable {
+			continue
+		}
+
+		if _, ok := as.Excluded[j.Name]; ok {
+			continue
+		}
+
+		if ok, _ := as.HasResources(j); !ok {
+			continue
+		}
+
+		if !scored {
+			as := as
+			target = as
+			found = true
+			break
+		}
+
+		if score := as.PreferenceScore(j); !found || score > targetScore {
+			as := as
+			target = as
+			targetScore = score
+			found = true
+		}
 	}
 
 	if !found {
@@ -98,6 +193,96 @@ func (lls *leastLoadedScheduler) DecideReschedule(clust *clusterState, j *job.Jo
 	return &dec, nil
 }
 
+// spreadAcrossDomains reorders the given agents for jobs defining
+// SpreadBy, so that agents whose domain - the value of the SpreadBy
+// metadata key - hosts the fewest instances of the job's template come
+// first. Agents lacking the metadata key come last. The relative order
+// of agents within a domain is preserved.
+func spreadAcrossDomains(agents []*agent.AgentState, j *job.Job) []*agent.AgentState {
+	key := j.SpreadBy()
+	if key == "" {
+		return agents
+	}
+
+	sas := domainAgentStates{
+		agents:     make([]*agent.AgentState, len(agents)),
+		key:        key,
+		population: domainPopulation(agents, key, j.Name),
+	}
+	copy(sas.agents, agents)
+	sort.Stable(sas)
+
+	return sas.agents
+}
+
+// domainPopulation counts the instances of the given unit's template
+// scheduled to the given agents, grouped by the value of the metadata key
+// of each agent. Every value found among the agents is present in the
+// result, even if no instances are scheduled there. The unit itself is
+// not counted.
+func domainPopulation(agents []*agent.AgentState, key, name string) map[string]int {
+	population := make(map[string]int)
+
+	uni := unit.NewUnitNameInfo(name)
+	for _, as := range agents {
+		domain, ok := as.MState.Metadata[key]
+		if !ok {
+			continue
+		}
+		if _, ok := population[domain]; !ok {
+			population[domain] = 0
+		}
+
+		if uni == nil || !uni.IsInstance() {
+			continue
+		}
+		for n := range as.Units {
+			if n == name {
+				continue
+			}
+			if sib := unit.NewUnitNameInfo(n); sib != nil && sib.Template == uni.Template {
+				population[domain]++
+			}
+		}
+	}
+
+	return population
+}
+
+type domainAgentStates struct {
+	agents     []*agent.AgentState
+	key        string
+	population map[string]int
+}
+
+func (sas domainAgentStates) Len() int { return len(sas.agents) }
+func (sas domainAgentStates) Swap(i, j int) {
+	sas.agents[i], sas.agents[j] = sas.agents[j], sas.agents[i]
+}
+
+func (sas domainAgentStates) Less(i, j int) bool {
+	di, iok := sas.agents[i].MState.Metadata[sas.key]
+	dj, jok := sas.agents[j].MState.Metadata[sas.key]
+	if !iok || !jok {
+		return iok && !jok
+	}
+	return sas.population[di] < sas.population[dj]
+}
+
+type leastLoadedScheduler struct{}
+
+func (lls *leastLoadedScheduler) Name() string {
+	return SchedulerLeastLoaded
+}
+
+func (lls *leastLoadedScheduler) Decide(clust *clusterState, j *job.Job) (*decision, error) {
+	return decide(lls.sortedAgents(clust), j)
+}
+
+func (lls *leastLoadedScheduler) DecideReschedule(clust *clusterState, j *job.Job) (*decision, error) {
+	return decideReschedule(lls.sortedAgents(clust), j)
+}
+
 // sortedAgents returns a list of AgentState objects sorted ascending
 // by the number of scheduled units
 func (lls *leastLoadedScheduler) sortedAgents(clust *clusterState) []*agent.AgentState {
@@ -122,3 +307,136 @@ func (sas sortableAgentStates) Less(i, j int) bool {
 	njUnits := len(sas[j].Units)
 	return niUnits < njUnits || (niUnits == njUnits && sas[i].MState.ID < sas[j].MState.ID)
 }
+
+type binPackScheduler struct{}
+
+func (bps *binPackScheduler) Name() string {
+	return SchedulerBinPack
+}
+
+func (bps *binPackScheduler) Decide(clust *clusterState, j *job.Job) (*decision, error) {
+	return decide(bps.sortedAgents(clust), j)
+}
+
+func (bps *binPackScheduler) DecideReschedule(clust *clusterState, j *job.Job) (*decision, error) {
+	return decideReschedule(bps.sortedAgents(clust), j)
+}
+
+// sortedAgents returns a list of AgentState objects sorted descending
+// by the number of scheduled units
+func (bps *binPackScheduler) sortedAgents(clust *clusterState) []*agent.AgentState {
+	agents := clust.agents()
+
+	sas := make(binPackAgentStates, 0)
+	for _, as := range agents {
+		sas = append(sas, as)
+	}
+	sort.Sort(sas)
+
+	return []*agent.AgentState(sas)
+}
+
+type binPackAgentStates []*agent.AgentState
+
+func (sas binPackAgentStates) Len() int      { return len(sas) }
+func (sas binPackAgentStates) Swap(i, j int) { sas[i], sas[j] = sas[j], sas[i] }
+
+func (sas binPackAgentStates) Less(i, j int) bool {
+	niUnits := len(sas[i].Units)
+	njUnits := len(sas[j].Units)
+	return niUnits > njUnits || (niUnits == njUnits && sas[i].MState.ID < sas[j].MState.ID)
+}
+
+type spreadScheduler struct{}
+
+func (ss *spreadScheduler) Name() string {
+	return SchedulerSpread
+}
+
+func (ss *spreadScheduler) Decide(clust *clusterState, j *job.Job) (*decision, error) {
+	return decide(ss.sortedAgents(clust, j), j)
+}
+
+func (ss *spreadScheduler) DecideReschedule(clust *clusterState, j *job.Job) (*decision, error) {
+	return decideReschedule(ss.sortedAgents(clust, j), j)
+}
+
+// sortedAgents returns a list of AgentState objects sorted ascending by
+// the number of scheduled instances of the job's template, falling back
+// to the number of scheduled units.
+func (ss *spreadScheduler) sortedAgents(clust *clusterState, j *job.Job) []*agent.AgentState {
+	agents := clust.agents()
+
+	sas := spreadAgentStates{
+		sortableAgentStates: make(sortableAgentStates, 0),
+		siblings:            make(map[string]int, len(agents)),
+	}
+	for _, as := range agents {
+		sas.sortableAgentStates = append(sas.sortableAgentStates, as)
+	}
+
+	if uni := unit.NewUnitNameInfo(j.Name); uni != nil && uni.IsInstance() {
+		for _, as := range agents {
+			for name := range as.Units {
+				if name == j.Name {
+					continue
+				}
+				if sib := unit.NewUnitNameInfo(name); sib != nil && sib.Template == uni.Template {
+					sas.siblings[as.MState.ID]++
+				}
+			}
+		}
+	}
+	sort.Sort(sas)
+
+	return []*agent.AgentState(sas.sortableAgentStates)
+}
+
+type spreadAgentStates struct {
+	sortableAgentStates
+	// siblings maps machine IDs to the number of instances of the
+	// same template scheduled to the machine
+	siblings map[string]int
+}
+
+func (sas spreadAgentStates) Less(i, j int) bool {
+	niSiblings := sas.siblings[sas.sortableAgentStates[i].MState.ID]
+	njSiblings := sas.siblings[sas.sortableAgentStates[j].MState.ID]
+	return niSiblings < njSiblings || (niSiblings == njSiblings && sas.sortableAgentStates.Less(i, j))
+}
+
+type randomScheduler struct {
+	rand *rand.Rand
+}
+
+func (rs *randomScheduler) Name() string {
+	return SchedulerRandom
+}
+
+func (rs *randomScheduler) Decide(clust *clusterState, j *job.Job) (*decision, error) {
+	return decide(rs.shuffledAgents(clust), j)
+}
+
+func (rs *randomScheduler) DecideReschedule(clust *clusterState, j *job.Job) (*decision, error) {
+	return decideReschedule(rs.shuffledAgents(clust), j)
+}
+
+// shuffledAgents returns a list of AgentState objects in random order.
+// The agents are sorted by machine ID before shuffling, so the result
+// only depends on the state of the random number generator.
+func (rs *randomScheduler) shuffledAgents(clust *clusterState) []*agent.AgentState {
+	agents := clust.agents()
+
+	ids := make([]string, 0, len(agents))
+	for id := range agents {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	shuffled := make([]*agent.AgentState, len(ids))
+	for i, p := range rs.rand.Perm(len(ids)) {
+		shuffled[i] = agents[ids[p]]
+	}
+
+	return shuffled
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/coreos/fleet/agent"
 	"github.com/coreos/fleet/job"
 	"github.com/coreos/fleet/machine"
+	"github.com/coreos/fleet/resource"
 )
 
 func TestSchedulerDecisions(t *testing.T) {
@@ -45,6 +46,27 @@ func TestSchedulerDecisions(t *testing.T) {
 				machineID: "XXX",
 			},
 		},
+
+		// skip machines lacking the resources required by the job
+		{
+			clust: newClusterState([]job.Unit{}, []job.ScheduledUnit{}, []machine.MachineState{
+				machine.MachineState{ID: "XXX", AllocatableResources: &resource.ResourceTuple{Cores: 100, Memory: 1024}},
+				machine.MachineState{ID: "YYY", AllocatableResources: &resource.ResourceTuple{Cores: 100, Memory: 4096}},
+			}),
+			job: &job.Job{Name: "foo.service", Unit: newFleetUnit(t, "MemoryRequired=2G")},
+			dec: &decision{
+				machineID: "YYY",
+			},
+		},
+
+		// refuse to overcommit any machine
+		{
+			clust: newClusterState([]job.Unit{}, []job.ScheduledUnit{}, []machine.MachineState{
+				machine.MachineState{ID: "XXX", AllocatableResources: &resource.ResourceTuple{Cores: 100, Memory: 1024}},
+			}),
+			job: &job.Job{Name: "foo.service", Unit: newFleetUnit(t, "MemoryRequired=2G")},
+			dec: nil,
+		},
 	}
 
 	for i, tt := range tests {
@@ -65,6 +87,90 @@ func TestSchedulerDecisions(t *testing.T) {
 	}
 }
 
+func TestSchedulerDecideReschedule(t *testing.T) {
+	units := []job.Unit{
+		job.Unit{Name: "bar.service"},
+	}
+	schedule := []job.ScheduledUnit{
+		job.ScheduledUnit{Name: "bar.service", TargetMachineID: "XXX"},
+	}
+
+	tests := []struct {
+		machines []machine.MachineState
+		excluded []string
+		machine  string
+	}{
+		// the current machine is skipped
+		{
+			machines: []machine.MachineState{
+				machine.MachineState{ID: "XXX"},
+				machine.MachineState{ID: "YYY"},
+			},
+			machine: "YYY",
+		},
+
+		// so are cordoned and draining machines
+		{
+			machines: []machine.MachineState{
+				machine.MachineState{ID: "XXX"},
+				machine.MachineState{ID: "YYY", Unschedulable: true},
+				machine.MachineState{ID: "ZZZ", Unschedulable: true, Draining: true},
+				machine.MachineState{ID: "ZZZZ"},
+			},
+			machine: "ZZZZ",
+		},
+		{
+			machines: []machine.MachineState{
+				machine.MachineState{ID: "XXX"},
+				machine.MachineState{ID: "YYY", Unschedulable: true},
+			},
+			machine: "",
+		},
+
+		// and machines excluded after the job failed there
+		{
+			machines: []machine.MachineState{
+				machine.MachineState{ID: "XXX"},
+				machine.MachineState{ID: "YYY"},
+				machine.MachineState{ID: "ZZZ"},
+			},
+			excluded: []string{"YYY"},
+			machine:  "ZZZ",
+		},
+		{
+			machines: []machine.MachineState{
+				machine.MachineState{ID: "XXX"},
+				machine.MachineState{ID: "YYY"},
+			},
+			excluded: []string{"YYY"},
+			machine:  "",
+		},
+	}
+
+	for i, tt := range tests {
+		clust := newClusterState(units, schedule, tt.machines)
+		for _, machID := range tt.excluded {
+			clust.exclude("foo.service", machID, "unit failed 3 times")
+		}
+		j := &job.Job{Name: "foo.service", TargetMachineID: "XXX", Unit: newFleetUnit(t, "Replaces=bar.service")}
+		dec, err := (&leastLoadedScheduler{}).DecideReschedule(clust, j)
+		if tt.machine == "" {
+			if err == nil {
+				t.Errorf("case %d: expected error, got decision %#v", i, dec)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+			continue
+		}
+
+		if dec.machineID != tt.machine {
+			t.Errorf("case %d: expected machine %s, got %s", i, tt.machine, dec.machineID)
+		}
+	}
+}
+
 func TestAgentStateSorting(t *testing.T) {
 	tests := []struct {
 		in  []*agent.AgentState
@@ -175,3 +281,288 @@ func TestAgentStateSorting(t *testing.T) {
 		}
 	}
 }
+
+func TestNewScheduler(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+		err  bool
+	}{
+		{name: "", want: SchedulerLeastLoaded},
+		{name: "least-loaded", want: SchedulerLeastLoaded},
+		{name: "bin-pack", want: SchedulerBinPack},
+		{name: "spread", want: SchedulerSpread},
+		{name: "random", want: SchedulerRandom},
+		{name: "fastest", err: true},
+	}
+
+	for i, tt := range tests {
+		sched, err := NewScheduler(tt.name, 1)
+		if tt.err {
+			if err == nil {
+				t.Errorf("case %d: expected error", i)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+			continue
+		}
+
+		if sched.Name() != tt.want {
+			t.Errorf("case %d: expected scheduler %q, got %q", i, tt.want, sched.Name())
+		}
+	}
+}
+
+func TestSchedulerStrategies(t *testing.T) {
+	machines := []machine.MachineState{
+		machine.MachineState{ID: "XXX"},
+		machine.MachineState{ID: "YYY"},
+		machine.MachineState{ID: "ZZZ"},
+	}
+	units := []job.Unit{
+		job.Unit{Name: "foo@1.service"},
+		job.Unit{Name: "foo@2.service"},
+		job.Unit{Name: "bar.service"},
+		job.Unit{Name: "baz.service"},
+	}
+	schedule := []job.ScheduledUnit{
+		job.ScheduledUnit{Name: "foo@1.service", TargetMachineID: "XXX"},
+		job.ScheduledUnit{Name: "foo@2.service", TargetMachineID: "YYY"},
+		job.ScheduledUnit{Name: "bar.service", TargetMachineID: "YYY"},
+		job.ScheduledUnit{Name: "baz.service", TargetMachineID: "ZZZ"},
+	}
+
+	tests := []struct {
+		sched   Scheduler
+		job     *job.Job
+		machine string
+	}{
+		// least-loaded picks the first of the least loaded machines
+		{
+			sched:   &leastLoadedScheduler{},
+			job:     &job.Job{Name: "foo@3.service"},
+			machine: "XXX",
+		},
+
+		// bin-pack picks the most loaded machine
+		{
+			sched:   &binPackScheduler{},
+			job:     &job.Job{Name: "foo@3.service"},
+			machine: "YYY",
+		},
+
+		// spread avoids machines running instances of the same template
+		{
+			sched:   &spreadScheduler{},
+			job:     &job.Job{Name: "foo@3.service"},
+			machine: "ZZZ",
+		},
+
+		// spread falls back to least-loaded for non-template units
+		{
+			sched:   &spreadScheduler{},
+			job:     &job.Job{Name: "qux.service"},
+			machine: "XXX",
+		},
+	}
+
+	for i, tt := range tests {
+		clust := newClusterState(units, schedule, machines)
+		dec, err := tt.sched.Decide(clust, tt.job)
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+			continue
+		}
+
+		if dec.machineID != tt.machine {
+			t.Errorf("case %d: expected machine %s, got %s", i, tt.machine, dec.machineID)
+		}
+	}
+}
+
+func TestRandomSchedulerSeed(t *testing.T) {
+	machines := []machine.MachineState{
+		machine.MachineState{ID: "AAA"},
+		machine.MachineState{ID: "BBB"},
+		machine.MachineState{ID: "CCC"},
+		machine.MachineState{ID: "DDD"},
+	}
+
+	decisions := func(seed int64) []string {
+		sched, err := NewScheduler(SchedulerRandom, seed)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		clust := newClusterState([]job.Unit{}, []job.ScheduledUnit{}, machines)
+		var ids []string
+		for i := 0; i < 10; i++ {
+			dec, err := sched.Decide(clust, &job.Job{Name: "foo.service"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			ids = append(ids, dec.machineID)
+		}
+		return ids
+	}
+
+	first := decisions(42)
+	second := decisions(42)
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("expected identical decisions for identical seeds, got %v and %v", first, second)
+	}
+}
+
+func TestSchedulerSpreadBy(t *testing.T) {
+	machines := []machine.MachineState{
+		machine.MachineState{ID: "XXX", Metadata: map[string]string{"rack": "r1"}},
+		machine.MachineState{ID: "YYY", Metadata: map[string]string{"rack": "r1"}},
+		machine.MachineState{ID: "ZZZ", Metadata: map[string]string{"rack": "r2"}},
+		machine.MachineState{ID: "AAA", Metadata: map[string]string{}},
+	}
+
+	tests := []struct {
+		units    []job.Unit
+		schedule []job.ScheduledUnit
+		job      *job.Job
+		machine  string
+	}{
+		// without any instances, fall back to the order of the strategy
+		{
+			units:    []job.Unit{},
+			schedule: []job.ScheduledUnit{},
+			job:      &job.Job{Name: "web@1.service", Unit: newFleetUnit(t, "SpreadBy=rack")},
+			machine:  "XXX",
+		},
+
+		// prefer the rack without instances over the emptier machine
+		{
+			units: []job.Unit{
+				job.Unit{Name: "web@1.service"},
+			},
+			schedule: []job.ScheduledUnit{
+				job.ScheduledUnit{Name: "web@1.service", TargetMachineID: "XXX"},
+			},
+			job:     &job.Job{Name: "web@2.service", Unit: newFleetUnit(t, "SpreadBy=rack")},
+			machine: "ZZZ",
+		},
+
+		// machines lacking the metadata key come last
+		{
+			units: []job.Unit{
+				job.Unit{Name: "web@1.service"},
+				job.Unit{Name: "web@2.service"},
+				job.Unit{Name: "web@3.service"},
+			},
+			schedule: []job.ScheduledUnit{
+				job.ScheduledUnit{Name: "web@1.service", TargetMachineID: "XXX"},
+				job.ScheduledUnit{Name: "web@2.service", TargetMachineID: "YYY"},
+				job.ScheduledUnit{Name: "web@3.service", TargetMachineID: "ZZZ"},
+			},
+			job:     &job.Job{Name: "web@4.service", Unit: newFleetUnit(t, "SpreadBy=rack")},
+			machine: "ZZZ",
+		},
+
+		// instances of other templates do not count
+		{
+			units: []job.Unit{
+				job.Unit{Name: "db@1.service"},
+				job.Unit{Name: "db@2.service"},
+			},
+			schedule: []job.ScheduledUnit{
+				job.ScheduledUnit{Name: "db@1.service", TargetMachineID: "AAA"},
+				job.ScheduledUnit{Name: "db@2.service", TargetMachineID: "ZZZ"},
+			},
+			job:     &job.Job{Name: "web@1.service", Unit: newFleetUnit(t, "SpreadBy=rack")},
+			machine: "XXX",
+		},
+	}
+
+	for i, tt := range tests {
+		clust := newClusterState(tt.units, tt.schedule, machines)
+		dec, err := (&leastLoadedScheduler{}).Decide(clust, tt.job)
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+			continue
+		}
+
+		if dec.machineID != tt.machine {
+			t.Errorf("case %d: expected machine %s, got %s", i, tt.machine, dec.machineID)
+		}
+	}
+}
+
+func TestSchedulerPreferences(t *testing.T) {
+	machines := []machine.MachineState{
+		machine.MachineState{ID: "XXX"},
+		machine.MachineState{ID: "YYY", Metadata: map[string]string{"ssd": "true"}},
+		machine.MachineState{ID: "ZZZ", Metadata: map[string]string{"ssd": "true"}},
+	}
+	units := []job.Unit{
+		job.Unit{Name: "cache@1.service"},
+		job.Unit{Name: "cache@2.service"},
+		job.Unit{Name: "blocker.service", Unit: newFleetUnit(t, "Conflicts=foo.service")},
+	}
+
+	tests := []struct {
+		schedule []job.ScheduledUnit
+		job      *job.Job
+		machine  string
+	}{
+		// the preferred machine wins over the least loaded one
+		{
+			schedule: []job.ScheduledUnit{},
+			job:      &job.Job{Name: "foo.service", Unit: newFleetUnit(t, "PreferMachineMetadata=ssd=true:50")},
+			machine:  "YYY",
+		},
+
+		// soft conflicts lower the score of a machine
+		{
+			schedule: []job.ScheduledUnit{
+				job.ScheduledUnit{Name: "cache@1.service", TargetMachineID: "YYY"},
+			},
+			job:     &job.Job{Name: "foo.service", Unit: newFleetUnit(t, "PreferMachineMetadata=ssd=true:50", "PreferConflicts=cache@*:20")},
+			machine: "ZZZ",
+		},
+
+		// weights decide between competing preferences
+		{
+			schedule: []job.ScheduledUnit{
+				job.ScheduledUnit{Name: "cache@1.service", TargetMachineID: "YYY"},
+				job.ScheduledUnit{Name: "cache@2.service", TargetMachineID: "ZZZ"},
+			},
+			job:     &job.Job{Name: "foo.service", Unit: newFleetUnit(t, "PreferMachineMetadata=ssd=true:10", "PreferConflicts=cache@*:20")},
+			machine: "XXX",
+		},
+
+		// hard requirements still exclude preferred machines
+		{
+			schedule: []job.ScheduledUnit{
+				job.ScheduledUnit{Name: "blocker.service", TargetMachineID: "YYY"},
+			},
+			job:     &job.Job{Name: "foo.service", Unit: newFleetUnit(t, "PreferMachineMetadata=ssd=true:50")},
+			machine: "ZZZ",
+		},
+
+		// fall back to machines not fulfilling any preference
+		{
+			schedule: []job.ScheduledUnit{},
+			job:      &job.Job{Name: "foo.service", Unit: newFleetUnit(t, "PreferMachineMetadata=gpu:50")},
+			machine:  "XXX",
+		},
+	}
+
+	for i, tt := range tests {
+		clust := newClusterState(units, tt.schedule, machines)
+		dec, err := (&leastLoadedScheduler{}).Decide(clust, tt.job)
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+			continue
+		}
+
+		if dec.machineID != tt.machine {
+			t.Errorf("case %d: expected machine %s, got %s", i, tt.machine, dec.machineID)
+		}
+	}
+}
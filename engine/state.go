@@ -18,12 +18,25 @@ import (
 	"github.com/coreos/fleet/agent"
 	"github.com/coreos/fleet/job"
 	"github.com/coreos/fleet/machine"
+	"github.com/coreos/fleet/unit"
 )
 
 type clusterState struct {
 	jobs     map[string]*job.Job
 	gUnits   map[string]*job.Unit
 	machines map[string]*machine.MachineState
+
+	// states holds the states of the jobs as reported by the machines
+	// they are scheduled to, if any
+	states map[string]*unit.UnitState
+
+	// excluded maps the names of jobs to the machines they must not be
+	// scheduled to, and the reason each machine is excluded
+	excluded map[string]map[string]string
+
+	// replicas holds the replica counts of templates set by scaling
+	// them, which override the Replicas option of their unit files
+	replicas map[string]int
 }
 
 func newClusterState(units []job.Unit, sUnits []job.ScheduledUnit, machines []machine.MachineState) *clusterState {
@@ -68,6 +81,42 @@ func newClusterState(units []job.Unit, sUnits []job.ScheduledUnit, machines []ma
 	}
 }
 
+// setUnitStates records the state of each job reported in the given
+// states by the machine the job is scheduled to.
+func (cs *clusterState) setUnitStates(states []*unit.UnitState) {
+	cs.states = make(map[string]*unit.UnitState)
+	for _, us := range states {
+		j := cs.jobs[us.UnitName]
+		if j == nil || !j.Scheduled() || us.MachineID != j.TargetMachineID {
+			continue
+		}
+		cs.states[us.UnitName] = us
+	}
+}
+
+// replicaCount returns the number of instances to maintain of the given
+// template job, see job.Replicas.
+func (cs *clusterState) replicaCount(j *job.Job) (int, bool) {
+	if n, ok := cs.replicas[j.Name]; ok {
+		if uni := unit.NewUnitNameInfo(j.Name); uni != nil && uni.IsTemplate() {
+			return n, true
+		}
+	}
+	return j.Replicas()
+}
+
+// exclude prevents the named job from being scheduled to the given
+// machine, and makes a job already scheduled to it unable to run there.
+func (cs *clusterState) exclude(jobName, machID, reason string) {
+	if cs.excluded == nil {
+		cs.excluded = make(map[string]map[string]string)
+	}
+	if cs.excluded[jobName] == nil {
+		cs.excluded[jobName] = make(map[string]string)
+	}
+	cs.excluded[jobName][machID] = reason
+}
+
 func (cs *clusterState) agents() map[string]*agent.AgentState {
 	agents := make(map[string]*agent.AgentState, len(cs.machines))
 	for _, ms := range cs.machines {
@@ -90,10 +139,23 @@ func (cs *clusterState) agents() map[string]*agent.AgentState {
 		}
 	}
 
+	for name, machines := range cs.excluded {
+		for id, reason := range machines {
+			as, ok := agents[id]
+			if !ok {
+				continue
+			}
+			if as.Excluded == nil {
+				as.Excluded = make(map[string]string)
+			}
+			as.Excluded[name] = reason
+		}
+	}
+
 	for _, gu := range cs.gUnits {
 		gu := gu
 		for _, a := range agents {
-			if !machine.HasMetadata(a.MState, gu.RequiredTargetMetadata()) {
+			if !machine.MatchesMetadata(a.MState, gu.RequiredTargetMetadataExprs()) {
 				continue
 			}
 
@@ -34,6 +34,18 @@ func newUnitWithMetadata(t *testing.T, metadata string) unit.UnitFile {
 	return *u
 }
 
+func newFleetUnit(t *testing.T, opts ...string) unit.UnitFile {
+	contents := "[X-Fleet]"
+	for _, v := range opts {
+		contents = fmt.Sprintf("%s\n%s", contents, v)
+	}
+	u, err := unit.NewUnitFile(contents)
+	if err != nil {
+		t.Fatalf("error creating unit from %q: %v", contents, err)
+	}
+	return *u
+}
+
 func TestClusterStateAgents(t *testing.T) {
 	tests := []struct {
 		clust  *clusterState
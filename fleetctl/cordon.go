@@ -0,0 +1,94 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/coreos/fleet/machine"
+)
+
+var (
+	cmdCordon = &cobra.Command{
+		Use:   "cordon MACHINE",
+		Short: "Stop scheduling new units to a machine",
+		Long: `Mark a machine as unschedulable. Units already running on the machine keep
+running, but no new units are scheduled to it, including global units.
+
+Cordon a machine given the prefix of its ID:
+	fleetctl cordon 2444264c`,
+		Run: runWrapper(runCordonMachine),
+	}
+
+	cmdUncordon = &cobra.Command{
+		Use:   "uncordon MACHINE",
+		Short: "Resume scheduling units to a cordoned or draining machine",
+		Long: `Mark a cordoned or draining machine as schedulable again. Units moved away
+while draining are not moved back.`,
+		Run: runWrapper(runUncordonMachine),
+	}
+
+	cmdDrain = &cobra.Command{
+		Use:   "drain MACHINE",
+		Short: "Move all units away from a machine",
+		Long: `Mark a machine as unschedulable and have the engine move its units to other
+machines, a few at a time as configured with drain_batch_size. Global units
+keep running. Units no other machine is able to run stay until the machine is
+uncordoned. Once all units moved away, the machine is listed as cordoned.
+
+Drain a machine before upgrading it:
+	fleetctl drain 2444264c`,
+		Run: runWrapper(runDrainMachine),
+	}
+)
+
+func init() {
+	cmdFleet.AddCommand(cmdCordon)
+	cmdFleet.AddCommand(cmdUncordon)
+	cmdFleet.AddCommand(cmdDrain)
+}
+
+func runCordonMachine(cCmd *cobra.Command, args []string) (exit int) {
+	return setMachineSchedulingState(args, machine.StateCordoned)
+}
+
+func runUncordonMachine(cCmd *cobra.Command, args []string) (exit int) {
+	return setMachineSchedulingState(args, machine.StateSchedulable)
+}
+
+func runDrainMachine(cCmd *cobra.Command, args []string) (exit int) {
+	return setMachineSchedulingState(args, machine.StateDraining)
+}
+
+func setMachineSchedulingState(args []string, state string) (exit int) {
+	if len(args) != 1 {
+		stderr("Provide a single machine")
+		return 1
+	}
+
+	machID, err := findMachineID(args[0])
+	if err != nil {
+		stderr("Unable to find machine %s: %v", args[0], err)
+		return 1
+	}
+
+	if err := cAPI.SetMachineSchedulingState(machID, state); err != nil {
+		stderr("Error setting state of machine %s: %v", machID, err)
+		return 1
+	}
+
+	stdout("Machine %s is %s", machID, state)
+	return 0
+}
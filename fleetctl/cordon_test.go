@@ -0,0 +1,65 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/coreos/fleet/client"
+)
+
+func TestSetMachineSchedulingState(t *testing.T) {
+	tests := []struct {
+		run     func(*cobra.Command, []string) int
+		cmd     *cobra.Command
+		args    []string
+		initial string
+		exit    int
+		state   string
+	}{
+		{runCordonMachine, cmdCordon, []string{"abc"}, "schedulable", 0, "cordoned"},
+		{runDrainMachine, cmdDrain, []string{"abcdef"}, "cordoned", 0, "draining"},
+		{runUncordonMachine, cmdUncordon, []string{"abc"}, "draining", 0, "schedulable"},
+		// machines must be identified unambiguously
+		{runCordonMachine, cmdCordon, []string{"xyz"}, "schedulable", 1, ""},
+		{runCordonMachine, cmdCordon, []string{}, "schedulable", 1, ""},
+		{runCordonMachine, cmdCordon, []string{"abc", "ghi"}, "schedulable", 1, ""},
+	}
+
+	for i, tt := range tests {
+		reg := newTestRegistryForListMachines()
+		cAPI = &client.RegistryClient{Registry: reg}
+		reg.SetMachineSchedulingState("abcdef", tt.initial)
+
+		exit := tt.run(tt.cmd, tt.args)
+		if exit != tt.exit {
+			t.Errorf("case %d: expected exit code %d, got %d", i, tt.exit, exit)
+			continue
+		}
+		if tt.exit != 0 {
+			continue
+		}
+
+		ms, err := reg.MachineState("abcdef")
+		if err != nil {
+			t.Fatalf("case %d: failed fetching machine: %v", i, err)
+		}
+		if got := ms.SchedulingState(); got != tt.state {
+			t.Errorf("case %d: expected state %q, got %q", i, tt.state, got)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/coreos/fleet/schema"
+)
+
+var (
+	cmdEngine = &cobra.Command{
+		Use:   "engine",
+		Short: "Inspect and control the engine leader",
+		Long: `Inspect and control the engine leader, the fleet daemon currently scheduling
+units in the cluster.`,
+	}
+
+	cmdEngineStatus = &cobra.Command{
+		Use:   "status",
+		Short: "Show the engine leader and its last reconciliation",
+		Long: `Show the machine holding the engine leadership, the state of its lease, the
+cluster engine version and the last reconciliation of the cluster.`,
+		Run: runWrapper(runEngineStatus),
+	}
+
+	cmdEngineStepDown = &cobra.Command{
+		Use:   "step-down",
+		Short: "Make the engine leader step down",
+		Long: `Release the lease held by the engine leader, so that the engine of another
+machine takes over. The engine stepping down does not attempt to lead again
+for the duration of a lease.`,
+		Run: runWrapper(runEngineStepDown),
+	}
+)
+
+func init() {
+	cmdFleet.AddCommand(cmdEngine)
+	cmdEngine.AddCommand(cmdEngineStatus)
+	cmdEngine.AddCommand(cmdEngineStepDown)
+}
+
+func runEngineStatus(cCmd *cobra.Command, args []string) (exit int) {
+	status, err := cAPI.EngineStatus()
+	if err != nil {
+		stderr("Error retrieving engine status: %v", err)
+		return 1
+	}
+
+	printEngineStatus(status)
+	out.Flush()
+	return 0
+}
+
+func printEngineStatus(status *schema.EngineStatus) {
+	if status.LeaderMachineID == "" {
+		fmt.Fprintln(out, "Leader:\tnone")
+	} else {
+		fmt.Fprintf(out, "Leader:\t%s\n", status.LeaderMachineID)
+		fmt.Fprintf(out, "Lease index:\t%d\n", status.LeaseIndex)
+		fmt.Fprintf(out, "Lease remaining:\t%s\n", seconds(status.LeaseTimeRemaining))
+	}
+	fmt.Fprintf(out, "Engine version:\t%d\n", status.EngineVersion)
+
+	rec := status.LastReconcile
+	if rec == nil {
+		fmt.Fprintln(out, "Last reconcile:\tnone")
+		return
+	}
+	fmt.Fprintf(out, "Last reconcile:\t%s by %s, took %s\n", rec.Time, rec.MachineID, seconds(rec.Duration))
+
+	tasks := make([]string, 0, len(rec.Tasks))
+	for _, t := range rec.Tasks {
+		tasks = append(tasks, fmt.Sprintf("%s=%d", t.Type, t.Count))
+	}
+	if len(tasks) == 0 {
+		tasks = append(tasks, "none")
+	}
+	fmt.Fprintf(out, "Tasks:\t%s\n", strings.Join(tasks, " "))
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+func runEngineStepDown(cCmd *cobra.Command, args []string) (exit int) {
+	status, err := cAPI.EngineStatus()
+	if err != nil {
+		stderr("Error retrieving engine status: %v", err)
+		return 1
+	}
+	if status.LeaderMachineID == "" {
+		stderr("No engine leader")
+		return 1
+	}
+
+	if err := cAPI.EngineStepDown(); err != nil {
+		stderr("Error releasing engine leadership: %v", err)
+		return 1
+	}
+
+	stdout("Engine leader %s stepped down", status.LeaderMachineID)
+	return 0
+}
@@ -0,0 +1,90 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/coreos/fleet/client"
+	"github.com/coreos/fleet/registry"
+	"github.com/coreos/fleet/schema"
+)
+
+func TestPrintEngineStatus(t *testing.T) {
+	tests := []struct {
+		status schema.EngineStatus
+		want   string
+	}{
+		{
+			status: schema.EngineStatus{EngineVersion: 1},
+			want: "Leader:\t\tnone\n" +
+				"Engine version:\t1\n" +
+				"Last reconcile:\tnone\n",
+		},
+		{
+			status: schema.EngineStatus{
+				LeaderMachineID:    "abcdef0123",
+				LeaseIndex:         42,
+				LeaseTimeRemaining: 7.5,
+				EngineVersion:      1,
+				LastReconcile: &schema.EngineReconcile{
+					MachineID: "abcdef0123",
+					Time:      "2016-05-04T12:00:00Z",
+					Duration:  0.25,
+					Tasks: []*schema.EngineTaskCount{
+						{Type: "AttemptScheduleUnit", Count: 3},
+						{Type: "UnscheduleUnit", Count: 1},
+					},
+				},
+			},
+			want: "Leader:\t\t\tabcdef0123\n" +
+				"Lease index:\t\t42\n" +
+				"Lease remaining:\t7.5s\n" +
+				"Engine version:\t\t1\n" +
+				"Last reconcile:\t\t2016-05-04T12:00:00Z by abcdef0123, took 250ms\n" +
+				"Tasks:\t\t\tAttemptScheduleUnit=3 UnscheduleUnit=1\n",
+		},
+	}
+
+	for i, tt := range tests {
+		var buf bytes.Buffer
+		out = getTabOutWithWriter(&buf)
+		printEngineStatus(&tt.status)
+		out.Flush()
+		if got := buf.String(); got != tt.want {
+			t.Errorf("case %d: expected output:\n%q\ngot:\n%q", i, tt.want, got)
+		}
+	}
+}
+
+func TestRunEngineStepDown(t *testing.T) {
+	lManager := registry.NewFakeLeaseRegistry()
+	cAPI = &client.RegistryClient{Registry: registry.NewFakeRegistry(), LeaseManager: lManager}
+
+	// there is nothing to step down from without a leader
+	if exit := runEngineStepDown(cmdEngineStepDown, nil); exit != 1 {
+		t.Errorf("expected exit code 1 without leader, got %d", exit)
+	}
+
+	lManager.SetLease("engine-leader", "abcdef0123", 1, time.Minute)
+	if exit := runEngineStepDown(cmdEngineStepDown, nil); exit != 0 {
+		t.Fatalf("expected exit code 0, got %d", exit)
+	}
+	if l, _ := lManager.GetLease("engine-leader"); l != nil {
+		t.Errorf("expected lease to be released, held by %s", l.MachineID())
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/coreos/fleet/schema"
+)
+
+var cmdExplain = &cobra.Command{
+	Use:   "explain [--full] UNIT...",
+	Short: "Explain why one or more units are not scheduled",
+	Long: `Explain why one or more units are not scheduled to a machine in the cluster.
+
+The engine records the reason it last failed to schedule a unit, along with
+the reason each machine was rejected. If the unit is already scheduled, the
+machine it is scheduled to is shown instead.
+
+Units using RescheduleOnFailure additionally show how often they failed on
+each machine, and until when a machine is excluded from running them.
+
+Explain why a unit is still inactive:
+	fleetctl explain foo.service`,
+	Run: runWrapper(runExplainUnit),
+}
+
+func init() {
+	cmdFleet.AddCommand(cmdExplain)
+
+	cmdExplain.Flags().BoolVar(&sharedFlags.Full, "full", false, "Do not ellipsize fields on output")
+}
+
+func runExplainUnit(cCmd *cobra.Command, args []string) (exit int) {
+	if len(args) == 0 {
+		stderr("No units given")
+		return 1
+	}
+
+	for i, arg := range args {
+		name := unitNameMangle(arg)
+		us, err := cAPI.UnitScheduling(name)
+		if err != nil {
+			stderr("Error retrieving scheduling information for unit %s: %v", name, err)
+			return 1
+		}
+		if us == nil {
+			stderr("Unit %s does not exist.", name)
+			return 1
+		}
+
+		if i != 0 {
+			fmt.Fprintln(out)
+		}
+		printUnitScheduling(us, sharedFlags.Full)
+	}
+
+	out.Flush()
+	return
+}
+
+func printUnitScheduling(us *schema.UnitScheduling, full bool) {
+	if us.MachineID != "" {
+		ms := cachedMachineState(us.MachineID)
+		legend := us.MachineID
+		if ms != nil {
+			legend = machineFullLegend(*ms, full)
+		}
+		fmt.Fprintf(out, "Unit %s is scheduled to %s\n", us.Name, legend)
+		printUnitFailures(us.Failures, full)
+		return
+	}
+
+	if us.Reason == "" {
+		fmt.Fprintf(out, "Unit %s is not scheduled; no scheduling attempt has been recorded\n", us.Name)
+		printUnitFailures(us.Failures, full)
+		return
+	}
+
+	fmt.Fprintf(out, "Unit %s is not scheduled: %s\n", us.Name, us.Reason)
+	if us.Since != "" {
+		fmt.Fprintf(out, "Since: %s\n", us.Since)
+	}
+	if len(us.Machines) > 0 {
+		fmt.Fprintln(out, "MACHINE\tREASON")
+		for _, m := range us.Machines {
+			legend := m.MachineID
+			if ms := cachedMachineState(m.MachineID); ms != nil {
+				legend = machineFullLegend(*ms, full)
+			}
+			fmt.Fprintf(out, "%s\t%s\n", legend, m.Reason)
+		}
+	}
+	printUnitFailures(us.Failures, full)
+}
+
+func printUnitFailures(failures []*schema.UnitSchedulingFailure, full bool) {
+	if len(failures) == 0 {
+		return
+	}
+
+	fmt.Fprintln(out, "MACHINE\tFAILURES\tEXCLUDED UNTIL")
+	for _, f := range failures {
+		legend := f.MachineID
+		if ms := cachedMachineState(f.MachineID); ms != nil {
+			legend = machineFullLegend(*ms, full)
+		}
+		until := f.ExcludedUntil
+		if until == "" {
+			until = "-"
+		}
+		fmt.Fprintf(out, "%s\t%d\t%s\n", legend, f.Count, until)
+	}
+}
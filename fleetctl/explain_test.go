@@ -0,0 +1,90 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/coreos/fleet/machine"
+	"github.com/coreos/fleet/schema"
+)
+
+func TestPrintUnitScheduling(t *testing.T) {
+	machineStates = map[string]*machine.MachineState{
+		"abcdef0123": &machine.MachineState{ID: "abcdef0123", PublicIP: "192.0.2.1"},
+	}
+	defer func() { machineStates = nil }()
+
+	tests := []struct {
+		us   schema.UnitScheduling
+		full bool
+		want string
+	}{
+		{
+			us:   schema.UnitScheduling{Name: "foo.service", MachineID: "abcdef0123"},
+			want: "Unit foo.service is scheduled to abcdef01.../192.0.2.1\n",
+		},
+		{
+			us:   schema.UnitScheduling{Name: "foo.service", MachineID: "abcdef0123"},
+			full: true,
+			want: "Unit foo.service is scheduled to abcdef0123/192.0.2.1\n",
+		},
+		{
+			us:   schema.UnitScheduling{Name: "foo.service"},
+			want: "Unit foo.service is not scheduled; no scheduling attempt has been recorded\n",
+		},
+		{
+			us: schema.UnitScheduling{
+				Name:   "foo.service",
+				Reason: "no agents able to run job",
+				Since:  "2016-05-04T12:00:00Z",
+				Machines: []*schema.UnitSchedulingMachine{
+					{MachineID: "abcdef0123", Reason: "insufficient resources"},
+					{MachineID: "unknown", Reason: "unit conflicts with bar.service"},
+				},
+			},
+			want: "Unit foo.service is not scheduled: no agents able to run job\n" +
+				"Since: 2016-05-04T12:00:00Z\n" +
+				"MACHINE\t\t\tREASON\n" +
+				"abcdef01.../192.0.2.1\tinsufficient resources\n" +
+				"unknown\t\t\tunit conflicts with bar.service\n",
+		},
+		{
+			us: schema.UnitScheduling{
+				Name:      "foo.service",
+				MachineID: "abcdef0123",
+				Failures: []*schema.UnitSchedulingFailure{
+					{MachineID: "abcdef0123", Count: 1},
+					{MachineID: "unknown", Count: 3, ExcludedUntil: "2016-05-04T12:10:00Z"},
+				},
+			},
+			want: "Unit foo.service is scheduled to abcdef01.../192.0.2.1\n" +
+				"MACHINE\t\t\tFAILURES\tEXCLUDED UNTIL\n" +
+				"abcdef01.../192.0.2.1\t1\t\t-\n" +
+				"unknown\t\t\t3\t\t2016-05-04T12:10:00Z\n",
+		},
+	}
+
+	for i, tt := range tests {
+		var buf bytes.Buffer
+		out = getTabOutWithWriter(&buf)
+		printUnitScheduling(&tt.us, tt.full)
+		out.Flush()
+		if got := buf.String(); got != tt.want {
+			t.Errorf("case %d: expected output:\n%q\ngot:\n%q", i, tt.want, got)
+		}
+	}
+}
@@ -38,10 +38,12 @@ import (
 
 	"github.com/coreos/fleet/api"
 	"github.com/coreos/fleet/client"
+	"github.com/coreos/fleet/engine"
 	"github.com/coreos/fleet/job"
 	"github.com/coreos/fleet/log"
 	"github.com/coreos/fleet/machine"
 	"github.com/coreos/fleet/pkg"
+	"github.com/coreos/fleet/pkg/lease"
 	"github.com/coreos/fleet/registry"
 	"github.com/coreos/fleet/schema"
 	"github.com/coreos/fleet/ssh"
@@ -485,7 +487,11 @@ func getRegistryClient(cCmd *cobra.Command) (client.API, error) {
 		stderr(msg)
 	}
 
-	return &client.RegistryClient{Registry: reg}, nil
+	return &client.RegistryClient{
+		Registry:     reg,
+		LeaseManager: lease.NewEtcdLeaseManager(kAPI, etcdKeyPrefix),
+		Planner:      engine.NewPlanner(reg),
+	}, nil
 }
 
 // getChecker creates and returns a HostKeyChecker, or nil if any error is encountered
@@ -673,6 +679,9 @@ func createUnit(name string, uf *unit.UnitFile) (*schema.Unit, error) {
 	if err := j.ValidateRequirements(); err != nil {
 		log.Warningf("Unit %s: %v", name, err)
 	}
+	for _, value := range j.IgnoredMetadata() {
+		log.Warningf("Unit %s: ignoring invalid MachineMetadata value %q", name, value)
+	}
 	err := cAPI.CreateUnit(&u)
 	if err != nil {
 		return nil, fmt.Errorf("failed creating unit %s: %v", name, err)
@@ -0,0 +1,86 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/coreos/fleet/schema"
+)
+
+var cmdHistory = &cobra.Command{
+	Use:   "history [--full] UNIT",
+	Short: "Show the recent state transitions of a unit",
+	Long: `Show the recent state transitions of a unit, oldest first.
+
+Each time systemd reports a different state or unit hash for a unit, the agent
+running it records the transition, along with the machine it happened on. Only
+the most recent transitions are kept, and the history of a unit is deleted
+along with the unit.
+
+Show how a unit moved across the cluster:
+	fleetctl history foo.service`,
+	Run: runWrapper(runHistory),
+}
+
+func init() {
+	cmdFleet.AddCommand(cmdHistory)
+
+	cmdHistory.Flags().BoolVar(&sharedFlags.Full, "full", false, "Do not ellipsize fields on output")
+}
+
+func runHistory(cCmd *cobra.Command, args []string) (exit int) {
+	if len(args) != 1 {
+		stderr("One unit file must be provided")
+		return 1
+	}
+
+	name := unitNameMangle(args[0])
+	h, err := cAPI.UnitStateHistory(name)
+	if err != nil {
+		stderr("Error retrieving state history of unit %s: %v", name, err)
+		return 1
+	}
+	if h == nil {
+		stderr("Unit %s does not exist.", name)
+		return 1
+	}
+
+	printUnitStateHistory(h, sharedFlags.Full)
+	out.Flush()
+	return
+}
+
+func printUnitStateHistory(h *schema.UnitStateHistory, full bool) {
+	if len(h.Transitions) == 0 {
+		fmt.Fprintf(out, "No state transitions recorded for unit %s\n", h.Name)
+		return
+	}
+
+	fmt.Fprintln(out, "TIME\tMACHINE\tLOAD\tACTIVE\tSUB\tHASH")
+	for _, t := range h.Transitions {
+		legend := t.MachineID
+		if ms := cachedMachineState(t.MachineID); ms != nil {
+			legend = machineFullLegend(*ms, full)
+		}
+		hash := t.UnitHash
+		if !full && len(hash) > 7 {
+			hash = hash[:7]
+		}
+		fmt.Fprintf(out, "%s\t%s\t%s\t%s\t%s\t%s\n", t.Time, legend, t.LoadState, t.ActiveState, t.SubState, hash)
+	}
+}
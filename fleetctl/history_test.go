@@ -0,0 +1,68 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/coreos/fleet/machine"
+	"github.com/coreos/fleet/schema"
+)
+
+func TestPrintUnitStateHistory(t *testing.T) {
+	machineStates = map[string]*machine.MachineState{
+		"abcdef0123": &machine.MachineState{ID: "abcdef0123", PublicIP: "192.0.2.1"},
+	}
+	defer func() { machineStates = nil }()
+
+	transitions := []*schema.UnitStateTransition{
+		{Time: "2016-05-04T12:00:00Z", MachineID: "abcdef0123", LoadState: "loaded", ActiveState: "active", SubState: "running", UnitHash: "0123456789abcdef"},
+		{Time: "2016-05-04T12:10:00Z", MachineID: "unknown", LoadState: "loaded", ActiveState: "failed", SubState: "failed", UnitHash: "0123456789abcdef"},
+	}
+
+	tests := []struct {
+		h    schema.UnitStateHistory
+		full bool
+		want string
+	}{
+		{
+			h:    schema.UnitStateHistory{Name: "foo.service"},
+			want: "No state transitions recorded for unit foo.service\n",
+		},
+		{
+			h: schema.UnitStateHistory{Name: "foo.service", Transitions: transitions},
+			want: "TIME\t\t\tMACHINE\t\t\tLOAD\tACTIVE\tSUB\tHASH\n" +
+				"2016-05-04T12:00:00Z\tabcdef01.../192.0.2.1\tloaded\tactive\trunning\t0123456\n" +
+				"2016-05-04T12:10:00Z\tunknown\t\t\tloaded\tfailed\tfailed\t0123456\n",
+		},
+		{
+			h:    schema.UnitStateHistory{Name: "foo.service", Transitions: transitions[:1]},
+			full: true,
+			want: "TIME\t\t\tMACHINE\t\t\tLOAD\tACTIVE\tSUB\tHASH\n" +
+				"2016-05-04T12:00:00Z\tabcdef0123/192.0.2.1\tloaded\tactive\trunning\t0123456789abcdef\n",
+		},
+	}
+
+	for i, tt := range tests {
+		var buf bytes.Buffer
+		out = getTabOutWithWriter(&buf)
+		printUnitStateHistory(&tt.h, tt.full)
+		out.Flush()
+		if got := buf.String(); got != tt.want {
+			t.Errorf("case %d: expected output:\n%q\ngot:\n%q", i, tt.want, got)
+		}
+	}
+}
@@ -25,7 +25,7 @@ import (
 )
 
 const (
-	defaultListMachinesFields = "machine,ip,metadata"
+	defaultListMachinesFields = "machine,ip,metadata,state"
 )
 
 var (
@@ -46,6 +46,9 @@ var (
 			}
 			return formatMetadata(ms.Metadata)
 		},
+		"state": func(ms *machine.MachineState, full bool) string {
+			return ms.SchedulingState()
+		},
 	}
 )
 
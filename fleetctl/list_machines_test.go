@@ -61,6 +61,17 @@ func TestListMachinesFieldsToStrings(t *testing.T) {
 
 	val = listMachinesFields["metadata"](ms, false)
 	assertEqual(t, "metadata", "foo=bar,ping=pong", val)
+
+	val = listMachinesFields["state"](ms, false)
+	assertEqual(t, "state", "schedulable", val)
+
+	ms.Unschedulable = true
+	val = listMachinesFields["state"](ms, false)
+	assertEqual(t, "state", "cordoned", val)
+
+	ms.Draining = true
+	val = listMachinesFields["state"](ms, false)
+	assertEqual(t, "state", "draining", val)
 }
 
 func TestListMachinesFieldsEmpty(t *testing.T) {
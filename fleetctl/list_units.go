@@ -21,8 +21,10 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/coreos/fleet/job"
 	"github.com/coreos/fleet/machine"
 	"github.com/coreos/fleet/schema"
+	"github.com/coreos/fleet/unit"
 )
 
 const (
@@ -66,6 +68,21 @@ var (
 			}
 			return machineFullLegend(*ms, full)
 		},
+		"spread": func(us *schema.UnitState, full bool) string {
+			if us == nil {
+				return "-"
+			}
+			if legend, ok := cachedSpreadLegend(us.Name); ok {
+				return legend
+			}
+			return "-"
+		},
+		"health": func(us *schema.UnitState, full bool) string {
+			if us == nil || us.Health == "" {
+				return "-"
+			}
+			return us.Health
+		},
 		"hash": func(us *schema.UnitState, full bool) string {
 			if us == nil || us.Hash == "" {
 				return "-"
@@ -80,6 +97,10 @@ var (
 
 type usToField func(us *schema.UnitState, full bool) string
 
+// spreadLegends memoizes the legends of the spread field for the life of
+// a fleetctl invocation.
+var spreadLegends map[string]string
+
 var cmdListUnits = &cobra.Command{
 	Use:   "list-units [--no-legend] [-l|--full] [--fields]",
 	Short: "List the current state of units in the cluster",
@@ -92,7 +113,13 @@ Output the list without ellipses:
 fleetctl list-units --full
 
 Or, choose the columns to display:
-fleetctl list-units --fields=unit,machine`,
+fleetctl list-units --fields=unit,machine
+
+Show how instances of templates using SpreadBy are spread across domains:
+fleetctl list-units --fields=unit,machine,spread
+
+Show the outcome of the health checks of units:
+fleetctl list-units --fields=unit,machine,active,health`,
 	Run: runWrapper(runListUnits),
 }
 
@@ -150,3 +177,90 @@ func usToFieldKeys(m map[string]usToField) (keys []string) {
 	sort.Strings(keys)
 	return
 }
+
+// cachedSpreadLegend makes a best-effort to describe the domain the given
+// unit runs in and the skew of its template, if the unit uses SpreadBy.
+// Any error encountered retrieving the units or machines is ignored.
+func cachedSpreadLegend(name string) (string, bool) {
+	if spreadLegends == nil {
+		spreadLegends = make(map[string]string)
+		units, err := cAPI.Units()
+		if err != nil {
+			return "", false
+		}
+		machines, err := cAPI.Machines()
+		if err != nil {
+			return "", false
+		}
+		spreadLegends = spreadLegendsForUnits(units, machines)
+	}
+	legend, ok := spreadLegends[name]
+	return legend, ok
+}
+
+// spreadLegendsForUnits maps the name of each scheduled unit using SpreadBy
+// to a legend of the form "key=domain (skew N)". The skew of a template is
+// the difference between the number of its instances in the most and in the
+// least populated domain.
+func spreadLegendsForUnits(units []*schema.Unit, machines []machine.MachineState) map[string]string {
+	domains := make(map[string]map[string]string)
+	for _, m := range machines {
+		domains[m.ID] = m.Metadata
+	}
+
+	type group struct {
+		template, key string
+	}
+	population := make(map[group]map[string]int)
+	members := make(map[string]group)
+	for _, u := range units {
+		uf := schema.MapSchemaUnitOptionsToUnitFile(u.Options)
+		key := (&job.Unit{Name: u.Name, Unit: *uf}).SpreadBy()
+		if key == "" || u.MachineID == "" {
+			continue
+		}
+
+		g := group{unit.NewUnitNameInfo(u.Name).Template, key}
+		if _, ok := population[g]; !ok {
+			population[g] = make(map[string]int)
+			for _, m := range machines {
+				if d, ok := m.Metadata[key]; ok {
+					population[g][d] = 0
+				}
+			}
+		}
+		if d, ok := domains[u.MachineID][key]; ok {
+			population[g][d]++
+		}
+		members[u.Name] = g
+	}
+
+	legends := make(map[string]string, len(members))
+	for _, u := range units {
+		g, ok := members[u.Name]
+		if !ok {
+			continue
+		}
+
+		min, max := -1, 0
+		for _, n := range population[g] {
+			if min < 0 || n < min {
+				min = n
+			}
+			if n > max {
+				max = n
+			}
+		}
+		if min < 0 {
+			min = 0
+		}
+
+		d, ok := domains[u.MachineID][g.key]
+		if !ok {
+			d = "-"
+		}
+		legends[u.Name] = fmt.Sprintf("%s=%s (skew %d)", g.key, d, max-min)
+	}
+
+	return legends
+}
@@ -43,7 +43,7 @@ func TestListUnitsFieldsToStrings(t *testing.T) {
 	cAPI = fakeAPI{}
 
 	// nil UnitState shouldn't happen, but just in case
-	for _, tt := range []string{"unit", "load", "active", "sub", "machine", "hash"} {
+	for _, tt := range []string{"unit", "load", "active", "sub", "machine", "hash", "health"} {
 		f := listUnitsFields[tt](nil, false)
 		assertEqual(t, tt, "-", f)
 	}
@@ -62,6 +62,7 @@ func TestListUnitsFieldsToStrings(t *testing.T) {
 		"sub":     "baz",
 		"machine": "-",
 		"unit":    "sleep",
+		"health":  "-",
 	} {
 		got := listUnitsFields[k](us, false)
 		assertEqual(t, k, want, got)
@@ -87,4 +88,44 @@ func TestListUnitsFieldsToStrings(t *testing.T) {
 	suh := listUnitsFields["hash"](us, false)
 	assertEqual(t, "hash", uh, fuh)
 	assertEqual(t, "hash", uh[:7], suh)
+
+	us.Health = "unhealthy"
+	assertEqual(t, "health", "unhealthy", listUnitsFields["health"](us, false))
+}
+
+func TestSpreadLegendsForUnits(t *testing.T) {
+	spreadBy := []*schema.UnitOption{
+		&schema.UnitOption{Section: "X-Fleet", Name: "SpreadBy", Value: "rack"},
+	}
+	machines := []machine.MachineState{
+		machine.MachineState{ID: "m1", Metadata: map[string]string{"rack": "r1"}},
+		machine.MachineState{ID: "m2", Metadata: map[string]string{"rack": "r1"}},
+		machine.MachineState{ID: "m3", Metadata: map[string]string{"rack": "r2"}},
+		machine.MachineState{ID: "m4", Metadata: map[string]string{}},
+	}
+	units := []*schema.Unit{
+		&schema.Unit{Name: "web@1.service", Options: spreadBy, MachineID: "m1"},
+		&schema.Unit{Name: "web@2.service", Options: spreadBy, MachineID: "m2"},
+		&schema.Unit{Name: "web@3.service", Options: spreadBy, MachineID: "m3"},
+		&schema.Unit{Name: "web@4.service", Options: spreadBy, MachineID: "m4"},
+		&schema.Unit{Name: "web@5.service", Options: spreadBy},
+		&schema.Unit{Name: "db@1.service", Options: spreadBy, MachineID: "m3"},
+		&schema.Unit{Name: "cache@1.service", MachineID: "m1"},
+	}
+
+	want := map[string]string{
+		"web@1.service": "rack=r1 (skew 1)",
+		"web@2.service": "rack=r1 (skew 1)",
+		"web@3.service": "rack=r2 (skew 1)",
+		"web@4.service": "rack=- (skew 1)",
+		"db@1.service":  "rack=r2 (skew 1)",
+	}
+
+	got := spreadLegendsForUnits(units, machines)
+	if len(got) != len(want) {
+		t.Errorf("expected %d legends, got %d: %v", len(want), len(got), got)
+	}
+	for name, legend := range want {
+		assertEqual(t, name, legend, got[name])
+	}
 }
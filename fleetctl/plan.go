@@ -0,0 +1,217 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/coreos/fleet/schema"
+)
+
+var (
+	planRemoveMachines []string
+	planAddMetadata    []string
+	planStrategy       string
+)
+
+var cmdPlan = &cobra.Command{
+	Use:   "plan [--remove-machine=ID] [--add-metadata=ID:KEY=VALUE] [--strategy=NAME] [UNIT...]",
+	Short: "Show how the cluster would be scheduled after proposed changes",
+	Long: `Show the tasks the engine would perform if the given units were started,
+machines removed or machine metadata added, without changing the cluster.
+
+Units are looked up in the Registry first, then on the local filesystem. Units
+that could not be scheduled are listed along with the reason each machine
+rejected them. The plan uses default engine settings, and lists the settings
+of the engine leader it does not take into account.
+
+Show where two units would be started:
+	fleetctl plan foo.service bar.service
+
+Show what would happen to running units if a machine went away:
+	fleetctl plan --remove-machine=2444264c
+
+Show where a unit would run once a machine is labeled:
+	fleetctl plan --add-metadata=2444264c:region=eu foo.service`,
+	Run: runWrapper(runPlan),
+}
+
+func init() {
+	cmdFleet.AddCommand(cmdPlan)
+
+	cmdPlan.Flags().StringSliceVar(&planRemoveMachines, "remove-machine", nil, "ID of a machine to remove from the cluster. May be given multiple times.")
+	cmdPlan.Flags().StringSliceVar(&planAddMetadata, "add-metadata", nil, "Metadata to add to a machine, as ID:KEY=VALUE. May be given multiple times.")
+	cmdPlan.Flags().StringVar(&planStrategy, "strategy", "", "Scheduler strategy to simulate. Defaults to least-loaded.")
+	cmdPlan.Flags().BoolVar(&sharedFlags.Full, "full", false, "Do not ellipsize fields on output")
+	cmdPlan.Flags().BoolVar(&sharedFlags.NoLegend, "no-legend", false, "Do not print a legend (column headers)")
+}
+
+func runPlan(cCmd *cobra.Command, args []string) (exit int) {
+	if len(args) == 0 && len(planRemoveMachines) == 0 && len(planAddMetadata) == 0 {
+		stderr("No units or changes given")
+		return 1
+	}
+
+	req := schema.PlanRequest{Strategy: planStrategy}
+
+	for _, id := range planRemoveMachines {
+		machID, err := findMachineID(id)
+		if err != nil {
+			stderr("Unable to remove machine %s: %v", id, err)
+			return 1
+		}
+		req.RemoveMachines = append(req.RemoveMachines, machID)
+	}
+
+	for _, arg := range planAddMetadata {
+		md, err := parsePlanMetadata(arg)
+		if err != nil {
+			stderr("Invalid metadata %q: %v", arg, err)
+			return 1
+		}
+		if md.MachineID, err = findMachineID(md.MachineID); err != nil {
+			stderr("Unable to add metadata %q: %v", arg, err)
+			return 1
+		}
+		req.AddMetadata = append(req.AddMetadata, md)
+	}
+
+	for _, arg := range args {
+		name := unitNameMangle(arg)
+		u, err := cAPI.Unit(name)
+		if err != nil {
+			stderr("Error retrieving unit %s: %v", name, err)
+			return 1
+		}
+		if u != nil {
+			req.Units = append(req.Units, &schema.Unit{Name: name})
+			continue
+		}
+
+		uf, err := getUnitFile(cCmd, arg)
+		if err != nil {
+			stderr("Error reading unit %s: %v", name, err)
+			return 1
+		}
+		req.Units = append(req.Units, &schema.Unit{
+			Name:    name,
+			Options: schema.MapUnitFileToSchemaUnitOptions(uf),
+		})
+	}
+
+	plan, err := cAPI.Plan(&req)
+	if err != nil {
+		stderr("Error planning changes: %v", err)
+		return 1
+	}
+
+	printPlan(plan, sharedFlags.Full, sharedFlags.NoLegend)
+	return 0
+}
+
+// parsePlanMetadata parses metadata given as ID:KEY=VALUE.
+func parsePlanMetadata(s string) (*schema.PlanMetadata, error) {
+	idx := strings.Index(s, ":")
+	if idx <= 0 {
+		return nil, fmt.Errorf("machine ID missing")
+	}
+
+	kv := strings.SplitN(s[idx+1:], "=", 2)
+	if len(kv) != 2 || kv[0] == "" {
+		return nil, fmt.Errorf("expected KEY=VALUE after machine ID")
+	}
+
+	return &schema.PlanMetadata{
+		MachineID: s[:idx],
+		Key:       kv[0],
+		Value:     kv[1],
+	}, nil
+}
+
+// findMachineID returns the ID of the single machine whose ID starts
+// with the given prefix.
+func findMachineID(prefix string) (string, error) {
+	machines, err := cAPI.Machines()
+	if err != nil {
+		return "", err
+	}
+
+	var match string
+	for _, ms := range machines {
+		if !strings.HasPrefix(ms.ID, prefix) {
+			continue
+		}
+		if match != "" {
+			return "", fmt.Errorf("found more than one machine")
+		}
+		match = ms.ID
+	}
+
+	if match == "" {
+		return "", fmt.Errorf("machine does not exist")
+	}
+	return match, nil
+}
+
+func printPlan(plan *schema.Plan, full, noLegend bool) {
+	if len(plan.Tasks) == 0 {
+		fmt.Fprintln(out, "No changes to the schedule.")
+	} else {
+		if !noLegend {
+			fmt.Fprintln(out, "ACTION\tUNIT\tMACHINE\tREASON")
+		}
+		for _, t := range plan.Tasks {
+			fmt.Fprintf(out, "%s\t%s\t%s\t%s\n", planAction(t.Type), t.UnitName, planMachineLegend(t.MachineID, full), t.Reason)
+		}
+	}
+
+	for _, us := range plan.Unschedulable {
+		fmt.Fprintln(out)
+		printUnitScheduling(us, full)
+	}
+
+	if len(plan.IgnoredSettings) > 0 {
+		fmt.Fprintln(out)
+		fmt.Fprintf(out, "Engine settings not taken into account: %s\n", strings.Join(plan.IgnoredSettings, ", "))
+	}
+
+	out.Flush()
+}
+
+func planAction(typ string) string {
+	switch typ {
+	case "AttemptScheduleUnit":
+		return "schedule"
+	case "UnscheduleUnit":
+		return "unschedule"
+	case "PreemptUnit":
+		return "preempt"
+	case "CreateUnit":
+		return "create"
+	case "DestroyUnit":
+		return "destroy"
+	}
+	return typ
+}
+
+func planMachineLegend(machID string, full bool) string {
+	if ms := cachedMachineState(machID); ms != nil {
+		return machineFullLegend(*ms, full)
+	}
+	return machID
+}
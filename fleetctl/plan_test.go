@@ -0,0 +1,101 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/coreos/fleet/machine"
+	"github.com/coreos/fleet/schema"
+)
+
+func TestParsePlanMetadata(t *testing.T) {
+	tests := []struct {
+		arg  string
+		md   *schema.PlanMetadata
+		fail bool
+	}{
+		{"abc:region=eu", &schema.PlanMetadata{MachineID: "abc", Key: "region", Value: "eu"}, false},
+		{"abc:region=", &schema.PlanMetadata{MachineID: "abc", Key: "region", Value: ""}, false},
+		{"abc:url=a=b", &schema.PlanMetadata{MachineID: "abc", Key: "url", Value: "a=b"}, false},
+		{"region=eu", nil, true},
+		{":region=eu", nil, true},
+		{"abc:region", nil, true},
+		{"abc:=eu", nil, true},
+	}
+
+	for i, tt := range tests {
+		md, err := parsePlanMetadata(tt.arg)
+		if tt.fail != (err != nil) {
+			t.Errorf("case %d: expected failure %t, got error %v", i, tt.fail, err)
+			continue
+		}
+		if !reflect.DeepEqual(tt.md, md) {
+			t.Errorf("case %d: expected %#v, got %#v", i, tt.md, md)
+		}
+	}
+}
+
+func TestPrintPlan(t *testing.T) {
+	machineStates = map[string]*machine.MachineState{
+		"abcdef0123": &machine.MachineState{ID: "abcdef0123", PublicIP: "192.0.2.1"},
+	}
+	defer func() { machineStates = nil }()
+
+	tests := []struct {
+		plan schema.Plan
+		want string
+	}{
+		{
+			plan: schema.Plan{},
+			want: "No changes to the schedule.\n",
+		},
+		{
+			plan: schema.Plan{
+				Tasks: []*schema.PlanTask{
+					{Type: "UnscheduleUnit", UnitName: "foo.service", MachineID: "unknown", Reason: "target Machine(unknown) went away"},
+					{Type: "AttemptScheduleUnit", UnitName: "foo.service", MachineID: "abcdef0123", Reason: "target state launched and unit not scheduled"},
+				},
+				Unschedulable: []*schema.UnitScheduling{
+					{Name: "bar.service", Reason: "zero agents available"},
+				},
+			},
+			want: "ACTION\t\tUNIT\t\tMACHINE\t\t\tREASON\n" +
+				"unschedule\tfoo.service\tunknown\t\t\ttarget Machine(unknown) went away\n" +
+				"schedule\tfoo.service\tabcdef01.../192.0.2.1\ttarget state launched and unit not scheduled\n" +
+				"\n" +
+				"Unit bar.service is not scheduled: zero agents available\n",
+		},
+		{
+			plan: schema.Plan{
+				IgnoredSettings: []string{"scheduler_seed", "failure_cooldown"},
+			},
+			want: "No changes to the schedule.\n" +
+				"\n" +
+				"Engine settings not taken into account: scheduler_seed, failure_cooldown\n",
+		},
+	}
+
+	for i, tt := range tests {
+		var buf bytes.Buffer
+		out = getTabOutWithWriter(&buf)
+		printPlan(&tt.plan, false, false)
+		if got := buf.String(); got != tt.want {
+			t.Errorf("case %d: expected output:\n%q\ngot:\n%q", i, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/coreos/fleet/unit"
+)
+
+var cmdScale = &cobra.Command{
+	Use:   "scale TEMPLATE REPLICAS",
+	Short: "Set the number of instances the engine maintains of a template unit",
+	Long: `Set the number of instances the engine maintains of a submitted template unit.
+
+The engine creates and launches the instances numbered 1 to REPLICAS, and
+destroys instances with a higher number.
+
+Run eight instances of a web server:
+	fleetctl scale web@.service 8
+
+Destroy all numbered instances:
+	fleetctl scale web@.service 0`,
+	Run: runWrapper(runScaleUnit),
+}
+
+func init() {
+	cmdFleet.AddCommand(cmdScale)
+}
+
+func runScaleUnit(cCmd *cobra.Command, args []string) (exit int) {
+	if len(args) != 2 {
+		stderr("Provide a template unit and the number of replicas")
+		return 1
+	}
+
+	name := unitNameMangle(args[0])
+	if un := unit.NewUnitNameInfo(name); un == nil || !un.IsTemplate() {
+		stderr("Unit %s is not a template", name)
+		return 1
+	}
+
+	replicas, err := strconv.Atoi(args[1])
+	if err != nil || replicas < 0 {
+		stderr("Invalid number of replicas %q", args[1])
+		return 1
+	}
+
+	u, err := cAPI.Unit(name)
+	if err != nil {
+		stderr("Error retrieving unit %s: %v", name, err)
+		return 1
+	}
+	if u == nil {
+		stderr("Unit %s does not exist. Submit it first.", name)
+		return 1
+	}
+
+	if err := cAPI.SetUnitReplicas(name, replicas); err != nil {
+		stderr("Error scaling unit %s: %v", name, err)
+		return 1
+	}
+
+	stdout("Scaled unit %s to %d replicas", name, replicas)
+	return 0
+}
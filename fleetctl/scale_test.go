@@ -0,0 +1,69 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/coreos/fleet/schema"
+)
+
+func TestRunScaleUnit(t *testing.T) {
+	tests := []struct {
+		args     []string
+		exit     int
+		replicas int64
+	}{
+		{[]string{"app@.service", "3"}, 0, 3},
+		{[]string{"app@", "0"}, 0, 0},
+		// only submitted templates can be scaled
+		{[]string{"app@1.service", "3"}, 1, 0},
+		{[]string{"db@.service", "3"}, 1, 0},
+		{[]string{"app@.service", "-1"}, 1, 0},
+		{[]string{"app@.service", "many"}, 1, 0},
+		{[]string{"app@.service"}, 1, 0},
+		// global templates cannot be scaled
+		{[]string{"web@.service", "3"}, 1, 0},
+	}
+
+	for i, tt := range tests {
+		cAPI = newFakeRegistryForCommands("web", 1, true)
+		app := &schema.Unit{
+			Name:         "app@.service",
+			DesiredState: "inactive",
+			Options: []*schema.UnitOption{
+				{Section: "Unit", Name: "Description", Value: "Template app@.service"},
+			},
+		}
+		if err := cAPI.CreateUnit(app); err != nil {
+			t.Fatalf("case %d: failed creating template: %v", i, err)
+		}
+		if exit := runScaleUnit(cmdScale, tt.args); exit != tt.exit {
+			t.Errorf("case %d: expected exit code %d, got %d", i, tt.exit, exit)
+			continue
+		}
+		if tt.exit != 0 {
+			continue
+		}
+
+		u, err := cAPI.Unit("app@.service")
+		if err != nil || u == nil {
+			t.Fatalf("case %d: failed fetching template: %v", i, err)
+		}
+		if u.Replicas != tt.replicas {
+			t.Errorf("case %d: expected %d replicas, got %d", i, tt.replicas, u.Replicas)
+		}
+	}
+}
@@ -27,6 +27,7 @@ import (
 
 	"github.com/coreos/fleet/agent"
 	"github.com/coreos/fleet/config"
+	"github.com/coreos/fleet/engine"
 	"github.com/coreos/fleet/log"
 	"github.com/coreos/fleet/pkg"
 	"github.com/coreos/fleet/registry"
@@ -85,9 +86,21 @@ func Main() {
 	cfgset.String("etcd_key_prefix", registry.DefaultKeyPrefix, "Keyspace for fleet data in etcd")
 	cfgset.Float64("etcd_request_timeout", 1.0, "Amount of time in seconds to allow a single etcd request before considering it failed.")
 	cfgset.Float64("engine_reconcile_interval", 2.0, "Interval at which the engine should reconcile the cluster schedule in etcd.")
+	cfgset.String("scheduler_strategy", engine.SchedulerLeastLoaded, "Strategy used by the engine to place units: least-loaded, bin-pack, spread or random")
+	cfgset.Int64("scheduler_seed", 0, "Seed of the random scheduler strategy. A seed derived from the current time is used if 0")
+	cfgset.Float64("rebalance_interval", 0, "Interval in seconds at which the engine moves units from busy to idle machines. Rebalancing is disabled if 0")
+	cfgset.Int("rebalance_max_moves", 1, "Maximum number of units moved per rebalance interval")
+	cfgset.Int("drain_batch_size", engine.DefaultDrainBatchSize, "Maximum number of units moved away from draining machines per reconciliation")
+	cfgset.Int("schedule_limit", 0, "Maximum number of units the engine schedules across the cluster per reconcile interval. Unlimited if 0")
+	cfgset.Int("schedule_limit_per_machine", 0, "Maximum number of units the engine schedules to a single machine per reconcile interval. Unlimited if 0")
+	cfgset.Float64("machine_loss_grace_period", 0, "Time in seconds units stay scheduled to a machine that went away before being rescheduled")
+	cfgset.Float64("failure_cooldown", engine.DefaultFailureCooldown.Seconds(), "Time in seconds a machine is excluded from running a unit which failed there as often as its RescheduleOnFailure allows")
+	cfgset.Float64("evacuation_timeout", 0, "Time in seconds fleetd waits on shutdown for its units to be moved to other machines before stopping them. Units are not moved if 0")
 	cfgset.String("public_ip", "", "IP address that fleet machine should publish")
 	cfgset.String("metadata", "", "List of key-value metadata to assign to the fleet machine")
 	cfgset.String("agent_ttl", agent.DefaultTTL, "TTL in seconds of fleet machine state in etcd")
+	cfgset.String("agent_status_socket", "", "Path of a unix socket the agent serves its local status on. Not served if empty")
+	cfgset.String("agent_state_file", agent.DefaultStateFile, "Path of the file the agent persists the units it should run to, to keep running them while etcd is unavailable. Not persisted if empty")
 	cfgset.String("units_directory", "/run/fleet/units/", "Path to the fleet units directory")
 	cfgset.Bool("systemd_user", false, "When true use systemd --user)")
 	cfgset.Int("token_limit", 100, "Maximum number of entries per page returned from API requests")
@@ -148,6 +161,7 @@ func Main() {
 		srvMutex.Lock()
 		defer srvMutex.Unlock()
 
+		srv.Evacuate()
 		srv.Kill()
 		srv.Purge()
 		os.Exit(0)
@@ -226,9 +240,21 @@ func getConfig(flagset *flag.FlagSet, userCfgFile string) (*config.Config, error
 		EtcdCAFile:              (*flagset.Lookup("etcd_cafile")).Value.(flag.Getter).Get().(string),
 		EtcdRequestTimeout:      (*flagset.Lookup("etcd_request_timeout")).Value.(flag.Getter).Get().(float64),
 		EngineReconcileInterval: (*flagset.Lookup("engine_reconcile_interval")).Value.(flag.Getter).Get().(float64),
+		SchedulerStrategy:       (*flagset.Lookup("scheduler_strategy")).Value.(flag.Getter).Get().(string),
+		SchedulerSeed:           (*flagset.Lookup("scheduler_seed")).Value.(flag.Getter).Get().(int64),
+		RebalanceInterval:       (*flagset.Lookup("rebalance_interval")).Value.(flag.Getter).Get().(float64),
+		RebalanceMaxMoves:       (*flagset.Lookup("rebalance_max_moves")).Value.(flag.Getter).Get().(int),
+		MachineLossGracePeriod:  (*flagset.Lookup("machine_loss_grace_period")).Value.(flag.Getter).Get().(float64),
+		FailureCooldown:         (*flagset.Lookup("failure_cooldown")).Value.(flag.Getter).Get().(float64),
+		EvacuationTimeout:       (*flagset.Lookup("evacuation_timeout")).Value.(flag.Getter).Get().(float64),
+		DrainBatchSize:          (*flagset.Lookup("drain_batch_size")).Value.(flag.Getter).Get().(int),
+		ScheduleLimit:           (*flagset.Lookup("schedule_limit")).Value.(flag.Getter).Get().(int),
+		ScheduleLimitPerMachine: (*flagset.Lookup("schedule_limit_per_machine")).Value.(flag.Getter).Get().(int),
 		PublicIP:                (*flagset.Lookup("public_ip")).Value.(flag.Getter).Get().(string),
 		RawMetadata:             (*flagset.Lookup("metadata")).Value.(flag.Getter).Get().(string),
 		AgentTTL:                (*flagset.Lookup("agent_ttl")).Value.(flag.Getter).Get().(string),
+		AgentStatusSocket:       (*flagset.Lookup("agent_status_socket")).Value.(flag.Getter).Get().(string),
+		AgentStateFile:          (*flagset.Lookup("agent_state_file")).Value.(flag.Getter).Get().(string),
 		DisableEngine:           (*flagset.Lookup("disable_engine")).Value.(flag.Getter).Get().(bool),
 		DisableWatches:          (*flagset.Lookup("disable_watches")).Value.(flag.Getter).Get().(bool),
 		EnableGRPC:              (*flagset.Lookup("enable_grpc")).Value.(flag.Getter).Get().(bool),
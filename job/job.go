@@ -16,9 +16,14 @@ package job
 
 import (
 	"fmt"
+	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/coreos/fleet/machine"
 	"github.com/coreos/fleet/pkg"
+	"github.com/coreos/fleet/resource"
 	"github.com/coreos/fleet/unit"
 )
 
@@ -30,6 +35,11 @@ const (
 	JobStateLoaded   = JobState("loaded")
 	JobStateLaunched = JobState("launched")
 
+	// JobStateMachineLost is reported as the current state of Jobs
+	// scheduled to a machine which went away, while the engine waits for
+	// it to return. It is never a valid target state.
+	JobStateMachineLost = JobState("machine-lost")
+
 	JobActionSchedule   = JobAction("job_action_schedule")
 	JobActionUnschedule = JobAction("job_action_unschedule")
 	JobActionReschedule = JobAction("job_action_reschedule")
@@ -53,6 +63,40 @@ const (
 	fleetMachineMetadata = "MachineMetadata"
 	// Require that the unit be scheduled on every machine in the cluster
 	fleetGlobal = "Global"
+	// Amount of memory the unit needs, e.g. 512M or 2G
+	fleetMemoryRequired = "MemoryRequired"
+	// Number of CPU cores the unit needs, e.g. 0.5 or 2
+	fleetCoresRequired = "CoresRequired"
+	// Amount of disk space the unit needs, e.g. 10G
+	fleetDiskRequired = "DiskRequired"
+	// Machine metadata key across whose values instances of a template are spread
+	fleetSpreadBy = "SpreadBy"
+	// Machine metadata expression machines are preferred for, with a weight
+	fleetPreferMachineMetadata = "PreferMachineMetadata"
+	// Glob pattern of units the unit should preferably not be collocated with, with a weight
+	fleetPreferConflicts = "PreferConflicts"
+	// Priority of the unit, units of lower priority may be preempted to make room for it
+	fleetPriority = "Priority"
+	// Prevent the unit from being moved by the rebalancer
+	fleetPinned = "Pinned"
+	// Time to wait for a lost machine to return before rescheduling the unit
+	fleetRescheduleAfter = "RescheduleAfter"
+	// Number of instances of a template unit the engine maintains
+	fleetReplicas = "Replicas"
+	// Number of machines concurrently loading a new version of a global unit
+	fleetMaxParallel = "MaxParallel"
+	// Number of machines concurrently replacing a running instance of a global unit
+	fleetMaxUnavailable = "MaxUnavailable"
+	// Command the agent runs to check whether the unit is healthy
+	fleetHealthCheckExec = "HealthCheckExec"
+	// URL the agent requests to check whether the unit is healthy
+	fleetHealthCheckHTTP = "HealthCheckHTTP"
+	// Time between two health checks of the unit
+	fleetHealthCheckInterval = "HealthCheckInterval"
+	// Time the unit may stay unhealthy before it is rescheduled
+	fleetRescheduleUnhealthyAfter = "RescheduleUnhealthyAfter"
+	// Number of failures on a machine after which the unit is rescheduled
+	fleetRescheduleOnFailure = "RescheduleOnFailure"
 
 	deprecatedXPrefix          = "X-"
 	deprecatedXConditionPrefix = "X-Condition"
@@ -71,6 +115,23 @@ var validRequirements = pkg.NewUnsafeSet(
 	fleetMachineMetadata,
 	fleetGlobal,
 	fleetReplaces,
+	fleetMemoryRequired,
+	fleetCoresRequired,
+	fleetDiskRequired,
+	fleetSpreadBy,
+	fleetPreferMachineMetadata,
+	fleetPreferConflicts,
+	fleetPriority,
+	fleetPinned,
+	fleetRescheduleAfter,
+	fleetReplicas,
+	fleetMaxParallel,
+	fleetMaxUnavailable,
+	fleetHealthCheckExec,
+	fleetHealthCheckHTTP,
+	fleetHealthCheckInterval,
+	fleetRescheduleUnhealthyAfter,
+	fleetRescheduleOnFailure,
 )
 
 func ParseJobState(s string) (JobState, error) {
@@ -85,6 +146,36 @@ func ParseJobState(s string) (JobState, error) {
 	return js, err
 }
 
+// MetadataPreference is a soft requirement on the metadata of a machine.
+// Machines matching Expr are preferred according to Weight.
+type MetadataPreference struct {
+	Expr   machine.MetadataExpr
+	Weight int
+}
+
+// ConflictPreference is a soft conflict with the units matching Pattern.
+// Machines not running any such unit are preferred according to Weight.
+type ConflictPreference struct {
+	Pattern string
+	Weight  int
+}
+
+// DefaultHealthCheckInterval is the time between two health checks of a
+// unit not defining HealthCheckInterval.
+const DefaultHealthCheckInterval = 30 * time.Second
+
+// HealthCheck describes how the agent running a unit checks whether the
+// unit actually serves, beyond systemd considering it active. A unit
+// defining both a command and a URL is healthy only if both checks pass.
+type HealthCheck struct {
+	// Exec is a command run by /bin/sh, which must exit with status 0
+	Exec string
+	// HTTP is a URL, whose response must have a status below 400
+	HTTP string
+	// Interval is the time between two checks
+	Interval time.Duration
+}
+
 // Job is a legacy construct encapsulating a scheduled unit in fleet
 type Job struct {
 	Name            string
@@ -101,6 +192,20 @@ type ScheduledUnit struct {
 	TargetMachineID string
 }
 
+// SchedulingExplanation records why the engine was unable to schedule a
+// Unit during its last reconciliation.
+type SchedulingExplanation struct {
+	Name string
+	// Reason summarizes why no machine was chosen
+	Reason string
+	// MachineReasons maps the ID of each machine rejecting the Unit
+	// to the reason it did so
+	MachineReasons map[string]string
+	// Since is the time at which the engine first determined the
+	// current reasons
+	Since time.Time
+}
+
 // Unit represents a Unit that has been submitted to fleet
 // (list-unit-files)
 type Unit struct {
@@ -162,6 +267,14 @@ func (u *Unit) Peers() []string {
 	return j.Peers()
 }
 
+func (u *Unit) Dependencies() []string {
+	j := &Job{
+		Name: u.Name,
+		Unit: u.Unit,
+	}
+	return j.Dependencies()
+}
+
 func (u *Unit) RequiredTarget() (string, bool) {
 	j := &Job{
 		Name: u.Name,
@@ -178,6 +291,62 @@ func (u *Unit) RequiredTargetMetadata() map[string]pkg.Set {
 	return j.RequiredTargetMetadata()
 }
 
+func (u *Unit) RequiredTargetMetadataExprs() []machine.MetadataExpr {
+	j := &Job{
+		Name: u.Name,
+		Unit: u.Unit,
+	}
+	return j.RequiredTargetMetadataExprs()
+}
+
+func (u *Unit) Resources() resource.ResourceTuple {
+	j := &Job{
+		Name: u.Name,
+		Unit: u.Unit,
+	}
+	return j.Resources()
+}
+
+func (u *Unit) SpreadBy() string {
+	j := &Job{
+		Name: u.Name,
+		Unit: u.Unit,
+	}
+	return j.SpreadBy()
+}
+
+func (u *Unit) Replicas() (int, bool) {
+	j := &Job{
+		Name: u.Name,
+		Unit: u.Unit,
+	}
+	return j.Replicas()
+}
+
+func (u *Unit) MaxParallel() (int, bool) {
+	j := &Job{
+		Name: u.Name,
+		Unit: u.Unit,
+	}
+	return j.MaxParallel()
+}
+
+func (u *Unit) MaxUnavailable() (int, bool) {
+	j := &Job{
+		Name: u.Name,
+		Unit: u.Unit,
+	}
+	return j.MaxUnavailable()
+}
+
+func (u *Unit) HealthCheck() (HealthCheck, bool) {
+	j := &Job{
+		Name: u.Name,
+		Unit: u.Unit,
+	}
+	return j.HealthCheck()
+}
+
 // requirements returns all relevant options from the [X-Fleet] section of a unit file.
 // Relevant options are identified with a `X-` prefix in the unit.
 // This prefix is stripped from relevant options before being returned.
@@ -208,11 +377,70 @@ func (j *Job) requirements() map[string][]string {
 // the job's associated unit file are known keys. If not, an error is
 // returned.
 func (j *Job) ValidateRequirements() error {
-	for key, _ := range j.requirements() {
+	requirements := j.requirements()
+	for key, _ := range requirements {
 		if !validRequirements.Contains(key) {
 			return fmt.Errorf("unrecognized requirement in [X-Fleet] section: %q", key)
 		}
 	}
+	if err := j.ValidateMetadata(); err != nil {
+		return err
+	}
+	for _, value := range requirements[fleetPreferMachineMetadata] {
+		expr, _, err := parsePreference(value)
+		if err == nil {
+			_, err = machine.ParseMetadataExpr(expr)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid value for %s in [X-Fleet] section: %v", fleetPreferMachineMetadata, err)
+		}
+	}
+	for _, value := range requirements[fleetPreferConflicts] {
+		if _, _, err := parsePreference(value); err != nil {
+			return fmt.Errorf("invalid value for %s in [X-Fleet] section: %v", fleetPreferConflicts, err)
+		}
+	}
+	for _, key := range []string{fleetRescheduleAfter, fleetRescheduleUnhealthyAfter} {
+		for _, value := range requirements[key] {
+			if _, err := parseDuration(value); err != nil {
+				return fmt.Errorf("invalid value for %s in [X-Fleet] section: %v", key, err)
+			}
+		}
+	}
+	for _, value := range requirements[fleetHealthCheckInterval] {
+		if d, err := parseDuration(value); err != nil || d == 0 {
+			return fmt.Errorf("invalid value for %s in [X-Fleet] section: %q is not a positive duration", fleetHealthCheckInterval, value)
+		}
+	}
+	for _, value := range requirements[fleetHealthCheckHTTP] {
+		if u, err := url.Parse(strings.TrimSpace(value)); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid value for %s in [X-Fleet] section: %q is not an HTTP URL", fleetHealthCheckHTTP, value)
+		}
+	}
+	for _, value := range requirements[fleetReplicas] {
+		if n, err := strconv.Atoi(strings.TrimSpace(value)); err != nil || n < 0 {
+			return fmt.Errorf("invalid value for %s in [X-Fleet] section: %q is not a non-negative integer", fleetReplicas, value)
+		}
+	}
+	for _, key := range []string{fleetMaxParallel, fleetMaxUnavailable, fleetRescheduleOnFailure} {
+		for _, value := range requirements[key] {
+			if n, err := strconv.Atoi(strings.TrimSpace(value)); err != nil || n < 1 {
+				return fmt.Errorf("invalid value for %s in [X-Fleet] section: %q is not a positive integer", key, value)
+			}
+		}
+	}
+	for _, value := range requirements[fleetPriority] {
+		if _, err := strconv.Atoi(strings.TrimSpace(value)); err != nil {
+			return fmt.Errorf("invalid value for %s in [X-Fleet] section: %q is not an integer", fleetPriority, value)
+		}
+	}
+	for key, parse := range resourceParsers {
+		for _, value := range requirements[key] {
+			if _, err := parse(value); err != nil {
+				return fmt.Errorf("invalid value for %s in [X-Fleet] section: %v", key, err)
+			}
+		}
+	}
 	return nil
 }
 
@@ -247,6 +475,25 @@ func (j *Job) Peers() []string {
 	return peers
 }
 
+// Dependencies returns a list of Job names that must be loaded and started
+// before this Job on its machine: those it is ordered after or requires in
+// its [Unit] section, and its peers.
+func (j *Job) Dependencies() []string {
+	uni := unit.NewUnitNameInfo(j.Name)
+	deps := make([]string, 0)
+	for _, key := range []string{"After", "Requires"} {
+		for _, value := range j.Unit.Contents["Unit"][key] {
+			for _, name := range strings.Fields(value) {
+				if uni != nil {
+					name = unitPrintf(name, *uni)
+				}
+				deps = append(deps, name)
+			}
+		}
+	}
+	return append(deps, j.Peers()...)
+}
+
 // RequiredTarget determines whether or not this Job must be scheduled to
 // a specific machine. If such a requirement exists, the first value returned
 // represents the ID of such a machine, while the second value will be a bool
@@ -283,33 +530,314 @@ func (j *Job) RequiredTarget() (string, bool) {
 
 // RequiredTargetMetadata return all machine-related metadata from a Job's
 // requirements. Valid metadata fields are strings of the form `key=value`,
-// where both key and value are not the empty string.
+// where both key and value are not the empty string. Other kinds of
+// metadata expressions are not included; see RequiredTargetMetadataExprs.
 func (j *Job) RequiredTargetMetadata() map[string]pkg.Set {
 	metadata := make(map[string]pkg.Set)
 
-	for _, key := range []string{
-		deprecatedXConditionPrefix + fleetMachineMetadata,
-		fleetMachineMetadata,
-	} {
-		for _, valuePair := range j.requirements()[key] {
-			s := strings.Split(valuePair, "=")
+	for _, expr := range j.RequiredTargetMetadataExprs() {
+		if expr.Op != machine.MetadataOpEqual {
+			continue
+		}
 
-			if len(s) != 2 {
+		if _, ok := metadata[expr.Key]; !ok {
+			metadata[expr.Key] = pkg.NewUnsafeSet()
+		}
+		metadata[expr.Key].Add(expr.Value)
+	}
+
+	return metadata
+}
+
+// RequiredTargetMetadataExprs returns all metadata expressions from a Job's
+// requirements, in the order they are defined. Besides `key=value`, these
+// may be negations (`key!=value`), existence checks (`key`, `!key`) or
+// numeric comparisons (`key>=value` etc.). Invalid expressions are ignored.
+func (j *Job) RequiredTargetMetadataExprs() []machine.MetadataExpr {
+	exprs := make([]machine.MetadataExpr, 0)
+
+	for _, key := range metadataRequirements {
+		for _, value := range j.requirements()[key] {
+			expr, err := machine.ParseMetadataExpr(value)
+			if err != nil {
 				continue
 			}
+			exprs = append(exprs, *expr)
+		}
+	}
 
-			if len(s[0]) == 0 || len(s[1]) == 0 {
-				continue
+	return exprs
+}
+
+// ValidateMetadata ensures that the metadata expressions of a Job's
+// requirements can be parsed, as expressions that cannot are ignored when
+// scheduling the Job, leaving it without the constraint. Values ignored
+// for compatibility are accepted, see isLegacyMetadata.
+func (j *Job) ValidateMetadata() error {
+	requirements := j.requirements()
+	for _, key := range metadataRequirements {
+		for _, value := range requirements[key] {
+			if _, err := machine.ParseMetadataExpr(value); err != nil && !isLegacyMetadata(value) {
+				return fmt.Errorf("invalid value for %s in [X-Fleet] section: %v", key, err)
 			}
+		}
+	}
+	return nil
+}
 
-			if _, ok := metadata[s[0]]; !ok {
-				metadata[s[0]] = pkg.NewUnsafeSet()
+// IgnoredMetadata returns the metadata values of a Job's requirements that
+// are ignored although ValidateRequirements accepts them, e.g. `foo=bar=baz`,
+// see isLegacyMetadata.
+func (j *Job) IgnoredMetadata() []string {
+	var ignored []string
+	for _, key := range metadataRequirements {
+		for _, value := range j.requirements()[key] {
+			if _, err := machine.ParseMetadataExpr(value); err != nil && isLegacyMetadata(value) {
+				ignored = append(ignored, value)
 			}
-			metadata[s[0]].Add(s[1])
 		}
 	}
+	return ignored
+}
 
-	return metadata
+// isLegacyMetadata reports whether the given invalid metadata expression
+// uses `=` as its only operator, like `foo=bar=baz`, `foo=` or `=bar`. Such
+// values were silently ignored before other kinds of expressions were
+// supported, so units defining them stay valid and the values ignored.
+func isLegacyMetadata(value string) bool {
+	return strings.Contains(value, "=") && !strings.ContainsAny(value, "!<>")
+}
+
+// PreferredMetadata returns the metadata preferences of a Job, given as
+// `expression:weight`, e.g. `ssd=true:50`. The weight defaults to 1 if
+// omitted. Invalid preferences are ignored.
+func (j *Job) PreferredMetadata() []MetadataPreference {
+	prefs := make([]MetadataPreference, 0)
+	for _, value := range j.requirements()[fleetPreferMachineMetadata] {
+		s, weight, err := parsePreference(value)
+		if err != nil {
+			continue
+		}
+		expr, err := machine.ParseMetadataExpr(s)
+		if err != nil {
+			continue
+		}
+		prefs = append(prefs, MetadataPreference{Expr: *expr, Weight: weight})
+	}
+	return prefs
+}
+
+// PreferredConflicts returns the soft conflicts of a Job, given as
+// `pattern:weight`, e.g. `cache@*:20`. The weight defaults to 1 if
+// omitted. Invalid preferences are ignored.
+func (j *Job) PreferredConflicts() []ConflictPreference {
+	prefs := make([]ConflictPreference, 0)
+	for _, value := range j.requirements()[fleetPreferConflicts] {
+		pattern, weight, err := parsePreference(value)
+		if err != nil {
+			continue
+		}
+		prefs = append(prefs, ConflictPreference{Pattern: pattern, Weight: weight})
+	}
+	return prefs
+}
+
+// HasPreferences returns true if the Job defines any soft requirements.
+func (j *Job) HasPreferences() bool {
+	requirements := j.requirements()
+	return len(requirements[fleetPreferMachineMetadata]) != 0 || len(requirements[fleetPreferConflicts]) != 0
+}
+
+// Resources returns the amount of resources a Job requires on the machine
+// it is scheduled to. Values that cannot be parsed are ignored, and if a
+// requirement is given multiple times, the last value found wins.
+func (j *Job) Resources() (res resource.ResourceTuple) {
+	requirements := j.requirements()
+	for key, dst := range map[string]*int{
+		fleetCoresRequired:  &res.Cores,
+		fleetMemoryRequired: &res.Memory,
+		fleetDiskRequired:   &res.Disk,
+	} {
+		values := requirements[key]
+		if len(values) == 0 {
+			continue
+		}
+		if v, err := resourceParsers[key](values[len(values)-1]); err == nil {
+			*dst = v
+		}
+	}
+	return
+}
+
+// SpreadBy returns the machine metadata key across whose distinct values
+// the instances of the Job's template should be spread evenly. An empty
+// string is returned if no such key is defined or the Job is not an
+// instance of a template. If multiple keys are given, the last one wins.
+func (j *Job) SpreadBy() string {
+	uni := unit.NewUnitNameInfo(j.Name)
+	if uni == nil || !uni.IsInstance() {
+		return ""
+	}
+
+	values := j.requirements()[fleetSpreadBy]
+	if len(values) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(values[len(values)-1])
+}
+
+// Priority returns the priority of the Job. Units of lower priority may be
+// preempted to make room for the Job if it cannot be scheduled otherwise.
+// The default priority is 0. If multiple priorities are given, the last
+// one wins; an invalid priority is treated as the default.
+func (j *Job) Priority() int {
+	values := j.requirements()[fleetPriority]
+	if len(values) == 0 {
+		return 0
+	}
+
+	p, err := strconv.Atoi(strings.TrimSpace(values[len(values)-1]))
+	if err != nil {
+		return 0
+	}
+	return p
+}
+
+// Pinned determines whether the Job opted out of being moved to another
+// machine by the rebalancer. Jobs requiring a specific machine, or being
+// collocated with other Jobs, are not moved either way.
+func (j *Job) Pinned() bool {
+	values := j.requirements()[fleetPinned]
+	if len(values) == 0 {
+		return false
+	}
+	// Last value found wins
+	return isTruthyValue(values[len(values)-1])
+}
+
+// RescheduleAfter returns how long the engine should wait for the machine
+// the Job is scheduled to to return after it went away, before
+// rescheduling the Job elsewhere. false is returned if the Job does not
+// define it, or defines an invalid duration. If multiple durations are
+// given, the last one wins.
+func (j *Job) RescheduleAfter() (time.Duration, bool) {
+	values := j.requirements()[fleetRescheduleAfter]
+	if len(values) == 0 {
+		return 0, false
+	}
+
+	d, err := parseDuration(values[len(values)-1])
+	if err != nil {
+		return 0, false
+	}
+	return d, true
+}
+
+// HealthCheck returns how the agent running the Job checks whether the
+// Job is healthy. false is returned if the Job defines neither a command
+// nor a URL to check. If multiple values are given for an option, the
+// last one wins.
+func (j *Job) HealthCheck() (HealthCheck, bool) {
+	requirements := j.requirements()
+	last := func(key string) string {
+		values := requirements[key]
+		if len(values) == 0 {
+			return ""
+		}
+		return strings.TrimSpace(values[len(values)-1])
+	}
+
+	hc := HealthCheck{
+		Exec:     last(fleetHealthCheckExec),
+		HTTP:     last(fleetHealthCheckHTTP),
+		Interval: DefaultHealthCheckInterval,
+	}
+	if hc.Exec == "" && hc.HTTP == "" {
+		return HealthCheck{}, false
+	}
+	if d, err := parseDuration(last(fleetHealthCheckInterval)); err == nil && d > 0 {
+		hc.Interval = d
+	}
+	return hc, true
+}
+
+// RescheduleUnhealthyAfter returns how long the Job may stay unhealthy
+// before the engine reschedules it to another machine. false is returned
+// if the Job does not define a health check, or no valid duration. If
+// multiple durations are given, the last one wins.
+func (j *Job) RescheduleUnhealthyAfter() (time.Duration, bool) {
+	if _, ok := j.HealthCheck(); !ok {
+		return 0, false
+	}
+
+	values := j.requirements()[fleetRescheduleUnhealthyAfter]
+	if len(values) == 0 {
+		return 0, false
+	}
+
+	d, err := parseDuration(values[len(values)-1])
+	if err != nil {
+		return 0, false
+	}
+	return d, true
+}
+
+// Replicas returns the number of instances the engine should maintain of
+// the template unit the Job represents. false is returned if the Job is
+// not a template, or does not define a valid count. If multiple counts
+// are given, the last one wins.
+func (j *Job) Replicas() (int, bool) {
+	if uni := unit.NewUnitNameInfo(j.Name); uni == nil || !uni.IsTemplate() {
+		return 0, false
+	}
+
+	values := j.requirements()[fleetReplicas]
+	if len(values) == 0 {
+		return 0, false
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(values[len(values)-1]))
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// MaxParallel returns the number of machines that may load a new version
+// of the global unit the Job represents at the same time. false is
+// returned if the Job does not define a valid limit. If multiple limits
+// are given, the last one wins.
+func (j *Job) MaxParallel() (int, bool) {
+	return j.positiveRequirement(fleetMaxParallel)
+}
+
+// MaxUnavailable returns the number of machines that may stop a running
+// instance of the global unit the Job represents to replace it with a new
+// version at the same time. false is returned if the Job does not define
+// a valid limit. If multiple limits are given, the last one wins.
+func (j *Job) MaxUnavailable() (int, bool) {
+	return j.positiveRequirement(fleetMaxUnavailable)
+}
+
+// RescheduleOnFailure returns the number of times the Job may fail on a
+// machine before the engine reschedules it to another machine. false is
+// returned if the Job does not define a valid count. If multiple counts
+// are given, the last one wins.
+func (j *Job) RescheduleOnFailure() (int, bool) {
+	return j.positiveRequirement(fleetRescheduleOnFailure)
+}
+
+func (j *Job) positiveRequirement(key string) (int, bool) {
+	values := j.requirements()[key]
+	if len(values) == 0 {
+		return 0, false
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(values[len(values)-1]))
+	if err != nil || n < 1 {
+		return 0, false
+	}
+	return n, true
 }
 
 func (j *Job) Scheduled() bool {
@@ -338,6 +866,109 @@ func isTruthyValue(s string) bool {
 	return chl == "true" || chl == "yes" || chl == "1" || chl == "on" || chl == "t"
 }
 
+// parseDuration parses a duration such as `90s` or `5m`. A plain number
+// is interpreted as seconds.
+func parseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	var d time.Duration
+	if secs, err := strconv.ParseUint(s, 10, 32); err == nil {
+		d = time.Duration(secs) * time.Second
+	} else if d, err = time.ParseDuration(s); err != nil {
+		return 0, fmt.Errorf("%q is not a duration", s)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("%q is negative", s)
+	}
+	return d, nil
+}
+
+// parsePreference splits a soft requirement of the form `value:weight`
+// into its value and weight. Only an integer after the last colon is taken
+// for the weight, so that values may contain colons themselves, e.g.
+// `zone=eu:west`. The weight must be positive; if it is omitted, it
+// defaults to 1.
+func parsePreference(s string) (string, int, error) {
+	value, weight := s, 1
+	if i := strings.LastIndex(s, ":"); i != -1 {
+		if w, err := strconv.Atoi(s[i+1:]); err == nil {
+			if w <= 0 {
+				return "", 0, fmt.Errorf("invalid weight in preference %q", s)
+			}
+			value, weight = s[:i], w
+		}
+	}
+	if len(value) == 0 {
+		return "", 0, fmt.Errorf("empty preference %q", s)
+	}
+	return value, weight, nil
+}
+
+// metadataRequirements lists the requirement keys holding metadata
+// expressions, deprecated keys first.
+var metadataRequirements = []string{
+	deprecatedXConditionPrefix + fleetMachineMetadata,
+	fleetMachineMetadata,
+}
+
+// resourceParsers maps each resource requirement key to the function
+// converting its value into the units used by resource.ResourceTuple.
+var resourceParsers = map[string]func(string) (int, error){
+	fleetCoresRequired:  parseCores,
+	fleetMemoryRequired: parseMegabytes,
+	fleetDiskRequired:   parseMegabytes,
+}
+
+// parseCores converts a (possibly fractional) number of CPU cores, e.g.
+// "0.5" or "2", into hundreds of a core.
+func parseCores(s string) (int, error) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("invalid number of cores %q", s)
+	}
+	return int(f*100 + 0.5), nil
+}
+
+// parseMegabytes converts a size with an optional K, M, G or T suffix
+// (powers of 1024), which may be followed by B, into megabytes. A size
+// without suffix is interpreted as megabytes, and sizes below one megabyte
+// are rounded up. A bare B suffix is rejected rather than taken for
+// megabytes.
+func parseMegabytes(s string) (int, error) {
+	str := strings.ToUpper(strings.TrimSpace(s))
+	if strings.HasSuffix(str, "B") {
+		str = str[:len(str)-1]
+		if str == "" || !strings.ContainsAny(str[len(str)-1:], "KMGT") {
+			return 0, fmt.Errorf("invalid size %q", s)
+		}
+	}
+
+	var kb float64
+	switch {
+	case strings.HasSuffix(str, "K"):
+		kb = 1
+	case strings.HasSuffix(str, "M"):
+		kb = 1 << 10
+	case strings.HasSuffix(str, "G"):
+		kb = 1 << 20
+	case strings.HasSuffix(str, "T"):
+		kb = 1 << 30
+	default:
+		str += "M"
+		kb = 1 << 10
+	}
+
+	f, err := strconv.ParseFloat(str[:len(str)-1], 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+
+	mb := f * kb / (1 << 10)
+	if mb > 0 && mb < 1 {
+		mb = 1
+	}
+	return int(mb + 0.5), nil
+}
+
 // splitCombine retrieves each word from an input string slice, to put each
 // one again into a single slice.
 func splitCombine(inStrs []string) []string {
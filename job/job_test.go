@@ -18,8 +18,11 @@ import (
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
+	"github.com/coreos/fleet/machine"
 	"github.com/coreos/fleet/pkg"
+	"github.com/coreos/fleet/resource"
 	"github.com/coreos/fleet/unit"
 )
 
@@ -467,6 +470,109 @@ X-ConditionMachineMetadata="one=abc" "two=def"`,
 	}
 }
 
+func TestJobRequiredMetadataExprs(t *testing.T) {
+	testCases := []struct {
+		unit string
+		out  []machine.MetadataExpr
+	}{
+		// no metadata
+		{
+			`[X-Fleet]`,
+			[]machine.MetadataExpr{},
+		},
+		// all kinds of expressions, in order of definition
+		{
+			`[X-Fleet]
+MachineMetadata=role!=db
+MachineMetadata="gpu" "!spot"
+MachineMetadata=disk_gb>=500
+MachineMetadata=region=us-east-1`,
+			[]machine.MetadataExpr{
+				{Key: "role", Op: machine.MetadataOpNotEqual, Value: "db"},
+				{Key: "gpu", Op: machine.MetadataOpExists},
+				{Key: "spot", Op: machine.MetadataOpNotExists},
+				{Key: "disk_gb", Op: machine.MetadataOpGreaterOrEqual, Value: "500"},
+				{Key: "region", Op: machine.MetadataOpEqual, Value: "us-east-1"},
+			},
+		},
+		// deprecated syntax comes first, invalid expressions are ignored
+		{
+			`[X-Fleet]
+MachineMetadata=foo<3
+MachineMetadata=foo<bar
+X-ConditionMachineMetadata=foo>1`,
+			[]machine.MetadataExpr{
+				{Key: "foo", Op: machine.MetadataOpGreater, Value: "1"},
+				{Key: "foo", Op: machine.MetadataOpLess, Value: "3"},
+			},
+		},
+	}
+	for i, tt := range testCases {
+		j := NewJob("echo.service", *newUnit(t, tt.unit))
+		md := j.RequiredTargetMetadataExprs()
+		if !reflect.DeepEqual(md, tt.out) {
+			t.Errorf("case %d: metadata expressions differ", i)
+			t.Logf("got: %#v", md)
+			t.Logf("want: %#v", tt.out)
+		}
+	}
+}
+
+func TestJobIgnoredMetadata(t *testing.T) {
+	j := NewJob("echo.service", *newUnit(t, `[X-Fleet]
+MachineMetadata=foo=bar=baz
+MachineMetadata="region=us-east-1" "=web" "role="
+MachineMetadata=disk_gb>=lots
+X-ConditionMachineMetadata=a=b=c`))
+
+	want := []string{"a=b=c", "foo=bar=baz", "=web", "role="}
+	if got := j.IgnoredMetadata(); !reflect.DeepEqual(want, got) {
+		t.Errorf("ignored metadata differs: got %#v, want %#v", got, want)
+	}
+
+	wantExprs := []machine.MetadataExpr{
+		{Key: "region", Op: machine.MetadataOpEqual, Value: "us-east-1"},
+	}
+	if got := j.RequiredTargetMetadataExprs(); !reflect.DeepEqual(wantExprs, got) {
+		t.Errorf("metadata expressions differ: got %#v, want %#v", got, wantExprs)
+	}
+}
+
+func TestJobPreferences(t *testing.T) {
+	j := NewJob("echo.service", *newUnit(t, `[X-Fleet]
+PreferMachineMetadata=ssd=true:50
+PreferMachineMetadata=gpu
+PreferMachineMetadata=ssd=:50
+PreferMachineMetadata=zone=eu:west
+PreferMachineMetadata=zone=eu:east:5
+PreferConflicts=cache@*:20
+PreferConflicts=db@*:0`))
+
+	wantMetadata := []MetadataPreference{
+		{Expr: machine.MetadataExpr{Key: "ssd", Op: machine.MetadataOpEqual, Value: "true"}, Weight: 50},
+		{Expr: machine.MetadataExpr{Key: "gpu", Op: machine.MetadataOpExists}, Weight: 1},
+		{Expr: machine.MetadataExpr{Key: "zone", Op: machine.MetadataOpEqual, Value: "eu:west"}, Weight: 1},
+		{Expr: machine.MetadataExpr{Key: "zone", Op: machine.MetadataOpEqual, Value: "eu:east"}, Weight: 5},
+	}
+	if md := j.PreferredMetadata(); !reflect.DeepEqual(md, wantMetadata) {
+		t.Errorf("unexpected metadata preferences: got %#v, want %#v", md, wantMetadata)
+	}
+
+	wantConflicts := []ConflictPreference{
+		{Pattern: "cache@*", Weight: 20},
+	}
+	if c := j.PreferredConflicts(); !reflect.DeepEqual(c, wantConflicts) {
+		t.Errorf("unexpected conflict preferences: got %#v, want %#v", c, wantConflicts)
+	}
+
+	if !j.HasPreferences() {
+		t.Errorf("expected job to have preferences")
+	}
+	if NewJob("echo.service", *newUnit(t, `[X-Fleet]`)).HasPreferences() {
+		t.Errorf("expected job without preferences")
+	}
+}
+
 func TestInstanceUnitPrintf(t *testing.T) {
 	u := unit.NewUnitNameInfo("foo@bar.waldo")
 	if u == nil {
@@ -593,6 +699,340 @@ func TestUnitIsTruthy(t *testing.T) {
 	}
 }
 
+func TestJobResources(t *testing.T) {
+	testCases := []struct {
+		contents  string
+		resources resource.ResourceTuple
+	}{
+		// no requirements at all
+		{``, resource.ResourceTuple{}},
+		// plain numbers are cores and megabytes
+		{`[X-Fleet]
+CoresRequired=2
+MemoryRequired=512
+DiskRequired=1024
+`, resource.ResourceTuple{Cores: 200, Memory: 512, Disk: 1024}},
+		// fractional cores and size suffixes
+		{`[X-Fleet]
+CoresRequired=0.25
+MemoryRequired=1.5G
+DiskRequired=1T
+`, resource.ResourceTuple{Cores: 25, Memory: 1536, Disk: 1048576}},
+		// sizes below one megabyte are rounded up
+		{`[X-Fleet]
+MemoryRequired=100K
+`, resource.ResourceTuple{Memory: 1}},
+		// last value wins
+		{`[X-Fleet]
+MemoryRequired=1G
+MemoryRequired=2GB
+`, resource.ResourceTuple{Memory: 2048}},
+		// invalid values are ignored
+		{`[X-Fleet]
+MemoryRequired=lots
+CoresRequired=1
+`, resource.ResourceTuple{Cores: 100}},
+		// bytes are not taken for megabytes
+		{`[X-Fleet]
+MemoryRequired=100B
+`, resource.ResourceTuple{}},
+	}
+	for i, tt := range testCases {
+		j := NewJob("echo.service", *newUnit(t, tt.contents))
+		res := j.Resources()
+		if res != tt.resources {
+			t.Errorf("case %d: unexpected resources: got %#v, want %#v", i, res, tt.resources)
+		}
+	}
+}
+
+func TestJobSpreadBy(t *testing.T) {
+	testCases := []struct {
+		name     string
+		contents string
+		spreadBy string
+	}{
+		// no requirement at all
+		{"web@1.service", ``, ""},
+		{"web@1.service", `[X-Fleet]
+SpreadBy=rack
+`, "rack"},
+		// last value wins
+		{"web@1.service", `[X-Fleet]
+SpreadBy=rack
+SpreadBy=az
+`, "az"},
+		// only instances of templates are spread
+		{"web.service", `[X-Fleet]
+SpreadBy=rack
+`, ""},
+	}
+	for i, tt := range testCases {
+		j := NewJob(tt.name, *newUnit(t, tt.contents))
+		if sb := j.SpreadBy(); sb != tt.spreadBy {
+			t.Errorf("case %d: unexpected SpreadBy: got %q, want %q", i, sb, tt.spreadBy)
+		}
+	}
+}
+
+func TestJobPriority(t *testing.T) {
+	testCases := []struct {
+		contents string
+		priority int
+	}{
+		// default priority
+		{``, 0},
+		{`[X-Fleet]
+Priority=100
+`, 100},
+		{`[X-Fleet]
+Priority=-10
+`, -10},
+		// last value wins
+		{`[X-Fleet]
+Priority=10
+Priority=20
+`, 20},
+		// invalid values are ignored
+		{`[X-Fleet]
+Priority=urgent
+`, 0},
+	}
+	for i, tt := range testCases {
+		j := NewJob("echo.service", *newUnit(t, tt.contents))
+		if p := j.Priority(); p != tt.priority {
+			t.Errorf("case %d: unexpected Priority: got %d, want %d", i, p, tt.priority)
+		}
+	}
+}
+
+func TestJobPinned(t *testing.T) {
+	testCases := []struct {
+		contents string
+		pinned   bool
+	}{
+		{``, false},
+		{`[X-Fleet]
+Pinned=true
+`, true},
+		{`[X-Fleet]
+Pinned=yes
+`, true},
+		{`[X-Fleet]
+Pinned=false
+`, false},
+		// last value wins
+		{`[X-Fleet]
+Pinned=true
+Pinned=no
+`, false},
+	}
+	for i, tt := range testCases {
+		j := NewJob("echo.service", *newUnit(t, tt.contents))
+		if p := j.Pinned(); p != tt.pinned {
+			t.Errorf("case %d: unexpected Pinned: got %t, want %t", i, p, tt.pinned)
+		}
+	}
+}
+
+func TestJobRescheduleAfter(t *testing.T) {
+	testCases := []struct {
+		contents string
+		after    time.Duration
+		ok       bool
+	}{
+		{``, 0, false},
+		// plain numbers are seconds
+		{`[X-Fleet]
+RescheduleAfter=30
+`, 30 * time.Second, true},
+		{`[X-Fleet]
+RescheduleAfter=5m
+`, 5 * time.Minute, true},
+		{`[X-Fleet]
+RescheduleAfter=0
+`, 0, true},
+		// last value wins
+		{`[X-Fleet]
+RescheduleAfter=1m
+RescheduleAfter=10s
+`, 10 * time.Second, true},
+		// invalid values are ignored
+		{`[X-Fleet]
+RescheduleAfter=later
+`, 0, false},
+		{`[X-Fleet]
+RescheduleAfter=-1m
+`, 0, false},
+	}
+	for i, tt := range testCases {
+		j := NewJob("echo.service", *newUnit(t, tt.contents))
+		after, ok := j.RescheduleAfter()
+		if after != tt.after || ok != tt.ok {
+			t.Errorf("case %d: unexpected RescheduleAfter: got (%v, %t), want (%v, %t)", i, after, ok, tt.after, tt.ok)
+		}
+	}
+}
+
+func TestJobHealthCheck(t *testing.T) {
+	testCases := []struct {
+		contents  string
+		hc        HealthCheck
+		ok        bool
+		unhealthy time.Duration
+		unhOK     bool
+	}{
+		{``, HealthCheck{}, false, 0, false},
+		// the interval alone does not define a check
+		{`[X-Fleet]
+HealthCheckInterval=10s
+RescheduleUnhealthyAfter=1m
+`, HealthCheck{}, false, 0, false},
+		{`[X-Fleet]
+HealthCheckExec=/usr/bin/check-web
+`, HealthCheck{Exec: "/usr/bin/check-web", Interval: DefaultHealthCheckInterval}, true, 0, false},
+		{`[X-Fleet]
+HealthCheckHTTP=http://localhost:8080/health
+HealthCheckInterval=10
+RescheduleUnhealthyAfter=2m
+`, HealthCheck{HTTP: "http://localhost:8080/health", Interval: 10 * time.Second}, true, 2 * time.Minute, true},
+		// last value wins, invalid intervals are ignored
+		{`[X-Fleet]
+HealthCheckExec=/bin/false
+HealthCheckExec=/bin/true
+HealthCheckHTTP=http://localhost/
+HealthCheckInterval=0
+`, HealthCheck{Exec: "/bin/true", HTTP: "http://localhost/", Interval: DefaultHealthCheckInterval}, true, 0, false},
+	}
+	for i, tt := range testCases {
+		j := NewJob("echo.service", *newUnit(t, tt.contents))
+		hc, ok := j.HealthCheck()
+		if hc != tt.hc || ok != tt.ok {
+			t.Errorf("case %d: unexpected HealthCheck: got (%+v, %t), want (%+v, %t)", i, hc, ok, tt.hc, tt.ok)
+		}
+		after, ok := j.RescheduleUnhealthyAfter()
+		if after != tt.unhealthy || ok != tt.unhOK {
+			t.Errorf("case %d: unexpected RescheduleUnhealthyAfter: got (%v, %t), want (%v, %t)", i, after, ok, tt.unhealthy, tt.unhOK)
+		}
+	}
+}
+
+func TestJobReplicas(t *testing.T) {
+	testCases := []struct {
+		name     string
+		contents string
+		replicas int
+		ok       bool
+	}{
+		{"web@.service", ``, 0, false},
+		{"web@.service", `[X-Fleet]
+Replicas=8
+`, 8, true},
+		{"web@.service", `[X-Fleet]
+Replicas=0
+`, 0, true},
+		// last value wins
+		{"web@.service", `[X-Fleet]
+Replicas=8
+Replicas=12
+`, 12, true},
+		// invalid values are ignored
+		{"web@.service", `[X-Fleet]
+Replicas=-1
+`, 0, false},
+		// only templates have replicas
+		{"web@1.service", `[X-Fleet]
+Replicas=8
+`, 0, false},
+		{"web.service", `[X-Fleet]
+Replicas=8
+`, 0, false},
+	}
+	for i, tt := range testCases {
+		j := NewJob(tt.name, *newUnit(t, tt.contents))
+		replicas, ok := j.Replicas()
+		if replicas != tt.replicas || ok != tt.ok {
+			t.Errorf("case %d: unexpected Replicas: got (%d, %t), want (%d, %t)", i, replicas, ok, tt.replicas, tt.ok)
+		}
+	}
+}
+
+func TestJobRolloutLimits(t *testing.T) {
+	testCases := []struct {
+		contents    string
+		parallel    int
+		parallelOK  bool
+		unavailable int
+		unavailOK   bool
+	}{
+		{``, 0, false, 0, false},
+		{`[X-Fleet]
+Global=true
+MaxParallel=3
+`, 3, true, 0, false},
+		{`[X-Fleet]
+Global=true
+MaxUnavailable=1
+`, 0, false, 1, true},
+		// last value wins
+		{`[X-Fleet]
+Global=true
+MaxParallel=3
+MaxParallel=5
+MaxUnavailable=2
+`, 5, true, 2, true},
+		// invalid values are ignored
+		{`[X-Fleet]
+Global=true
+MaxParallel=0
+MaxUnavailable=all
+`, 0, false, 0, false},
+	}
+	for i, tt := range testCases {
+		j := NewJob("log.service", *newUnit(t, tt.contents))
+		parallel, ok := j.MaxParallel()
+		if parallel != tt.parallel || ok != tt.parallelOK {
+			t.Errorf("case %d: unexpected MaxParallel: got (%d, %t), want (%d, %t)", i, parallel, ok, tt.parallel, tt.parallelOK)
+		}
+		unavailable, ok := j.MaxUnavailable()
+		if unavailable != tt.unavailable || ok != tt.unavailOK {
+			t.Errorf("case %d: unexpected MaxUnavailable: got (%d, %t), want (%d, %t)", i, unavailable, ok, tt.unavailable, tt.unavailOK)
+		}
+	}
+}
+
+func TestJobRescheduleOnFailure(t *testing.T) {
+	testCases := []struct {
+		contents string
+		count    int
+		ok       bool
+	}{
+		{``, 0, false},
+		{`[X-Fleet]
+RescheduleOnFailure=3
+`, 3, true},
+		// last value wins
+		{`[X-Fleet]
+RescheduleOnFailure=3
+RescheduleOnFailure=1
+`, 1, true},
+		// invalid values are ignored
+		{`[X-Fleet]
+RescheduleOnFailure=0
+`, 0, false},
+		{`[X-Fleet]
+RescheduleOnFailure=twice
+`, 0, false},
+	}
+	for i, tt := range testCases {
+		j := NewJob("echo.service", *newUnit(t, tt.contents))
+		count, ok := j.RescheduleOnFailure()
+		if count != tt.count || ok != tt.ok {
+			t.Errorf("case %d: unexpected RescheduleOnFailure: got (%d, %t), want (%d, %t)", i, count, ok, tt.count, tt.ok)
+		}
+	}
+}
+
 func TestValidateRequirements(t *testing.T) {
 	tests := []string{
 		"MachineID=asdf",
@@ -604,8 +1044,37 @@ func TestValidateRequirements(t *testing.T) {
 		"Conflicts=foo",
 		"X-ConditionMachineMetadata=up=down",
 		"MachineMetadata=true=false",
+		"MachineMetadata=role!=db",
+		"MachineMetadata=gpu",
+		"MachineMetadata=!spot",
+		"MachineMetadata=disk_gb>=500",
+		// ignored, as before other expressions were supported
+		"MachineMetadata=foo=",
+		"MachineMetadata=foo=bar=baz",
+		"X-ConditionMachineMetadata=foo=bar=baz",
+		"PreferMachineMetadata=ssd=true:50",
+		"PreferMachineMetadata=zone=eu:west",
+		"PreferMachineMetadata=!spot",
+		"PreferConflicts=cache@*:20",
 		"Global=true",
 		"Replaces=foo",
+		"MemoryRequired=512M",
+		"CoresRequired=0.5",
+		"DiskRequired=10G",
+		"Priority=100",
+		"Priority=-5",
+		"Pinned=true",
+		"RescheduleAfter=30",
+		"RescheduleAfter=5m",
+		"Replicas=8",
+		"Replicas=0",
+		"MaxParallel=2",
+		"MaxUnavailable=1",
+		"HealthCheckExec=/usr/bin/check-web",
+		"HealthCheckHTTP=https://localhost:8443/health",
+		"HealthCheckInterval=10s",
+		"RescheduleUnhealthyAfter=5m",
+		"RescheduleOnFailure=3",
 	}
 	for i, req := range tests {
 		contents := fmt.Sprintf("[X-Fleet]\n%s", req)
@@ -627,6 +1096,30 @@ func TestBadValidateRequirements(t *testing.T) {
 		"MachineId=true",
 		"X-MachineMetadata=none",
 		"X-ConditionMetadata=foo=foo",
+		"MemoryRequired=lots",
+		"CoresRequired=-1",
+		"DiskRequired=10X",
+		"MemoryRequired=100B",
+		"MachineMetadata=!=db",
+		"MachineMetadata=disk_gb>=lots",
+		"MachineMetadata=foo=bar>baz",
+		"PreferMachineMetadata=ssd=true:0",
+		"PreferMachineMetadata=ssd=true:-5",
+		"PreferMachineMetadata=ssd=:5",
+		"PreferConflicts=:20",
+		"Priority=high",
+		"Priority=1.5",
+		"RescheduleAfter=soon",
+		"RescheduleAfter=-30s",
+		"Replicas=-1",
+		"Replicas=many",
+		"MaxParallel=0",
+		"MaxUnavailable=some",
+		"HealthCheckHTTP=localhost:8080",
+		"HealthCheckHTTP=ftp://localhost/",
+		"HealthCheckInterval=0",
+		"RescheduleUnhealthyAfter=never",
+		"RescheduleOnFailure=0",
 	}
 	for i, req := range tests {
 		contents := fmt.Sprintf("[X-Fleet]\n%s", req)
@@ -636,3 +1129,35 @@ func TestBadValidateRequirements(t *testing.T) {
 		}
 	}
 }
+
+func TestJobDependencies(t *testing.T) {
+	testCases := []struct {
+		name     string
+		contents string
+		deps     []string
+	}{
+		{"echo.service", ``, []string{}},
+		{"echo.service", `[Unit]
+After=foo.service bar.service
+After=baz.socket
+Requires=foo.service
+`, []string{"foo.service", "bar.service", "baz.socket", "foo.service"}},
+		{"echo.service", `[Unit]
+Requires=foo.service
+[X-Fleet]
+MachineOf=bar.service
+`, []string{"foo.service", "bar.service"}},
+		{"echo@1.service", `[Unit]
+After=db@%i.service
+[X-Fleet]
+MachineOf=%p-data@%i.service
+`, []string{"db@1.service", "echo-data@1.service"}},
+	}
+	for i, tt := range testCases {
+		j := NewJob(tt.name, *newUnit(t, tt.contents))
+		deps := j.Dependencies()
+		if !reflect.DeepEqual(deps, tt.deps) {
+			t.Errorf("case %d: unexpected dependencies: got %#v, want %#v", i, deps, tt.deps)
+		}
+	}
+}
@@ -15,22 +15,30 @@
 package machine
 
 import (
+	"bufio"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net"
+	"os"
 	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/vishvananda/netlink"
 
 	"github.com/coreos/fleet/log"
+	"github.com/coreos/fleet/resource"
 	"github.com/coreos/fleet/unit"
 )
 
 const (
 	machineIDPath = "/etc/machine-id"
+	meminfoPath   = "/proc/meminfo"
 )
 
 func NewCoreOSMachine(static MachineState, um unit.UnitManager) *CoreOSMachine {
@@ -107,11 +115,22 @@ func (m *CoreOSMachine) currentState() *MachineState {
 		return nil
 	}
 	publicIP := getLocalIP()
-	return &MachineState{
+	ms := &MachineState{
 		ID:       id,
 		PublicIP: publicIP,
 		Metadata: make(map[string]string, 0),
 	}
+
+	total, err := readLocalResources("/")
+	if err != nil {
+		log.Warningf("Unable to determine local resources: %v", err)
+	} else {
+		alloc := allocatableResources(total)
+		ms.TotalResources = &total
+		ms.AllocatableResources = &alloc
+	}
+
+	return ms
 }
 
 // IsLocalMachineID returns whether the given machine ID is equal to that of the local machine
@@ -133,6 +152,71 @@ func readLocalMachineID(root string) (string, error) {
 	return mID, nil
 }
 
+// readLocalResources determines the total amount of CPU, memory and disk
+// space of the host whose root filesystem is mounted at the given path.
+func readLocalResources(root string) (resource.ResourceTuple, error) {
+	mem, err := readTotalMemory(filepath.Join(root, meminfoPath))
+	if err != nil {
+		return resource.ResourceTuple{}, err
+	}
+
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(root, &st); err != nil {
+		return resource.ResourceTuple{}, err
+	}
+
+	res := resource.ResourceTuple{
+		Cores:  runtime.NumCPU() * 100,
+		Memory: mem,
+		Disk:   int(uint64(st.Blocks) * uint64(st.Bsize) >> 20),
+	}
+	return res, nil
+}
+
+// readTotalMemory returns the MemTotal field of the given meminfo file in
+// megabytes.
+func readTotalMemory(path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "MemTotal:" {
+			continue
+		}
+		kb, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return 0, fmt.Errorf("invalid MemTotal value %q", fields[1])
+		}
+		return kb >> 10, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+
+	return 0, errors.New("MemTotal not found")
+}
+
+// allocatableResources returns the part of the given total resources that
+// is not reserved for the host itself. No component ever drops below zero.
+func allocatableResources(total resource.ResourceTuple) resource.ResourceTuple {
+	alloc := resource.Sub(total, resource.HostResources)
+	if alloc.Cores < 0 {
+		alloc.Cores = 0
+	}
+	if alloc.Memory < 0 {
+		alloc.Memory = 0
+	}
+	if alloc.Disk < 0 {
+		alloc.Disk = 0
+	}
+	return alloc
+}
+
 func getLocalIP() (got string) {
 	iface := getDefaultGatewayIface()
 	if iface == nil {
@@ -20,6 +20,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/coreos/fleet/resource"
 )
 
 func TestReadLocalMachineIDMissing(t *testing.T) {
@@ -100,3 +102,65 @@ func TestUsableAddress(t *testing.T) {
 		}
 	}
 }
+
+func TestReadLocalResources(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "fleet-")
+	if err != nil {
+		t.Fatalf("Failed creating tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := readLocalResources(dir); err == nil {
+		t.Fatal("Expected error for missing meminfo, but got nil")
+	}
+
+	tmpMeminfoPath := filepath.Join(dir, "/proc/meminfo")
+	err = os.MkdirAll(filepath.Dir(tmpMeminfoPath), os.FileMode(0755))
+	if err != nil {
+		t.Fatalf("Failed setting up fake meminfo path: %v", err)
+	}
+
+	meminfo := "MemTotal:        4045216 kB\nMemFree:          123456 kB\n"
+	err = ioutil.WriteFile(tmpMeminfoPath, []byte(meminfo), os.FileMode(0644))
+	if err != nil {
+		t.Fatalf("Failed writing fake meminfo file: %v", err)
+	}
+
+	res, err := readLocalResources(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error reading resources: %v", err)
+	}
+	if res.Memory != 3950 {
+		t.Errorf("Received incorrect memory %d, expected 3950", res.Memory)
+	}
+	if res.Cores <= 0 || res.Cores%100 != 0 {
+		t.Errorf("Received incorrect cores %d", res.Cores)
+	}
+	if res.Disk <= 0 {
+		t.Errorf("Received incorrect disk %d", res.Disk)
+	}
+}
+
+func TestAllocatableResources(t *testing.T) {
+	tests := []struct {
+		total resource.ResourceTuple
+		want  resource.ResourceTuple
+	}{
+		{
+			resource.ResourceTuple{Cores: 400, Memory: 4096, Disk: 10240},
+			resource.ResourceTuple{Cores: 300, Memory: 3840, Disk: 10240},
+		},
+		// never go below zero
+		{
+			resource.ResourceTuple{Cores: 50, Memory: 128, Disk: 0},
+			resource.ResourceTuple{Cores: 0, Memory: 0, Disk: 0},
+		},
+	}
+
+	for i, tt := range tests {
+		got := allocatableResources(tt.total)
+		if got != tt.want {
+			t.Errorf("case %d: got %v, want %v", i, got, tt.want)
+		}
+	}
+}
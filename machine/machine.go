@@ -15,10 +15,150 @@
 package machine
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+
 	"github.com/coreos/fleet/log"
 	"github.com/coreos/fleet/pkg"
 )
 
+type MetadataOp string
+
+const (
+	MetadataOpEqual          = MetadataOp("=")
+	MetadataOpNotEqual       = MetadataOp("!=")
+	MetadataOpExists         = MetadataOp("")
+	MetadataOpNotExists      = MetadataOp("!")
+	MetadataOpGreater        = MetadataOp(">")
+	MetadataOpGreaterOrEqual = MetadataOp(">=")
+	MetadataOpLess           = MetadataOp("<")
+	MetadataOpLessOrEqual    = MetadataOp("<=")
+)
+
+// metadataOps lists the binary operators in the order they must be
+// matched, i.e. two-character operators before their prefixes.
+var metadataOps = []MetadataOp{
+	MetadataOpGreaterOrEqual,
+	MetadataOpLessOrEqual,
+	MetadataOpNotEqual,
+	MetadataOpGreater,
+	MetadataOpLess,
+	MetadataOpEqual,
+}
+
+// MetadataExpr is a single condition on the metadata of a machine, as
+// given in the MachineMetadata option of a unit file.
+type MetadataExpr struct {
+	Key   string
+	Op    MetadataOp
+	Value string
+}
+
+// ParseMetadataExpr parses a metadata condition of one of the forms
+// `key=value`, `key!=value`, `key` (key exists), `!key` (key does not
+// exist) or `key>value`, `key>=value`, `key<value`, `key<=value` (numeric
+// comparison).
+func ParseMetadataExpr(s string) (*MetadataExpr, error) {
+	i := strings.IndexAny(s, "!=<>")
+	if i == -1 {
+		if len(s) == 0 {
+			return nil, fmt.Errorf("empty metadata expression")
+		}
+		return &MetadataExpr{Key: s, Op: MetadataOpExists}, nil
+	}
+
+	if i == 0 && s[0] == '!' && !strings.ContainsAny(s[1:], "!=<>") {
+		if len(s) == 1 {
+			return nil, fmt.Errorf("missing key in metadata expression %q", s)
+		}
+		return &MetadataExpr{Key: s[1:], Op: MetadataOpNotExists}, nil
+	}
+
+	key, rest := s[:i], s[i:]
+	for _, op := range metadataOps {
+		if !strings.HasPrefix(rest, string(op)) {
+			continue
+		}
+
+		value := rest[len(op):]
+		switch {
+		case len(key) == 0:
+			return nil, fmt.Errorf("missing key in metadata expression %q", s)
+		case len(value) == 0:
+			return nil, fmt.Errorf("missing value in metadata expression %q", s)
+		case strings.ContainsAny(value, "!=<>"):
+			return nil, fmt.Errorf("invalid value in metadata expression %q", s)
+		}
+
+		expr := &MetadataExpr{Key: key, Op: op, Value: value}
+		if expr.numeric() {
+			if _, err := strconv.ParseFloat(value, 64); err != nil {
+				return nil, fmt.Errorf("non-numeric value in metadata expression %q", s)
+			}
+		}
+		return expr, nil
+	}
+
+	return nil, fmt.Errorf("invalid metadata expression %q", s)
+}
+
+func (e MetadataExpr) String() string {
+	if e.Op == MetadataOpNotExists {
+		return string(e.Op) + e.Key
+	}
+	return e.Key + string(e.Op) + e.Value
+}
+
+func (e MetadataExpr) numeric() bool {
+	switch e.Op {
+	case MetadataOpGreater, MetadataOpGreaterOrEqual, MetadataOpLess, MetadataOpLessOrEqual:
+		return true
+	}
+	return false
+}
+
+// Matches determines whether the given metadata fulfills the expression.
+// Numeric comparisons never match if the local value is not a number.
+func (e MetadataExpr) Matches(metadata map[string]string) bool {
+	local, ok := metadata[e.Key]
+
+	switch e.Op {
+	case MetadataOpExists:
+		return ok
+	case MetadataOpNotExists:
+		return !ok
+	case MetadataOpEqual:
+		return ok && local == e.Value
+	case MetadataOpNotEqual:
+		return !ok || local != e.Value
+	}
+
+	if !ok {
+		return false
+	}
+	lv, err := strconv.ParseFloat(local, 64)
+	if err != nil {
+		return false
+	}
+	rv, err := strconv.ParseFloat(e.Value, 64)
+	if err != nil {
+		return false
+	}
+
+	switch e.Op {
+	case MetadataOpGreater:
+		return lv > rv
+	case MetadataOpGreaterOrEqual:
+		return lv >= rv
+	case MetadataOpLess:
+		return lv < rv
+	case MetadataOpLessOrEqual:
+		return lv <= rv
+	}
+	return false
+}
+
 type Machine interface {
 	State() MachineState
 }
@@ -45,3 +185,27 @@ func HasMetadata(state *MachineState, metadata map[string]pkg.Set) bool {
 
 	return true
 }
+
+// MatchesMetadata determines if the Metadata of a given MachineState
+// fulfills all of the given expressions. As with HasMetadata, equality
+// expressions for the same key are alternatives, of which only one has
+// to match. All other expressions must match.
+func MatchesMetadata(state *MachineState, exprs []MetadataExpr) bool {
+	equal := make(map[string]pkg.Set)
+	for _, e := range exprs {
+		if e.Op == MetadataOpEqual {
+			if _, ok := equal[e.Key]; !ok {
+				equal[e.Key] = pkg.NewUnsafeSet()
+			}
+			equal[e.Key].Add(e.Value)
+			continue
+		}
+
+		if !e.Matches(state.Metadata) {
+			log.Debugf("Local Metadata does not match requirement %s", e)
+			return false
+		}
+	}
+
+	return HasMetadata(state, equal)
+}
@@ -81,3 +81,104 @@ func TestHasMetadata(t *testing.T) {
 		}
 	}
 }
+
+func TestParseMetadataExpr(t *testing.T) {
+	testCases := []struct {
+		in   string
+		want *MetadataExpr
+	}{
+		{"role=web", &MetadataExpr{Key: "role", Op: MetadataOpEqual, Value: "web"}},
+		{"role!=db", &MetadataExpr{Key: "role", Op: MetadataOpNotEqual, Value: "db"}},
+		{"gpu", &MetadataExpr{Key: "gpu", Op: MetadataOpExists}},
+		{"!spot", &MetadataExpr{Key: "spot", Op: MetadataOpNotExists}},
+		{"disk_gb>500", &MetadataExpr{Key: "disk_gb", Op: MetadataOpGreater, Value: "500"}},
+		{"disk_gb>=500", &MetadataExpr{Key: "disk_gb", Op: MetadataOpGreaterOrEqual, Value: "500"}},
+		{"cores<8", &MetadataExpr{Key: "cores", Op: MetadataOpLess, Value: "8"}},
+		{"load<=0.5", &MetadataExpr{Key: "load", Op: MetadataOpLessOrEqual, Value: "0.5"}},
+
+		// invalid expressions
+		{"", nil},
+		{"!", nil},
+		{"=web", nil},
+		{"role=", nil},
+		{"!role=db", nil},
+		{"role=web=db", nil},
+		{"disk_gb>=lots", nil},
+		{"role=>db", nil},
+	}
+
+	for i, tt := range testCases {
+		got, err := ParseMetadataExpr(tt.in)
+		if tt.want == nil {
+			if err == nil {
+				t.Errorf("case %d: expected error parsing %q, got %#v", i, tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("case %d: unexpected error parsing %q: %v", i, tt.in, err)
+			continue
+		}
+		if *got != *tt.want {
+			t.Errorf("case %d: parsing %q returned %#v, expected %#v", i, tt.in, *got, *tt.want)
+		}
+		if got.String() != tt.in {
+			t.Errorf("case %d: expression %#v printed as %q, expected %q", i, *got, got.String(), tt.in)
+		}
+	}
+}
+
+func TestMatchesMetadata(t *testing.T) {
+	metadata := map[string]string{
+		"role":    "web",
+		"region":  "us-east-1",
+		"gpu":     "",
+		"disk_gb": "1000",
+	}
+
+	testCases := []struct {
+		exprs []string
+		want  bool
+	}{
+		{[]string{}, true},
+		{[]string{"role=web"}, true},
+		{[]string{"role=db"}, false},
+		// equality on the same key matches any of the values
+		{[]string{"role=db", "role=web"}, true},
+		{[]string{"role!=db"}, true},
+		{[]string{"role!=web"}, false},
+		{[]string{"rack!=r1"}, true},
+		{[]string{"gpu"}, true},
+		{[]string{"spot"}, false},
+		{[]string{"!spot"}, true},
+		{[]string{"!gpu"}, false},
+		{[]string{"disk_gb>=500"}, true},
+		{[]string{"disk_gb>1000"}, false},
+		{[]string{"disk_gb<=1000"}, true},
+		{[]string{"disk_gb<500"}, false},
+		// numeric comparisons never match non-numeric or missing values
+		{[]string{"role>1"}, false},
+		{[]string{"cores>1"}, false},
+		// all other expressions must match
+		{[]string{"role=web", "!spot", "disk_gb>=500"}, true},
+		{[]string{"role=web", "!gpu", "disk_gb>=500"}, false},
+		{[]string{"role!=db", "region=us-west-1"}, false},
+	}
+
+	for i, tt := range testCases {
+		exprs := make([]MetadataExpr, 0)
+		for _, s := range tt.exprs {
+			e, err := ParseMetadataExpr(s)
+			if err != nil {
+				t.Fatalf("case %d: unexpected error parsing %q: %v", i, s, err)
+			}
+			exprs = append(exprs, *e)
+		}
+
+		ms := &MachineState{Metadata: metadata}
+		got := MatchesMetadata(ms, exprs)
+		if got != tt.want {
+			t.Errorf("case %d: MatchesMetadata returned %t, expected %t", i, got, tt.want)
+		}
+	}
+}
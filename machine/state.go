@@ -14,10 +14,24 @@
 
 package machine
 
+import (
+	"fmt"
+
+	"github.com/coreos/fleet/resource"
+)
+
 const (
 	shortIDLen = 8
 )
 
+// Scheduling states of a machine. Cordoned machines are not assigned any
+// new units, while units are moved away from draining machines as well.
+const (
+	StateSchedulable = "schedulable"
+	StateCordoned    = "cordoned"
+	StateDraining    = "draining"
+)
+
 // MachineState represents a point-in-time snapshot of the
 // state of the local host.
 type MachineState struct {
@@ -26,6 +40,45 @@ type MachineState struct {
 	Metadata     map[string]string
 	Capabilities Capabilities
 	Version      string
+
+	// TotalResources is the capacity of the host as detected by fleet,
+	// while AllocatableResources is the part of it that may be consumed
+	// by units, i.e. excluding resource.HostResources. Both are nil if
+	// the capacity of the host is unknown.
+	TotalResources       *resource.ResourceTuple `json:",omitempty"`
+	AllocatableResources *resource.ResourceTuple `json:",omitempty"`
+
+	// Unschedulable is set on machines which were cordoned or are being
+	// drained. Neither is published by the machine itself.
+	Unschedulable bool `json:",omitempty"`
+	Draining      bool `json:",omitempty"`
+}
+
+// SchedulingState returns StateSchedulable, StateCordoned or StateDraining.
+func (ms MachineState) SchedulingState() string {
+	switch {
+	case ms.Draining:
+		return StateDraining
+	case ms.Unschedulable:
+		return StateCordoned
+	}
+	return StateSchedulable
+}
+
+// SetSchedulingState sets the flags of the machine according to the given
+// scheduling state, returning an error if it is unknown.
+func (ms *MachineState) SetSchedulingState(state string) error {
+	switch state {
+	case StateSchedulable:
+		ms.Unschedulable, ms.Draining = false, false
+	case StateCordoned:
+		ms.Unschedulable, ms.Draining = true, false
+	case StateDraining:
+		ms.Unschedulable, ms.Draining = true, true
+	default:
+		return fmt.Errorf("invalid scheduling state %q", state)
+	}
+	return nil
 }
 
 func (ms MachineState) ShortID() string {
@@ -67,5 +120,13 @@ func stackState(top, bottom MachineState) MachineState {
 		state.Version = top.Version
 	}
 
+	if top.TotalResources != nil {
+		state.TotalResources = top.TotalResources
+	}
+
+	if top.AllocatableResources != nil {
+		state.AllocatableResources = top.AllocatableResources
+	}
+
 	return state
 }
@@ -86,11 +86,11 @@ var shortIDTests = []struct {
 	},
 	{
 		m: MachineState{
-			"595989bb-cbb7-49ce-8726-722d6e157b4e",
-			"5.6.7.8",
-			map[string]string{"foo": "bar"},
-			Capabilities{},
-			"",
+			ID:           "595989bb-cbb7-49ce-8726-722d6e157b4e",
+			PublicIP:     "5.6.7.8",
+			Metadata:     map[string]string{"foo": "bar"},
+			Capabilities: Capabilities{},
+			Version:      "",
 		},
 		s: "595989bb",
 		l: "595989bb-cbb7-49ce-8726-722d6e157b4e",
@@ -136,3 +136,38 @@ func TestStateMatchID(t *testing.T) {
 		}
 	}
 }
+
+func TestSetSchedulingState(t *testing.T) {
+	tests := []struct {
+		state         string
+		unschedulable bool
+		draining      bool
+		err           bool
+	}{
+		{StateSchedulable, false, false, false},
+		{StateCordoned, true, false, false},
+		{StateDraining, true, true, false},
+		{"paused", false, false, true},
+	}
+
+	for i, tt := range tests {
+		ms := MachineState{Unschedulable: true, Draining: true}
+		err := ms.SetSchedulingState(tt.state)
+		if tt.err {
+			if err == nil {
+				t.Errorf("#%d: expected error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: unexpected error: %v", i, err)
+			continue
+		}
+		if ms.Unschedulable != tt.unschedulable || ms.Draining != tt.draining {
+			t.Errorf("#%d: got Unschedulable=%t Draining=%t, want %t %t", i, ms.Unschedulable, ms.Draining, tt.unschedulable, tt.draining)
+		}
+		if g := ms.SchedulingState(); g != tt.state {
+			t.Errorf("#%d: got %q, want %q", i, g, tt.state)
+		}
+	}
+}
@@ -32,6 +32,7 @@ const (
 	MachineAway     engineFailure = "machine_away"
 	RunFailure      engineFailure = "run"
 	ScheduleFailure engineFailure = "schedule"
+	RateLimited     engineFailure = "rate_limited"
 	Get             registryOp    = "get"
 	Set             registryOp    = "set"
 	GetAll          registryOp    = "get_all"
@@ -59,6 +60,13 @@ var (
 		Help:      "Counter of engine schedule task failures.",
 	}, []string{"type"})
 
+	engineSchedulerStrategy = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "engine",
+		Name:      "scheduler_strategy",
+		Help:      "Scheduler strategy used by the engine leader, set to 1 for the active strategy.",
+	}, []string{"strategy"})
+
 	engineReconcileCount = prometheus.NewCounter(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "engine",
@@ -73,6 +81,13 @@ var (
 		Help:      "Histogram of time (in seconds) each schedule round takes.",
 	})
 
+	engineSchedulePending = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "engine",
+		Name:      "schedule_pending",
+		Help:      "Number of units deferred to the next reconcile round by schedule limits.",
+	})
+
 	engineReconcileFailureCount = prometheus.NewCounterVec(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: "engine",
@@ -106,8 +121,10 @@ func init() {
 	prometheus.MustRegister(leaderGauge)
 	prometheus.MustRegister(engineTaskCount)
 	prometheus.MustRegister(engineTaskFailureCount)
+	prometheus.MustRegister(engineSchedulerStrategy)
 	prometheus.MustRegister(engineReconcileCount)
 	prometheus.MustRegister(engineReconcileFailureCount)
+	prometheus.MustRegister(engineSchedulePending)
 }
 
 func ReportEngineLeader() {
@@ -122,6 +139,10 @@ func ReportEngineTaskFailure(task string) {
 	task = strings.ToLower(task)
 	engineTaskFailureCount.WithLabelValues(string(task)).Inc()
 }
+func ReportEngineSchedulerStrategy(strategy string) {
+	engineSchedulerStrategy.Reset()
+	engineSchedulerStrategy.WithLabelValues(strategy).Set(1)
+}
 func ReportEngineReconcileSuccess(start time.Time) {
 	engineReconcileCount.Inc()
 	engineReconcileDuration.Observe(float64(time.Since(start)) / float64(time.Second))
@@ -129,6 +150,9 @@ func ReportEngineReconcileSuccess(start time.Time) {
 func ReportEngineReconcileFailure(reason engineFailure) {
 	engineReconcileFailureCount.WithLabelValues(string(reason)).Inc()
 }
+func ReportEngineSchedulePending(n int) {
+	engineSchedulePending.Set(float64(n))
+}
 func ReportRegistryOpSuccess(op registryOp, start time.Time) {
 	registryOpCount.WithLabelValues(string(op)).Inc()
 	registryOpDuration.WithLabelValues(string(op)).Observe(float64(time.Since(start)) / float64(time.Second))
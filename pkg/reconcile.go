@@ -15,6 +15,7 @@
 package pkg
 
 import (
+	"sync"
 	"time"
 
 	"github.com/jonboulle/clockwork"
@@ -31,6 +32,80 @@ type EventStream interface {
 	Next(stop chan struct{}) chan Event
 }
 
+// MergeEventStreams combines the given EventStreams into one emitting the
+// events of all of them. Nil streams are ignored, and nil is returned if
+// none remain.
+func MergeEventStreams(streams ...EventStream) EventStream {
+	var nonNil []EventStream
+	for _, s := range streams {
+		if s != nil {
+			nonNil = append(nonNil, s)
+		}
+	}
+
+	switch len(nonNil) {
+	case 0:
+		return nil
+	case 1:
+		return nonNil[0]
+	}
+	return &mergedStream{streams: nonNil}
+}
+
+// mergedStream forwards the events of its streams from a goroutine per
+// stream, which runs until the stop channel passed to Next is closed.
+// As callers pass the same stop channel to consecutive calls of Next,
+// the goroutines are only started when a new stop channel is seen.
+type mergedStream struct {
+	streams []EventStream
+
+	mutex  sync.Mutex
+	stop   chan struct{}
+	events chan Event
+}
+
+func (ms *mergedStream) Next(stop chan struct{}) chan Event {
+	ms.mutex.Lock()
+	if stop != ms.stop {
+		ms.stop = stop
+		ms.events = make(chan Event)
+		for _, s := range ms.streams {
+			go forwardEvents(s, ms.events, stop)
+		}
+	}
+	events := ms.events
+	ms.mutex.Unlock()
+
+	evchan := make(chan Event)
+	go func() {
+		select {
+		case <-stop:
+		case ev := <-events:
+			select {
+			case evchan <- ev:
+			case <-stop:
+			}
+		}
+	}()
+
+	return evchan
+}
+
+func forwardEvents(s EventStream, events chan<- Event, stop chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case ev := <-s.Next(stop):
+			select {
+			case events <- ev:
+			case <-stop:
+				return
+			}
+		}
+	}
+}
+
 type PeriodicReconciler interface {
 	Run(stop <-chan struct{})
 }
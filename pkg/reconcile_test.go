@@ -136,3 +136,35 @@ func TestPeriodicReconcilerRun(t *testing.T) {
 		t.Fatalf("PeriodicReconciler.Run did not return after stop signal!")
 	}
 }
+
+func TestMergeEventStreams(t *testing.T) {
+	if MergeEventStreams(nil, nil) != nil {
+		t.Fatalf("expected nil stream when merging nil streams")
+	}
+	fes := &fakeEventStream{make(chan Event)}
+	if MergeEventStreams(nil, fes) != fes {
+		t.Fatalf("expected single stream to be returned as is")
+	}
+
+	fes1 := &fakeEventStream{make(chan Event)}
+	fes2 := &fakeEventStream{make(chan Event)}
+	es := MergeEventStreams(fes1, fes2)
+	stop := make(chan struct{})
+	defer close(stop)
+
+	for i, fes := range []*fakeEventStream{fes1, fes2, fes1} {
+		next := es.Next(stop)
+		select {
+		case <-next:
+			t.Fatalf("case %d: event emitted unexpectedly", i)
+		default:
+		}
+
+		fes.trigger()
+		select {
+		case <-next:
+		case <-time.After(time.Second):
+			t.Fatalf("case %d: event not emitted after trigger", i)
+		}
+	}
+}
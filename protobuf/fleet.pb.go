@@ -267,6 +267,7 @@ type UnitState struct {
 	ActiveState string `protobuf:"bytes,4,opt,name=active_state,json=activeState,proto3" json:"active_state,omitempty"`
 	SubState    string `protobuf:"bytes,5,opt,name=sub_state,json=subState,proto3" json:"sub_state,omitempty"`
 	MachineID   string `protobuf:"bytes,6,opt,name=machine_id,json=machineId,proto3" json:"machine_id,omitempty"`
+	Health      string `protobuf:"bytes,7,opt,name=health,proto3" json:"health,omitempty"`
 }
 
 func (m *UnitState) Reset()                    { *m = UnitState{} }
@@ -1722,6 +1723,12 @@ func (m *UnitState) MarshalTo(dAtA []byte) (int, error) {
 		i = encodeVarintFleet(dAtA, i, uint64(len(m.MachineID)))
 		i += copy(dAtA[i:], m.MachineID)
 	}
+	if len(m.Health) > 0 {
+		dAtA[i] = 0x3a
+		i++
+		i = encodeVarintFleet(dAtA, i, uint64(len(m.Health)))
+		i += copy(dAtA[i:], m.Health)
+	}
 	return i, nil
 }
 
@@ -2268,6 +2275,10 @@ func (m *UnitState) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovFleet(uint64(l))
 	}
+	l = len(m.Health)
+	if l > 0 {
+		n += 1 + l + sovFleet(uint64(l))
+	}
 	return n
 }
 
@@ -3926,6 +3937,35 @@ func (m *UnitState) Unmarshal(dAtA []byte) error {
 			}
 			m.MachineID = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Health", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowFleet
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthFleet
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Health = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipFleet(dAtA[iNdEx:])
@@ -4966,75 +5006,75 @@ var (
 func init() { proto.RegisterFile("fleet.proto", fileDescriptorFleet) }
 
 var fileDescriptorFleet = []byte{
-	// 1106 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xd4, 0x56, 0xdb, 0x72, 0xdb, 0x44,
-	0x18, 0x8e, 0xec, 0x38, 0xb1, 0x7f, 0x1f, 0xbb, 0x09, 0xd4, 0x09, 0x43, 0x02, 0xe2, 0xd0, 0x52,
-	0xa8, 0xc3, 0xb8, 0xd3, 0x0e, 0xa4, 0x53, 0x20, 0x89, 0x73, 0xf0, 0x90, 0x3a, 0x1d, 0x39, 0x69,
-	0x87, 0x2b, 0x8f, 0x2c, 0xfd, 0xb1, 0x35, 0x55, 0x24, 0xa3, 0x5d, 0x65, 0x26, 0xf0, 0x02, 0xdc,
-	0xf2, 0x1e, 0x3c, 0x04, 0x57, 0x4c, 0x2f, 0xfb, 0x04, 0x19, 0xc8, 0x93, 0x30, 0x7b, 0x90, 0x2c,
-	0xb9, 0x6a, 0xd3, 0x61, 0xe0, 0x82, 0x3b, 0xfd, 0x87, 0x6f, 0xff, 0xd3, 0xb7, 0xda, 0x1f, 0xca,
-	0xa7, 0x2e, 0x22, 0x6b, 0x4d, 0x02, 0x9f, 0xf9, 0x24, 0x1f, 0x4c, 0xac, 0xd5, 0xbb, 0x23, 0x87,
-	0x8d, 0xc3, 0x61, 0xcb, 0xf2, 0xcf, 0x36, 0x46, 0xfe, 0xc8, 0xdf, 0x10, 0xb6, 0x61, 0x78, 0x2a,
-	0x24, 0x21, 0x88, 0x2f, 0x89, 0xd1, 0x5b, 0x40, 0x0e, 0xd0, 0x74, 0xd9, 0x78, 0x67, 0x8c, 0xd6,
-	0x73, 0x03, 0x7f, 0x0c, 0x91, 0x32, 0xd2, 0x84, 0x45, 0x8a, 0xc1, 0xb9, 0x63, 0x61, 0x53, 0xfb,
-	0x40, 0xbb, 0x5d, 0x32, 0x22, 0x51, 0xff, 0x55, 0x83, 0xa5, 0x14, 0x80, 0x4e, 0x7c, 0x8f, 0x22,
-	0xf9, 0x06, 0x16, 0x28, 0x33, 0x59, 0x48, 0x05, 0xa0, 0xd6, 0xfe, 0xb4, 0x15, 0x4c, 0xac, 0x56,
-	0x86, 0x67, 0xab, 0xcf, 0x4f, 0xf2, 0x46, 0x7d, 0xe1, 0x6d, 0x28, 0x94, 0xbe, 0x09, 0xd5, 0x94,
-	0x81, 0x94, 0x61, 0xf1, 0xa4, 0xf7, 0x7d, 0xef, 0xe8, 0x59, 0xaf, 0x31, 0xc7, 0x85, 0xfe, 0xae,
-	0xf1, 0xb4, 0xdb, 0xdb, 0x6f, 0x68, 0xa4, 0x0e, 0xe5, 0xde, 0xd1, 0xf1, 0x20, 0x52, 0xe4, 0xf4,
-	0x8f, 0xe0, 0xc6, 0x63, 0xd3, 0x1a, 0x3b, 0x1e, 0x3e, 0x09, 0xfc, 0x09, 0x06, 0xcc, 0x41, 0x4a,
-	0x6a, 0x90, 0x73, 0x6c, 0x95, 0x7d, 0xce, 0xb1, 0xf5, 0xcf, 0xa0, 0x72, 0x32, 0xb1, 0x4d, 0x86,
-	0x36, 0x0f, 0x80, 0x64, 0x05, 0x8a, 0xa1, 0xe7, 0xb0, 0x81, 0x63, 0xf3, 0x94, 0xf3, 0xbc, 0x46,
-	0x2e, 0x77, 0x6d, 0xaa, 0xff, 0xa1, 0x41, 0xfd, 0xc4, 0x73, 0x98, 0x70, 0xdc, 0x73, 0x5c, 0x86,
-	0x01, 0x21, 0x30, 0xef, 0x99, 0x67, 0x51, 0x3b, 0xc4, 0x37, 0xd7, 0x8d, 0x4d, 0x3a, 0x6e, 0xe6,
-	0xa4, 0x8e, 0x7f, 0x93, 0xf7, 0x01, 0x5c, 0xdf, 0xb4, 0x07, 0xbc, 0x2c, 0x6c, 0xe6, 0x85, 0xa5,
-	0xc4, 0x35, 0x32, 0xea, 0x87, 0x50, 0x31, 0x2d, 0xe6, 0x9c, 0xa3, 0x72, 0x98, 0x17, 0x0e, 0x65,
-	0xa9, 0x93, 0x2e, 0xef, 0x41, 0x89, 0x86, 0x43, 0x65, 0x2f, 0x08, 0x7b, 0x91, 0x86, 0x43, 0x69,
-	0xfc, 0x02, 0xe0, 0x4c, 0x96, 0x3a, 0x70, 0xec, 0xe6, 0x02, 0xb7, 0x6e, 0x57, 0xaf, 0x2e, 0xd7,
-	0x4b, 0xaa, 0x01, 0xdd, 0x8e, 0x51, 0x52, 0x0e, 0x5d, 0x5b, 0xdf, 0x04, 0xe0, 0x75, 0xa8, 0x12,
-	0xd2, 0x58, 0xed, 0x1a, 0xec, 0x33, 0x58, 0xea, 0x5b, 0x63, 0xb4, 0x43, 0x17, 0xf9, 0x19, 0x11,
-	0x33, 0xb2, 0xfa, 0x90, 0x3e, 0x38, 0x77, 0xcd, 0xc1, 0x3f, 0xc0, 0x3b, 0x27, 0x1e, 0xfd, 0x4f,
-	0x8e, 0x7e, 0x0e, 0xcb, 0x7d, 0xf3, 0x1c, 0xe3, 0xd9, 0xbd, 0xe9, 0xe4, 0x8f, 0xa1, 0x20, 0x5b,
-	0xcc, 0x0f, 0x2d, 0xb7, 0x6b, 0x82, 0xaf, 0x53, 0xa4, 0x34, 0x92, 0x15, 0xc8, 0x33, 0xe6, 0x8a,
-	0x39, 0x16, 0xb6, 0x17, 0xaf, 0x2e, 0xd7, 0xf3, 0xc7, 0xc7, 0x87, 0x06, 0xd7, 0xe9, 0x63, 0x28,
-	0x1d, 0xa0, 0x19, 0xb0, 0x21, 0x9a, 0xff, 0x42, 0xee, 0x6f, 0x8a, 0x54, 0x83, 0xca, 0x3e, 0x7a,
-	0x18, 0x38, 0x96, 0x81, 0x13, 0xf7, 0x42, 0x6f, 0x41, 0x81, 0x27, 0x4a, 0xc9, 0x27, 0x50, 0xe0,
-	0x9c, 0x95, 0x04, 0x2e, 0xb7, 0x4b, 0x71, 0x0d, 0xdb, 0xf3, 0x2f, 0x2e, 0xd7, 0xe7, 0x0c, 0x69,
-	0xd5, 0x1f, 0x49, 0x1a, 0x88, 0xc2, 0x28, 0xd9, 0x80, 0xb2, 0x20, 0xbe, 0x28, 0x30, 0x82, 0xce,
-	0x96, 0x0f, 0x61, 0x0c, 0xd0, 0x7f, 0xd7, 0xa0, 0x14, 0x5b, 0xfe, 0x9f, 0x17, 0xe1, 0x3b, 0xa8,
-	0x45, 0x64, 0xb6, 0x65, 0xeb, 0x5a, 0xe9, 0xd6, 0x11, 0x51, 0x7f, 0xca, 0x27, 0xdd, 0xc3, 0x5f,
-	0x34, 0xa8, 0xa6, 0xcc, 0x99, 0x8d, 0xb8, 0x0f, 0x55, 0x2b, 0x0c, 0x02, 0xf4, 0x54, 0x7b, 0x45,
-	0x47, 0x6a, 0xed, 0x86, 0x38, 0xfd, 0xd8, 0x0c, 0x46, 0xa8, 0xfa, 0x5b, 0x51, 0x6e, 0x59, 0xc5,
-	0xe4, 0xaf, 0x29, 0x66, 0x0d, 0x8a, 0x3c, 0x81, 0x9e, 0xea, 0xfc, 0x6c, 0x12, 0xfa, 0x4f, 0x30,
-	0xff, 0xda, 0x04, 0x6f, 0xc1, 0x3c, 0xaf, 0x47, 0x91, 0xbe, 0x1a, 0x4f, 0x7d, 0xcf, 0x71, 0x51,
-	0x15, 0x2c, 0x1c, 0x78, 0x25, 0x36, 0x52, 0x27, 0xc0, 0xe4, 0x04, 0x33, 0x2b, 0x51, 0x6e, 0x42,
-	0xd2, 0x7f, 0x06, 0xf2, 0xd8, 0xbc, 0x18, 0x62, 0xba, 0x55, 0xb7, 0x55, 0x54, 0x4d, 0x44, 0xcd,
-	0xe8, 0xf5, 0x41, 0x14, 0xf6, 0x73, 0x28, 0x7a, 0x3e, 0x3b, 0xf5, 0x43, 0xcf, 0x4e, 0xe5, 0xd8,
-	0xf3, 0xd9, 0x1e, 0x57, 0x1e, 0xcc, 0x19, 0xb1, 0xc3, 0x76, 0x0d, 0x2a, 0x0e, 0x1d, 0x44, 0xbf,
-	0x12, 0x5b, 0x47, 0x28, 0x89, 0xe0, 0x22, 0xe6, 0x7a, 0x2a, 0xe6, 0xf4, 0x6a, 0xfc, 0xb3, 0x50,
-	0x00, 0xc5, 0xb1, 0x49, 0x07, 0x1c, 0xa8, 0x03, 0x14, 0x23, 0x1f, 0xbd, 0x23, 0x67, 0xc1, 0xdb,
-	0x47, 0xbe, 0x82, 0x8a, 0xb8, 0x58, 0xfe, 0x84, 0x39, 0xbe, 0x17, 0x31, 0xab, 0x1e, 0x47, 0x3e,
-	0x12, 0x7a, 0xd5, 0x65, 0x71, 0x07, 0xa5, 0x86, 0xea, 0x4f, 0xe4, 0x05, 0x95, 0xa2, 0x7c, 0x7c,
-	0x2d, 0xfe, 0x39, 0x7d, 0x7c, 0x85, 0x18, 0x4f, 0x34, 0x97, 0x98, 0xe8, 0x32, 0x14, 0xce, 0x4d,
-	0x37, 0x8c, 0xae, 0x98, 0x14, 0xee, 0xdc, 0x87, 0x72, 0x62, 0x48, 0xa4, 0x02, 0xc5, 0x6e, 0x6f,
-	0x6b, 0xe7, 0xb8, 0xfb, 0x74, 0xb7, 0x31, 0x47, 0x00, 0x16, 0x0e, 0x8f, 0xb6, 0x3a, 0xbb, 0x9d,
-	0x86, 0xc6, 0x2d, 0x87, 0x5b, 0x27, 0xbd, 0x9d, 0x83, 0xdd, 0x4e, 0x23, 0xd7, 0xfe, 0x6d, 0x11,
-	0x8a, 0x06, 0x8e, 0x1c, 0xca, 0x82, 0x0b, 0xf2, 0x35, 0xdc, 0xd8, 0x47, 0x36, 0x73, 0x6f, 0xea,
-	0x49, 0xca, 0x30, 0x0c, 0x56, 0x97, 0x5e, 0x9d, 0x26, 0x25, 0x9b, 0xd0, 0x98, 0x85, 0x92, 0x29,
-	0xd9, 0x38, 0x73, 0x57, 0x6f, 0x0a, 0x31, 0x93, 0x2c, 0x8b, 0xfb, 0xc8, 0xb2, 0x20, 0xb5, 0x29,
-	0x44, 0x98, 0x6f, 0x41, 0x51, 0x79, 0x66, 0xe4, 0x05, 0xb1, 0x82, 0x92, 0xbb, 0xfc, 0x07, 0xca,
-	0xa6, 0xff, 0xb0, 0xcc, 0x73, 0xa7, 0xe6, 0x07, 0x50, 0x4d, 0xba, 0x53, 0xb2, 0x9c, 0x76, 0x50,
-	0x11, 0xea, 0x69, 0x2d, 0x25, 0x0f, 0x80, 0xec, 0xb8, 0x68, 0x06, 0x82, 0x66, 0xf1, 0xd3, 0x30,
-	0x13, 0xec, 0x86, 0x10, 0x93, 0xff, 0x73, 0x72, 0x07, 0x60, 0x27, 0x40, 0x93, 0xc9, 0xaa, 0xa6,
-	0x54, 0xcd, 0xf2, 0xdd, 0x80, 0x72, 0x07, 0x29, 0x0b, 0xfc, 0x8b, 0xac, 0x0e, 0x65, 0x00, 0xda,
-	0x50, 0x4d, 0xe7, 0x53, 0x8b, 0x36, 0x33, 0x29, 0x67, 0x61, 0xee, 0x41, 0xdd, 0xc0, 0x33, 0x3f,
-	0xf1, 0x92, 0xbe, 0x45, 0xa0, 0x47, 0x50, 0x4d, 0x3d, 0xbe, 0x64, 0x45, 0x32, 0x23, 0xe3, 0x41,
-	0xce, 0x82, 0x3f, 0x84, 0x4a, 0x72, 0xdf, 0x20, 0xcd, 0x14, 0xaf, 0x12, 0x7b, 0x42, 0x36, 0x98,
-	0xf4, 0xe5, 0xc4, 0x92, 0xac, 0xcf, 0xf8, 0xd1, 0x64, 0x81, 0xbf, 0x85, 0x5a, 0x7a, 0x21, 0x21,
-	0xab, 0xaa, 0x58, 0xfa, 0x76, 0xd1, 0x37, 0xa1, 0xbc, 0x35, 0x42, 0x8f, 0xed, 0x9e, 0xa3, 0xc7,
-	0x28, 0x79, 0x57, 0xd1, 0x74, 0x66, 0x23, 0x55, 0xc8, 0xe4, 0x12, 0xfa, 0xa5, 0x46, 0x1e, 0xc2,
-	0x82, 0x5a, 0x78, 0x6f, 0xbe, 0xba, 0x31, 0xcb, 0x88, 0xcd, 0xd7, 0xad, 0xd2, 0xdb, 0x8d, 0x97,
-	0x7f, 0xad, 0x69, 0x2f, 0xae, 0xd6, 0xb4, 0x97, 0x57, 0x6b, 0xda, 0x9f, 0x57, 0x6b, 0xda, 0x70,
-	0x41, 0x6c, 0xf5, 0xf7, 0xfe, 0x0e, 0x00, 0x00, 0xff, 0xff, 0x2d, 0x64, 0x9f, 0x8f, 0x18, 0x0c,
-	0x00, 0x00,
+	// 1112 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xd4, 0x56, 0x4b, 0x73, 0xdb, 0x54,
+	0x14, 0x8e, 0xec, 0xf8, 0x75, 0xfc, 0xec, 0x6d, 0x68, 0x9d, 0x30, 0x24, 0x45, 0x3c, 0x5a, 0x0a,
+	0x75, 0x18, 0x77, 0xda, 0x81, 0x74, 0x0a, 0x24, 0x71, 0x1e, 0x1e, 0x52, 0xa7, 0x23, 0x27, 0xed,
+	0xb0, 0xf2, 0xc8, 0xd2, 0x89, 0xad, 0xa9, 0x23, 0x19, 0xdd, 0xab, 0xcc, 0x04, 0xfe, 0x00, 0x5b,
+	0xfe, 0x07, 0xbf, 0x83, 0xe9, 0xb2, 0x4b, 0x56, 0x19, 0xc8, 0x2f, 0x61, 0xee, 0x43, 0xb2, 0xe4,
+	0xaa, 0x4d, 0x87, 0x81, 0x05, 0x3b, 0x9d, 0xc7, 0x77, 0x5e, 0xf7, 0xbb, 0xba, 0x07, 0xca, 0x27,
+	0x13, 0x44, 0xd6, 0x9a, 0xfa, 0x1e, 0xf3, 0x48, 0xd6, 0x9f, 0x5a, 0x2b, 0xf7, 0x46, 0x0e, 0x1b,
+	0x07, 0xc3, 0x96, 0xe5, 0x9d, 0xae, 0x8f, 0xbc, 0x91, 0xb7, 0x2e, 0x6c, 0xc3, 0xe0, 0x44, 0x48,
+	0x42, 0x10, 0x5f, 0x12, 0xa3, 0xb7, 0x80, 0xec, 0xa3, 0x39, 0x61, 0xe3, 0xed, 0x31, 0x5a, 0x2f,
+	0x0c, 0xfc, 0x31, 0x40, 0xca, 0x48, 0x13, 0x0a, 0x14, 0xfd, 0x33, 0xc7, 0xc2, 0xa6, 0x76, 0x4b,
+	0xbb, 0x53, 0x32, 0x42, 0x51, 0xff, 0x55, 0x83, 0xeb, 0x09, 0x00, 0x9d, 0x7a, 0x2e, 0x45, 0xf2,
+	0x0d, 0xe4, 0x29, 0x33, 0x59, 0x40, 0x05, 0xa0, 0xd6, 0xfe, 0xb4, 0xe5, 0x4f, 0xad, 0x56, 0x8a,
+	0x67, 0xab, 0xcf, 0x23, 0xb9, 0xa3, 0xbe, 0xf0, 0x36, 0x14, 0x4a, 0xdf, 0x80, 0x6a, 0xc2, 0x40,
+	0xca, 0x50, 0x38, 0xee, 0x7d, 0xdf, 0x3b, 0x7c, 0xde, 0x6b, 0x2c, 0x70, 0xa1, 0xbf, 0x63, 0x3c,
+	0xeb, 0xf6, 0xf6, 0x1a, 0x1a, 0xa9, 0x43, 0xb9, 0x77, 0x78, 0x34, 0x08, 0x15, 0x19, 0xfd, 0x23,
+	0xb8, 0xf6, 0xc4, 0xb4, 0xc6, 0x8e, 0x8b, 0x4f, 0x7d, 0x6f, 0x8a, 0x3e, 0x73, 0x90, 0x92, 0x1a,
+	0x64, 0x1c, 0x5b, 0x55, 0x9f, 0x71, 0x6c, 0xfd, 0x33, 0xa8, 0x1c, 0x4f, 0x6d, 0x93, 0xa1, 0xcd,
+	0x13, 0x20, 0x59, 0x86, 0x62, 0xe0, 0x3a, 0x6c, 0xe0, 0xd8, 0xbc, 0xe4, 0x2c, 0xef, 0x91, 0xcb,
+	0x5d, 0x9b, 0xea, 0xbf, 0x6b, 0x50, 0x3f, 0x76, 0x1d, 0x26, 0x1c, 0x77, 0x9d, 0x09, 0x43, 0x9f,
+	0x10, 0x58, 0x74, 0xcd, 0xd3, 0x70, 0x1c, 0xe2, 0x9b, 0xeb, 0xc6, 0x26, 0x1d, 0x37, 0x33, 0x52,
+	0xc7, 0xbf, 0xc9, 0x07, 0x00, 0x13, 0xcf, 0xb4, 0x07, 0xbc, 0x2d, 0x6c, 0x66, 0x85, 0xa5, 0xc4,
+	0x35, 0x32, 0xeb, 0x87, 0x50, 0x31, 0x2d, 0xe6, 0x9c, 0xa1, 0x72, 0x58, 0x14, 0x0e, 0x65, 0xa9,
+	0x93, 0x2e, 0xef, 0x43, 0x89, 0x06, 0x43, 0x65, 0xcf, 0x09, 0x7b, 0x91, 0x06, 0x43, 0x69, 0xfc,
+	0x02, 0xe0, 0x54, 0xb6, 0x3a, 0x70, 0xec, 0x66, 0x9e, 0x5b, 0xb7, 0xaa, 0x97, 0x17, 0x6b, 0x25,
+	0x35, 0x80, 0x6e, 0xc7, 0x28, 0x29, 0x87, 0xae, 0xad, 0x6f, 0x00, 0xf0, 0x3e, 0x54, 0x0b, 0x49,
+	0xac, 0x76, 0x05, 0xf6, 0x39, 0x5c, 0xef, 0x5b, 0x63, 0xb4, 0x83, 0x09, 0xf2, 0x18, 0x21, 0x33,
+	0xd2, 0xe6, 0x90, 0x0c, 0x9c, 0xb9, 0x22, 0xf0, 0x0f, 0xf0, 0xde, 0xb1, 0x4b, 0xff, 0x93, 0xd0,
+	0x2f, 0x60, 0xa9, 0x6f, 0x9e, 0x61, 0x74, 0x76, 0x6f, 0x8b, 0xfc, 0x31, 0xe4, 0xe4, 0x88, 0x79,
+	0xd0, 0x72, 0xbb, 0x26, 0xf8, 0x3a, 0x43, 0x4a, 0x23, 0x59, 0x86, 0x2c, 0x63, 0x13, 0x71, 0x8e,
+	0xb9, 0xad, 0xc2, 0xe5, 0xc5, 0x5a, 0xf6, 0xe8, 0xe8, 0xc0, 0xe0, 0x3a, 0x7d, 0x0c, 0xa5, 0x7d,
+	0x34, 0x7d, 0x36, 0x44, 0xf3, 0x5f, 0xa8, 0xfd, 0x6d, 0x99, 0x6a, 0x50, 0xd9, 0x43, 0x17, 0x7d,
+	0xc7, 0x32, 0x70, 0x3a, 0x39, 0xd7, 0x5b, 0x90, 0xe3, 0x85, 0x52, 0xf2, 0x09, 0xe4, 0x38, 0x67,
+	0x25, 0x81, 0xcb, 0xed, 0x52, 0xd4, 0xc3, 0xd6, 0xe2, 0xcb, 0x8b, 0xb5, 0x05, 0x43, 0x5a, 0xf5,
+	0xc7, 0x00, 0x51, 0x63, 0x94, 0xac, 0x43, 0x59, 0x10, 0x5f, 0x34, 0x18, 0x42, 0xe7, 0xdb, 0x87,
+	0x20, 0x02, 0xe8, 0x7f, 0x68, 0x50, 0x8a, 0x2c, 0xff, 0xcb, 0x8b, 0x40, 0x6e, 0x40, 0x7e, 0x2c,
+	0x7e, 0x45, 0xcd, 0x82, 0x88, 0xa3, 0x24, 0xfd, 0x3b, 0xa8, 0x85, 0x24, 0xb7, 0xe5, 0x48, 0x5b,
+	0xc9, 0x91, 0x12, 0x31, 0x97, 0x84, 0x4f, 0x72, 0xb6, 0xbf, 0x68, 0x50, 0x4d, 0x98, 0x53, 0x07,
+	0xf4, 0x00, 0xaa, 0x56, 0xe0, 0xfb, 0xe8, 0xaa, 0xb1, 0x8b, 0x49, 0xd5, 0xda, 0x0d, 0x11, 0xfd,
+	0xc8, 0xf4, 0x47, 0xa8, 0xe6, 0x5e, 0x51, 0x6e, 0x69, 0x4d, 0x66, 0xaf, 0x60, 0xff, 0x2a, 0x14,
+	0x79, 0x01, 0x3d, 0x75, 0x22, 0xf3, 0x45, 0xe8, 0x3f, 0xc1, 0xe2, 0x1b, 0x0b, 0xbc, 0x0d, 0x8b,
+	0xbc, 0x1f, 0x75, 0x19, 0xaa, 0x11, 0x1b, 0x76, 0x9d, 0x09, 0xaa, 0x86, 0x85, 0x03, 0xef, 0xc4,
+	0x46, 0xea, 0xf8, 0x18, 0x3f, 0xd9, 0xd4, 0x4e, 0x94, 0x9b, 0x90, 0xf4, 0x9f, 0x81, 0x3c, 0x31,
+	0xcf, 0x87, 0x98, 0x1c, 0xd5, 0x1d, 0x95, 0x55, 0xbb, 0xa5, 0xa5, 0xcf, 0x7a, 0x3f, 0x4c, 0xfb,
+	0x39, 0x14, 0x5d, 0x8f, 0x9d, 0x78, 0x81, 0x6b, 0x27, 0x6a, 0xec, 0x79, 0x6c, 0x97, 0x2b, 0xf7,
+	0x17, 0x8c, 0xc8, 0x61, 0xab, 0x06, 0x15, 0x87, 0x0e, 0xc2, 0x5f, 0x8c, 0xad, 0x23, 0x94, 0x44,
+	0x72, 0x91, 0x73, 0x2d, 0x91, 0x73, 0x76, 0x65, 0xfe, 0x59, 0x2a, 0x80, 0xe2, 0xd8, 0xa4, 0x03,
+	0x0e, 0xd4, 0x01, 0x8a, 0xa1, 0x8f, 0xde, 0x81, 0x62, 0x38, 0x3e, 0xf2, 0x15, 0x54, 0xc4, 0x85,
+	0xf3, 0xa6, 0xcc, 0xf1, 0xdc, 0x90, 0x59, 0xf5, 0x28, 0xf3, 0xa1, 0xd0, 0xab, 0x29, 0x97, 0x83,
+	0x48, 0x43, 0xf5, 0xa7, 0xf2, 0xe2, 0x4a, 0x51, 0x3e, 0xca, 0x16, 0xff, 0x9c, 0x3d, 0xca, 0x42,
+	0x8c, 0x4e, 0x34, 0x13, 0x3b, 0xd1, 0x25, 0xc8, 0x9d, 0x99, 0x93, 0x20, 0xbc, 0x7a, 0x52, 0xb8,
+	0xfb, 0x00, 0xca, 0xb1, 0x43, 0x22, 0x15, 0x28, 0x76, 0x7b, 0x9b, 0xdb, 0x47, 0xdd, 0x67, 0x3b,
+	0x8d, 0x05, 0x02, 0x90, 0x3f, 0x38, 0xdc, 0xec, 0xec, 0x74, 0x1a, 0x1a, 0xb7, 0x1c, 0x6c, 0x1e,
+	0xf7, 0xb6, 0xf7, 0x77, 0x3a, 0x8d, 0x4c, 0xfb, 0xb7, 0x02, 0x14, 0x0d, 0x1c, 0x39, 0x94, 0xf9,
+	0xe7, 0xe4, 0x6b, 0xb8, 0xb6, 0x87, 0x6c, 0xee, 0xde, 0xd4, 0xe3, 0x94, 0x61, 0xe8, 0xaf, 0x5c,
+	0x7f, 0xfd, 0x34, 0x29, 0xd9, 0x80, 0xc6, 0x3c, 0x94, 0xcc, 0xc8, 0xc6, 0x99, 0xbb, 0x72, 0x53,
+	0x88, 0xa9, 0x64, 0x29, 0xec, 0x21, 0x4b, 0x83, 0xd4, 0x66, 0x10, 0x61, 0xbe, 0x0d, 0x45, 0xe5,
+	0x99, 0x52, 0x17, 0x44, 0x0a, 0x4a, 0xee, 0x41, 0x45, 0x39, 0xca, 0x71, 0xa4, 0xc6, 0x9d, 0x99,
+	0x1f, 0x42, 0x35, 0xee, 0x4e, 0xc9, 0x52, 0xd2, 0x41, 0x65, 0xa8, 0x27, 0xb5, 0x94, 0x3c, 0x04,
+	0xb2, 0x3d, 0x41, 0xd3, 0x17, 0x34, 0x8b, 0x9e, 0x8c, 0xb9, 0x64, 0xd7, 0x84, 0x18, 0xff, 0xcf,
+	0x93, 0xbb, 0x00, 0xdb, 0x3e, 0x9a, 0x4c, 0x76, 0x35, 0xa3, 0x6a, 0x9a, 0xef, 0x3a, 0x94, 0x3b,
+	0x48, 0x99, 0xef, 0x9d, 0xa7, 0x4d, 0x28, 0x05, 0xd0, 0x86, 0x6a, 0xb2, 0x9e, 0x5a, 0xb8, 0xb1,
+	0x49, 0x39, 0x0d, 0x73, 0x1f, 0xea, 0x06, 0x9e, 0x7a, 0xb1, 0x17, 0xf6, 0x1d, 0x12, 0x3d, 0x86,
+	0x6a, 0xe2, 0x51, 0x26, 0xcb, 0x92, 0x19, 0x29, 0x0f, 0x75, 0x1a, 0xfc, 0x11, 0x54, 0xe2, 0x7b,
+	0x08, 0x69, 0x26, 0x78, 0x15, 0xdb, 0x1f, 0xd2, 0xc1, 0xa4, 0x2f, 0x4f, 0x2c, 0xce, 0xfa, 0x94,
+	0x1f, 0x4d, 0x1a, 0xf8, 0x5b, 0xa8, 0x25, 0x17, 0x15, 0xb2, 0xa2, 0x9a, 0xa5, 0xef, 0x96, 0x7d,
+	0x03, 0xca, 0x9b, 0x23, 0x74, 0xd9, 0xce, 0x19, 0xba, 0x8c, 0x92, 0x1b, 0x8a, 0xa6, 0x73, 0x9b,
+	0xaa, 0x42, 0xc6, 0x97, 0xd3, 0x2f, 0x35, 0xf2, 0x08, 0xf2, 0x6a, 0x11, 0xbe, 0xf9, 0xfa, 0x26,
+	0x2d, 0x33, 0x36, 0xdf, 0xb4, 0x62, 0x6f, 0x35, 0x5e, 0xfd, 0xb5, 0xaa, 0xbd, 0xbc, 0x5c, 0xd5,
+	0x5e, 0x5d, 0xae, 0x6a, 0x7f, 0x5e, 0xae, 0x6a, 0xc3, 0xbc, 0xd8, 0xf6, 0xef, 0xff, 0x3d, 0x00,
+	0x45, 0xb0, 0xa0, 0x8b, 0x30, 0x0c, 0x00, 0x00,
 }
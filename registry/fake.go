@@ -44,10 +44,16 @@ type FakeRegistry struct {
 	Registry
 	sync.RWMutex
 
-	machines      []machine.MachineState
-	jobStates     map[string]map[string]*unit.UnitState
-	jobs          map[string]job.Job
-	daemonVersion *semver.Version
+	machines        []machine.MachineState
+	jobStates       map[string]map[string]*unit.UnitState
+	jobs            map[string]job.Job
+	explanations    map[string]job.SchedulingExplanation
+	lostMachines    map[string]time.Time
+	unitFailures    map[string][]UnitFailure
+	reconcileStatus *ReconcileStatus
+	history         map[string][]UnitStateTransition
+	replicas        map[string]int
+	daemonVersion   *semver.Version
 }
 
 func (f *FakeRegistry) SetMachines(machines []machine.MachineState) {
@@ -183,15 +189,13 @@ func (f *FakeRegistry) CreateUnit(u *job.Unit) error {
 	f.Lock()
 	defer f.Unlock()
 
-	_, ok := f.jobs[u.Name]
-	if ok {
-		return errors.New("unit already exists")
-	}
-
-	j := job.Job{
-		Name: u.Name,
-		Unit: u.Unit,
+	// like the EtcdRegistry, existing units are replaced, keeping
+	// their schedule
+	j, ok := f.jobs[u.Name]
+	if !ok {
+		j = job.Job{Name: u.Name}
 	}
+	j.Unit = u.Unit
 
 	f.jobs[u.Name] = j
 	return f.unsafeSetUnitTargetState(u.Name, u.TargetState)
@@ -202,6 +206,32 @@ func (f *FakeRegistry) DestroyUnit(name string) error {
 	defer f.Unlock()
 
 	delete(f.jobs, name)
+	delete(f.history, name)
+	delete(f.replicas, name)
+	delete(f.explanations, name)
+	delete(f.unitFailures, name)
+	return nil
+}
+
+func (f *FakeRegistry) UnitReplicas() (map[string]int, error) {
+	f.RLock()
+	defer f.RUnlock()
+
+	replicas := make(map[string]int, len(f.replicas))
+	for name, n := range f.replicas {
+		replicas[name] = n
+	}
+	return replicas, nil
+}
+
+func (f *FakeRegistry) SetUnitReplicas(name string, replicas int) error {
+	f.Lock()
+	defer f.Unlock()
+
+	if f.replicas == nil {
+		f.replicas = make(map[string]int)
+	}
+	f.replicas[name] = replicas
 	return nil
 }
 
@@ -241,6 +271,21 @@ func (f *FakeRegistry) ScheduleUnit(name string, machID string) error {
 	return nil
 }
 
+func (f *FakeRegistry) UnscheduleUnit(name, machID string) error {
+	f.Lock()
+	defer f.Unlock()
+
+	// like the EtcdRegistry, only units scheduled to the given machine
+	// are unscheduled
+	j, ok := f.jobs[name]
+	if ok && j.TargetMachineID == machID {
+		j.TargetMachineID = ""
+		f.jobs[name] = j
+	}
+
+	return nil
+}
+
 func (f *FakeRegistry) SaveUnitState(jobName string, unitState *unit.UnitState, ttl time.Duration) {
 	f.Lock()
 	defer f.Unlock()
@@ -323,6 +368,20 @@ func (f *FakeRegistry) SetMachineMetadata(machID string, key string, value strin
 	return nil
 }
 
+func (f *FakeRegistry) SetMachineSchedulingState(machID string, state string) error {
+	f.Lock()
+	defer f.Unlock()
+
+	for i := range f.machines {
+		if f.machines[i].ID == machID {
+			if err := f.machines[i].SetSchedulingState(state); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func (f *FakeRegistry) DeleteMachineMetadata(machID string, key string) error {
 	for _, mach := range f.machines {
 		if mach.ID == machID {
@@ -345,6 +404,140 @@ func (f *FakeRegistry) MachineState(machID string) (machine.MachineState, error)
 	return machine.MachineState{}, errors.New("Machine state not found")
 }
 
+func (f *FakeRegistry) SchedulingExplanations() ([]job.SchedulingExplanation, error) {
+	f.RLock()
+	defer f.RUnlock()
+
+	names := make([]string, 0, len(f.explanations))
+	for name := range f.explanations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var exps []job.SchedulingExplanation
+	for _, name := range names {
+		exps = append(exps, f.explanations[name])
+	}
+	return exps, nil
+}
+
+func (f *FakeRegistry) SchedulingExplanation(name string) (*job.SchedulingExplanation, error) {
+	f.RLock()
+	defer f.RUnlock()
+
+	exp, ok := f.explanations[name]
+	if !ok {
+		return nil, nil
+	}
+	return &exp, nil
+}
+
+func (f *FakeRegistry) SetSchedulingExplanation(exp job.SchedulingExplanation) error {
+	f.Lock()
+	defer f.Unlock()
+
+	if f.explanations == nil {
+		f.explanations = make(map[string]job.SchedulingExplanation)
+	}
+	f.explanations[exp.Name] = exp
+	return nil
+}
+
+func (f *FakeRegistry) RemoveSchedulingExplanation(name string) error {
+	f.Lock()
+	defer f.Unlock()
+
+	delete(f.explanations, name)
+	return nil
+}
+
+func (f *FakeRegistry) LostMachines() (map[string]time.Time, error) {
+	f.RLock()
+	defer f.RUnlock()
+
+	return f.lostMachines, nil
+}
+
+func (f *FakeRegistry) SetLostMachines(lost map[string]time.Time) error {
+	f.Lock()
+	defer f.Unlock()
+
+	f.lostMachines = lost
+	return nil
+}
+
+func (f *FakeRegistry) UnitFailures() ([]UnitFailure, error) {
+	f.RLock()
+	defer f.RUnlock()
+
+	names := make([]string, 0, len(f.unitFailures))
+	for name := range f.unitFailures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var failures []UnitFailure
+	for _, name := range names {
+		failures = append(failures, f.unitFailures[name]...)
+	}
+	return failures, nil
+}
+
+func (f *FakeRegistry) SetUnitFailures(name string, failures []UnitFailure) error {
+	f.Lock()
+	defer f.Unlock()
+
+	if f.unitFailures == nil {
+		f.unitFailures = make(map[string][]UnitFailure)
+	}
+	f.unitFailures[name] = failures
+	return nil
+}
+
+func (f *FakeRegistry) RemoveUnitFailures(name string) error {
+	f.Lock()
+	defer f.Unlock()
+
+	delete(f.unitFailures, name)
+	return nil
+}
+
+func (f *FakeRegistry) ReconcileStatus() (*ReconcileStatus, error) {
+	f.RLock()
+	defer f.RUnlock()
+
+	return f.reconcileStatus, nil
+}
+
+func (f *FakeRegistry) SetReconcileStatus(status ReconcileStatus) error {
+	f.Lock()
+	defer f.Unlock()
+
+	f.reconcileStatus = &status
+	return nil
+}
+
+func (f *FakeRegistry) UnitStateHistory(name string) ([]UnitStateTransition, error) {
+	f.RLock()
+	defer f.RUnlock()
+
+	return f.history[name], nil
+}
+
+func (f *FakeRegistry) RecordUnitStateTransition(name string, t UnitStateTransition) error {
+	f.Lock()
+	defer f.Unlock()
+
+	if _, ok := f.jobs[name]; !ok {
+		return nil
+	}
+	if f.history == nil {
+		f.history = make(map[string][]UnitStateTransition)
+	}
+	f.history[name], _ = appendTransition(f.history[name], t)
+	return nil
+}
+
 func NewFakeClusterRegistry(dVersion *semver.Version, eVersion int) *FakeClusterRegistry {
 	return &FakeClusterRegistry{
 		dVersion: dVersion,
@@ -0,0 +1,160 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"sort"
+	"time"
+
+	etcd "github.com/coreos/etcd/client"
+	"golang.org/x/net/context"
+)
+
+const (
+	// Namespace for the state transitions of units
+	historyPrefix = "/history/"
+
+	// UnitHistorySize is the number of state transitions kept per Unit
+	UnitHistorySize = 20
+
+	// attempts at recording a transition while other machines record
+	// transitions of the same Unit
+	historyRecordAttempts = 5
+)
+
+// UnitStateTransition records a Unit entering a new state on a machine.
+type UnitStateTransition struct {
+	Time        time.Time
+	MachineID   string
+	LoadState   string
+	ActiveState string
+	SubState    string
+	UnitHash    string
+}
+
+func (t UnitStateTransition) sameState(o UnitStateTransition) bool {
+	return t.LoadState == o.LoadState && t.ActiveState == o.ActiveState &&
+		t.SubState == o.SubState && t.UnitHash == o.UnitHash
+}
+
+type unitStateTransitions []UnitStateTransition
+
+func (ts unitStateTransitions) Len() int           { return len(ts) }
+func (ts unitStateTransitions) Swap(i, j int)      { ts[i], ts[j] = ts[j], ts[i] }
+func (ts unitStateTransitions) Less(i, j int) bool { return ts[i].Time.Before(ts[j].Time) }
+
+// appendTransition adds the given transition to the history, oldest
+// transition first, and compacts it to the latest UnitHistorySize
+// transitions. The history is returned unchanged, along with false, if
+// the latest transition of the Unit on the same machine already left it
+// in the same state, e.g. when an agent restarted.
+func appendTransition(history []UnitStateTransition, t UnitStateTransition) ([]UnitStateTransition, bool) {
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].MachineID != t.MachineID {
+			continue
+		}
+		if history[i].sameState(t) {
+			return history, false
+		}
+		break
+	}
+
+	updated := make([]UnitStateTransition, len(history), len(history)+1)
+	copy(updated, history)
+	updated = append(updated, t)
+	sort.Stable(unitStateTransitions(updated))
+	if len(updated) > UnitHistorySize {
+		updated = updated[len(updated)-UnitHistorySize:]
+	}
+	return updated, true
+}
+
+// UnitStateHistory returns the latest state transitions of the Unit of the
+// given name on any machine, oldest transition first.
+func (r *EtcdRegistry) UnitStateHistory(name string) ([]UnitStateTransition, error) {
+	history, _, err := r.unitStateHistory(name)
+	return history, err
+}
+
+func (r *EtcdRegistry) unitStateHistory(name string) ([]UnitStateTransition, uint64, error) {
+	res, err := r.kAPI.Get(context.Background(), r.unitHistoryPath(name), nil)
+	if err != nil {
+		if isEtcdError(err, etcd.ErrorCodeKeyNotFound) {
+			err = nil
+		}
+		return nil, 0, err
+	}
+
+	var history []UnitStateTransition
+	if err := unmarshal(res.Node.Value, &history); err != nil {
+		return nil, 0, err
+	}
+	return history, res.Node.ModifiedIndex, nil
+}
+
+// RecordUnitStateTransition adds the given transition to the history of
+// the Unit of the given name, see appendTransition. The whole history of
+// a Unit is stored in a single key, which is compared-and-swapped as
+// several machines may record transitions of the Unit at once. Units not
+// known to the Registry get no history, so that none is left behind by
+// Units destroyed meanwhile.
+func (r *EtcdRegistry) RecordUnitStateTransition(name string, t UnitStateTransition) error {
+	key := r.unitHistoryPath(name)
+	for attempt := 1; ; attempt++ {
+		history, index, err := r.unitStateHistory(name)
+		if err != nil {
+			return err
+		}
+
+		updated, changed := appendTransition(history, t)
+		if !changed {
+			return nil
+		}
+		val, err := marshal(updated)
+		if err != nil {
+			return err
+		}
+
+		opts := &etcd.SetOptions{PrevIndex: index}
+		if index == 0 {
+			u, err := r.Unit(name)
+			if err != nil || u == nil {
+				return err
+			}
+			opts.PrevExist = etcd.PrevNoExist
+		}
+
+		_, err = r.kAPI.Set(context.Background(), key, val, opts)
+		if err == nil || attempt == historyRecordAttempts {
+			return err
+		}
+		if !isEtcdError(err, etcd.ErrorCodeTestFailed) && !isEtcdError(err, etcd.ErrorCodeNodeExist) {
+			return err
+		}
+	}
+}
+
+// removeUnitStateHistory deletes the history of the Unit of the given name.
+func (r *EtcdRegistry) removeUnitStateHistory(name string) error {
+	_, err := r.kAPI.Delete(context.Background(), r.unitHistoryPath(name), nil)
+	if isEtcdError(err, etcd.ErrorCodeKeyNotFound) {
+		err = nil
+	}
+	return err
+}
+
+func (r *EtcdRegistry) unitHistoryPath(name string) string {
+	return r.prefixed(historyPrefix, name)
+}
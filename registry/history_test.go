@@ -0,0 +1,191 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	etcd "github.com/coreos/etcd/client"
+
+	"github.com/coreos/fleet/job"
+)
+
+func TestAppendTransition(t *testing.T) {
+	at := func(sec int, machID, active, hash string) UnitStateTransition {
+		return UnitStateTransition{
+			Time:        time.Unix(int64(sec), 0),
+			MachineID:   machID,
+			LoadState:   "loaded",
+			ActiveState: active,
+			SubState:    active,
+			UnitHash:    hash,
+		}
+	}
+
+	full := make([]UnitStateTransition, UnitHistorySize)
+	for i := range full {
+		full[i] = at(i, "XXX", []string{"active", "inactive"}[i%2], "abc")
+	}
+
+	tests := []struct {
+		history []UnitStateTransition
+		t       UnitStateTransition
+		want    []UnitStateTransition
+		changed bool
+	}{
+		// the first transition starts the history
+		{
+			history: nil,
+			t:       at(1, "XXX", "active", "abc"),
+			want:    []UnitStateTransition{at(1, "XXX", "active", "abc")},
+			changed: true,
+		},
+		// the same state on the same machine is not recorded again
+		{
+			history: []UnitStateTransition{at(1, "XXX", "active", "abc")},
+			t:       at(2, "XXX", "active", "abc"),
+			want:    []UnitStateTransition{at(1, "XXX", "active", "abc")},
+			changed: false,
+		},
+		// unless the unit hash changed
+		{
+			history: []UnitStateTransition{at(1, "XXX", "active", "abc")},
+			t:       at(2, "XXX", "active", "def"),
+			want:    []UnitStateTransition{at(1, "XXX", "active", "abc"), at(2, "XXX", "active", "def")},
+			changed: true,
+		},
+		// only the latest transition on the same machine is compared
+		{
+			history: []UnitStateTransition{at(1, "XXX", "active", "abc"), at(2, "YYY", "inactive", "abc")},
+			t:       at(3, "XXX", "inactive", "abc"),
+			want:    []UnitStateTransition{at(1, "XXX", "active", "abc"), at(2, "YYY", "inactive", "abc"), at(3, "XXX", "inactive", "abc")},
+			changed: true,
+		},
+		{
+			history: []UnitStateTransition{at(1, "XXX", "active", "abc"), at(2, "YYY", "inactive", "abc")},
+			t:       at(3, "XXX", "active", "abc"),
+			want:    []UnitStateTransition{at(1, "XXX", "active", "abc"), at(2, "YYY", "inactive", "abc")},
+			changed: false,
+		},
+		// transitions are kept in order of time across machines
+		{
+			history: []UnitStateTransition{at(1, "XXX", "active", "abc"), at(3, "YYY", "active", "abc")},
+			t:       at(2, "XXX", "inactive", "abc"),
+			want:    []UnitStateTransition{at(1, "XXX", "active", "abc"), at(2, "XXX", "inactive", "abc"), at(3, "YYY", "active", "abc")},
+			changed: true,
+		},
+		// the oldest transition is dropped from a full history
+		{
+			history: full,
+			t:       at(UnitHistorySize, "YYY", "active", "abc"),
+			want:    append(append([]UnitStateTransition{}, full[1:]...), at(UnitHistorySize, "YYY", "active", "abc")),
+			changed: true,
+		},
+	}
+
+	for i, tt := range tests {
+		var before []UnitStateTransition
+		if tt.history != nil {
+			before = append(before, tt.history...)
+		}
+		got, changed := appendTransition(tt.history, tt.t)
+		if changed != tt.changed {
+			t.Errorf("case %d: expected changed %t, got %t", i, tt.changed, changed)
+		}
+		if !reflect.DeepEqual(tt.want, got) {
+			t.Errorf("case %d: unexpected history\nexpected %v\n     got %v", i, tt.want, got)
+		}
+		if !reflect.DeepEqual(before, tt.history) {
+			t.Errorf("case %d: history passed in was modified", i)
+		}
+	}
+}
+
+func TestFakeRegistryUnitStateHistory(t *testing.T) {
+	r := NewFakeRegistry()
+	tr := UnitStateTransition{Time: time.Unix(1, 0), MachineID: "XXX", LoadState: "loaded", ActiveState: "active", SubState: "running", UnitHash: "abc"}
+
+	// units unknown to the registry get no history
+	if err := r.RecordUnitStateTransition("foo.service", tr); err != nil {
+		t.Fatalf("Failed recording transition: %v", err)
+	}
+	if h, err := r.UnitStateHistory("foo.service"); err != nil || len(h) != 0 {
+		t.Fatalf("expected no history, got %v, %v", h, err)
+	}
+
+	if err := r.CreateUnit(&job.Unit{Name: "foo.service"}); err != nil {
+		t.Fatalf("Failed creating unit: %v", err)
+	}
+	if err := r.RecordUnitStateTransition("foo.service", tr); err != nil {
+		t.Fatalf("Failed recording transition: %v", err)
+	}
+	if h, err := r.UnitStateHistory("foo.service"); err != nil || !reflect.DeepEqual([]UnitStateTransition{tr}, h) {
+		t.Fatalf("unexpected history %v, %v", h, err)
+	}
+
+	// the history goes along with the unit
+	if err := r.DestroyUnit("foo.service"); err != nil {
+		t.Fatalf("Failed destroying unit: %v", err)
+	}
+	if h, err := r.UnitStateHistory("foo.service"); err != nil || len(h) != 0 {
+		t.Fatalf("expected no history, got %v, %v", h, err)
+	}
+}
+
+func TestRecordUnitStateTransition(t *testing.T) {
+	tr := UnitStateTransition{Time: time.Unix(2, 0).UTC(), MachineID: "XXX", LoadState: "loaded", ActiveState: "active", SubState: "running", UnitHash: "abc"}
+	old := UnitStateTransition{Time: time.Unix(1, 0).UTC(), MachineID: "YYY", LoadState: "loaded", ActiveState: "inactive", SubState: "dead", UnitHash: "abc"}
+	val, err := marshal([]UnitStateTransition{old})
+	if err != nil {
+		t.Fatalf("Failed marshaling history: %v", err)
+	}
+	want, err := marshal([]UnitStateTransition{old, tr})
+	if err != nil {
+		t.Fatalf("Failed marshaling history: %v", err)
+	}
+	stored := &etcd.Response{Node: &etcd.Node{Key: "/fleet/history/foo.service", Value: val, ModifiedIndex: 7}}
+
+	// a transition recorded by another machine meanwhile is retried
+	e := &testEtcdKeysAPI{
+		res: []*etcd.Response{stored, nil, stored, nil},
+		err: []error{nil, etcd.Error{Code: etcd.ErrorCodeTestFailed}, nil, nil},
+	}
+	r := &EtcdRegistry{kAPI: e, keyPrefix: "/fleet/"}
+	if err := r.RecordUnitStateTransition("foo.service", tr); err != nil {
+		t.Fatalf("Failed recording transition: %v", err)
+	}
+	wantSets := []action{
+		{key: "/fleet/history/foo.service", val: want},
+		{key: "/fleet/history/foo.service", val: want},
+	}
+	if !reflect.DeepEqual(wantSets, e.sets) {
+		t.Errorf("unexpected sets\nexpected %#v\n     got %#v", wantSets, e.sets)
+	}
+
+	// other errors are returned as is
+	e = &testEtcdKeysAPI{
+		res: []*etcd.Response{stored},
+		err: []error{nil, etcd.Error{Code: etcd.ErrorCodeRaftInternal}},
+	}
+	r = &EtcdRegistry{kAPI: e, keyPrefix: "/fleet/"}
+	if err := r.RecordUnitStateTransition("foo.service", tr); err == nil {
+		t.Errorf("expected error recording transition")
+	}
+	if len(e.sets) != 1 {
+		t.Errorf("expected a single set, got %v", e.sets)
+	}
+}
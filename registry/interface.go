@@ -36,11 +36,27 @@ type Registry interface {
 	SaveUnitState(jobName string, unitState *unit.UnitState, ttl time.Duration)
 	ScheduleUnit(name, machID string) error
 	SetUnitTargetState(name string, state job.JobState) error
+	UnitReplicas() (map[string]int, error)
+	SetUnitReplicas(name string, replicas int) error
 	SetMachineState(ms machine.MachineState, ttl time.Duration) (uint64, error)
 	MachineState(machID string) (machine.MachineState, error)
 	UnscheduleUnit(name, machID string) error
 	SetMachineMetadata(machID string, key string, value string) error
 	DeleteMachineMetadata(machID string, key string) error
+	SetMachineSchedulingState(machID string, state string) error
+	SchedulingExplanations() ([]job.SchedulingExplanation, error)
+	SchedulingExplanation(name string) (*job.SchedulingExplanation, error)
+	SetSchedulingExplanation(exp job.SchedulingExplanation) error
+	RemoveSchedulingExplanation(name string) error
+	LostMachines() (map[string]time.Time, error)
+	SetLostMachines(lost map[string]time.Time) error
+	UnitFailures() ([]UnitFailure, error)
+	SetUnitFailures(name string, failures []UnitFailure) error
+	RemoveUnitFailures(name string) error
+	ReconcileStatus() (*ReconcileStatus, error)
+	SetReconcileStatus(status ReconcileStatus) error
+	UnitStateHistory(name string) ([]UnitStateTransition, error)
+	RecordUnitStateTransition(name string, t UnitStateTransition) error
 
 	IsRegistryReady() bool
 	UseEtcdRegistry() bool
@@ -307,6 +307,20 @@ func (r *EtcdRegistry) DestroyUnit(name string) error {
 		return err
 	}
 
+	// the history and replica count of the Unit go along with it
+	if err := r.removeUnitStateHistory(name); err != nil {
+		return err
+	}
+	if err := r.removeUnitReplicas(name); err != nil {
+		return err
+	}
+	if err := r.RemoveSchedulingExplanation(name); err != nil {
+		return err
+	}
+	if err := r.RemoveUnitFailures(name); err != nil {
+		return err
+	}
+
 	// TODO(jonboulle): add unit reference counting and actually destroying Units
 	return nil
 }
@@ -0,0 +1,54 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"errors"
+
+	"github.com/coreos/fleet/pkg/lease"
+)
+
+// EngineLeaseName is the name of the lease that must be held by the lead
+// engine in a cluster.
+const EngineLeaseName = "engine-leader"
+
+// ErrNoEngineLeader is returned by EngineStepDown if no engine holds the
+// leadership.
+var ErrNoEngineLeader = errors.New("no engine leader")
+
+// EngineLeader returns the lease held by the engine leader, or nil if no
+// engine currently holds the leadership.
+func EngineLeader(lManager lease.Manager) (lease.Lease, error) {
+	return lManager.GetLease(EngineLeaseName)
+}
+
+// EngineStepDown releases the lease held by the engine leader. The engine
+// that held it does not attempt to acquire the leadership again for the
+// TTL of the lease, so that another engine takes over if there is one. The
+// ID of the machine that stepped down is returned.
+func EngineStepDown(lManager lease.Manager) (string, error) {
+	l, err := EngineLeader(lManager)
+	if err != nil {
+		return "", err
+	}
+	if l == nil {
+		return "", ErrNoEngineLeader
+	}
+
+	if err := l.Release(); err != nil {
+		return "", err
+	}
+	return l.MachineID(), nil
+}
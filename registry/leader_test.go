@@ -0,0 +1,39 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEngineStepDown(t *testing.T) {
+	lReg := NewFakeLeaseRegistry()
+	if _, err := EngineStepDown(lReg); err != ErrNoEngineLeader {
+		t.Errorf("expected ErrNoEngineLeader without leader, got %v", err)
+	}
+
+	lReg.SetLease(EngineLeaseName, "XXX", 1, time.Minute)
+	machID, err := EngineStepDown(lReg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if machID != "XXX" {
+		t.Errorf("expected Machine(XXX) to step down, got %q", machID)
+	}
+	if l, _ := EngineLeader(lReg); l != nil {
+		t.Errorf("expected lease to be released, held by %s", l.MachineID())
+	}
+}
@@ -135,6 +135,27 @@ func (r *EtcdRegistry) DeleteMachineMetadata(machID string, key string) error {
 	return r.SetMachineMetadata(machID, key, "")
 }
 
+// SetMachineSchedulingState cordons, drains or uncordons the given machine.
+// Like dynamic metadata, the state is kept while the machine is away.
+func (r *EtcdRegistry) SetMachineSchedulingState(machID string, state string) error {
+	var ms machine.MachineState
+	if err := ms.SetSchedulingState(state); err != nil {
+		return err
+	}
+
+	key := r.prefixed(machinePrefix, machID, "scheduling")
+	if state == machine.StateSchedulable {
+		_, err := r.kAPI.Delete(context.Background(), key, nil)
+		if isEtcdError(err, etcd.ErrorCodeKeyNotFound) {
+			err = nil
+		}
+		return err
+	}
+
+	_, err := r.kAPI.Set(context.Background(), key, state, nil)
+	return err
+}
+
 func (r *EtcdRegistry) RemoveMachineState(machID string) error {
 	key := r.prefixed(machinePrefix, machID, "object")
 	_, err := r.kAPI.Delete(context.Background(), key, nil)
@@ -172,6 +193,7 @@ func mergeMetadata(machineMetadata, dynamicMetadata map[string]string) map[strin
 // readMachineState reads machine state from an etcd node
 func readMachineState(node *etcd.Node) (mach machine.MachineState, err error) {
 	var metadata map[string]string
+	state := machine.StateSchedulable
 
 	for _, obj := range node.Nodes {
 		if strings.HasSuffix(obj.Key, "/object") {
@@ -185,9 +207,12 @@ func readMachineState(node *etcd.Node) (mach machine.MachineState, err error) {
 			for _, mdnode := range obj.Nodes {
 				metadata[path.Base(mdnode.Key)] = mdnode.Value
 			}
+		} else if strings.HasSuffix(obj.Key, "/scheduling") {
+			state = obj.Value
 		}
 	}
 
 	mach.Metadata = mergeMetadata(mach.Metadata, metadata)
+	err = mach.SetSchedulingState(state)
 	return
 }
@@ -0,0 +1,60 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"github.com/coreos/fleet/job"
+)
+
+// Planner simulates how the engine would schedule the cluster with
+// proposed changes applied, without modifying the Registry.
+type Planner interface {
+	// Plan simulates a reconciliation using the named scheduling
+	// strategy, or the default one if strategy is empty.
+	Plan(strategy string, change PlanChange) (*PlanResult, error)
+}
+
+// PlanChange describes proposed changes to the cluster, the effect of
+// which on scheduling can be simulated by a Planner.
+type PlanChange struct {
+	// Units are submitted with their TargetState, replacing any
+	// existing Unit of the same name.
+	Units []job.Unit
+
+	// RemoveMachines holds the IDs of machines leaving the cluster.
+	RemoveMachines []string
+
+	// AddMetadata is merged into the metadata of the machine with
+	// the given ID.
+	AddMetadata map[string]map[string]string
+}
+
+// PlanTask is a task the engine would perform to reconcile the cluster.
+type PlanTask struct {
+	Type      string
+	Reason    string
+	JobName   string
+	MachineID string
+}
+
+// PlanResult holds the outcome of a simulated reconciliation.
+type PlanResult struct {
+	Tasks         []PlanTask
+	Unschedulable []job.SchedulingExplanation
+
+	// IgnoredSettings lists the settings of the engine the simulation
+	// did not take into account.
+	IgnoredSettings []string
+}
@@ -0,0 +1,79 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"path"
+	"strconv"
+
+	etcd "github.com/coreos/etcd/client"
+	"golang.org/x/net/context"
+
+	"github.com/coreos/fleet/log"
+)
+
+// Namespace for the replica counts of template units set by scaling them
+const replicasPrefix = "/replicas/"
+
+// UnitReplicas returns the replica counts of template Units set by
+// scaling them, indexed by the name of the template. These override the
+// Replicas option of the templates' unit files.
+func (r *EtcdRegistry) UnitReplicas() (map[string]int, error) {
+	replicas := make(map[string]int)
+	opts := &etcd.GetOptions{
+		Recursive: true,
+	}
+	res, err := r.kAPI.Get(context.Background(), r.prefixed(replicasPrefix), opts)
+	if err != nil {
+		if isEtcdError(err, etcd.ErrorCodeKeyNotFound) {
+			err = nil
+		}
+		return replicas, err
+	}
+
+	for _, node := range res.Node.Nodes {
+		_, name := path.Split(node.Key)
+		n, err := strconv.Atoi(node.Value)
+		if err != nil || n < 0 {
+			log.Errorf("Ignoring invalid replica count %q of Unit(%s)", node.Value, name)
+			continue
+		}
+		replicas[name] = n
+	}
+	return replicas, nil
+}
+
+// SetUnitReplicas sets the number of instances the engine maintains of
+// the template Unit of the given name. The count is stored apart from the
+// unit file, so that the template and the instances created from it keep
+// the contents they were submitted with.
+func (r *EtcdRegistry) SetUnitReplicas(name string, replicas int) error {
+	_, err := r.kAPI.Set(context.Background(), r.unitReplicasPath(name), strconv.Itoa(replicas), nil)
+	return err
+}
+
+// removeUnitReplicas deletes the replica count of the Unit of the given
+// name, if any.
+func (r *EtcdRegistry) removeUnitReplicas(name string) error {
+	_, err := r.kAPI.Delete(context.Background(), r.unitReplicasPath(name), nil)
+	if isEtcdError(err, etcd.ErrorCodeKeyNotFound) {
+		err = nil
+	}
+	return err
+}
+
+func (r *EtcdRegistry) unitReplicasPath(name string) string {
+	return r.prefixed(replicasPrefix, name)
+}
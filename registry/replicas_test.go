@@ -0,0 +1,62 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"reflect"
+	"testing"
+
+	etcd "github.com/coreos/etcd/client"
+)
+
+func TestUnitReplicas(t *testing.T) {
+	e := &testEtcdKeysAPI{
+		res: []*etcd.Response{
+			&etcd.Response{Node: &etcd.Node{Key: "/fleet/replicas", Dir: true, Nodes: etcd.Nodes{
+				&etcd.Node{Key: "/fleet/replicas/web@.service", Value: "8"},
+				&etcd.Node{Key: "/fleet/replicas/api@.service", Value: "0"},
+				&etcd.Node{Key: "/fleet/replicas/bad@.service", Value: "-1"},
+				&etcd.Node{Key: "/fleet/replicas/worse@.service", Value: "many"},
+			}}},
+		},
+	}
+	r := &EtcdRegistry{kAPI: e, keyPrefix: "/fleet/"}
+
+	// invalid counts are ignored
+	got, err := r.UnitReplicas()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]int{"web@.service": 8, "api@.service": 0}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("unexpected replicas: got %v, want %v", got, want)
+	}
+
+	// no counts are set before any template is scaled
+	e = &testEtcdKeysAPI{err: []error{etcd.Error{Code: etcd.ErrorCodeKeyNotFound}}}
+	r = &EtcdRegistry{kAPI: e, keyPrefix: "/fleet/"}
+	if got, err := r.UnitReplicas(); err != nil || len(got) != 0 {
+		t.Errorf("expected no replicas, got %v, %v", got, err)
+	}
+
+	e = &testEtcdKeysAPI{}
+	r = &EtcdRegistry{kAPI: e, keyPrefix: "/fleet/"}
+	if err := r.SetUnitReplicas("web@.service", 3); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []action{{key: "/fleet/replicas/web@.service", val: "3"}}; !reflect.DeepEqual(want, e.sets) {
+		t.Errorf("unexpected sets: got %v, want %v", e.sets, want)
+	}
+}
@@ -345,3 +345,67 @@ func (r *RegistryMux) SetMachineMetadata(machID string, key string, value string
 func (r *RegistryMux) DeleteMachineMetadata(machID string, key string) error {
 	return r.etcdRegistry.DeleteMachineMetadata(machID, key)
 }
+
+func (r *RegistryMux) SetMachineSchedulingState(machID string, state string) error {
+	return r.etcdRegistry.SetMachineSchedulingState(machID, state)
+}
+
+func (r *RegistryMux) SchedulingExplanations() ([]job.SchedulingExplanation, error) {
+	return r.etcdRegistry.SchedulingExplanations()
+}
+
+func (r *RegistryMux) SchedulingExplanation(name string) (*job.SchedulingExplanation, error) {
+	return r.etcdRegistry.SchedulingExplanation(name)
+}
+
+func (r *RegistryMux) SetSchedulingExplanation(exp job.SchedulingExplanation) error {
+	return r.etcdRegistry.SetSchedulingExplanation(exp)
+}
+
+func (r *RegistryMux) RemoveSchedulingExplanation(name string) error {
+	return r.etcdRegistry.RemoveSchedulingExplanation(name)
+}
+
+func (r *RegistryMux) LostMachines() (map[string]time.Time, error) {
+	return r.etcdRegistry.LostMachines()
+}
+
+func (r *RegistryMux) SetLostMachines(lost map[string]time.Time) error {
+	return r.etcdRegistry.SetLostMachines(lost)
+}
+
+func (r *RegistryMux) UnitFailures() ([]registry.UnitFailure, error) {
+	return r.etcdRegistry.UnitFailures()
+}
+
+func (r *RegistryMux) SetUnitFailures(name string, failures []registry.UnitFailure) error {
+	return r.etcdRegistry.SetUnitFailures(name, failures)
+}
+
+func (r *RegistryMux) RemoveUnitFailures(name string) error {
+	return r.etcdRegistry.RemoveUnitFailures(name)
+}
+
+func (r *RegistryMux) ReconcileStatus() (*registry.ReconcileStatus, error) {
+	return r.etcdRegistry.ReconcileStatus()
+}
+
+func (r *RegistryMux) SetReconcileStatus(status registry.ReconcileStatus) error {
+	return r.etcdRegistry.SetReconcileStatus(status)
+}
+
+func (r *RegistryMux) UnitReplicas() (map[string]int, error) {
+	return r.etcdRegistry.UnitReplicas()
+}
+
+func (r *RegistryMux) SetUnitReplicas(name string, replicas int) error {
+	return r.etcdRegistry.SetUnitReplicas(name, replicas)
+}
+
+func (r *RegistryMux) UnitStateHistory(name string) ([]registry.UnitStateTransition, error) {
+	return r.etcdRegistry.UnitStateHistory(name)
+}
+
+func (r *RegistryMux) RecordUnitStateTransition(name string, t registry.UnitStateTransition) error {
+	return r.etcdRegistry.RecordUnitStateTransition(name, t)
+}
@@ -29,6 +29,7 @@ import (
 	"github.com/coreos/fleet/log"
 	"github.com/coreos/fleet/machine"
 	pb "github.com/coreos/fleet/protobuf"
+	"github.com/coreos/fleet/registry"
 	"github.com/coreos/fleet/unit"
 )
 
@@ -224,6 +225,70 @@ func (r *RPCRegistry) DeleteMachineMetadata(machID string, key string) error {
 	panic("Delete machine metadata function not implemented")
 }
 
+func (r *RPCRegistry) SetMachineSchedulingState(machID string, state string) error {
+	panic("Set machine scheduling state function not implemented")
+}
+
+func (r *RPCRegistry) SchedulingExplanations() ([]job.SchedulingExplanation, error) {
+	panic("Scheduling explanations function not implemented")
+}
+
+func (r *RPCRegistry) SchedulingExplanation(name string) (*job.SchedulingExplanation, error) {
+	panic("Scheduling explanation function not implemented")
+}
+
+func (r *RPCRegistry) SetSchedulingExplanation(exp job.SchedulingExplanation) error {
+	panic("Set scheduling explanation function not implemented")
+}
+
+func (r *RPCRegistry) RemoveSchedulingExplanation(name string) error {
+	panic("Remove scheduling explanation function not implemented")
+}
+
+func (r *RPCRegistry) LostMachines() (map[string]time.Time, error) {
+	panic("Lost machines function not implemented")
+}
+
+func (r *RPCRegistry) SetLostMachines(lost map[string]time.Time) error {
+	panic("Set lost machines function not implemented")
+}
+
+func (r *RPCRegistry) UnitFailures() ([]registry.UnitFailure, error) {
+	panic("Unit failures function not implemented")
+}
+
+func (r *RPCRegistry) SetUnitFailures(name string, failures []registry.UnitFailure) error {
+	panic("Set unit failures function not implemented")
+}
+
+func (r *RPCRegistry) RemoveUnitFailures(name string) error {
+	panic("Remove unit failures function not implemented")
+}
+
+func (r *RPCRegistry) ReconcileStatus() (*registry.ReconcileStatus, error) {
+	panic("Reconcile status function not implemented")
+}
+
+func (r *RPCRegistry) SetReconcileStatus(status registry.ReconcileStatus) error {
+	panic("Set reconcile status function not implemented")
+}
+
+func (r *RPCRegistry) UnitReplicas() (map[string]int, error) {
+	panic("Unit replicas function not implemented")
+}
+
+func (r *RPCRegistry) SetUnitReplicas(name string, replicas int) error {
+	panic("Set unit replicas function not implemented")
+}
+
+func (r *RPCRegistry) UnitStateHistory(name string) ([]registry.UnitStateTransition, error) {
+	panic("Unit state history function not implemented")
+}
+
+func (r *RPCRegistry) RecordUnitStateTransition(name string, t registry.UnitStateTransition) error {
+	panic("Record unit state transition function not implemented")
+}
+
 func (r *RPCRegistry) Machines() ([]machine.MachineState, error) {
 	panic("Machines function not implemented")
 }
@@ -339,6 +404,7 @@ func (r *RPCRegistry) UnitState(unitName string) (*unit.UnitState, error) {
 		LoadState:   state.LoadState,
 		ActiveState: state.ActiveState,
 		SubState:    state.SubState,
+		Health:      state.Health,
 	}, nil
 }
 
@@ -362,6 +428,7 @@ func (r *RPCRegistry) UnitStates() ([]*unit.UnitState, error) {
 			LoadState:   state.LoadState,
 			ActiveState: state.ActiveState,
 			SubState:    state.SubState,
+			Health:      state.Health,
 		}
 	}
 	return nUnitStates, nil
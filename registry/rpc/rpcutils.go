@@ -41,6 +41,7 @@ func rpcUnitStateToExtUnitState(state *pb.UnitState) *unit.UnitState {
 		LoadState:   state.LoadState,
 		ActiveState: state.ActiveState,
 		SubState:    state.SubState,
+		Health:      state.Health,
 		MachineID:   state.MachineID,
 	}
 }
@@ -0,0 +1,248 @@
+// Copyright 2014 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"time"
+
+	etcd "github.com/coreos/etcd/client"
+	"golang.org/x/net/context"
+
+	"github.com/coreos/fleet/job"
+)
+
+const (
+	// Namespace for the explanations of the engine leader why Units
+	// are not scheduled, one key per Unit
+	schedulingPrefix = "/scheduling/"
+	// Namespace for the failures of Units counted by the engine
+	// leader, one key per Unit
+	failuresPrefix = "/failures/"
+)
+
+// SchedulingExplanations returns the explanations recorded by the engine
+// leader for all Units it was unable to schedule, ordered by name.
+func (r *EtcdRegistry) SchedulingExplanations() ([]job.SchedulingExplanation, error) {
+	opts := &etcd.GetOptions{
+		Recursive: true,
+		Sort:      true,
+	}
+	res, err := r.kAPI.Get(context.Background(), r.prefixed(schedulingPrefix), opts)
+	if err != nil {
+		if isEtcdError(err, etcd.ErrorCodeKeyNotFound) {
+			err = nil
+		}
+		return nil, err
+	}
+
+	exps := make([]job.SchedulingExplanation, 0, len(res.Node.Nodes))
+	for _, node := range res.Node.Nodes {
+		var exp job.SchedulingExplanation
+		if err := unmarshal(node.Value, &exp); err != nil {
+			return nil, err
+		}
+		exps = append(exps, exp)
+	}
+	return exps, nil
+}
+
+// SchedulingExplanation returns the explanation recorded by the engine
+// leader for the Unit of the given name, or nil if there is none.
+func (r *EtcdRegistry) SchedulingExplanation(name string) (*job.SchedulingExplanation, error) {
+	res, err := r.kAPI.Get(context.Background(), r.schedulingPath(name), nil)
+	if err != nil {
+		if isEtcdError(err, etcd.ErrorCodeKeyNotFound) {
+			err = nil
+		}
+		return nil, err
+	}
+
+	var exp job.SchedulingExplanation
+	if err := unmarshal(res.Node.Value, &exp); err != nil {
+		return nil, err
+	}
+	return &exp, nil
+}
+
+// SetSchedulingExplanation records why the engine leader was unable to
+// schedule the Unit named by the given explanation.
+func (r *EtcdRegistry) SetSchedulingExplanation(exp job.SchedulingExplanation) error {
+	val, err := marshal(exp)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.kAPI.Set(context.Background(), r.schedulingPath(exp.Name), val, nil)
+	return err
+}
+
+// RemoveSchedulingExplanation deletes the explanation recorded for the
+// Unit of the given name, e.g. once the Unit is scheduled.
+func (r *EtcdRegistry) RemoveSchedulingExplanation(name string) error {
+	_, err := r.kAPI.Delete(context.Background(), r.schedulingPath(name), nil)
+	if isEtcdError(err, etcd.ErrorCodeKeyNotFound) {
+		err = nil
+	}
+	return err
+}
+
+func (r *EtcdRegistry) schedulingPath(name string) string {
+	return r.prefixed(schedulingPrefix, name)
+}
+
+// LostMachines returns the IDs of the machines the engine leader noticed
+// went away while Units were still scheduled to them, along with the time
+// each machine was first missed.
+func (r *EtcdRegistry) LostMachines() (map[string]time.Time, error) {
+	res, err := r.kAPI.Get(context.Background(), r.lostMachinesPath(), nil)
+	if err != nil {
+		if isEtcdError(err, etcd.ErrorCodeKeyNotFound) {
+			err = nil
+		}
+		return nil, err
+	}
+
+	var lost map[string]time.Time
+	if err := unmarshal(res.Node.Value, &lost); err != nil {
+		return nil, err
+	}
+	return lost, nil
+}
+
+// SetLostMachines replaces the lost machines recorded in the Registry.
+func (r *EtcdRegistry) SetLostMachines(lost map[string]time.Time) error {
+	val, err := marshal(lost)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.kAPI.Set(context.Background(), r.lostMachinesPath(), val, nil)
+	return err
+}
+
+func (r *EtcdRegistry) lostMachinesPath() string {
+	return r.prefixed("/engine/lost-machines")
+}
+
+// UnitFailure records how often the engine leader saw a Unit defining
+// RescheduleOnFailure fail on a machine.
+type UnitFailure struct {
+	Name      string
+	MachineID string
+	// Count is the number of times the Unit entered the failed state
+	// while scheduled to the machine
+	Count int
+	// Failed is set while the machine reports the Unit as failed
+	Failed bool
+	// ExcludedUntil is set once the Unit failed too often, and holds
+	// the time until which the Unit is not scheduled to the machine
+	ExcludedUntil time.Time
+}
+
+// UnitFailures returns the failures of Units recorded by the engine
+// leader, ordered by Unit name and machine ID.
+func (r *EtcdRegistry) UnitFailures() ([]UnitFailure, error) {
+	opts := &etcd.GetOptions{
+		Recursive: true,
+		Sort:      true,
+	}
+	res, err := r.kAPI.Get(context.Background(), r.prefixed(failuresPrefix), opts)
+	if err != nil {
+		if isEtcdError(err, etcd.ErrorCodeKeyNotFound) {
+			err = nil
+		}
+		return nil, err
+	}
+
+	var failures []UnitFailure
+	for _, node := range res.Node.Nodes {
+		var unitFailures []UnitFailure
+		if err := unmarshal(node.Value, &unitFailures); err != nil {
+			return nil, err
+		}
+		failures = append(failures, unitFailures...)
+	}
+	return failures, nil
+}
+
+// SetUnitFailures replaces the failures of the Unit of the given name
+// recorded in the Registry, ordered by machine ID.
+func (r *EtcdRegistry) SetUnitFailures(name string, failures []UnitFailure) error {
+	val, err := marshal(failures)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.kAPI.Set(context.Background(), r.unitFailuresPath(name), val, nil)
+	return err
+}
+
+// RemoveUnitFailures deletes the failures recorded for the Unit of the
+// given name.
+func (r *EtcdRegistry) RemoveUnitFailures(name string) error {
+	_, err := r.kAPI.Delete(context.Background(), r.unitFailuresPath(name), nil)
+	if isEtcdError(err, etcd.ErrorCodeKeyNotFound) {
+		err = nil
+	}
+	return err
+}
+
+func (r *EtcdRegistry) unitFailuresPath(name string) string {
+	return r.prefixed(failuresPrefix, name)
+}
+
+// ReconcileStatus describes the last reconciliation of the engine leader.
+type ReconcileStatus struct {
+	// MachineID identifies the engine that reconciled the cluster
+	MachineID string
+	Time      time.Time
+	Duration  time.Duration
+	// Tasks maps each type of task to the number of tasks of that
+	// type performed
+	Tasks map[string]int
+}
+
+// ReconcileStatus returns the status of the last reconciliation recorded
+// by the engine leader, or nil if there is none.
+func (r *EtcdRegistry) ReconcileStatus() (*ReconcileStatus, error) {
+	res, err := r.kAPI.Get(context.Background(), r.reconcileStatusPath(), nil)
+	if err != nil {
+		if isEtcdError(err, etcd.ErrorCodeKeyNotFound) {
+			err = nil
+		}
+		return nil, err
+	}
+
+	var status ReconcileStatus
+	if err := unmarshal(res.Node.Value, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// SetReconcileStatus records the status of the last reconciliation.
+func (r *EtcdRegistry) SetReconcileStatus(status ReconcileStatus) error {
+	val, err := marshal(status)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.kAPI.Set(context.Background(), r.reconcileStatusPath(), val, nil)
+	return err
+}
+
+func (r *EtcdRegistry) reconcileStatusPath() string {
+	return r.prefixed("/engine/reconcile")
+}
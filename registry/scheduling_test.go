@@ -0,0 +1,155 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"reflect"
+	"testing"
+
+	etcd "github.com/coreos/etcd/client"
+
+	"github.com/coreos/fleet/job"
+)
+
+func TestSchedulingExplanations(t *testing.T) {
+	foo := job.SchedulingExplanation{
+		Name:           "foo.service",
+		Reason:         "no agents able to run job",
+		MachineReasons: map[string]string{"XXX": "local Machine metadata insufficient"},
+	}
+	fooVal, err := marshal(foo)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	e := &testEtcdKeysAPI{
+		res: []*etcd.Response{
+			&etcd.Response{Node: &etcd.Node{Key: "/fleet/scheduling", Dir: true, Nodes: etcd.Nodes{
+				&etcd.Node{Key: "/fleet/scheduling/foo.service", Value: fooVal},
+			}}},
+			&etcd.Response{Node: &etcd.Node{Key: "/fleet/scheduling/foo.service", Value: fooVal}},
+		},
+	}
+	r := &EtcdRegistry{kAPI: e, keyPrefix: "/fleet/"}
+
+	exps, err := r.SchedulingExplanations()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []job.SchedulingExplanation{foo}; !reflect.DeepEqual(want, exps) {
+		t.Errorf("unexpected explanations: got %v, want %v", exps, want)
+	}
+
+	exp, err := r.SchedulingExplanation("foo.service")
+	if err != nil || exp == nil || !reflect.DeepEqual(foo, *exp) {
+		t.Errorf("unexpected explanation: got %v, %v", exp, err)
+	}
+
+	wantGets := []action{
+		{key: "/fleet/scheduling", rec: true},
+		{key: "/fleet/scheduling/foo.service"},
+	}
+	if !reflect.DeepEqual(wantGets, e.gets) {
+		t.Errorf("unexpected gets: got %v, want %v", e.gets, wantGets)
+	}
+
+	// units without explanation
+	e = &testEtcdKeysAPI{err: []error{
+		etcd.Error{Code: etcd.ErrorCodeKeyNotFound},
+		etcd.Error{Code: etcd.ErrorCodeKeyNotFound},
+	}}
+	r = &EtcdRegistry{kAPI: e, keyPrefix: "/fleet/"}
+	if exps, err := r.SchedulingExplanations(); err != nil || len(exps) != 0 {
+		t.Errorf("expected no explanations, got %v, %v", exps, err)
+	}
+	if exp, err := r.SchedulingExplanation("bar.service"); err != nil || exp != nil {
+		t.Errorf("expected no explanation, got %v, %v", exp, err)
+	}
+
+	// each unit has its own key, removed regardless of whether it exists
+	e = &testEtcdKeysAPI{err: []error{nil, etcd.Error{Code: etcd.ErrorCodeKeyNotFound}}}
+	r = &EtcdRegistry{kAPI: e, keyPrefix: "/fleet/"}
+	if err := r.SetSchedulingExplanation(foo); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.RemoveSchedulingExplanation("bar.service"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []action{{key: "/fleet/scheduling/foo.service", val: fooVal}}; !reflect.DeepEqual(want, e.sets) {
+		t.Errorf("unexpected sets: got %v, want %v", e.sets, want)
+	}
+	if want := []action{{key: "/fleet/scheduling/bar.service"}}; !reflect.DeepEqual(want, e.deletes) {
+		t.Errorf("unexpected deletes: got %v, want %v", e.deletes, want)
+	}
+}
+
+func TestUnitFailures(t *testing.T) {
+	foo := []UnitFailure{
+		{Name: "foo.service", MachineID: "XXX", Count: 1, Failed: true},
+		{Name: "foo.service", MachineID: "YYY", Count: 2},
+	}
+	bar := []UnitFailure{
+		{Name: "bar.service", MachineID: "XXX", Count: 1},
+	}
+	fooVal, err := marshal(foo)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	barVal, err := marshal(bar)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	e := &testEtcdKeysAPI{
+		res: []*etcd.Response{
+			&etcd.Response{Node: &etcd.Node{Key: "/fleet/failures", Dir: true, Nodes: etcd.Nodes{
+				&etcd.Node{Key: "/fleet/failures/bar.service", Value: barVal},
+				&etcd.Node{Key: "/fleet/failures/foo.service", Value: fooVal},
+			}}},
+		},
+	}
+	r := &EtcdRegistry{kAPI: e, keyPrefix: "/fleet/"}
+
+	got, err := r.UnitFailures()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := append(bar, foo...); !reflect.DeepEqual(want, got) {
+		t.Errorf("unexpected failures: got %v, want %v", got, want)
+	}
+
+	// no failures are recorded before any unit failed
+	e = &testEtcdKeysAPI{err: []error{etcd.Error{Code: etcd.ErrorCodeKeyNotFound}}}
+	r = &EtcdRegistry{kAPI: e, keyPrefix: "/fleet/"}
+	if got, err := r.UnitFailures(); err != nil || len(got) != 0 {
+		t.Errorf("expected no failures, got %v, %v", got, err)
+	}
+
+	// each unit has its own key, removed regardless of whether it exists
+	e = &testEtcdKeysAPI{err: []error{nil, etcd.Error{Code: etcd.ErrorCodeKeyNotFound}}}
+	r = &EtcdRegistry{kAPI: e, keyPrefix: "/fleet/"}
+	if err := r.SetUnitFailures("foo.service", foo); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.RemoveUnitFailures("bar.service"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []action{{key: "/fleet/failures/foo.service", val: fooVal}}; !reflect.DeepEqual(want, e.sets) {
+		t.Errorf("unexpected sets: got %v, want %v", e.sets, want)
+	}
+	if want := []action{{key: "/fleet/failures/bar.service"}}; !reflect.DeepEqual(want, e.deletes) {
+		t.Errorf("unexpected deletes: got %v, want %v", e.deletes, want)
+	}
+}
@@ -258,7 +258,7 @@ func TestModelToUnitState(t *testing.T) {
 			want: nil,
 		},
 		{
-			in: &unitStateModel{"foo", "bar", "baz", nil, ""},
+			in: &unitStateModel{"foo", "bar", "baz", nil, "", ""},
 			want: &unit.UnitState{
 				LoadState:   "foo",
 				ActiveState: "bar",
@@ -269,7 +269,7 @@ func TestModelToUnitState(t *testing.T) {
 			},
 		},
 		{
-			in: &unitStateModel{"z", "x", "y", &machine.MachineState{ID: "abcd"}, ""},
+			in: &unitStateModel{"z", "x", "y", &machine.MachineState{ID: "abcd"}, "", "healthy"},
 			want: &unit.UnitState{
 				LoadState:   "z",
 				ActiveState: "x",
@@ -277,6 +277,7 @@ func TestModelToUnitState(t *testing.T) {
 				MachineID:   "abcd",
 				UnitHash:    "",
 				UnitName:    "name",
+				Health:      "healthy",
 			},
 		},
 	} {
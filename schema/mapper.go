@@ -15,6 +15,9 @@
 package schema
 
 import (
+	"sort"
+	"time"
+
 	gsunit "github.com/coreos/go-systemd/unit"
 
 	"github.com/coreos/fleet/job"
@@ -63,6 +66,10 @@ func MapUnitToSchemaUnit(u *job.Unit, su *job.ScheduledUnit) *Unit {
 		DesiredState: string(u.TargetState),
 	}
 
+	if n, ok := u.Replicas(); ok {
+		s.Replicas = int64(n)
+	}
+
 	if su != nil {
 		s.MachineID = su.TargetMachineID
 		if su.State != nil {
@@ -88,6 +95,11 @@ func MapMachineStateToSchema(ms *machine.MachineState) *Machine {
 		Id:        ms.ID,
 		PrimaryIP: ms.PublicIP,
 	}
+	// schedulable machines are reported without a state, as before
+	// machines could be cordoned
+	if ms.Unschedulable {
+		sm.State = ms.SchedulingState()
+	}
 
 	sm.Metadata = make(map[string]string, len(ms.Metadata))
 	for k, v := range ms.Metadata {
@@ -106,6 +118,10 @@ func MapSchemaToMachineStates(entities []*Machine) []machine.MachineState {
 			ID:       me.Id,
 			PublicIP: me.PrimaryIP,
 		}
+		if me.State != "" {
+			// unknown states of newer servers are treated as schedulable
+			ms.SetSchedulingState(me.State)
+		}
 
 		ms.Metadata = make(map[string]string, len(me.Metadata))
 		for k, v := range me.Metadata {
@@ -135,6 +151,7 @@ func MapUnitStateToSchemaUnitState(entity *unit.UnitState) *UnitState {
 		SystemdLoadState:   entity.LoadState,
 		SystemdActiveState: entity.ActiveState,
 		SystemdSubState:    entity.SubState,
+		Health:             entity.Health,
 	}
 
 	return &us
@@ -150,6 +167,7 @@ func MapSchemaUnitStatesToUnitStates(entities []*UnitState) []*unit.UnitState {
 			LoadState:   e.SystemdLoadState,
 			ActiveState: e.SystemdActiveState,
 			SubState:    e.SystemdSubState,
+			Health:      e.Health,
 		}
 	}
 
@@ -173,3 +191,28 @@ func MapSchemaUnitsToScheduledUnits(entities []*Unit) []job.ScheduledUnit {
 
 	return su
 }
+
+func MapSchedulingExplanationToSchema(exp *job.SchedulingExplanation) *UnitScheduling {
+	us := UnitScheduling{
+		Name:   exp.Name,
+		Reason: exp.Reason,
+	}
+	if !exp.Since.IsZero() {
+		us.Since = exp.Since.UTC().Format(time.RFC3339)
+	}
+
+	ids := make([]string, 0, len(exp.MachineReasons))
+	for id := range exp.MachineReasons {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		us.Machines = append(us.Machines, &UnitSchedulingMachine{
+			MachineID: id,
+			Reason:    exp.MachineReasons[id],
+		})
+	}
+
+	return &us
+}
@@ -65,7 +65,9 @@ func New(client *http.Client) (*Service, error) {
 		return nil, errors.New("client is nil")
 	}
 	s := &Service{client: client, BasePath: basePath}
+	s.Engine = NewEngineService(s)
 	s.Machines = NewMachinesService(s)
+	s.Plan = NewPlanService(s)
 	s.UnitState = NewUnitStateService(s)
 	s.Units = NewUnitsService(s)
 	return s, nil
@@ -76,8 +78,12 @@ type Service struct {
 	BasePath  string // API endpoint base URL
 	UserAgent string // optional additional User-Agent fragment
 
+	Engine *EngineService
+
 	Machines *MachinesService
 
+	Plan *PlanService
+
 	UnitState *UnitStateService
 
 	Units *UnitsService
@@ -90,6 +96,15 @@ func (s *Service) userAgent() string {
 	return googleapi.UserAgent + " " + s.UserAgent
 }
 
+func NewEngineService(s *Service) *EngineService {
+	rs := &EngineService{s: s}
+	return rs
+}
+
+type EngineService struct {
+	s *Service
+}
+
 func NewMachinesService(s *Service) *MachinesService {
 	rs := &MachinesService{s: s}
 	return rs
@@ -99,6 +114,15 @@ type MachinesService struct {
 	s *Service
 }
 
+func NewPlanService(s *Service) *PlanService {
+	rs := &PlanService{s: s}
+	return rs
+}
+
+type PlanService struct {
+	s *Service
+}
+
 func NewUnitStateService(s *Service) *UnitStateService {
 	rs := &UnitStateService{s: s}
 	return rs
@@ -117,6 +141,104 @@ type UnitsService struct {
 	s *Service
 }
 
+type EngineReconcile struct {
+	Duration float64 `json:"duration,omitempty"`
+
+	MachineID string `json:"machineID,omitempty"`
+
+	Tasks []*EngineTaskCount `json:"tasks,omitempty"`
+
+	Time string `json:"time,omitempty"`
+
+	// ForceSendFields is a list of field names (e.g. "Duration") to
+	// unconditionally include in API requests. By default, fields with
+	// empty values are omitted from API requests. However, any non-pointer,
+	// non-interface field appearing in ForceSendFields will be sent to the
+	// server regardless of whether the field is empty or not. This may be
+	// used to include empty fields in Patch requests.
+	ForceSendFields []string `json:"-"`
+
+	// NullFields is a list of field names (e.g. "Duration") to include in
+	// API requests with the JSON null value. By default, fields with empty
+	// values are omitted from API requests. However, any field with an
+	// empty value appearing in NullFields will be sent to the server as
+	// null. It is an error if a field in this list has a non-empty value.
+	// This may be used to include null fields in Patch requests.
+	NullFields []string `json:"-"`
+}
+
+func (s *EngineReconcile) MarshalJSON() ([]byte, error) {
+	type noMethod EngineReconcile
+	raw := noMethod(*s)
+	return gensupport.MarshalJSON(raw, s.ForceSendFields, s.NullFields)
+}
+
+type EngineStatus struct {
+	EngineVersion int64 `json:"engineVersion,omitempty"`
+
+	LastReconcile *EngineReconcile `json:"lastReconcile,omitempty"`
+
+	LeaderMachineID string `json:"leaderMachineID,omitempty"`
+
+	LeaseIndex int64 `json:"leaseIndex,omitempty"`
+
+	LeaseTimeRemaining float64 `json:"leaseTimeRemaining,omitempty"`
+
+	// ServerResponse contains the HTTP response code and headers from the
+	// server.
+	googleapi.ServerResponse `json:"-"`
+
+	// ForceSendFields is a list of field names (e.g. "EngineVersion") to
+	// unconditionally include in API requests. By default, fields with
+	// empty values are omitted from API requests. However, any non-pointer,
+	// non-interface field appearing in ForceSendFields will be sent to the
+	// server regardless of whether the field is empty or not. This may be
+	// used to include empty fields in Patch requests.
+	ForceSendFields []string `json:"-"`
+
+	// NullFields is a list of field names (e.g. "EngineVersion") to include
+	// in API requests with the JSON null value. By default, fields with
+	// empty values are omitted from API requests. However, any field with
+	// an empty value appearing in NullFields will be sent to the server as
+	// null. It is an error if a field in this list has a non-empty value.
+	// This may be used to include null fields in Patch requests.
+	NullFields []string `json:"-"`
+}
+
+func (s *EngineStatus) MarshalJSON() ([]byte, error) {
+	type noMethod EngineStatus
+	raw := noMethod(*s)
+	return gensupport.MarshalJSON(raw, s.ForceSendFields, s.NullFields)
+}
+
+type EngineTaskCount struct {
+	Count int64 `json:"count,omitempty"`
+
+	Type string `json:"type,omitempty"`
+
+	// ForceSendFields is a list of field names (e.g. "Count") to
+	// unconditionally include in API requests. By default, fields with
+	// empty values are omitted from API requests. However, any non-pointer,
+	// non-interface field appearing in ForceSendFields will be sent to the
+	// server regardless of whether the field is empty or not. This may be
+	// used to include empty fields in Patch requests.
+	ForceSendFields []string `json:"-"`
+
+	// NullFields is a list of field names (e.g. "Count") to include in API
+	// requests with the JSON null value. By default, fields with empty
+	// values are omitted from API requests. However, any field with an
+	// empty value appearing in NullFields will be sent to the server as
+	// null. It is an error if a field in this list has a non-empty value.
+	// This may be used to include null fields in Patch requests.
+	NullFields []string `json:"-"`
+}
+
+func (s *EngineTaskCount) MarshalJSON() ([]byte, error) {
+	type noMethod EngineTaskCount
+	raw := noMethod(*s)
+	return gensupport.MarshalJSON(raw, s.ForceSendFields, s.NullFields)
+}
+
 type Machine struct {
 	Id string `json:"id,omitempty"`
 
@@ -124,6 +246,12 @@ type Machine struct {
 
 	PrimaryIP string `json:"primaryIP,omitempty"`
 
+	// Possible values:
+	//   "schedulable"
+	//   "cordoned"
+	//   "draining"
+	State string `json:"state,omitempty"`
+
 	// ForceSendFields is a list of field names (e.g. "Id") to
 	// unconditionally include in API requests. By default, fields with
 	// empty values are omitted from API requests. However, any non-pointer,
@@ -179,11 +307,141 @@ func (s *MachinePage) MarshalJSON() ([]byte, error) {
 	return gensupport.MarshalJSON(raw, s.ForceSendFields, s.NullFields)
 }
 
+type Plan struct {
+	IgnoredSettings []string `json:"ignoredSettings,omitempty"`
+
+	Tasks []*PlanTask `json:"tasks,omitempty"`
+
+	Unschedulable []*UnitScheduling `json:"unschedulable,omitempty"`
+
+	// ServerResponse contains the HTTP response code and headers from the
+	// server.
+	googleapi.ServerResponse `json:"-"`
+
+	// ForceSendFields is a list of field names (e.g. "IgnoredSettings") to
+	// unconditionally include in API requests. By default, fields with
+	// empty values are omitted from API requests. However, any non-pointer,
+	// non-interface field appearing in ForceSendFields will be sent to the
+	// server regardless of whether the field is empty or not. This may be
+	// used to include empty fields in Patch requests.
+	ForceSendFields []string `json:"-"`
+
+	// NullFields is a list of field names (e.g. "IgnoredSettings") to
+	// include in API requests with the JSON null value. By default, fields
+	// with empty values are omitted from API requests. However, any field
+	// with an empty value appearing in NullFields will be sent to the
+	// server as null. It is an error if a field in this list has a
+	// non-empty value. This may be used to include null fields in Patch
+	// requests.
+	NullFields []string `json:"-"`
+}
+
+func (s *Plan) MarshalJSON() ([]byte, error) {
+	type noMethod Plan
+	raw := noMethod(*s)
+	return gensupport.MarshalJSON(raw, s.ForceSendFields, s.NullFields)
+}
+
+type PlanMetadata struct {
+	Key string `json:"key,omitempty"`
+
+	MachineID string `json:"machineID,omitempty"`
+
+	Value string `json:"value,omitempty"`
+
+	// ForceSendFields is a list of field names (e.g. "Key") to
+	// unconditionally include in API requests. By default, fields with
+	// empty values are omitted from API requests. However, any non-pointer,
+	// non-interface field appearing in ForceSendFields will be sent to the
+	// server regardless of whether the field is empty or not. This may be
+	// used to include empty fields in Patch requests.
+	ForceSendFields []string `json:"-"`
+
+	// NullFields is a list of field names (e.g. "Key") to include in API
+	// requests with the JSON null value. By default, fields with empty
+	// values are omitted from API requests. However, any field with an
+	// empty value appearing in NullFields will be sent to the server as
+	// null. It is an error if a field in this list has a non-empty value.
+	// This may be used to include null fields in Patch requests.
+	NullFields []string `json:"-"`
+}
+
+func (s *PlanMetadata) MarshalJSON() ([]byte, error) {
+	type noMethod PlanMetadata
+	raw := noMethod(*s)
+	return gensupport.MarshalJSON(raw, s.ForceSendFields, s.NullFields)
+}
+
+type PlanRequest struct {
+	AddMetadata []*PlanMetadata `json:"addMetadata,omitempty"`
+
+	RemoveMachines []string `json:"removeMachines,omitempty"`
+
+	Strategy string `json:"strategy,omitempty"`
+
+	Units []*Unit `json:"units,omitempty"`
+
+	// ForceSendFields is a list of field names (e.g. "AddMetadata") to
+	// unconditionally include in API requests. By default, fields with
+	// empty values are omitted from API requests. However, any non-pointer,
+	// non-interface field appearing in ForceSendFields will be sent to the
+	// server regardless of whether the field is empty or not. This may be
+	// used to include empty fields in Patch requests.
+	ForceSendFields []string `json:"-"`
+
+	// NullFields is a list of field names (e.g. "AddMetadata") to include
+	// in API requests with the JSON null value. By default, fields with
+	// empty values are omitted from API requests. However, any field with
+	// an empty value appearing in NullFields will be sent to the server as
+	// null. It is an error if a field in this list has a non-empty value.
+	// This may be used to include null fields in Patch requests.
+	NullFields []string `json:"-"`
+}
+
+func (s *PlanRequest) MarshalJSON() ([]byte, error) {
+	type noMethod PlanRequest
+	raw := noMethod(*s)
+	return gensupport.MarshalJSON(raw, s.ForceSendFields, s.NullFields)
+}
+
+type PlanTask struct {
+	MachineID string `json:"machineID,omitempty"`
+
+	Reason string `json:"reason,omitempty"`
+
+	Type string `json:"type,omitempty"`
+
+	UnitName string `json:"unitName,omitempty"`
+
+	// ForceSendFields is a list of field names (e.g. "MachineID") to
+	// unconditionally include in API requests. By default, fields with
+	// empty values are omitted from API requests. However, any non-pointer,
+	// non-interface field appearing in ForceSendFields will be sent to the
+	// server regardless of whether the field is empty or not. This may be
+	// used to include empty fields in Patch requests.
+	ForceSendFields []string `json:"-"`
+
+	// NullFields is a list of field names (e.g. "MachineID") to include in
+	// API requests with the JSON null value. By default, fields with empty
+	// values are omitted from API requests. However, any field with an
+	// empty value appearing in NullFields will be sent to the server as
+	// null. It is an error if a field in this list has a non-empty value.
+	// This may be used to include null fields in Patch requests.
+	NullFields []string `json:"-"`
+}
+
+func (s *PlanTask) MarshalJSON() ([]byte, error) {
+	type noMethod PlanTask
+	raw := noMethod(*s)
+	return gensupport.MarshalJSON(raw, s.ForceSendFields, s.NullFields)
+}
+
 type Unit struct {
 	// Possible values:
 	//   "inactive"
 	//   "loaded"
 	//   "launched"
+	//   "machine-lost"
 	CurrentState string `json:"currentState,omitempty"`
 
 	// Possible values:
@@ -198,6 +456,8 @@ type Unit struct {
 
 	Options []*UnitOption `json:"options,omitempty"`
 
+	Replicas int64 `json:"replicas,omitempty"`
+
 	// ServerResponse contains the HTTP response code and headers from the
 	// server.
 	googleapi.ServerResponse `json:"-"`
@@ -287,24 +547,24 @@ func (s *UnitPage) MarshalJSON() ([]byte, error) {
 	return gensupport.MarshalJSON(raw, s.ForceSendFields, s.NullFields)
 }
 
-type UnitState struct {
-	Hash string `json:"hash,omitempty"`
+type UnitScheduling struct {
+	Failures []*UnitSchedulingFailure `json:"failures,omitempty"`
 
 	MachineID string `json:"machineID,omitempty"`
 
-	Name string `json:"name,omitempty"`
+	Machines []*UnitSchedulingMachine `json:"machines,omitempty"`
 
-	SystemdActiveState string `json:"systemdActiveState,omitempty"`
+	Name string `json:"name,omitempty"`
 
-	SystemdLoadState string `json:"systemdLoadState,omitempty"`
+	Reason string `json:"reason,omitempty"`
 
-	SystemdSubState string `json:"systemdSubState,omitempty"`
+	Since string `json:"since,omitempty"`
 
 	// ServerResponse contains the HTTP response code and headers from the
 	// server.
 	googleapi.ServerResponse `json:"-"`
 
-	// ForceSendFields is a list of field names (e.g. "Hash") to
+	// ForceSendFields is a list of field names (e.g. "Failures") to
 	// unconditionally include in API requests. By default, fields with
 	// empty values are omitted from API requests. However, any non-pointer,
 	// non-interface field appearing in ForceSendFields will be sent to the
@@ -312,8 +572,8 @@ type UnitState struct {
 	// used to include empty fields in Patch requests.
 	ForceSendFields []string `json:"-"`
 
-	// NullFields is a list of field names (e.g. "Hash") to include in API
-	// requests with the JSON null value. By default, fields with empty
+	// NullFields is a list of field names (e.g. "Failures") to include in
+	// API requests with the JSON null value. By default, fields with empty
 	// values are omitted from API requests. However, any field with an
 	// empty value appearing in NullFields will be sent to the server as
 	// null. It is an error if a field in this list has a non-empty value.
@@ -321,22 +581,22 @@ type UnitState struct {
 	NullFields []string `json:"-"`
 }
 
-func (s *UnitState) MarshalJSON() ([]byte, error) {
-	type noMethod UnitState
+func (s *UnitScheduling) MarshalJSON() ([]byte, error) {
+	type noMethod UnitScheduling
 	raw := noMethod(*s)
 	return gensupport.MarshalJSON(raw, s.ForceSendFields, s.NullFields)
 }
 
-type UnitStatePage struct {
-	NextPageToken string `json:"nextPageToken,omitempty"`
+type UnitSchedulingFailure struct {
+	Count int64 `json:"count,omitempty"`
 
-	States []*UnitState `json:"states,omitempty"`
+	ExcludedUntil string `json:"excludedUntil,omitempty"`
 
-	// ServerResponse contains the HTTP response code and headers from the
-	// server.
-	googleapi.ServerResponse `json:"-"`
+	Failed bool `json:"failed,omitempty"`
 
-	// ForceSendFields is a list of field names (e.g. "NextPageToken") to
+	MachineID string `json:"machineID,omitempty"`
+
+	// ForceSendFields is a list of field names (e.g. "Count") to
 	// unconditionally include in API requests. By default, fields with
 	// empty values are omitted from API requests. However, any non-pointer,
 	// non-interface field appearing in ForceSendFields will be sent to the
@@ -344,55 +604,420 @@ type UnitStatePage struct {
 	// used to include empty fields in Patch requests.
 	ForceSendFields []string `json:"-"`
 
-	// NullFields is a list of field names (e.g. "NextPageToken") to include
-	// in API requests with the JSON null value. By default, fields with
-	// empty values are omitted from API requests. However, any field with
-	// an empty value appearing in NullFields will be sent to the server as
+	// NullFields is a list of field names (e.g. "Count") to include in API
+	// requests with the JSON null value. By default, fields with empty
+	// values are omitted from API requests. However, any field with an
+	// empty value appearing in NullFields will be sent to the server as
 	// null. It is an error if a field in this list has a non-empty value.
 	// This may be used to include null fields in Patch requests.
 	NullFields []string `json:"-"`
 }
 
-func (s *UnitStatePage) MarshalJSON() ([]byte, error) {
-	type noMethod UnitStatePage
+func (s *UnitSchedulingFailure) MarshalJSON() ([]byte, error) {
+	type noMethod UnitSchedulingFailure
 	raw := noMethod(*s)
 	return gensupport.MarshalJSON(raw, s.ForceSendFields, s.NullFields)
 }
 
-// method id "fleet.Machine.List":
+type UnitSchedulingMachine struct {
+	MachineID string `json:"machineID,omitempty"`
 
-type MachinesListCall struct {
-	s            *Service
-	urlParams_   gensupport.URLParams
-	ifNoneMatch_ string
-	ctx_         context.Context
-	header_      http.Header
-}
+	Reason string `json:"reason,omitempty"`
 
-// List: Retrieve a page of Machine objects.
-func (r *MachinesService) List() *MachinesListCall {
-	c := &MachinesListCall{s: r.s, urlParams_: make(gensupport.URLParams)}
-	return c
-}
+	// ForceSendFields is a list of field names (e.g. "MachineID") to
+	// unconditionally include in API requests. By default, fields with
+	// empty values are omitted from API requests. However, any non-pointer,
+	// non-interface field appearing in ForceSendFields will be sent to the
+	// server regardless of whether the field is empty or not. This may be
+	// used to include empty fields in Patch requests.
+	ForceSendFields []string `json:"-"`
 
-// NextPageToken sets the optional parameter "nextPageToken":
-func (c *MachinesListCall) NextPageToken(nextPageToken string) *MachinesListCall {
-	c.urlParams_.Set("nextPageToken", nextPageToken)
-	return c
+	// NullFields is a list of field names (e.g. "MachineID") to include in
+	// API requests with the JSON null value. By default, fields with empty
+	// values are omitted from API requests. However, any field with an
+	// empty value appearing in NullFields will be sent to the server as
+	// null. It is an error if a field in this list has a non-empty value.
+	// This may be used to include null fields in Patch requests.
+	NullFields []string `json:"-"`
 }
 
-// Fields allows partial responses to be retrieved. See
-// https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
-// for more information.
-func (c *MachinesListCall) Fields(s ...googleapi.Field) *MachinesListCall {
-	c.urlParams_.Set("fields", googleapi.CombineFields(s))
-	return c
+func (s *UnitSchedulingMachine) MarshalJSON() ([]byte, error) {
+	type noMethod UnitSchedulingMachine
+	raw := noMethod(*s)
+	return gensupport.MarshalJSON(raw, s.ForceSendFields, s.NullFields)
 }
 
-// IfNoneMatch sets the optional parameter which makes the operation
-// fail if the object's ETag matches the given value. This is useful for
-// getting updates only after the object has changed since the last
-// request. Use googleapi.IsNotModified to check whether the response
+type UnitState struct {
+	Hash string `json:"hash,omitempty"`
+
+	Health string `json:"health,omitempty"`
+
+	MachineID string `json:"machineID,omitempty"`
+
+	Name string `json:"name,omitempty"`
+
+	SystemdActiveState string `json:"systemdActiveState,omitempty"`
+
+	SystemdLoadState string `json:"systemdLoadState,omitempty"`
+
+	SystemdSubState string `json:"systemdSubState,omitempty"`
+
+	// ServerResponse contains the HTTP response code and headers from the
+	// server.
+	googleapi.ServerResponse `json:"-"`
+
+	// ForceSendFields is a list of field names (e.g. "Hash") to
+	// unconditionally include in API requests. By default, fields with
+	// empty values are omitted from API requests. However, any non-pointer,
+	// non-interface field appearing in ForceSendFields will be sent to the
+	// server regardless of whether the field is empty or not. This may be
+	// used to include empty fields in Patch requests.
+	ForceSendFields []string `json:"-"`
+
+	// NullFields is a list of field names (e.g. "Hash") to include in API
+	// requests with the JSON null value. By default, fields with empty
+	// values are omitted from API requests. However, any field with an
+	// empty value appearing in NullFields will be sent to the server as
+	// null. It is an error if a field in this list has a non-empty value.
+	// This may be used to include null fields in Patch requests.
+	NullFields []string `json:"-"`
+}
+
+func (s *UnitState) MarshalJSON() ([]byte, error) {
+	type noMethod UnitState
+	raw := noMethod(*s)
+	return gensupport.MarshalJSON(raw, s.ForceSendFields, s.NullFields)
+}
+
+type UnitStateHistory struct {
+	Name string `json:"name,omitempty"`
+
+	Transitions []*UnitStateTransition `json:"transitions,omitempty"`
+
+	// ServerResponse contains the HTTP response code and headers from the
+	// server.
+	googleapi.ServerResponse `json:"-"`
+
+	// ForceSendFields is a list of field names (e.g. "Name") to
+	// unconditionally include in API requests. By default, fields with
+	// empty values are omitted from API requests. However, any non-pointer,
+	// non-interface field appearing in ForceSendFields will be sent to the
+	// server regardless of whether the field is empty or not. This may be
+	// used to include empty fields in Patch requests.
+	ForceSendFields []string `json:"-"`
+
+	// NullFields is a list of field names (e.g. "Name") to include in API
+	// requests with the JSON null value. By default, fields with empty
+	// values are omitted from API requests. However, any field with an
+	// empty value appearing in NullFields will be sent to the server as
+	// null. It is an error if a field in this list has a non-empty value.
+	// This may be used to include null fields in Patch requests.
+	NullFields []string `json:"-"`
+}
+
+func (s *UnitStateHistory) MarshalJSON() ([]byte, error) {
+	type noMethod UnitStateHistory
+	raw := noMethod(*s)
+	return gensupport.MarshalJSON(raw, s.ForceSendFields, s.NullFields)
+}
+
+type UnitStatePage struct {
+	NextPageToken string `json:"nextPageToken,omitempty"`
+
+	States []*UnitState `json:"states,omitempty"`
+
+	// ServerResponse contains the HTTP response code and headers from the
+	// server.
+	googleapi.ServerResponse `json:"-"`
+
+	// ForceSendFields is a list of field names (e.g. "NextPageToken") to
+	// unconditionally include in API requests. By default, fields with
+	// empty values are omitted from API requests. However, any non-pointer,
+	// non-interface field appearing in ForceSendFields will be sent to the
+	// server regardless of whether the field is empty or not. This may be
+	// used to include empty fields in Patch requests.
+	ForceSendFields []string `json:"-"`
+
+	// NullFields is a list of field names (e.g. "NextPageToken") to include
+	// in API requests with the JSON null value. By default, fields with
+	// empty values are omitted from API requests. However, any field with
+	// an empty value appearing in NullFields will be sent to the server as
+	// null. It is an error if a field in this list has a non-empty value.
+	// This may be used to include null fields in Patch requests.
+	NullFields []string `json:"-"`
+}
+
+func (s *UnitStatePage) MarshalJSON() ([]byte, error) {
+	type noMethod UnitStatePage
+	raw := noMethod(*s)
+	return gensupport.MarshalJSON(raw, s.ForceSendFields, s.NullFields)
+}
+
+type UnitStateTransition struct {
+	ActiveState string `json:"activeState,omitempty"`
+
+	LoadState string `json:"loadState,omitempty"`
+
+	MachineID string `json:"machineID,omitempty"`
+
+	SubState string `json:"subState,omitempty"`
+
+	Time string `json:"time,omitempty"`
+
+	UnitHash string `json:"unitHash,omitempty"`
+
+	// ForceSendFields is a list of field names (e.g. "ActiveState") to
+	// unconditionally include in API requests. By default, fields with
+	// empty values are omitted from API requests. However, any non-pointer,
+	// non-interface field appearing in ForceSendFields will be sent to the
+	// server regardless of whether the field is empty or not. This may be
+	// used to include empty fields in Patch requests.
+	ForceSendFields []string `json:"-"`
+
+	// NullFields is a list of field names (e.g. "ActiveState") to include
+	// in API requests with the JSON null value. By default, fields with
+	// empty values are omitted from API requests. However, any field with
+	// an empty value appearing in NullFields will be sent to the server as
+	// null. It is an error if a field in this list has a non-empty value.
+	// This may be used to include null fields in Patch requests.
+	NullFields []string `json:"-"`
+}
+
+func (s *UnitStateTransition) MarshalJSON() ([]byte, error) {
+	type noMethod UnitStateTransition
+	raw := noMethod(*s)
+	return gensupport.MarshalJSON(raw, s.ForceSendFields, s.NullFields)
+}
+
+// method id "fleet.Engine.Get":
+
+type EngineGetCall struct {
+	s            *Service
+	urlParams_   gensupport.URLParams
+	ifNoneMatch_ string
+	ctx_         context.Context
+	header_      http.Header
+}
+
+// Get: Retrieve the status of the engine leader.
+func (r *EngineService) Get() *EngineGetCall {
+	c := &EngineGetCall{s: r.s, urlParams_: make(gensupport.URLParams)}
+	return c
+}
+
+// Fields allows partial responses to be retrieved. See
+// https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
+// for more information.
+func (c *EngineGetCall) Fields(s ...googleapi.Field) *EngineGetCall {
+	c.urlParams_.Set("fields", googleapi.CombineFields(s))
+	return c
+}
+
+// IfNoneMatch sets the optional parameter which makes the operation
+// fail if the object's ETag matches the given value. This is useful for
+// getting updates only after the object has changed since the last
+// request. Use googleapi.IsNotModified to check whether the response
+// error from Do is the result of In-None-Match.
+func (c *EngineGetCall) IfNoneMatch(entityTag string) *EngineGetCall {
+	c.ifNoneMatch_ = entityTag
+	return c
+}
+
+// Context sets the context to be used in this call's Do method. Any
+// pending HTTP request will be aborted if the provided context is
+// canceled.
+func (c *EngineGetCall) Context(ctx context.Context) *EngineGetCall {
+	c.ctx_ = ctx
+	return c
+}
+
+// Header returns an http.Header that can be modified by the caller to
+// add HTTP headers to the request.
+func (c *EngineGetCall) Header() http.Header {
+	if c.header_ == nil {
+		c.header_ = make(http.Header)
+	}
+	return c.header_
+}
+
+func (c *EngineGetCall) doRequest(alt string) (*http.Response, error) {
+	reqHeaders := make(http.Header)
+	for k, v := range c.header_ {
+		reqHeaders[k] = v
+	}
+	reqHeaders.Set("User-Agent", c.s.userAgent())
+	if c.ifNoneMatch_ != "" {
+		reqHeaders.Set("If-None-Match", c.ifNoneMatch_)
+	}
+	var body io.Reader = nil
+	c.urlParams_.Set("alt", alt)
+	urls := googleapi.ResolveRelative(c.s.BasePath, "engine")
+	urls += "?" + c.urlParams_.Encode()
+	req, _ := http.NewRequest("GET", urls, body)
+	req.Header = reqHeaders
+	return gensupport.SendRequest(c.ctx_, c.s.client, req)
+}
+
+// Do executes the "fleet.Engine.Get" call.
+// Exactly one of *EngineStatus or error will be non-nil. Any non-2xx
+// status code is an error. Response headers are in either
+// *EngineStatus.ServerResponse.Header or (if a response was returned at
+// all) in error.(*googleapi.Error).Header. Use googleapi.IsNotModified
+// to check whether the returned error was because
+// http.StatusNotModified was returned.
+func (c *EngineGetCall) Do(opts ...googleapi.CallOption) (*EngineStatus, error) {
+	gensupport.SetOptions(c.urlParams_, opts...)
+	res, err := c.doRequest("json")
+	if res != nil && res.StatusCode == http.StatusNotModified {
+		if res.Body != nil {
+			res.Body.Close()
+		}
+		return nil, &googleapi.Error{
+			Code:   res.StatusCode,
+			Header: res.Header,
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer googleapi.CloseBody(res)
+	if err := googleapi.CheckResponse(res); err != nil {
+		return nil, err
+	}
+	ret := &EngineStatus{
+		ServerResponse: googleapi.ServerResponse{
+			Header:         res.Header,
+			HTTPStatusCode: res.StatusCode,
+		},
+	}
+	target := &ret
+	if err := json.NewDecoder(res.Body).Decode(target); err != nil {
+		return nil, err
+	}
+	return ret, nil
+	// {
+	//   "description": "Retrieve the status of the engine leader.",
+	//   "httpMethod": "GET",
+	//   "id": "fleet.Engine.Get",
+	//   "path": "engine",
+	//   "response": {
+	//     "$ref": "EngineStatus"
+	//   }
+	// }
+
+}
+
+// method id "fleet.Engine.StepDown":
+
+type EngineStepDownCall struct {
+	s          *Service
+	urlParams_ gensupport.URLParams
+	ctx_       context.Context
+	header_    http.Header
+}
+
+// StepDown: Release the engine leadership, making the current leader
+// step down.
+func (r *EngineService) StepDown() *EngineStepDownCall {
+	c := &EngineStepDownCall{s: r.s, urlParams_: make(gensupport.URLParams)}
+	return c
+}
+
+// Fields allows partial responses to be retrieved. See
+// https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
+// for more information.
+func (c *EngineStepDownCall) Fields(s ...googleapi.Field) *EngineStepDownCall {
+	c.urlParams_.Set("fields", googleapi.CombineFields(s))
+	return c
+}
+
+// Context sets the context to be used in this call's Do method. Any
+// pending HTTP request will be aborted if the provided context is
+// canceled.
+func (c *EngineStepDownCall) Context(ctx context.Context) *EngineStepDownCall {
+	c.ctx_ = ctx
+	return c
+}
+
+// Header returns an http.Header that can be modified by the caller to
+// add HTTP headers to the request.
+func (c *EngineStepDownCall) Header() http.Header {
+	if c.header_ == nil {
+		c.header_ = make(http.Header)
+	}
+	return c.header_
+}
+
+func (c *EngineStepDownCall) doRequest(alt string) (*http.Response, error) {
+	reqHeaders := make(http.Header)
+	for k, v := range c.header_ {
+		reqHeaders[k] = v
+	}
+	reqHeaders.Set("User-Agent", c.s.userAgent())
+	var body io.Reader = nil
+	c.urlParams_.Set("alt", alt)
+	urls := googleapi.ResolveRelative(c.s.BasePath, "engine/leader")
+	urls += "?" + c.urlParams_.Encode()
+	req, _ := http.NewRequest("DELETE", urls, body)
+	req.Header = reqHeaders
+	return gensupport.SendRequest(c.ctx_, c.s.client, req)
+}
+
+// Do executes the "fleet.Engine.StepDown" call.
+func (c *EngineStepDownCall) Do(opts ...googleapi.CallOption) error {
+	gensupport.SetOptions(c.urlParams_, opts...)
+	res, err := c.doRequest("json")
+	if err != nil {
+		return err
+	}
+	defer googleapi.CloseBody(res)
+	if err := googleapi.CheckResponse(res); err != nil {
+		return err
+	}
+	return nil
+	// {
+	//   "description": "Release the engine leadership, making the current leader step down.",
+	//   "httpMethod": "DELETE",
+	//   "id": "fleet.Engine.StepDown",
+	//   "path": "engine/leader"
+	// }
+
+}
+
+// method id "fleet.Machine.List":
+
+type MachinesListCall struct {
+	s            *Service
+	urlParams_   gensupport.URLParams
+	ifNoneMatch_ string
+	ctx_         context.Context
+	header_      http.Header
+}
+
+// List: Retrieve a page of Machine objects.
+func (r *MachinesService) List() *MachinesListCall {
+	c := &MachinesListCall{s: r.s, urlParams_: make(gensupport.URLParams)}
+	return c
+}
+
+// NextPageToken sets the optional parameter "nextPageToken":
+func (c *MachinesListCall) NextPageToken(nextPageToken string) *MachinesListCall {
+	c.urlParams_.Set("nextPageToken", nextPageToken)
+	return c
+}
+
+// Fields allows partial responses to be retrieved. See
+// https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
+// for more information.
+func (c *MachinesListCall) Fields(s ...googleapi.Field) *MachinesListCall {
+	c.urlParams_.Set("fields", googleapi.CombineFields(s))
+	return c
+}
+
+// IfNoneMatch sets the optional parameter which makes the operation
+// fail if the object's ETag matches the given value. This is useful for
+// getting updates only after the object has changed since the last
+// request. Use googleapi.IsNotModified to check whether the response
 // error from Do is the result of In-None-Match.
 func (c *MachinesListCall) IfNoneMatch(entityTag string) *MachinesListCall {
 	c.ifNoneMatch_ = entityTag
@@ -427,21 +1052,265 @@ func (c *MachinesListCall) doRequest(alt string) (*http.Response, error) {
 	}
 	var body io.Reader = nil
 	c.urlParams_.Set("alt", alt)
-	urls := googleapi.ResolveRelative(c.s.BasePath, "machines")
+	urls := googleapi.ResolveRelative(c.s.BasePath, "machines")
+	urls += "?" + c.urlParams_.Encode()
+	req, _ := http.NewRequest("GET", urls, body)
+	req.Header = reqHeaders
+	return gensupport.SendRequest(c.ctx_, c.s.client, req)
+}
+
+// Do executes the "fleet.Machine.List" call.
+// Exactly one of *MachinePage or error will be non-nil. Any non-2xx
+// status code is an error. Response headers are in either
+// *MachinePage.ServerResponse.Header or (if a response was returned at
+// all) in error.(*googleapi.Error).Header. Use googleapi.IsNotModified
+// to check whether the returned error was because
+// http.StatusNotModified was returned.
+func (c *MachinesListCall) Do(opts ...googleapi.CallOption) (*MachinePage, error) {
+	gensupport.SetOptions(c.urlParams_, opts...)
+	res, err := c.doRequest("json")
+	if res != nil && res.StatusCode == http.StatusNotModified {
+		if res.Body != nil {
+			res.Body.Close()
+		}
+		return nil, &googleapi.Error{
+			Code:   res.StatusCode,
+			Header: res.Header,
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer googleapi.CloseBody(res)
+	if err := googleapi.CheckResponse(res); err != nil {
+		return nil, err
+	}
+	ret := &MachinePage{
+		ServerResponse: googleapi.ServerResponse{
+			Header:         res.Header,
+			HTTPStatusCode: res.StatusCode,
+		},
+	}
+	target := &ret
+	if err := json.NewDecoder(res.Body).Decode(target); err != nil {
+		return nil, err
+	}
+	return ret, nil
+	// {
+	//   "description": "Retrieve a page of Machine objects.",
+	//   "httpMethod": "GET",
+	//   "id": "fleet.Machine.List",
+	//   "parameters": {
+	//     "nextPageToken": {
+	//       "location": "query",
+	//       "type": "string"
+	//     }
+	//   },
+	//   "path": "machines",
+	//   "response": {
+	//     "$ref": "MachinePage"
+	//   }
+	// }
+
+}
+
+// method id "fleet.Plan.Create":
+
+type PlanCreateCall struct {
+	s           *Service
+	planrequest *PlanRequest
+	urlParams_  gensupport.URLParams
+	ctx_        context.Context
+	header_     http.Header
+}
+
+// Create: Simulate scheduling of the cluster with proposed changes
+// applied.
+func (r *PlanService) Create(planrequest *PlanRequest) *PlanCreateCall {
+	c := &PlanCreateCall{s: r.s, urlParams_: make(gensupport.URLParams)}
+	c.planrequest = planrequest
+	return c
+}
+
+// Fields allows partial responses to be retrieved. See
+// https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
+// for more information.
+func (c *PlanCreateCall) Fields(s ...googleapi.Field) *PlanCreateCall {
+	c.urlParams_.Set("fields", googleapi.CombineFields(s))
+	return c
+}
+
+// Context sets the context to be used in this call's Do method. Any
+// pending HTTP request will be aborted if the provided context is
+// canceled.
+func (c *PlanCreateCall) Context(ctx context.Context) *PlanCreateCall {
+	c.ctx_ = ctx
+	return c
+}
+
+// Header returns an http.Header that can be modified by the caller to
+// add HTTP headers to the request.
+func (c *PlanCreateCall) Header() http.Header {
+	if c.header_ == nil {
+		c.header_ = make(http.Header)
+	}
+	return c.header_
+}
+
+func (c *PlanCreateCall) doRequest(alt string) (*http.Response, error) {
+	reqHeaders := make(http.Header)
+	for k, v := range c.header_ {
+		reqHeaders[k] = v
+	}
+	reqHeaders.Set("User-Agent", c.s.userAgent())
+	var body io.Reader = nil
+	body, err := googleapi.WithoutDataWrapper.JSONReader(c.planrequest)
+	if err != nil {
+		return nil, err
+	}
+	reqHeaders.Set("Content-Type", "application/json")
+	c.urlParams_.Set("alt", alt)
+	urls := googleapi.ResolveRelative(c.s.BasePath, "plan")
+	urls += "?" + c.urlParams_.Encode()
+	req, _ := http.NewRequest("POST", urls, body)
+	req.Header = reqHeaders
+	return gensupport.SendRequest(c.ctx_, c.s.client, req)
+}
+
+// Do executes the "fleet.Plan.Create" call.
+// Exactly one of *Plan or error will be non-nil. Any non-2xx status
+// code is an error. Response headers are in either
+// *Plan.ServerResponse.Header or (if a response was returned at all) in
+// error.(*googleapi.Error).Header. Use googleapi.IsNotModified to check
+// whether the returned error was because http.StatusNotModified was
+// returned.
+func (c *PlanCreateCall) Do(opts ...googleapi.CallOption) (*Plan, error) {
+	gensupport.SetOptions(c.urlParams_, opts...)
+	res, err := c.doRequest("json")
+	if res != nil && res.StatusCode == http.StatusNotModified {
+		if res.Body != nil {
+			res.Body.Close()
+		}
+		return nil, &googleapi.Error{
+			Code:   res.StatusCode,
+			Header: res.Header,
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer googleapi.CloseBody(res)
+	if err := googleapi.CheckResponse(res); err != nil {
+		return nil, err
+	}
+	ret := &Plan{
+		ServerResponse: googleapi.ServerResponse{
+			Header:         res.Header,
+			HTTPStatusCode: res.StatusCode,
+		},
+	}
+	target := &ret
+	if err := json.NewDecoder(res.Body).Decode(target); err != nil {
+		return nil, err
+	}
+	return ret, nil
+	// {
+	//   "description": "Simulate scheduling of the cluster with proposed changes applied.",
+	//   "httpMethod": "POST",
+	//   "id": "fleet.Plan.Create",
+	//   "path": "plan",
+	//   "request": {
+	//     "$ref": "PlanRequest"
+	//   },
+	//   "response": {
+	//     "$ref": "Plan"
+	//   }
+	// }
+
+}
+
+// method id "fleet.UnitState.Get":
+
+type UnitStateGetCall struct {
+	s            *Service
+	unitName     string
+	urlParams_   gensupport.URLParams
+	ifNoneMatch_ string
+	ctx_         context.Context
+	header_      http.Header
+}
+
+// Get: Retrieve a page of a single UnitState object.
+func (r *UnitStateService) Get(unitName string) *UnitStateGetCall {
+	c := &UnitStateGetCall{s: r.s, urlParams_: make(gensupport.URLParams)}
+	c.unitName = unitName
+	return c
+}
+
+// Fields allows partial responses to be retrieved. See
+// https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
+// for more information.
+func (c *UnitStateGetCall) Fields(s ...googleapi.Field) *UnitStateGetCall {
+	c.urlParams_.Set("fields", googleapi.CombineFields(s))
+	return c
+}
+
+// IfNoneMatch sets the optional parameter which makes the operation
+// fail if the object's ETag matches the given value. This is useful for
+// getting updates only after the object has changed since the last
+// request. Use googleapi.IsNotModified to check whether the response
+// error from Do is the result of In-None-Match.
+func (c *UnitStateGetCall) IfNoneMatch(entityTag string) *UnitStateGetCall {
+	c.ifNoneMatch_ = entityTag
+	return c
+}
+
+// Context sets the context to be used in this call's Do method. Any
+// pending HTTP request will be aborted if the provided context is
+// canceled.
+func (c *UnitStateGetCall) Context(ctx context.Context) *UnitStateGetCall {
+	c.ctx_ = ctx
+	return c
+}
+
+// Header returns an http.Header that can be modified by the caller to
+// add HTTP headers to the request.
+func (c *UnitStateGetCall) Header() http.Header {
+	if c.header_ == nil {
+		c.header_ = make(http.Header)
+	}
+	return c.header_
+}
+
+func (c *UnitStateGetCall) doRequest(alt string) (*http.Response, error) {
+	reqHeaders := make(http.Header)
+	for k, v := range c.header_ {
+		reqHeaders[k] = v
+	}
+	reqHeaders.Set("User-Agent", c.s.userAgent())
+	if c.ifNoneMatch_ != "" {
+		reqHeaders.Set("If-None-Match", c.ifNoneMatch_)
+	}
+	var body io.Reader = nil
+	c.urlParams_.Set("alt", alt)
+	urls := googleapi.ResolveRelative(c.s.BasePath, "state/{unitName}")
 	urls += "?" + c.urlParams_.Encode()
 	req, _ := http.NewRequest("GET", urls, body)
 	req.Header = reqHeaders
+	googleapi.Expand(req.URL, map[string]string{
+		"unitName": c.unitName,
+	})
 	return gensupport.SendRequest(c.ctx_, c.s.client, req)
 }
 
-// Do executes the "fleet.Machine.List" call.
-// Exactly one of *MachinePage or error will be non-nil. Any non-2xx
+// Do executes the "fleet.UnitState.Get" call.
+// Exactly one of *UnitState or error will be non-nil. Any non-2xx
 // status code is an error. Response headers are in either
-// *MachinePage.ServerResponse.Header or (if a response was returned at
+// *UnitState.ServerResponse.Header or (if a response was returned at
 // all) in error.(*googleapi.Error).Header. Use googleapi.IsNotModified
 // to check whether the returned error was because
 // http.StatusNotModified was returned.
-func (c *MachinesListCall) Do(opts ...googleapi.CallOption) (*MachinePage, error) {
+func (c *UnitStateGetCall) Do(opts ...googleapi.CallOption) (*UnitState, error) {
 	gensupport.SetOptions(c.urlParams_, opts...)
 	res, err := c.doRequest("json")
 	if res != nil && res.StatusCode == http.StatusNotModified {
@@ -460,7 +1329,7 @@ func (c *MachinesListCall) Do(opts ...googleapi.CallOption) (*MachinePage, error
 	if err := googleapi.CheckResponse(res); err != nil {
 		return nil, err
 	}
-	ret := &MachinePage{
+	ret := &UnitState{
 		ServerResponse: googleapi.ServerResponse{
 			Header:         res.Header,
 			HTTPStatusCode: res.StatusCode,
@@ -472,26 +1341,30 @@ func (c *MachinesListCall) Do(opts ...googleapi.CallOption) (*MachinePage, error
 	}
 	return ret, nil
 	// {
-	//   "description": "Retrieve a page of Machine objects.",
+	//   "description": "Retrieve a page of a single UnitState object.",
 	//   "httpMethod": "GET",
-	//   "id": "fleet.Machine.List",
+	//   "id": "fleet.UnitState.Get",
+	//   "parameterOrder": [
+	//     "unitName"
+	//   ],
 	//   "parameters": {
-	//     "nextPageToken": {
-	//       "location": "query",
+	//     "unitName": {
+	//       "location": "path",
+	//       "required": true,
 	//       "type": "string"
 	//     }
 	//   },
-	//   "path": "machines",
+	//   "path": "state/{unitName}",
 	//   "response": {
-	//     "$ref": "MachinePage"
+	//     "$ref": "UnitState"
 	//   }
 	// }
 
 }
 
-// method id "fleet.UnitState.Get":
+// method id "fleet.UnitState.History":
 
-type UnitStateGetCall struct {
+type UnitStateHistoryCall struct {
 	s            *Service
 	unitName     string
 	urlParams_   gensupport.URLParams
@@ -500,9 +1373,9 @@ type UnitStateGetCall struct {
 	header_      http.Header
 }
 
-// Get: Retrieve a page of a single UnitState object.
-func (r *UnitStateService) Get(unitName string) *UnitStateGetCall {
-	c := &UnitStateGetCall{s: r.s, urlParams_: make(gensupport.URLParams)}
+// History: Retrieve the recent state transitions of a single Unit.
+func (r *UnitStateService) History(unitName string) *UnitStateHistoryCall {
+	c := &UnitStateHistoryCall{s: r.s, urlParams_: make(gensupport.URLParams)}
 	c.unitName = unitName
 	return c
 }
@@ -510,7 +1383,7 @@ func (r *UnitStateService) Get(unitName string) *UnitStateGetCall {
 // Fields allows partial responses to be retrieved. See
 // https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
 // for more information.
-func (c *UnitStateGetCall) Fields(s ...googleapi.Field) *UnitStateGetCall {
+func (c *UnitStateHistoryCall) Fields(s ...googleapi.Field) *UnitStateHistoryCall {
 	c.urlParams_.Set("fields", googleapi.CombineFields(s))
 	return c
 }
@@ -520,7 +1393,7 @@ func (c *UnitStateGetCall) Fields(s ...googleapi.Field) *UnitStateGetCall {
 // getting updates only after the object has changed since the last
 // request. Use googleapi.IsNotModified to check whether the response
 // error from Do is the result of In-None-Match.
-func (c *UnitStateGetCall) IfNoneMatch(entityTag string) *UnitStateGetCall {
+func (c *UnitStateHistoryCall) IfNoneMatch(entityTag string) *UnitStateHistoryCall {
 	c.ifNoneMatch_ = entityTag
 	return c
 }
@@ -528,21 +1401,21 @@ func (c *UnitStateGetCall) IfNoneMatch(entityTag string) *UnitStateGetCall {
 // Context sets the context to be used in this call's Do method. Any
 // pending HTTP request will be aborted if the provided context is
 // canceled.
-func (c *UnitStateGetCall) Context(ctx context.Context) *UnitStateGetCall {
+func (c *UnitStateHistoryCall) Context(ctx context.Context) *UnitStateHistoryCall {
 	c.ctx_ = ctx
 	return c
 }
 
 // Header returns an http.Header that can be modified by the caller to
 // add HTTP headers to the request.
-func (c *UnitStateGetCall) Header() http.Header {
+func (c *UnitStateHistoryCall) Header() http.Header {
 	if c.header_ == nil {
 		c.header_ = make(http.Header)
 	}
 	return c.header_
 }
 
-func (c *UnitStateGetCall) doRequest(alt string) (*http.Response, error) {
+func (c *UnitStateHistoryCall) doRequest(alt string) (*http.Response, error) {
 	reqHeaders := make(http.Header)
 	for k, v := range c.header_ {
 		reqHeaders[k] = v
@@ -553,7 +1426,7 @@ func (c *UnitStateGetCall) doRequest(alt string) (*http.Response, error) {
 	}
 	var body io.Reader = nil
 	c.urlParams_.Set("alt", alt)
-	urls := googleapi.ResolveRelative(c.s.BasePath, "state/{unitName}")
+	urls := googleapi.ResolveRelative(c.s.BasePath, "state/{unitName}/history")
 	urls += "?" + c.urlParams_.Encode()
 	req, _ := http.NewRequest("GET", urls, body)
 	req.Header = reqHeaders
@@ -563,14 +1436,14 @@ func (c *UnitStateGetCall) doRequest(alt string) (*http.Response, error) {
 	return gensupport.SendRequest(c.ctx_, c.s.client, req)
 }
 
-// Do executes the "fleet.UnitState.Get" call.
-// Exactly one of *UnitState or error will be non-nil. Any non-2xx
-// status code is an error. Response headers are in either
-// *UnitState.ServerResponse.Header or (if a response was returned at
-// all) in error.(*googleapi.Error).Header. Use googleapi.IsNotModified
-// to check whether the returned error was because
-// http.StatusNotModified was returned.
-func (c *UnitStateGetCall) Do(opts ...googleapi.CallOption) (*UnitState, error) {
+// Do executes the "fleet.UnitState.History" call.
+// Exactly one of *UnitStateHistory or error will be non-nil. Any
+// non-2xx status code is an error. Response headers are in either
+// *UnitStateHistory.ServerResponse.Header or (if a response was
+// returned at all) in error.(*googleapi.Error).Header. Use
+// googleapi.IsNotModified to check whether the returned error was
+// because http.StatusNotModified was returned.
+func (c *UnitStateHistoryCall) Do(opts ...googleapi.CallOption) (*UnitStateHistory, error) {
 	gensupport.SetOptions(c.urlParams_, opts...)
 	res, err := c.doRequest("json")
 	if res != nil && res.StatusCode == http.StatusNotModified {
@@ -589,7 +1462,7 @@ func (c *UnitStateGetCall) Do(opts ...googleapi.CallOption) (*UnitState, error)
 	if err := googleapi.CheckResponse(res); err != nil {
 		return nil, err
 	}
-	ret := &UnitState{
+	ret := &UnitStateHistory{
 		ServerResponse: googleapi.ServerResponse{
 			Header:         res.Header,
 			HTTPStatusCode: res.StatusCode,
@@ -601,9 +1474,9 @@ func (c *UnitStateGetCall) Do(opts ...googleapi.CallOption) (*UnitState, error)
 	}
 	return ret, nil
 	// {
-	//   "description": "Retrieve a page of a single UnitState object.",
+	//   "description": "Retrieve the recent state transitions of a single Unit.",
 	//   "httpMethod": "GET",
-	//   "id": "fleet.UnitState.Get",
+	//   "id": "fleet.UnitState.History",
 	//   "parameterOrder": [
 	//     "unitName"
 	//   ],
@@ -614,9 +1487,9 @@ func (c *UnitStateGetCall) Do(opts ...googleapi.CallOption) (*UnitState, error)
 	//       "type": "string"
 	//     }
 	//   },
-	//   "path": "state/{unitName}",
+	//   "path": "state/{unitName}/history",
 	//   "response": {
-	//     "$ref": "UnitState"
+	//     "$ref": "UnitStateHistory"
 	//   }
 	// }
 
@@ -1126,6 +1999,240 @@ func (c *UnitsListCall) Do(opts ...googleapi.CallOption) (*UnitPage, error) {
 
 }
 
+// method id "fleet.Unit.Patch":
+
+type UnitsPatchCall struct {
+	s          *Service
+	unitName   string
+	unit       *Unit
+	urlParams_ gensupport.URLParams
+	ctx_       context.Context
+	header_    http.Header
+}
+
+// Patch: Modify the replica count of a template Unit.
+func (r *UnitsService) Patch(unitName string, unit *Unit) *UnitsPatchCall {
+	c := &UnitsPatchCall{s: r.s, urlParams_: make(gensupport.URLParams)}
+	c.unitName = unitName
+	c.unit = unit
+	return c
+}
+
+// Fields allows partial responses to be retrieved. See
+// https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
+// for more information.
+func (c *UnitsPatchCall) Fields(s ...googleapi.Field) *UnitsPatchCall {
+	c.urlParams_.Set("fields", googleapi.CombineFields(s))
+	return c
+}
+
+// Context sets the context to be used in this call's Do method. Any
+// pending HTTP request will be aborted if the provided context is
+// canceled.
+func (c *UnitsPatchCall) Context(ctx context.Context) *UnitsPatchCall {
+	c.ctx_ = ctx
+	return c
+}
+
+// Header returns an http.Header that can be modified by the caller to
+// add HTTP headers to the request.
+func (c *UnitsPatchCall) Header() http.Header {
+	if c.header_ == nil {
+		c.header_ = make(http.Header)
+	}
+	return c.header_
+}
+
+func (c *UnitsPatchCall) doRequest(alt string) (*http.Response, error) {
+	reqHeaders := make(http.Header)
+	for k, v := range c.header_ {
+		reqHeaders[k] = v
+	}
+	reqHeaders.Set("User-Agent", c.s.userAgent())
+	var body io.Reader = nil
+	body, err := googleapi.WithoutDataWrapper.JSONReader(c.unit)
+	if err != nil {
+		return nil, err
+	}
+	reqHeaders.Set("Content-Type", "application/json")
+	c.urlParams_.Set("alt", alt)
+	urls := googleapi.ResolveRelative(c.s.BasePath, "units/{unitName}")
+	urls += "?" + c.urlParams_.Encode()
+	req, _ := http.NewRequest("PATCH", urls, body)
+	req.Header = reqHeaders
+	googleapi.Expand(req.URL, map[string]string{
+		"unitName": c.unitName,
+	})
+	return gensupport.SendRequest(c.ctx_, c.s.client, req)
+}
+
+// Do executes the "fleet.Unit.Patch" call.
+func (c *UnitsPatchCall) Do(opts ...googleapi.CallOption) error {
+	gensupport.SetOptions(c.urlParams_, opts...)
+	res, err := c.doRequest("json")
+	if err != nil {
+		return err
+	}
+	defer googleapi.CloseBody(res)
+	if err := googleapi.CheckResponse(res); err != nil {
+		return err
+	}
+	return nil
+	// {
+	//   "description": "Modify the replica count of a template Unit.",
+	//   "httpMethod": "PATCH",
+	//   "id": "fleet.Unit.Patch",
+	//   "parameterOrder": [
+	//     "unitName"
+	//   ],
+	//   "parameters": {
+	//     "unitName": {
+	//       "location": "path",
+	//       "required": true,
+	//       "type": "string"
+	//     }
+	//   },
+	//   "path": "units/{unitName}",
+	//   "request": {
+	//     "$ref": "Unit"
+	//   }
+	// }
+
+}
+
+// method id "fleet.Unit.Scheduling":
+
+type UnitsSchedulingCall struct {
+	s            *Service
+	unitName     string
+	urlParams_   gensupport.URLParams
+	ifNoneMatch_ string
+	ctx_         context.Context
+	header_      http.Header
+}
+
+// Scheduling: Explain why a Unit is not scheduled.
+func (r *UnitsService) Scheduling(unitName string) *UnitsSchedulingCall {
+	c := &UnitsSchedulingCall{s: r.s, urlParams_: make(gensupport.URLParams)}
+	c.unitName = unitName
+	return c
+}
+
+// Fields allows partial responses to be retrieved. See
+// https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
+// for more information.
+func (c *UnitsSchedulingCall) Fields(s ...googleapi.Field) *UnitsSchedulingCall {
+	c.urlParams_.Set("fields", googleapi.CombineFields(s))
+	return c
+}
+
+// IfNoneMatch sets the optional parameter which makes the operation
+// fail if the object's ETag matches the given value. This is useful for
+// getting updates only after the object has changed since the last
+// request. Use googleapi.IsNotModified to check whether the response
+// error from Do is the result of In-None-Match.
+func (c *UnitsSchedulingCall) IfNoneMatch(entityTag string) *UnitsSchedulingCall {
+	c.ifNoneMatch_ = entityTag
+	return c
+}
+
+// Context sets the context to be used in this call's Do method. Any
+// pending HTTP request will be aborted if the provided context is
+// canceled.
+func (c *UnitsSchedulingCall) Context(ctx context.Context) *UnitsSchedulingCall {
+	c.ctx_ = ctx
+	return c
+}
+
+// Header returns an http.Header that can be modified by the caller to
+// add HTTP headers to the request.
+func (c *UnitsSchedulingCall) Header() http.Header {
+	if c.header_ == nil {
+		c.header_ = make(http.Header)
+	}
+	return c.header_
+}
+
+func (c *UnitsSchedulingCall) doRequest(alt string) (*http.Response, error) {
+	reqHeaders := make(http.Header)
+	for k, v := range c.header_ {
+		reqHeaders[k] = v
+	}
+	reqHeaders.Set("User-Agent", c.s.userAgent())
+	if c.ifNoneMatch_ != "" {
+		reqHeaders.Set("If-None-Match", c.ifNoneMatch_)
+	}
+	var body io.Reader = nil
+	c.urlParams_.Set("alt", alt)
+	urls := googleapi.ResolveRelative(c.s.BasePath, "units/{unitName}/scheduling")
+	urls += "?" + c.urlParams_.Encode()
+	req, _ := http.NewRequest("GET", urls, body)
+	req.Header = reqHeaders
+	googleapi.Expand(req.URL, map[string]string{
+		"unitName": c.unitName,
+	})
+	return gensupport.SendRequest(c.ctx_, c.s.client, req)
+}
+
+// Do executes the "fleet.Unit.Scheduling" call.
+// Exactly one of *UnitScheduling or error will be non-nil. Any non-2xx
+// status code is an error. Response headers are in either
+// *UnitScheduling.ServerResponse.Header or (if a response was returned
+// at all) in error.(*googleapi.Error).Header. Use
+// googleapi.IsNotModified to check whether the returned error was
+// because http.StatusNotModified was returned.
+func (c *UnitsSchedulingCall) Do(opts ...googleapi.CallOption) (*UnitScheduling, error) {
+	gensupport.SetOptions(c.urlParams_, opts...)
+	res, err := c.doRequest("json")
+	if res != nil && res.StatusCode == http.StatusNotModified {
+		if res.Body != nil {
+			res.Body.Close()
+		}
+		return nil, &googleapi.Error{
+			Code:   res.StatusCode,
+			Header: res.Header,
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer googleapi.CloseBody(res)
+	if err := googleapi.CheckResponse(res); err != nil {
+		return nil, err
+	}
+	ret := &UnitScheduling{
+		ServerResponse: googleapi.ServerResponse{
+			Header:         res.Header,
+			HTTPStatusCode: res.StatusCode,
+		},
+	}
+	target := &ret
+	if err := json.NewDecoder(res.Body).Decode(target); err != nil {
+		return nil, err
+	}
+	return ret, nil
+	// {
+	//   "description": "Explain why a Unit is not scheduled.",
+	//   "httpMethod": "GET",
+	//   "id": "fleet.Unit.Scheduling",
+	//   "parameterOrder": [
+	//     "unitName"
+	//   ],
+	//   "parameters": {
+	//     "unitName": {
+	//       "location": "path",
+	//       "required": true,
+	//       "type": "string"
+	//     }
+	//   },
+	//   "path": "units/{unitName}/scheduling",
+	//   "response": {
+	//     "$ref": "UnitScheduling"
+	//   }
+	// }
+
+}
+
 // method id "fleet.Unit.Set":
 
 type UnitsSetCall struct {
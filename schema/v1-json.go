@@ -58,6 +58,14 @@ const DiscoveryJSON = `{
           "additionalProperties": {
             "type": "string"
           }
+        },
+        "state": {
+          "type": "string",
+          "enum": [
+            "schedulable",
+            "cordoned",
+            "draining"
+          ]
         }
       }
     },
@@ -117,12 +125,16 @@ const DiscoveryJSON = `{
           "enum": [
             "inactive",
             "loaded",
-            "launched"
+            "launched",
+            "machine-lost"
           ]
         },
         "machineID": {
           "type": "string",
           "required": true
+        },
+        "replicas": {
+          "type": "integer"
         }
       }
     },
@@ -162,6 +174,9 @@ const DiscoveryJSON = `{
         },
         "systemdSubState": {
           "type": "string"
+        },
+        "health": {
+          "type": "string"
         }
       }
     },
@@ -179,6 +194,246 @@ const DiscoveryJSON = `{
           "type": "string"
         }
       }
+    },
+    "UnitStateTransition": {
+      "id": "UnitStateTransition",
+      "type": "object",
+      "properties": {
+        "time": {
+          "type": "string"
+        },
+        "machineID": {
+          "type": "string"
+        },
+        "loadState": {
+          "type": "string"
+        },
+        "activeState": {
+          "type": "string"
+        },
+        "subState": {
+          "type": "string"
+        },
+        "unitHash": {
+          "type": "string"
+        }
+      }
+    },
+    "UnitStateHistory": {
+      "id": "UnitStateHistory",
+      "type": "object",
+      "properties": {
+        "name": {
+          "type": "string"
+        },
+        "transitions": {
+          "type": "array",
+          "items": {
+            "$ref": "UnitStateTransition"
+          }
+        }
+      }
+    },
+    "UnitScheduling": {
+      "id": "UnitScheduling",
+      "type": "object",
+      "properties": {
+        "name": {
+          "type": "string"
+        },
+        "machineID": {
+          "type": "string"
+        },
+        "reason": {
+          "type": "string"
+        },
+        "since": {
+          "type": "string"
+        },
+        "machines": {
+          "type": "array",
+          "items": {
+            "$ref": "UnitSchedulingMachine"
+          }
+        },
+        "failures": {
+          "type": "array",
+          "items": {
+            "$ref": "UnitSchedulingFailure"
+          }
+        }
+      }
+    },
+    "UnitSchedulingMachine": {
+      "id": "UnitSchedulingMachine",
+      "type": "object",
+      "properties": {
+        "machineID": {
+          "type": "string"
+        },
+        "reason": {
+          "type": "string"
+        }
+      }
+    },
+    "UnitSchedulingFailure": {
+      "id": "UnitSchedulingFailure",
+      "type": "object",
+      "properties": {
+        "machineID": {
+          "type": "string"
+        },
+        "count": {
+          "type": "integer"
+        },
+        "failed": {
+          "type": "boolean"
+        },
+        "excludedUntil": {
+          "type": "string"
+        }
+      }
+    },
+    "PlanRequest": {
+      "id": "PlanRequest",
+      "type": "object",
+      "properties": {
+        "strategy": {
+          "type": "string"
+        },
+        "units": {
+          "type": "array",
+          "items": {
+            "$ref": "Unit"
+          }
+        },
+        "removeMachines": {
+          "type": "array",
+          "items": {
+            "type": "string"
+          }
+        },
+        "addMetadata": {
+          "type": "array",
+          "items": {
+            "$ref": "PlanMetadata"
+          }
+        }
+      }
+    },
+    "PlanMetadata": {
+      "id": "PlanMetadata",
+      "type": "object",
+      "properties": {
+        "machineID": {
+          "type": "string",
+          "required": true
+        },
+        "key": {
+          "type": "string",
+          "required": true
+        },
+        "value": {
+          "type": "string",
+          "required": true
+        }
+      }
+    },
+    "Plan": {
+      "id": "Plan",
+      "type": "object",
+      "properties": {
+        "tasks": {
+          "type": "array",
+          "items": {
+            "$ref": "PlanTask"
+          }
+        },
+        "unschedulable": {
+          "type": "array",
+          "items": {
+            "$ref": "UnitScheduling"
+          }
+        },
+        "ignoredSettings": {
+          "type": "array",
+          "items": {
+            "type": "string"
+          }
+        }
+      }
+    },
+    "PlanTask": {
+      "id": "PlanTask",
+      "type": "object",
+      "properties": {
+        "type": {
+          "type": "string"
+        },
+        "unitName": {
+          "type": "string"
+        },
+        "machineID": {
+          "type": "string"
+        },
+        "reason": {
+          "type": "string"
+        }
+      }
+    },
+    "EngineStatus": {
+      "id": "EngineStatus",
+      "type": "object",
+      "properties": {
+        "leaderMachineID": {
+          "type": "string"
+        },
+        "leaseIndex": {
+          "type": "integer"
+        },
+        "leaseTimeRemaining": {
+          "type": "number"
+        },
+        "engineVersion": {
+          "type": "integer"
+        },
+        "lastReconcile": {
+          "$ref": "EngineReconcile"
+        }
+      }
+    },
+    "EngineReconcile": {
+      "id": "EngineReconcile",
+      "type": "object",
+      "properties": {
+        "machineID": {
+          "type": "string"
+        },
+        "time": {
+          "type": "string"
+        },
+        "duration": {
+          "type": "number"
+        },
+        "tasks": {
+          "type": "array",
+          "items": {
+            "$ref": "EngineTaskCount"
+          }
+        }
+      }
+    },
+    "EngineTaskCount": {
+      "id": "EngineTaskCount",
+      "type": "object",
+      "properties": {
+        "type": {
+          "type": "string"
+        },
+        "count": {
+          "type": "integer"
+        }
+      }
     }
   },
   "resources": {
@@ -271,6 +526,44 @@ const DiscoveryJSON = `{
           "request": {
             "$ref": "Unit"
           }
+        },
+        "Patch": {
+          "id": "fleet.Unit.Patch",
+          "description": "Modify the replica count of a template Unit.",
+          "httpMethod": "PATCH",
+          "path": "units/{unitName}",
+          "parameters": {
+            "unitName": {
+              "type": "string",
+              "location": "path",
+              "required": true
+            }
+          },
+          "parameterOrder": [
+            "unitName"
+          ],
+          "request": {
+            "$ref": "Unit"
+          }
+        },
+        "Scheduling": {
+          "id": "fleet.Unit.Scheduling",
+          "description": "Explain why a Unit is not scheduled.",
+          "httpMethod": "GET",
+          "path": "units/{unitName}/scheduling",
+          "parameters": {
+            "unitName": {
+              "type": "string",
+              "location": "path",
+              "required": true
+            }
+          },
+          "parameterOrder": [
+            "unitName"
+          ],
+          "response": {
+            "$ref": "UnitScheduling"
+          }
         }
       }
     },
@@ -317,6 +610,60 @@ const DiscoveryJSON = `{
           "response": {
             "$ref": "UnitStatePage"
           }
+        },
+        "History": {
+          "id": "fleet.UnitState.History",
+          "description": "Retrieve the recent state transitions of a single Unit.",
+          "httpMethod": "GET",
+          "path": "state/{unitName}/history",
+          "parameters": {
+            "unitName": {
+              "type": "string",
+              "location": "path",
+              "required": true
+            }
+          },
+          "parameterOrder": [
+            "unitName"
+          ],
+          "response": {
+            "$ref": "UnitStateHistory"
+          }
+        }
+      }
+    },
+    "Plan": {
+      "methods": {
+        "Create": {
+          "id": "fleet.Plan.Create",
+          "description": "Simulate scheduling of the cluster with proposed changes applied.",
+          "httpMethod": "POST",
+          "path": "plan",
+          "request": {
+            "$ref": "PlanRequest"
+          },
+          "response": {
+            "$ref": "Plan"
+          }
+        }
+      }
+    },
+    "Engine": {
+      "methods": {
+        "Get": {
+          "id": "fleet.Engine.Get",
+          "description": "Retrieve the status of the engine leader.",
+          "httpMethod": "GET",
+          "path": "engine",
+          "response": {
+            "$ref": "EngineStatus"
+          }
+        },
+        "StepDown": {
+          "id": "fleet.Engine.StepDown",
+          "description": "Release the engine leadership, making the current leader step down.",
+          "httpMethod": "DELETE",
+          "path": "engine/leader"
         }
       }
     }
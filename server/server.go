@@ -54,6 +54,8 @@ type Server struct {
 	aReconciler    *agent.AgentReconciler
 	usPub          *agent.UnitStatePublisher
 	usGen          *unit.UnitStateGenerator
+	health         *agent.HealthChecker
+	status         *agent.StatusServer
 	engine         *engine.Engine
 	mach           *machine.CoreOSMachine
 	hrt            heart.Heart
@@ -64,6 +66,7 @@ type Server struct {
 	restartServer  bool
 
 	engineReconcileInterval time.Duration
+	evacuationTimeout       time.Duration
 
 	killc chan struct{}  // used to signal monitor to shutdown server
 	stopc chan struct{}  // used to terminate all other goroutines
@@ -128,26 +131,60 @@ func New(cfg config.Config, listeners []net.Listener) (*Server, error) {
 	pub := agent.NewUnitStatePublisher(reg, mach, agentTTL)
 	gen := unit.NewUnitStateGenerator(mgr)
 
+	hc := agent.NewHealthChecker()
+	gen.SetHealthReporter(hc)
+
 	a := agent.New(mgr, gen, reg, mach, agentTTL)
+	a.SetHealthChecker(hc)
 
 	var rStream pkg.EventStream
 	if !cfg.DisableWatches {
 		rStream = registry.NewEtcdEventStream(kAPI, cfg.EtcdKeyPrefix)
 	}
 
-	ar := agent.NewReconciler(reg, rStream)
+	ar := agent.NewReconciler(reg, lManager, pkg.MergeEventStreams(rStream, gen))
+	if cfg.AgentStateFile != "" {
+		ar.SetStateFile(cfg.AgentStateFile)
+	}
+
+	var status *agent.StatusServer
+	if cfg.AgentStatusSocket != "" {
+		status = agent.NewStatusServer(cfg.AgentStatusSocket, a, ar, pub)
+	}
+
+	sched, err := engine.NewScheduler(cfg.SchedulerStrategy, cfg.SchedulerSeed)
+	if err != nil {
+		return nil, err
+	}
 
 	var e *engine.Engine
 	if !cfg.EnableGRPC {
-		e = engine.New(reg, lManager, rStream, mach, nil)
+		e = engine.New(reg, lManager, rStream, mach, sched, nil)
 	} else {
 		regMux := genericReg.(*rpc.RegistryMux)
-		e = engine.New(reg, lManager, rStream, mach, regMux.EngineChanged)
+		e = engine.New(reg, lManager, rStream, mach, sched, regMux.EngineChanged)
 		if cfg.DisableEngine {
 			go regMux.ConnectToRegistry(e)
 		}
 	}
 
+	if cfg.RebalanceInterval > 0 {
+		e.EnableRebalancing(time.Duration(cfg.RebalanceInterval*1000)*time.Millisecond, cfg.RebalanceMaxMoves)
+	}
+	if cfg.DrainBatchSize > 0 {
+		e.SetDrainBatchSize(cfg.DrainBatchSize)
+	}
+	eIval := time.Duration(cfg.EngineReconcileInterval*1000) * time.Millisecond
+	if cfg.ScheduleLimit > 0 || cfg.ScheduleLimitPerMachine > 0 {
+		e.SetScheduleLimits(cfg.ScheduleLimit, cfg.ScheduleLimitPerMachine, eIval)
+	}
+	if cfg.MachineLossGracePeriod > 0 {
+		e.SetMachineLossGracePeriod(time.Duration(cfg.MachineLossGracePeriod*1000) * time.Millisecond)
+	}
+	if cfg.FailureCooldown > 0 {
+		e.SetFailureCooldown(time.Duration(cfg.FailureCooldown*1000) * time.Millisecond)
+	}
+
 	if len(listeners) == 0 {
 		listeners, err = activation.Listeners(false)
 		if err != nil {
@@ -158,24 +195,25 @@ func New(cfg config.Config, listeners []net.Listener) (*Server, error) {
 	hrt := heart.New(reg, mach)
 	mon := NewMonitor(agentTTL)
 
-	apiServer := api.NewServer(listeners, api.NewServeMux(reg, cfg.TokenLimit))
+	apiServer := api.NewServer(listeners, api.NewServeMux(reg, lManager, cfg.TokenLimit))
 	apiServer.Serve()
 
-	eIval := time.Duration(cfg.EngineReconcileInterval*1000) * time.Millisecond
-
 	srv := Server{
-		agent:       a,
-		aReconciler: ar,
-		usGen:       gen,
-		usPub:       pub,
-		engine:      e,
-		mach:        mach,
-		hrt:         hrt,
-		mon:         mon,
-		api:         apiServer,
-		killc:       make(chan struct{}),
-		stopc:       nil,
+		agent:                   a,
+		aReconciler:             ar,
+		usGen:                   gen,
+		usPub:                   pub,
+		health:                  hc,
+		status:                  status,
+		engine:                  e,
+		mach:                    mach,
+		hrt:                     hrt,
+		mon:                     mon,
+		api:                     apiServer,
+		killc:                   make(chan struct{}),
+		stopc:                   nil,
 		engineReconcileInterval: eIval,
+		evacuationTimeout:       time.Duration(cfg.EvacuationTimeout*1000) * time.Millisecond,
 		disableEngine:           cfg.DisableEngine,
 		reconfigServer:          false,
 		restartServer:           false,
@@ -206,6 +244,7 @@ func (s *Server) Run() {
 	log.Infof("Establishing etcd connectivity")
 
 	var err error
+	restored := false
 	for sleep := time.Second; ; sleep = pkg.ExpBackoff(sleep, time.Minute) {
 		if s.restartServer {
 			_, err = s.hrt.Beat(s.mon.TTL)
@@ -221,6 +260,11 @@ func (s *Server) Run() {
 			}
 		}
 		log.Warningf("Server register machine failed: %v, retrying in %d sec.", err, sleep)
+		// keep running the units of the agent while etcd is unavailable
+		if !s.restartServer && !restored {
+			s.aReconciler.Restore(s.agent)
+			restored = true
+		}
 		time.Sleep(sleep)
 	}
 
@@ -238,6 +282,10 @@ func (s *Server) Run() {
 		func() { s.aReconciler.Run(s.agent, s.stopc) },
 		func() { s.usGen.Run(beatc, s.stopc) },
 		func() { s.usPub.Run(beatc, s.stopc) },
+		func() { s.health.Run(s.stopc) },
+	}
+	if s.status != nil {
+		components = append(components, func() { s.status.Run(s.stopc) })
 	}
 	if s.disableEngine {
 		log.Info("Not starting engine; disable-engine is set")
@@ -294,11 +342,24 @@ func (s *Server) Kill() {
 	}
 }
 
+// Evacuate moves the units of the Server's machine to other machines
+// before a planned shutdown, if configured to. See
+// agent.AgentReconciler.Evacuate.
+func (s *Server) Evacuate() {
+	if s.evacuationTimeout <= 0 {
+		return
+	}
+	if err := s.aReconciler.Evacuate(s.agent, s.evacuationTimeout); err != nil {
+		log.Errorf("Failed evacuating machine: %v", err)
+	}
+}
+
 func (s *Server) Purge() {
 	s.aReconciler.Purge(s.agent)
 	s.usPub.Purge()
 	s.engine.Purge()
 	s.hrt.Clear()
+	s.aReconciler.FinishEvacuation(s.agent)
 }
 
 func (s *Server) MarshalJSON() ([]byte, error) {
@@ -0,0 +1,435 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sim simulates a fleet cluster of many machines in a single
+// process. Each simulated machine runs an agent and an engine, sharing a
+// registry.FakeRegistry and an in-memory lease manager. Units are loaded
+// into a unit.FakeUnitManager instead of systemd. Time only passes as the
+// cluster is stepped, so simulations are deterministic.
+package sim
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+
+	"github.com/coreos/fleet/agent"
+	"github.com/coreos/fleet/client"
+	"github.com/coreos/fleet/engine"
+	"github.com/coreos/fleet/job"
+	"github.com/coreos/fleet/machine"
+	"github.com/coreos/fleet/pkg"
+	"github.com/coreos/fleet/registry"
+	"github.com/coreos/fleet/unit"
+)
+
+// DefaultInterval is the time that passes with each step of a Cluster,
+// unless configured otherwise.
+const DefaultInterval = time.Second
+
+// Cluster is a simulated fleet cluster. A Cluster is not threadsafe.
+type Cluster struct {
+	// Registry is shared by all machines of the cluster
+	Registry *registry.FakeRegistry
+
+	// Clock is the clock of all machines of the cluster
+	Clock clockwork.FakeClock
+
+	// Interval is the time that passes with each step. Engines are
+	// configured to reconcile at this interval.
+	Interval time.Duration
+
+	reg       *clusterRegistry
+	leases    *leaseManager
+	strategy  string
+	seed      int64
+	configure []func(*engine.Engine)
+
+	machines map[string]*Machine
+	stop     chan struct{}
+}
+
+// clusterRegistry adds the cluster-wide operations the engine requires to
+// a FakeRegistry.
+type clusterRegistry struct {
+	*registry.FakeRegistry
+	*registry.FakeClusterRegistry
+}
+
+// NewCluster creates a Cluster without any machines. Engines use the named
+// scheduler strategy, seeded with the given seed for reproducible random
+// decisions.
+func NewCluster(strategy string, seed int64) (*Cluster, error) {
+	if _, err := engine.NewScheduler(strategy, seed); err != nil {
+		return nil, err
+	}
+
+	fReg := registry.NewFakeRegistry()
+	clock := clockwork.NewFakeClock()
+	return &Cluster{
+		Registry: fReg,
+		Clock:    clock,
+		Interval: DefaultInterval,
+		reg: &clusterRegistry{
+			FakeRegistry:        fReg,
+			FakeClusterRegistry: registry.NewFakeClusterRegistry(nil, 0),
+		},
+		leases:   newLeaseManager(clock),
+		strategy: strategy,
+		seed:     seed,
+		machines: make(map[string]*Machine),
+		stop:     make(chan struct{}),
+	}, nil
+}
+
+// Client returns a client.API operating on the cluster, as used by
+// fleetctl.
+func (c *Cluster) Client() client.API {
+	return &client.RegistryClient{Registry: c.Registry, LeaseManager: c.leases, Planner: engine.NewPlanner(c.Registry)}
+}
+
+// ConfigureEngines applies the given function to the engines of all
+// machines, including those added or restored later.
+func (c *Cluster) ConfigureEngines(f func(*engine.Engine)) {
+	c.configure = append(c.configure, f)
+	for _, m := range c.machines {
+		if m.engine != nil {
+			f(m.engine)
+		}
+	}
+}
+
+// AddMachine adds a machine with the given ID and metadata to the cluster
+// and boots it.
+func (c *Cluster) AddMachine(id string, metadata map[string]string) *Machine {
+	m := &Machine{
+		ID:    id,
+		state: machine.MachineState{ID: id, Metadata: metadata},
+	}
+	c.machines[id] = m
+	c.boot(m)
+	return m
+}
+
+// Machine returns the machine with the given ID, or nil if the cluster has
+// no such machine.
+func (c *Cluster) Machine(id string) *Machine {
+	return c.machines[id]
+}
+
+// Machines returns all machines of the cluster, whether reachable or not,
+// sorted by ID.
+func (c *Cluster) Machines() []*Machine {
+	ids := make([]string, 0, len(c.machines))
+	for id := range c.machines {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	machines := make([]*Machine, len(ids))
+	for i, id := range ids {
+		machines[i] = c.machines[id]
+	}
+	return machines
+}
+
+// Launch submits a unit with the given contents to the cluster, to be
+// launched.
+func (c *Cluster) Launch(name, contents string) error {
+	uf, err := unit.NewUnitFile(contents)
+	if err != nil {
+		return err
+	}
+	return c.Registry.CreateUnit(&job.Unit{Name: name, Unit: *uf, TargetState: job.JobStateLaunched})
+}
+
+// Kill stops the machine with the given ID. The units it ran are gone,
+// and it leaves the cluster until restored.
+func (c *Cluster) Kill(id string) error {
+	m, err := c.disconnect(id)
+	if err != nil {
+		return err
+	}
+	m.running = false
+	m.agent, m.reconciler, m.engine, m.units = nil, nil, nil, nil
+	return nil
+}
+
+// Partition cuts the machine with the given ID off the rest of the cluster.
+// It leaves the cluster until restored, while the units it runs keep
+// running.
+func (c *Cluster) Partition(id string) error {
+	_, err := c.disconnect(id)
+	return err
+}
+
+// Restore has a killed or partitioned machine rejoin the cluster. A killed
+// machine boots anew, without any units.
+func (c *Cluster) Restore(id string) error {
+	m, ok := c.machines[id]
+	if !ok {
+		return fmt.Errorf("no machine %s", id)
+	}
+	if m.connected {
+		return nil
+	}
+	if !m.running {
+		c.boot(m)
+		return nil
+	}
+
+	m.connected = true
+	machines, _ := c.Registry.Machines()
+	c.setMachines(append(machines, m.state))
+	return nil
+}
+
+func (c *Cluster) boot(m *Machine) {
+	sched, _ := engine.NewScheduler(c.strategy, c.seed)
+	mach := &machine.FakeMachine{MachineState: m.state}
+
+	m.units = newUnitManager()
+	m.agent = agent.New(m.units, unit.NewUnitStateGenerator(m.units), c.Registry, mach, 5*c.Interval)
+	m.reconciler = agent.NewReconciler(c.Registry, c.leases, nil)
+	m.engine = engine.New(c.reg, c.leases, nil, mach, sched, nil)
+	m.engine.SetClock(c.Clock)
+	for _, f := range c.configure {
+		f(m.engine)
+	}
+	m.running = true
+	m.connected = true
+
+	machines, _ := c.Registry.Machines()
+	c.setMachines(append(machines, m.state))
+}
+
+// disconnect removes the machine with the given ID from the Registry,
+// remembering its last known state for it to rejoin later.
+func (c *Cluster) disconnect(id string) (*Machine, error) {
+	m, ok := c.machines[id]
+	if !ok {
+		return nil, fmt.Errorf("no machine %s", id)
+	}
+	if !m.connected {
+		return m, nil
+	}
+
+	if ms, err := c.Registry.MachineState(id); err == nil {
+		m.state = ms
+	}
+	machines, _ := c.Registry.Machines()
+	var remaining []machine.MachineState
+	for _, ms := range machines {
+		if ms.ID != id {
+			remaining = append(remaining, ms)
+		}
+	}
+	c.setMachines(remaining)
+	m.connected = false
+	return m, nil
+}
+
+func (c *Cluster) setMachines(machines []machine.MachineState) {
+	sorted := make([]machine.MachineState, len(machines))
+	copy(sorted, machines)
+	sort.Sort(machinesByID(sorted))
+	c.Registry.SetMachines(sorted)
+}
+
+type machinesByID []machine.MachineState
+
+func (s machinesByID) Len() int           { return len(s) }
+func (s machinesByID) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s machinesByID) Less(i, j int) bool { return s[i].ID < s[j].ID }
+
+// Step has the engine and agent of every reachable machine reconcile once,
+// in order of their IDs, and then advances the clock by the Interval. It
+// reports whether anything changed: whether the engine leader performed
+// any tasks, or any agent loaded, unloaded, started or stopped a unit. A
+// step without any engine leader counts as a change, as the cluster is
+// waiting for one to take over.
+func (c *Cluster) Step() bool {
+	now := c.Clock.Now()
+	machines := c.Machines()
+
+	for _, m := range machines {
+		if m.connected {
+			m.engine.Step(c.Interval, c.stop)
+		}
+	}
+	changed := true
+	if status, err := c.Registry.ReconcileStatus(); err == nil && status != nil && status.Time.Equal(now) {
+		changed = len(status.Tasks) > 0
+	}
+
+	for _, m := range machines {
+		if !m.connected {
+			continue
+		}
+		before := m.units.snapshot()
+		m.reconciler.Reconcile(m.agent)
+		if !reflect.DeepEqual(before, m.units.snapshot()) {
+			changed = true
+		}
+	}
+
+	c.Clock.Advance(c.Interval)
+	return changed
+}
+
+// Converge steps the cluster until a step changes nothing, for at most the
+// given number of steps. The number of steps taken is returned, along with
+// an error if the cluster did not converge. Units waiting for a period of
+// time to pass, e.g. for a lost machine to return, or held back by rate
+// limits, do not count as change; step the cluster further to get past
+// them.
+func (c *Cluster) Converge(maxSteps int) (int, error) {
+	for i := 1; i <= maxSteps; i++ {
+		if !c.Step() {
+			return i, nil
+		}
+	}
+	return maxSteps, fmt.Errorf("cluster did not converge within %d steps", maxSteps)
+}
+
+// Leader returns the ID of the machine whose engine leads the cluster, or
+// an empty string if there is no leader.
+func (c *Cluster) Leader() string {
+	l, err := registry.EngineLeader(c.leases)
+	if err != nil || l == nil {
+		return ""
+	}
+	return l.MachineID()
+}
+
+// Running returns the IDs of all running machines on which the named unit
+// is active, sorted by ID.
+func (c *Cluster) Running(name string) []string {
+	ids := []string{}
+	for _, m := range c.Machines() {
+		if m.Active(name) {
+			ids = append(ids, m.ID)
+		}
+	}
+	return ids
+}
+
+// Machine is a machine of a simulated cluster.
+type Machine struct {
+	ID string
+
+	// state is the last known state of a machine that left the cluster
+	state machine.MachineState
+
+	// running is false after the machine was killed, while connected
+	// is false while the machine is not part of the cluster
+	running   bool
+	connected bool
+
+	units      *unitManager
+	agent      *agent.Agent
+	reconciler *agent.AgentReconciler
+	engine     *engine.Engine
+}
+
+// Running reports whether the machine runs, whether part of the cluster or
+// partitioned.
+func (m *Machine) Running() bool {
+	return m.running
+}
+
+// Connected reports whether the machine is part of the cluster.
+func (m *Machine) Connected() bool {
+	return m.connected
+}
+
+// Units returns the names of all units loaded on the machine, sorted.
+func (m *Machine) Units() []string {
+	if m.units == nil {
+		return []string{}
+	}
+	names, _ := m.units.Units()
+	sort.Strings(names)
+	return names
+}
+
+// Active reports whether the named unit is active on the machine.
+func (m *Machine) Active(name string) bool {
+	return m.units != nil && m.units.active.Contains(name)
+}
+
+// unitManager is a unit.FakeUnitManager keeping track of the units started
+// on a simulated machine, which are reported as active.
+type unitManager struct {
+	*unit.FakeUnitManager
+	active pkg.Set
+}
+
+func newUnitManager() *unitManager {
+	return &unitManager{
+		FakeUnitManager: unit.NewFakeUnitManager(),
+		active:          pkg.NewThreadsafeSet(),
+	}
+}
+
+func (um *unitManager) Unload(name string) error {
+	um.active.Remove(name)
+	return um.FakeUnitManager.Unload(name)
+}
+
+func (um *unitManager) TriggerStart(name string) error {
+	if us, _ := um.GetUnitState(name); us == nil {
+		return fmt.Errorf("unit %s not loaded", name)
+	}
+	um.active.Add(name)
+	return nil
+}
+
+func (um *unitManager) TriggerStop(name string) error {
+	um.active.Remove(name)
+	return nil
+}
+
+func (um *unitManager) GetUnitState(name string) (*unit.UnitState, error) {
+	us, err := um.FakeUnitManager.GetUnitState(name)
+	if us != nil && !um.active.Contains(name) {
+		us.ActiveState, us.SubState = "inactive", "dead"
+	}
+	return us, err
+}
+
+func (um *unitManager) GetUnitStates(filter pkg.Set) (map[string]*unit.UnitState, error) {
+	states, err := um.FakeUnitManager.GetUnitStates(filter)
+	for name, us := range states {
+		if !um.active.Contains(name) {
+			us.ActiveState, us.SubState = "inactive", "dead"
+		}
+	}
+	return states, err
+}
+
+// snapshot maps the name of each loaded unit to its hash and whether it is
+// active.
+func (um *unitManager) snapshot() map[string]string {
+	names, _ := um.Units()
+	snap := make(map[string]string, len(names))
+	for _, name := range names {
+		us, _ := um.GetUnitState(name)
+		snap[name] = us.UnitHash + "/" + us.ActiveState
+	}
+	return snap
+}
@@ -0,0 +1,236 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sim
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/coreos/fleet/engine"
+)
+
+func newTestCluster(t *testing.T, n int) *Cluster {
+	c, err := NewCluster("", 0)
+	if err != nil {
+		t.Fatalf("failed creating cluster: %v", err)
+	}
+	for i := 0; i < n; i++ {
+		c.AddMachine(fmt.Sprintf("m%04d", i), nil)
+	}
+	return c
+}
+
+func converge(t *testing.T, c *Cluster, maxSteps int) {
+	if _, err := c.Converge(maxSteps); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// assertScheduled ensures each of the named units runs on exactly one
+// machine of the cluster, and returns the ID of each unit's machine.
+func assertScheduled(t *testing.T, c *Cluster, names []string) map[string]string {
+	where := make(map[string]string)
+	for _, name := range names {
+		running := c.Running(name)
+		if len(running) != 1 {
+			t.Fatalf("expected %s to run on a single machine, runs on %v", name, running)
+		}
+		where[name] = running[0]
+	}
+	return where
+}
+
+func launchUnits(t *testing.T, c *Cluster, n int, contents string) []string {
+	var names []string
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("app@%d.service", i)
+		if err := c.Launch(name, contents); err != nil {
+			t.Fatalf("failed launching %s: %v", name, err)
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestClusterSchedulesUnits(t *testing.T) {
+	c := newTestCluster(t, 5)
+	names := launchUnits(t, c, 10, "[Service]\nExecStart=/bin/true\n")
+	converge(t, c, 10)
+
+	assertScheduled(t, c, names)
+	for _, m := range c.Machines() {
+		if got := len(m.Units()); got != 2 {
+			t.Errorf("expected 2 units on %s, got %v", m.ID, m.Units())
+		}
+	}
+
+	// a converged cluster stays as it is
+	if c.Step() {
+		t.Errorf("expected converged cluster not to change")
+	}
+}
+
+func TestClusterGlobalUnit(t *testing.T) {
+	c := newTestCluster(t, 3)
+	c.AddMachine("web", map[string]string{"role": "web"})
+	if err := c.Launch("log.service", "[X-Fleet]\nGlobal=true\n"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Launch("proxy.service", "[X-Fleet]\nGlobal=true\nMachineMetadata=role=web\n"); err != nil {
+		t.Fatal(err)
+	}
+	converge(t, c, 10)
+
+	if got, want := c.Running("log.service"), []string{"m0000", "m0001", "m0002", "web"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected log.service on %v, got %v", want, got)
+	}
+	if got, want := c.Running("proxy.service"), []string{"web"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected proxy.service on %v, got %v", want, got)
+	}
+}
+
+func TestClusterKillLeader(t *testing.T) {
+	c := newTestCluster(t, 4)
+	names := launchUnits(t, c, 8, "[Service]\nExecStart=/bin/true\n")
+	converge(t, c, 10)
+
+	leader := c.Leader()
+	if leader == "" {
+		t.Fatalf("expected an engine leader")
+	}
+	before := assertScheduled(t, c, names)
+
+	if err := c.Kill(leader); err != nil {
+		t.Fatal(err)
+	}
+	// another engine takes over once the lease of the leader expired
+	converge(t, c, 20)
+
+	if got := c.Leader(); got == "" || got == leader {
+		t.Errorf("expected new engine leader, got %q", got)
+	}
+	after := assertScheduled(t, c, names)
+	for _, name := range names {
+		if before[name] != leader && after[name] != before[name] {
+			t.Errorf("expected %s to stay on %s, moved to %s", name, before[name], after[name])
+		}
+		if after[name] == leader {
+			t.Errorf("expected %s to move away from killed %s", name, leader)
+		}
+	}
+
+	// the machine returns without any units
+	if err := c.Restore(leader); err != nil {
+		t.Fatal(err)
+	}
+	converge(t, c, 10)
+	if units := c.Machine(leader).Units(); len(units) != 0 {
+		t.Errorf("expected restored machine without units, got %v", units)
+	}
+}
+
+func TestClusterPartition(t *testing.T) {
+	c := newTestCluster(t, 4)
+	c.ConfigureEngines(func(e *engine.Engine) {
+		e.SetMachineLossGracePeriod(10 * time.Second)
+	})
+	names := launchUnits(t, c, 3, "[Service]\nExecStart=/bin/true\n[X-Fleet]\nConflicts=app@*\n")
+	converge(t, c, 10)
+	before := assertScheduled(t, c, names)
+
+	// the units of the partitioned machine keep running there while the
+	// engine waits for the machine to return
+	victim := before[names[2]]
+	if err := c.Partition(victim); err != nil {
+		t.Fatal(err)
+	}
+	converge(t, c, 10)
+	if got := c.Running(names[2]); !reflect.DeepEqual(got, []string{victim}) {
+		t.Fatalf("expected %s to keep running on %s, runs on %v", names[2], victim, got)
+	}
+	if !reflect.DeepEqual(before, assertScheduled(t, c, names)) {
+		t.Errorf("expected units to stay during the grace period")
+	}
+
+	// the machine returns in time
+	if err := c.Restore(victim); err != nil {
+		t.Fatal(err)
+	}
+	converge(t, c, 10)
+	if !reflect.DeepEqual(before, assertScheduled(t, c, names)) {
+		t.Errorf("expected units to stay on the returning machine")
+	}
+
+	// once the grace period passed, the unit runs on another machine as
+	// well, until the partitioned machine returns and stops it
+	if err := c.Partition(victim); err != nil {
+		t.Fatal(err)
+	}
+	c.ConfigureEngines(func(e *engine.Engine) {
+		e.SetMachineLossGracePeriod(0)
+	})
+	converge(t, c, 10)
+	if got := len(c.Running(names[2])); got != 2 {
+		t.Fatalf("expected %s to run on two machines while partitioned, runs on %d", names[2], got)
+	}
+	if err := c.Restore(victim); err != nil {
+		t.Fatal(err)
+	}
+	converge(t, c, 10)
+	if where := assertScheduled(t, c, names); where[names[2]] == victim {
+		t.Errorf("expected %s to move away from %s", names[2], victim)
+	}
+}
+
+func TestClusterRollout(t *testing.T) {
+	c := newTestCluster(t, 10)
+	if err := c.Launch("log.service", "[X-Fleet]\nGlobal=true\nMaxParallel=3\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	// each step, machines that became active release their slots to
+	// others, so no more than three machines start the unit at a time
+	prev := 0
+	for i := 0; i < 20 && prev < 10; i++ {
+		c.Step()
+		running := len(c.Running("log.service"))
+		if running-prev > 3 {
+			t.Fatalf("step %d: %d machines started log.service at once", i, running-prev)
+		}
+		prev = running
+	}
+	if prev != 10 {
+		t.Fatalf("expected log.service on all machines, runs on %d", prev)
+	}
+}
+
+func TestClusterScale(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping simulation of a large cluster in short mode")
+	}
+
+	c := newTestCluster(t, 1000)
+	names := launchUnits(t, c, 2000, "[Service]\nExecStart=/bin/true\n")
+	converge(t, c, 10)
+
+	assertScheduled(t, c, names)
+	for _, m := range c.Machines() {
+		if got := len(m.Units()); got != 2 {
+			t.Fatalf("expected 2 units on %s, got %d", m.ID, got)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sim
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+
+	"github.com/coreos/fleet/pkg/lease"
+)
+
+var errLeaseChanged = errors.New("lease changed")
+
+// leaseManager is an in-memory lease.Manager standing in for etcd. Its
+// leases expire according to the clock of the simulated cluster.
+type leaseManager struct {
+	mu     sync.Mutex
+	clock  clockwork.Clock
+	index  uint64
+	leases map[string]*leaseRecord
+}
+
+type leaseRecord struct {
+	machID  string
+	ver     int
+	idx     uint64
+	expires time.Time
+}
+
+func newLeaseManager(clock clockwork.Clock) *leaseManager {
+	return &leaseManager{
+		clock:  clock,
+		leases: make(map[string]*leaseRecord),
+	}
+}
+
+// current returns the unexpired record of the named lease, if any.
+func (m *leaseManager) current(name string) *leaseRecord {
+	rec, ok := m.leases[name]
+	if !ok {
+		return nil
+	}
+	if !m.clock.Now().Before(rec.expires) {
+		delete(m.leases, name)
+		return nil
+	}
+	return rec
+}
+
+func (m *leaseManager) set(name, machID string, ver int, period time.Duration) *simLease {
+	m.index++
+	rec := &leaseRecord{
+		machID:  machID,
+		ver:     ver,
+		idx:     m.index,
+		expires: m.clock.Now().Add(period),
+	}
+	m.leases[name] = rec
+	return m.lease(name, rec)
+}
+
+func (m *leaseManager) lease(name string, rec *leaseRecord) *simLease {
+	return &simLease{
+		mgr:    m,
+		name:   name,
+		machID: rec.machID,
+		ver:    rec.ver,
+		idx:    rec.idx,
+		ttl:    rec.expires.Sub(m.clock.Now()),
+	}
+}
+
+func (m *leaseManager) GetLease(name string) (lease.Lease, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rec := m.current(name)
+	if rec == nil {
+		return nil, nil
+	}
+	return m.lease(name, rec), nil
+}
+
+func (m *leaseManager) AcquireLease(name, machID string, ver int, period time.Duration) (lease.Lease, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.current(name) != nil {
+		return nil, nil
+	}
+	return m.set(name, machID, ver, period), nil
+}
+
+func (m *leaseManager) StealLease(name, machID string, ver int, period time.Duration, idx uint64) (lease.Lease, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rec := m.current(name)
+	if rec == nil || rec.idx != idx {
+		return nil, errLeaseChanged
+	}
+	return m.set(name, machID, ver, period), nil
+}
+
+// simLease is a lease.Lease as fetched from a leaseManager.
+type simLease struct {
+	mgr    *leaseManager
+	name   string
+	machID string
+	ver    int
+	idx    uint64
+	ttl    time.Duration
+}
+
+func (l *simLease) Renew(period time.Duration) error {
+	l.mgr.mu.Lock()
+	defer l.mgr.mu.Unlock()
+
+	rec := l.mgr.current(l.name)
+	if rec == nil || rec.idx != l.idx {
+		return errLeaseChanged
+	}
+	*l = *l.mgr.set(l.name, l.machID, l.ver, period)
+	return nil
+}
+
+func (l *simLease) Release() error {
+	l.mgr.mu.Lock()
+	defer l.mgr.mu.Unlock()
+
+	rec := l.mgr.current(l.name)
+	if rec == nil || rec.idx != l.idx {
+		return errLeaseChanged
+	}
+	delete(l.mgr.leases, l.name)
+	return nil
+}
+
+func (l *simLease) MachineID() string {
+	return l.machID
+}
+
+func (l *simLease) Version() int {
+	return l.ver
+}
+
+func (l *simLease) Index() uint64 {
+	return l.idx
+}
+
+func (l *simLease) TimeRemaining() time.Duration {
+	return l.ttl
+}
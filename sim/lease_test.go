@@ -0,0 +1,72 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sim
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+)
+
+func TestLeaseManager(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+	m := newLeaseManager(clock)
+
+	l, err := m.AcquireLease("foo", "A", 1, 10*time.Second)
+	if err != nil || l == nil {
+		t.Fatalf("expected to acquire lease, got %v, %v", l, err)
+	}
+	if l2, err := m.AcquireLease("foo", "B", 1, 10*time.Second); err != nil || l2 != nil {
+		t.Fatalf("expected held lease not to be acquired, got %v, %v", l2, err)
+	}
+
+	// renewing extends the lease
+	clock.Advance(8 * time.Second)
+	if err := l.Renew(10 * time.Second); err != nil {
+		t.Fatalf("failed renewing lease: %v", err)
+	}
+	clock.Advance(8 * time.Second)
+	if got, _ := m.GetLease("foo"); got == nil || got.MachineID() != "A" || got.TimeRemaining() != 2*time.Second {
+		t.Fatalf("expected lease of A with 2s remaining, got %v", got)
+	}
+
+	// stealing requires the current index
+	if _, err := m.StealLease("foo", "B", 1, 10*time.Second, l.Index()-1); err == nil {
+		t.Errorf("expected stealing with stale index to fail")
+	}
+	stolen, err := m.StealLease("foo", "B", 1, 10*time.Second, l.Index())
+	if err != nil || stolen == nil || stolen.MachineID() != "B" {
+		t.Fatalf("expected B to steal lease, got %v, %v", stolen, err)
+	}
+	if err := l.Renew(10 * time.Second); err == nil {
+		t.Errorf("expected renewing stolen lease to fail")
+	}
+	if err := l.Release(); err == nil {
+		t.Errorf("expected releasing stolen lease to fail")
+	}
+
+	// expired leases are gone
+	clock.Advance(10 * time.Second)
+	if got, _ := m.GetLease("foo"); got != nil {
+		t.Errorf("expected lease to expire, held by %s", got.MachineID())
+	}
+	if err := stolen.Renew(10 * time.Second); err == nil {
+		t.Errorf("expected renewing expired lease to fail")
+	}
+	if l, _ := m.AcquireLease("foo", "C", 1, 10*time.Second); l == nil {
+		t.Errorf("expected expired lease to be acquired")
+	}
+}
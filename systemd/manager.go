@@ -29,8 +29,9 @@ import (
 )
 
 type systemdUnitManager struct {
-	systemd  *dbus.Conn
-	unitsDir string
+	systemd     *dbus.Conn
+	systemdUser bool
+	unitsDir    string
 
 	hashes map[string]unit.Hash
 	mutex  sync.RWMutex
@@ -58,10 +59,11 @@ func NewSystemdUnitManager(uDir string, systemdUser bool) (*systemdUnitManager,
 	}
 
 	mgr := systemdUnitManager{
-		systemd:  systemd,
-		unitsDir: uDir,
-		hashes:   hashes,
-		mutex:    sync.RWMutex{},
+		systemd:     systemd,
+		systemdUser: systemdUser,
+		unitsDir:    uDir,
+		hashes:      hashes,
+		mutex:       sync.RWMutex{},
 	}
 	return &mgr, nil
 }
@@ -181,6 +183,15 @@ func (m *systemdUnitManager) getUnitState(name string) (*unit.UnitState, error)
 	return &us, nil
 }
 
+// UnitFile reads the file of the indicated unit from disk.
+func (m *systemdUnitManager) UnitFile(name string) (*unit.UnitFile, error) {
+	contents, err := m.readUnit(name)
+	if err != nil {
+		return nil, err
+	}
+	return unit.NewUnitFile(contents)
+}
+
 func (m *systemdUnitManager) readUnit(name string) (string, error) {
 	path := m.getUnitFilePath(name)
 	contents, err := ioutil.ReadFile(path)
@@ -0,0 +1,155 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package systemd
+
+import (
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	godbus "github.com/godbus/dbus"
+
+	"github.com/coreos/fleet/log"
+)
+
+const (
+	unitPathPrefix = "/org/freedesktop/systemd1/unit/"
+
+	signalPropertiesChanged = "org.freedesktop.DBus.Properties.PropertiesChanged"
+	signalJobRemoved        = "org.freedesktop.systemd1.Manager.JobRemoved"
+)
+
+// WatchUnits subscribes to the PropertiesChanged and JobRemoved signals
+// systemd emits as units change state, calling notify with the name of
+// each unit concerned. Signals are received on a connection of their own,
+// which is closed once the stop channel is. The returned channel is closed
+// when the watch ends, either because of the stop channel or because the
+// connection was lost.
+func (m *systemdUnitManager) WatchUnits(notify func(name string), stop <-chan struct{}) (<-chan struct{}, error) {
+	conn, err := m.dialSignals()
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []string{
+		"type='signal',interface='org.freedesktop.DBus.Properties',member='PropertiesChanged',arg0='org.freedesktop.systemd1.Unit'",
+		"type='signal',interface='org.freedesktop.systemd1.Manager',member='JobRemoved'",
+	}
+	for _, match := range matches {
+		if err := conn.BusObject().Call("org.freedesktop.DBus.AddMatch", 0, match).Err; err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	// systemd only emits signals while at least one client subscribed
+	sysobj := conn.Object("org.freedesktop.systemd1", godbus.ObjectPath("/org/freedesktop/systemd1"))
+	if err := sysobj.Call("org.freedesktop.systemd1.Manager.Subscribe", 0).Err; err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	sigc := make(chan *godbus.Signal, 64)
+	conn.Signal(sigc)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				conn.Close()
+				return
+			case sig, ok := <-sigc:
+				if !ok {
+					log.Warningf("Lost systemd D-Bus connection watching unit state changes")
+					return
+				}
+				if name := signalUnitName(sig); name != "" {
+					notify(name)
+				}
+			}
+		}
+	}()
+
+	return done, nil
+}
+
+func (m *systemdUnitManager) dialSignals() (*godbus.Conn, error) {
+	dial := godbus.SystemBusPrivate
+	if m.systemdUser {
+		dial = godbus.SessionBusPrivate
+	}
+	conn, err := dial()
+	if err != nil {
+		return nil, err
+	}
+
+	methods := []godbus.Auth{godbus.AuthExternal(strconv.Itoa(os.Getuid()))}
+	if err := conn.Auth(methods); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := conn.Hello(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// signalUnitName returns the name of the unit a signal is about, or an
+// empty string if the signal is not about a unit.
+func signalUnitName(sig *godbus.Signal) string {
+	switch sig.Name {
+	case signalJobRemoved:
+		// JobRemoved(u id, o job, s unit, s result)
+		if len(sig.Body) >= 3 {
+			if name, ok := sig.Body[2].(string); ok {
+				return name
+			}
+		}
+	case signalPropertiesChanged:
+		p := string(sig.Path)
+		if strings.HasPrefix(p, unitPathPrefix) {
+			return pathBusUnescape(path.Base(p))
+		}
+	}
+	return ""
+}
+
+// pathBusUnescape reverses the escaping of unit names in D-Bus object
+// paths, where every byte other than a letter or digit, as well as a
+// leading digit, is replaced by an underscore followed by its two
+// hexadecimal digits.
+func pathBusUnescape(s string) string {
+	if s == "_" {
+		return ""
+	}
+
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] == '_' && i+2 < len(s) {
+			if c, err := strconv.ParseUint(s[i+1:i+3], 16, 8); err == nil {
+				b = append(b, byte(c))
+				i += 2
+				continue
+			}
+		}
+		b = append(b, s[i])
+	}
+	return string(b)
+}
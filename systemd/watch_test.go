@@ -0,0 +1,79 @@
+// Copyright 2016 The fleet Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package systemd
+
+import (
+	"testing"
+
+	godbus "github.com/godbus/dbus"
+)
+
+func TestSignalUnitName(t *testing.T) {
+	tests := []struct {
+		sig  godbus.Signal
+		name string
+	}{
+		{
+			godbus.Signal{Name: signalJobRemoved, Body: []interface{}{uint32(42), godbus.ObjectPath("/org/freedesktop/systemd1/job/42"), "foo.service", "done"}},
+			"foo.service",
+		},
+		{
+			godbus.Signal{Name: signalJobRemoved, Body: []interface{}{uint32(42)}},
+			"",
+		},
+		{
+			godbus.Signal{Name: signalPropertiesChanged, Path: "/org/freedesktop/systemd1/unit/foo_2eservice"},
+			"foo.service",
+		},
+		{
+			godbus.Signal{Name: signalPropertiesChanged, Path: "/org/freedesktop/systemd1/unit/foo_40bar_2d1_2eservice"},
+			"foo@bar-1.service",
+		},
+		{
+			godbus.Signal{Name: signalPropertiesChanged, Path: "/org/freedesktop/systemd1/job/42"},
+			"",
+		},
+		{
+			godbus.Signal{Name: "org.freedesktop.systemd1.Manager.UnitNew", Path: "/org/freedesktop/systemd1"},
+			"",
+		},
+	}
+
+	for i, tt := range tests {
+		if name := signalUnitName(&tt.sig); name != tt.name {
+			t.Errorf("case %d: expected unit %q, got %q", i, tt.name, name)
+		}
+	}
+}
+
+func TestPathBusUnescape(t *testing.T) {
+	tests := []struct {
+		in  string
+		out string
+	}{
+		{"foo_2eservice", "foo.service"},
+		{"_31foo_2eservice", "1foo.service"},
+		{"foo_5fbar_2eservice", "foo_bar.service"},
+		{"_", ""},
+		{"foo_", "foo_"},
+		{"foo_zz", "foo_zz"},
+	}
+
+	for i, tt := range tests {
+		if out := pathBusUnescape(tt.in); out != tt.out {
+			t.Errorf("case %d: expected %q, got %q", i, tt.out, out)
+		}
+	}
+}
@@ -15,25 +15,27 @@
 package unit
 
 import (
+	"fmt"
 	"sync"
 
 	"github.com/coreos/fleet/pkg"
 )
 
 func NewFakeUnitManager() *FakeUnitManager {
-	return &FakeUnitManager{u: map[string]bool{}}
+	return &FakeUnitManager{u: map[string]UnitFile{}}
 }
 
 type FakeUnitManager struct {
 	sync.RWMutex
-	u map[string]bool
+	// u holds the file of each loaded unit
+	u map[string]UnitFile
 }
 
 func (fum *FakeUnitManager) Load(name string, u UnitFile) error {
 	fum.Lock()
 	defer fum.Unlock()
 
-	fum.u[name] = false
+	fum.u[name] = u
 	return nil
 }
 
@@ -63,15 +65,27 @@ func (fum *FakeUnitManager) Units() ([]string, error) {
 	return lst, nil
 }
 
+func (fum *FakeUnitManager) UnitFile(name string) (*UnitFile, error) {
+	fum.RLock()
+	defer fum.RUnlock()
+
+	u, ok := fum.u[name]
+	if !ok {
+		return nil, fmt.Errorf("unit %s not loaded", name)
+	}
+	return &u, nil
+}
+
 func (fum *FakeUnitManager) GetUnitState(name string) (us *UnitState, err error) {
 	fum.RLock()
 	defer fum.RUnlock()
 
-	if _, ok := fum.u[name]; ok {
+	if u, ok := fum.u[name]; ok {
 		us = &UnitState{
 			LoadState:   "loaded",
 			ActiveState: "active",
 			SubState:    "running",
+			UnitHash:    u.Hash().String(),
 		}
 	}
 	return
@@ -83,8 +97,8 @@ func (fum *FakeUnitManager) GetUnitStates(filter pkg.Set) (map[string]*UnitState
 
 	states := make(map[string]*UnitState)
 	for _, name := range filter.Values() {
-		if _, ok := fum.u[name]; ok {
-			states[name] = &UnitState{"loaded", "active", "running", "", "", name}
+		if u, ok := fum.u[name]; ok {
+			states[name] = &UnitState{"loaded", "active", "running", "", u.Hash().String(), name, ""}
 		}
 	}
 
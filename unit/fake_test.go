@@ -61,6 +61,7 @@ func TestFakeUnitManagerLoadUnload(t *testing.T) {
 	}
 
 	eus := NewUnitState("loaded", "active", "running", "")
+	eus.UnitHash = (&UnitFile{}).Hash().String()
 	if !reflect.DeepEqual(*us, *eus) {
 		t.Fatalf("Expected UnitState %v, got %v", eus, *us)
 	}
@@ -107,6 +108,7 @@ func TestFakeUnitManagerGetUnitStates(t *testing.T) {
 			LoadState:   "loaded",
 			ActiveState: "active",
 			SubState:    "running",
+			UnitHash:    (&UnitFile{}).Hash().String(),
 			UnitName:    "hello.service",
 		},
 	}
@@ -16,12 +16,26 @@ package unit
 
 import (
 	"encoding/json"
+	"sync"
 	"time"
 
 	"github.com/coreos/fleet/log"
 	"github.com/coreos/fleet/pkg"
 )
 
+const (
+	// interval at which unit states are polled if the UnitManager is
+	// unable to notify about state changes
+	pollInterval = time.Second
+	// interval at which unit states are polled while watching for state
+	// changes, in case a notification got lost
+	watchedPollInterval = 30 * time.Second
+	// time between attempts to watch for state changes
+	watchRetryInterval = 10 * time.Second
+
+	eventUnitStateChanged = pkg.Event("UnitStateChanged")
+)
+
 type UnitStateHeartbeat struct {
 	Name  string
 	State *UnitState
@@ -29,16 +43,40 @@ type UnitStateHeartbeat struct {
 
 func NewUnitStateGenerator(mgr UnitManager) *UnitStateGenerator {
 	return &UnitStateGenerator{
-		mgr:        mgr,
-		subscribed: pkg.NewThreadsafeSet(),
+		mgr:                 mgr,
+		subscribed:          pkg.NewThreadsafeSet(),
+		pollInterval:        pollInterval,
+		watchedPollInterval: watchedPollInterval,
+		changed:             make(chan struct{}, 1),
+		changedc:            make(chan struct{}),
 	}
 }
 
+// HealthReporter reports the outcome of the latest health check of a unit,
+// or an empty string if the unit was not checked.
+type HealthReporter interface {
+	Health(name string) string
+}
+
 type UnitStateGenerator struct {
-	mgr UnitManager
+	mgr    UnitManager
+	health HealthReporter
 
 	subscribed     pkg.Set
 	lastSubscribed pkg.Set
+
+	// units are polled every pollInterval, or every watchedPollInterval
+	// while the UnitManager notifies about state changes
+	pollInterval        time.Duration
+	watchedPollInterval time.Duration
+
+	// changed triggers generating the states of all units after a
+	// subscribed unit changed, while changedc is closed and replaced on
+	// each such change to wake up the callers of Next
+	changed  chan struct{}
+	mutex    sync.Mutex
+	watching bool
+	changedc chan struct{}
 }
 
 func (g *UnitStateGenerator) MarshalJSON() ([]byte, error) {
@@ -51,28 +89,120 @@ func (g *UnitStateGenerator) MarshalJSON() ([]byte, error) {
 	return json.Marshal(data)
 }
 
-// Run periodically calls Generate and sends received *UnitStateHeartbeat
-// objects to the provided channel.
+// SetHealthReporter makes the generator publish the health reported by
+// the given HealthReporter in the states of the units.
+func (g *UnitStateGenerator) SetHealthReporter(hr HealthReporter) {
+	g.health = hr
+}
+
+// Run calls Generate and sends received *UnitStateHeartbeat objects to the
+// provided channel whenever a subscribed unit changes state, if the
+// UnitManager is able to tell, and periodically otherwise.
 func (g *UnitStateGenerator) Run(receiver chan<- *UnitStateHeartbeat, stop <-chan struct{}) {
-	tick := time.Tick(time.Second)
+	if w, ok := g.mgr.(UnitWatcher); ok {
+		go g.watch(w, stop)
+	}
+
 	for {
 		select {
 		case <-stop:
 			return
-		case <-tick:
-			beatchan, err := g.Generate()
-			if err != nil {
-				log.Errorf("Failed fetching current unit states: %v", err)
-				continue
-			}
+		case <-time.After(g.interval()):
+		case <-g.changed:
+		}
 
-			for ush := range beatchan {
-				receiver <- ush
-			}
+		beatchan, err := g.Generate()
+		if err != nil {
+			log.Errorf("Failed fetching current unit states: %v", err)
+			continue
+		}
+
+		for ush := range beatchan {
+			receiver <- ush
+		}
+	}
+}
+
+// watch keeps watching the given UnitWatcher for state changes until the
+// stop channel is closed, retrying if the watch fails.
+func (g *UnitStateGenerator) watch(w UnitWatcher, stop <-chan struct{}) {
+	for {
+		done, err := w.WatchUnits(g.notify, stop)
+		if err != nil {
+			log.Errorf("Failed watching unit state changes, polling every %v: %v", g.pollInterval, err)
+		} else {
+			log.Infof("Watching unit state changes, polling every %v", g.watchedPollInterval)
+			g.setWatching(true)
+			<-done
+			g.setWatching(false)
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(watchRetryInterval):
 		}
 	}
 }
 
+func (g *UnitStateGenerator) setWatching(watching bool) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.watching = watching
+}
+
+func (g *UnitStateGenerator) interval() time.Duration {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.watching {
+		return g.watchedPollInterval
+	}
+	return g.pollInterval
+}
+
+// notify makes Run generate the states of all subscribed units, and wakes
+// up the callers of Next, if the named unit is subscribed. Changes of
+// several units during a single Generate are coalesced.
+func (g *UnitStateGenerator) notify(name string) {
+	if !g.subscribed.Contains(name) {
+		return
+	}
+
+	select {
+	case g.changed <- struct{}{}:
+	default:
+	}
+
+	g.mutex.Lock()
+	close(g.changedc)
+	g.changedc = make(chan struct{})
+	g.mutex.Unlock()
+}
+
+// Next returns a channel emitting an event once a subscribed unit changes
+// state, making the UnitStateGenerator a pkg.EventStream.
+func (g *UnitStateGenerator) Next(stop chan struct{}) chan pkg.Event {
+	g.mutex.Lock()
+	changedc := g.changedc
+	g.mutex.Unlock()
+
+	evchan := make(chan pkg.Event)
+	go func() {
+		select {
+		case <-stop:
+		case <-changedc:
+			select {
+			case evchan <- eventUnitStateChanged:
+			case <-stop:
+			}
+		}
+	}()
+
+	return evchan
+}
+
 // Generate returns and fills a channel with *UnitStateHeartbeat objects. Objects will
 // only be returned for units to which this generator is currently subscribed.
 func (g *UnitStateGenerator) Generate() (<-chan *UnitStateHeartbeat, error) {
@@ -93,6 +223,9 @@ func (g *UnitStateGenerator) Generate() (<-chan *UnitStateHeartbeat, error) {
 	go func() {
 		for name, us := range reportable {
 			us := us
+			if us != nil && g.health != nil {
+				us.Health = g.health.Health(name)
+			}
 			beatchan <- &UnitStateHeartbeat{
 				Name:  name,
 				State: us,
@@ -17,6 +17,7 @@ package unit
 import (
 	"reflect"
 	"testing"
+	"time"
 )
 
 func assertGenerateUnitStateHeartbeats(t *testing.T, um UnitManager, gen *UnitStateGenerator, expect []UnitStateHeartbeat) {
@@ -49,7 +50,7 @@ func TestUnitStateGeneratorSubscribeLifecycle(t *testing.T) {
 
 	// subscribed to foo.service so we should get a heartbeat
 	expect := []UnitStateHeartbeat{
-		UnitStateHeartbeat{Name: "foo.service", State: &UnitState{"loaded", "active", "running", "", "", "foo.service"}},
+		UnitStateHeartbeat{Name: "foo.service", State: &UnitState{"loaded", "active", "running", "", (&UnitFile{}).Hash().String(), "foo.service", ""}},
 	}
 	assertGenerateUnitStateHeartbeats(t, um, gen, expect)
 
@@ -77,3 +78,97 @@ func TestUnitStateGeneratorNoState(t *testing.T) {
 	// subscribed to foo.service but no underlying state so no heartbeat
 	assertGenerateUnitStateHeartbeats(t, um, gen, []UnitStateHeartbeat{})
 }
+
+type fakeHealthReporter map[string]string
+
+func (f fakeHealthReporter) Health(name string) string {
+	return f[name]
+}
+
+func TestUnitStateGeneratorHealth(t *testing.T) {
+	um := NewFakeUnitManager()
+	um.Load("foo.service", UnitFile{})
+	um.Load("bar.service", UnitFile{})
+
+	gen := NewUnitStateGenerator(um)
+	gen.SetHealthReporter(fakeHealthReporter{"foo.service": HealthUnhealthy})
+	gen.Subscribe("foo.service")
+	gen.Subscribe("bar.service")
+
+	beatchan, err := gen.Generate()
+	if err != nil {
+		t.Fatalf("Unexpected error from Generate(): %v", err)
+	}
+	got := make(map[string]string)
+	for beat := range beatchan {
+		got[beat.Name] = beat.State.Health
+	}
+	expect := map[string]string{"foo.service": HealthUnhealthy, "bar.service": ""}
+	if !reflect.DeepEqual(got, expect) {
+		t.Fatalf("got health %v, expected %v", got, expect)
+	}
+}
+
+type fakeUnitWatcher struct {
+	*FakeUnitManager
+	notify chan func(string)
+}
+
+func (f *fakeUnitWatcher) WatchUnits(notify func(string), stop <-chan struct{}) (<-chan struct{}, error) {
+	f.notify <- notify
+	done := make(chan struct{})
+	go func() {
+		<-stop
+		close(done)
+	}()
+	return done, nil
+}
+
+func TestUnitStateGeneratorWatch(t *testing.T) {
+	um := &fakeUnitWatcher{NewFakeUnitManager(), make(chan func(string), 1)}
+	um.Load("foo.service", UnitFile{})
+
+	gen := NewUnitStateGenerator(um)
+	gen.pollInterval = time.Hour
+	gen.watchedPollInterval = time.Hour
+	gen.Subscribe("foo.service")
+
+	beatc := make(chan *UnitStateHeartbeat)
+	stop := make(chan struct{})
+	defer close(stop)
+	go gen.Run(beatc, stop)
+
+	var notify func(string)
+	select {
+	case notify = <-um.notify:
+	case <-time.After(time.Second):
+		t.Fatalf("UnitManager not watched")
+	}
+
+	// changes of units not subscribed to are ignored
+	next := gen.Next(stop)
+	notify("bar.service")
+	select {
+	case <-beatc:
+		t.Fatalf("heartbeat sent unexpectedly")
+	case <-next:
+		t.Fatalf("event emitted unexpectedly")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// while changes of subscribed units trigger a heartbeat and an event
+	notify("foo.service")
+	select {
+	case beat := <-beatc:
+		if beat.Name != "foo.service" {
+			t.Fatalf("unexpected heartbeat for %s", beat.Name)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("no heartbeat sent after unit state change")
+	}
+	select {
+	case <-next:
+	case <-time.After(time.Second):
+		t.Fatalf("no event emitted after unit state change")
+	}
+}
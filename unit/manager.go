@@ -27,6 +27,17 @@ type UnitManager interface {
 	TriggerStop(string) error
 
 	Units() ([]string, error)
+	UnitFile(string) (*UnitFile, error)
 	GetUnitStates(pkg.Set) (map[string]*UnitState, error)
 	GetUnitState(string) (*UnitState, error)
 }
+
+// UnitWatcher is implemented by UnitManagers able to notify about state
+// changes of units as they happen, rather than having to be polled.
+type UnitWatcher interface {
+	// WatchUnits calls notify with the name of each unit changing state
+	// until the stop channel is closed. The returned channel is closed
+	// once the watch ends, which may happen before stop is closed if
+	// the watch fails.
+	WatchUnits(notify func(name string), stop <-chan struct{}) (<-chan struct{}, error)
+}
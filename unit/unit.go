@@ -201,8 +201,16 @@ type UnitState struct {
 	MachineID   string
 	UnitHash    string
 	UnitName    string
+	// Health is the outcome of the latest health check of the unit, if
+	// it defines one and was checked already
+	Health string `json:",omitempty"`
 }
 
+const (
+	HealthHealthy   = "healthy"
+	HealthUnhealthy = "unhealthy"
+)
+
 func NewUnitState(loadState, activeState, subState, mID string) *UnitState {
 	return &UnitState{
 		LoadState:   loadState,
@@ -38,6 +38,7 @@ func (s UnitState) ToPB() *pb.UnitState {
 		LoadState:   s.LoadState,
 		ActiveState: s.ActiveState,
 		SubState:    s.SubState,
+		Health:      s.Health,
 		MachineID:   s.MachineID,
 	}
 }